@@ -57,7 +57,7 @@ func (s *Session) TableName() string {
 type Provider struct {
 	ormx.UuidModel
 	Name           string `json:"name" gorm:"type:varchar(255);column:name;not null"`
-	ApiKey         string `json:"apiKey" gorm:"type:varchar(255);column:api_key;comment:'api_key';not null"`
+	ApiKey         string `json:"apiKey" gorm:"type:varchar(1024);column:api_key;comment:'api_key';not null;serializer:encrypted"`
 	ApiEndpoint    string `json:"apiEndpoint" gorm:"type:varchar(255);column:api_endpoint;comment:'api_endpoint';not null"`
 	Type           string `json:"type" gorm:"type:varchar(255);not null;column:type"`
 	DefaultHeaders string `json:"defaultHeaders" gorm:"type:varchar(255);not null;column:default_headers"`
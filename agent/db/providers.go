@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"github.com/xiehqing/common/pkg/ormx"
 )
 
 // GetProviders 获取所有provider
@@ -91,3 +92,8 @@ func (q *Queries) GetBigModels(ctx context.Context) ([]BigModel, error) {
 	}
 	return bigModels, nil
 }
+
+// ReEncryptProviders 重新加密 provider 的 api_key, 用于历史明文数据迁移和密钥轮换
+func (q *Queries) ReEncryptProviders(ctx context.Context) (*ormx.ReEncryptResult, error) {
+	return ormx.ReEncrypt(ctx, q.db, &Provider{}, 500)
+}
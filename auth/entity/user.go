@@ -8,7 +8,8 @@ import (
 type User struct {
 	ormx.DeleteAbleModel
 	Username       string     `json:"username" gorm:"column:username;type:varchar(255);not null"`
-	NickName       string     `json:"nickName" gorm:"column:nickName;type:varchar(255);"`
+	NickName       string     `json:"nickName" gorm:"column:nickName;type:varchar(255);pinyin:nick_name_pinyin"`
+	NickNamePinyin string     `json:"-" gorm:"column:nick_name_pinyin;type:varchar(1000);"` // 昵称的拼音搜索关键字, 由 pinyin.RegisterCallbacks 注册的回调维护
 	Password       string     `json:"password" gorm:"column:password;type:varchar(255);not null"`
 	Phone          string     `json:"phone" gorm:"column:phone;type:varchar(255);"`
 	Email          string     `json:"email" gorm:"column:email;type:varchar(255);"`
@@ -18,6 +19,8 @@ type User struct {
 	Signature      string     `json:"signature" gorm:"column:signature;type:varchar(5000);"`
 	Status         UserStatus `json:"status" gorm:"column:status;type:tinyint;not null"`
 	LastActiveTime *time.Time `json:"lastActiveTime" gorm:"column:last_active_time;type:dateTime;"`
+	// PasswordChangedAt 密码最后修改时间, 用于判断密码是否过期
+	PasswordChangedAt *time.Time `json:"passwordChangedAt" gorm:"column:password_changed_at;type:dateTime;"`
 }
 
 type UserStatus int
@@ -0,0 +1,14 @@
+package entity
+
+import "github.com/xiehqing/common/pkg/ormx"
+
+// UserPasswordHistory 用户历史密码, 保存密码哈希用于限制重复使用
+type UserPasswordHistory struct {
+	ormx.BaseModel
+	UserID   int64  `json:"userId" gorm:"column:user_id;type:bigint;not null;index"`
+	Password string `json:"-" gorm:"column:password;type:varchar(255);not null"`
+}
+
+func (h *UserPasswordHistory) TableName() string {
+	return "user_password_history"
+}
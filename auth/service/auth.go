@@ -3,17 +3,53 @@ package service
 import (
 	"github.com/pkg/errors"
 	"github.com/xiehqing/common/auth/entity"
+	"github.com/xiehqing/common/pkg/cfg"
+	"github.com/xiehqing/common/pkg/jwtx/store"
 	"github.com/xiehqing/common/pkg/logs"
 	"github.com/xiehqing/common/pkg/ormx"
+	"github.com/xiehqing/common/pkg/password"
+	"github.com/xiehqing/common/pkg/pinyin"
 	"gorm.io/gorm"
+	"sort"
 	"time"
 )
 
 type BaseService struct {
+	passwords  *password.Manager
+	loginStore store.Store
+	configs    *cfg.Remote
 }
 
-func NewAuthService() *BaseService {
-	return &BaseService{}
+// ServiceOption 服务选项
+type ServiceOption func(*BaseService)
+
+// WithPasswordManager 设置密码管理, 用于密码校验、修改和策略检查
+func WithPasswordManager(m *password.Manager) ServiceOption {
+	return func(bs *BaseService) {
+		bs.passwords = m
+	}
+}
+
+// WithLoginStore 设置登录失败次数存储, 通常为 jwtx.Jwt.Store
+func WithLoginStore(s store.Store) ServiceOption {
+	return func(bs *BaseService) {
+		bs.loginStore = s
+	}
+}
+
+// WithConfigs 设置远程配置, 系统配置优先从其缓存读取, 修改后无需重启即可生效
+func WithConfigs(r *cfg.Remote) ServiceOption {
+	return func(bs *BaseService) {
+		bs.configs = r
+	}
+}
+
+func NewAuthService(opts ...ServiceOption) *BaseService {
+	bs := &BaseService{}
+	for _, opt := range opts {
+		opt(bs)
+	}
+	return bs
 }
 
 // GetUsers 获取用户列表
@@ -64,6 +100,42 @@ func (bs *BaseService) GetUsers(db *gorm.DB, where string, args ...interface{})
 	return userRecords, nil
 }
 
+// SearchUsers 按昵称或昵称拼音搜索用户, 支持 zs、zhangs、张s 这类查询, 结果按匹配度排序.
+// 需要在 db 上调用 pinyin.RegisterCallbacks 维护昵称的拼音列, 历史数据使用 pinyin.Backfill 生成
+func (bs *BaseService) SearchUsers(db *gorm.DB, keyword string, limit int) ([]*User, error) {
+	query := db.Model(&entity.User{}).Scopes(pinyin.SearchScope("nickName", "nick_name_pinyin", keyword))
+	if limit > 0 {
+		// 数据库只做粗筛, 先按完全匹配、前缀匹配粗排, 多取一些候选再按匹配度排序
+		query = query.Scopes(pinyin.RankScope("nickName", "nick_name_pinyin", keyword)).Order("id").Limit(limit * 5)
+	}
+	var ids []int64
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return nil, errors.WithMessagef(err, "搜索用户错误.")
+	}
+	if len(ids) == 0 {
+		return []*User{}, nil
+	}
+	users, err := bs.GetUsers(db, "id in ?", ids)
+	if err != nil {
+		return nil, err
+	}
+	// 数据库粗筛的结果是匹配结果的超集, 不匹配的记录在这里去掉
+	scores := make(map[int64]int, len(users))
+	matched := users[:0]
+	for _, u := range users {
+		if score := pinyin.NewNameIndex(u.NickName).Match(keyword); score > 0 {
+			scores[u.ID] = score
+			matched = append(matched, u)
+		}
+	}
+	users = matched
+	sort.SliceStable(users, func(i, j int) bool { return scores[users[i].ID] > scores[users[j].ID] })
+	if limit > 0 && len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
+
 // GetUserByID 根据ID获取用户
 func (bs *BaseService) GetUserByID(db *gorm.DB, id int64) (*User, error) {
 	if id == 0 {
@@ -338,8 +410,13 @@ func (bs *BaseService) GetAllTenants(db *gorm.DB) ([]*entity.Tenant, error) {
 	return tenants, nil
 }
 
-// GetConfigValue 获取系统配置
+// GetConfigValue 获取系统配置, 设置了远程配置时优先读取缓存
 func (bs *BaseService) GetConfigValue(db *gorm.DB, key string) (string, error) {
+	if bs.configs != nil {
+		if value, ok := bs.configs.Value(key); ok {
+			return value, nil
+		}
+	}
 	var lst []string
 	err := db.Model(&entity.SystemConfigs{}).Where("config_key = ?", key).Pluck("config_value", &lst).Error
 	if err != nil {
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/xiehqing/common/auth/entity"
+	"github.com/xiehqing/common/pkg/pinyin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSearchUsers(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := pinyin.RegisterCallbacks(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&entity.User{}, &entity.UserRole{}, &entity.UserTenant{}, &entity.RoleOperation{}); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"张三", "李四", "张三丰", "赵四"} {
+		u := &entity.User{Username: "user" + string(rune('a'+i)), NickName: name, Password: "-", Status: entity.UserStatusNormal}
+		if err := db.Create(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bs := NewAuthService()
+	// 全拼与首字母混合的查询词不是拼音列的子串, 数据库粗筛不能把它们过滤掉
+	cases := map[string][]string{
+		"zhs":     {"张三", "赵四", "张三丰"},
+		"zhangsf": {"张三丰"},
+		"张s":      {"张三", "张三丰"},
+		"sf":      {"张三丰"},
+		"zhf":     nil,
+	}
+	for query, want := range cases {
+		users, err := bs.SearchUsers(db, query, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, u := range users {
+			got = append(got, u.NickName)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SearchUsers(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
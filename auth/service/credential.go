@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/auth/entity"
+	"github.com/xiehqing/common/pkg/crypto"
+	"github.com/xiehqing/common/pkg/logs"
+	"github.com/xiehqing/common/pkg/redisx"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("用户名或密码错误")
+	ErrTooManyLoginFailed = errors.New("登录失败次数过多, 请稍后再试")
+	ErrUserLocked         = errors.New("用户已被锁定")
+)
+
+const (
+	defaultLoginFailWindow = 300
+	defaultLoginFailLimit  = 5
+)
+
+// CredentialResult 凭证校验结果
+type CredentialResult struct {
+	User            *User
+	PasswordExpired bool // 密码已过期, 调用方应要求用户修改密码
+	Rehashed        bool // 密码哈希已按当前配置重新计算
+}
+
+// VerifyCredentials 校验用户名和密码, 失败时在登录存储中累加失败次数, 达到 login_fail_count 配置的次数后拒绝登录.
+// 旧版明文或 AES 加密保存的密码校验通过后会被重新哈希, 哈希参数变化时同样会重新哈希
+func (bs *BaseService) VerifyCredentials(ctx context.Context, db *gorm.DB, username, pwd string) (*CredentialResult, error) {
+	if bs.passwords == nil {
+		return nil, errors.New("未配置密码管理")
+	}
+	failKey, window, limit := bs.loginFailPolicy(db, username)
+	if bs.loginStore != nil {
+		count, err := bs.loginStore.GetLoginFailedCount(ctx, failKey)
+		if err != nil {
+			logs.CtxWarnf(ctx, "获取用户%s登录失败次数错误: %v", username, err)
+		} else if count >= limit {
+			return nil, ErrTooManyLoginFailed
+		}
+	}
+	var user *entity.User
+	err := db.Where("username = ?", username).First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.WithMessagef(err, "获取用户信息失败,username:%s", username)
+	}
+	if user == nil || user.ID == 0 {
+		// 用户不存在时同样计算一次哈希, 避免通过响应时间判断用户是否存在
+		_, _ = bs.passwords.Hash(pwd)
+		bs.incrLoginFailed(ctx, failKey, window)
+		return nil, ErrInvalidCredentials
+	}
+	ok, needsRehash, err := bs.passwords.Verify(user.Password, pwd)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "校验用户%s密码失败", username)
+	}
+	if !ok && !bs.passwords.IsHashed(user.Password) && bs.verifyLegacyPassword(db, user.Password, pwd) {
+		ok, needsRehash = true, true
+	}
+	if !ok {
+		bs.incrLoginFailed(ctx, failKey, window)
+		return nil, ErrInvalidCredentials
+	}
+	if user.Status == entity.UserStatusLocked {
+		return nil, ErrUserLocked
+	}
+	result := &CredentialResult{PasswordExpired: bs.passwords.Policy().Expired(user.PasswordChangedAt)}
+	if needsRehash {
+		hash, err := bs.passwords.Hash(pwd)
+		if err == nil {
+			err = db.Model(&entity.User{}).Where("id = ?", user.ID).Update("password", hash).Error
+		}
+		if err != nil {
+			logs.CtxWarnf(ctx, "用户%s密码重新哈希失败: %v", username, err)
+		} else {
+			result.Rehashed = true
+		}
+	}
+	result.User, err = bs.GetUserByID(db, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ChangePassword 用户修改密码, 需要校验旧密码
+func (bs *BaseService) ChangePassword(ctx context.Context, db *gorm.DB, userID int64, oldPassword, newPassword string) error {
+	if bs.passwords == nil {
+		return errors.New("未配置密码管理")
+	}
+	var user *entity.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("用户不存在")
+		}
+		return errors.WithMessagef(err, "获取用户信息失败")
+	}
+	ok, _, err := bs.passwords.Verify(user.Password, oldPassword)
+	if err != nil {
+		return err
+	}
+	if !ok && !bs.passwords.IsHashed(user.Password) {
+		ok = bs.verifyLegacyPassword(db, user.Password, oldPassword)
+	}
+	if !ok {
+		return errors.New("原密码错误")
+	}
+	return bs.setPassword(ctx, db, user, newPassword)
+}
+
+// ResetPassword 管理员重置密码, 不校验旧密码, 仍然校验密码策略
+func (bs *BaseService) ResetPassword(ctx context.Context, db *gorm.DB, userID int64, newPassword string) error {
+	if bs.passwords == nil {
+		return errors.New("未配置密码管理")
+	}
+	var user *entity.User
+	if err := db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("用户不存在")
+		}
+		return errors.WithMessagef(err, "获取用户信息失败")
+	}
+	return bs.setPassword(ctx, db, user, newPassword)
+}
+
+// setPassword 校验密码策略和历史密码, 保存新密码并记录历史
+func (bs *BaseService) setPassword(ctx context.Context, db *gorm.DB, user *entity.User, newPassword string) error {
+	historySize := bs.passwords.Policy().Config().HistorySize
+	var history []string
+	if historySize > 0 {
+		err := db.Model(&entity.UserPasswordHistory{}).Where("user_id = ?", user.ID).
+			Order("id desc").Limit(historySize).Pluck("password", &history).Error
+		if err != nil {
+			return errors.WithMessagef(err, "获取用户历史密码失败")
+		}
+		// 当前密码同样不允许重复使用
+		if bs.passwords.IsHashed(user.Password) {
+			history = append([]string{user.Password}, history...)
+		}
+	}
+	if err := bs.passwords.Validate(newPassword, user.Username, history); err != nil {
+		return err
+	}
+	hash, err := bs.passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.User{}).Where("id = ?", user.ID).
+			Updates(map[string]interface{}{"password": hash, "password_changed_at": now}).Error
+		if err != nil {
+			return errors.WithMessagef(err, "更新用户密码失败")
+		}
+		if historySize <= 0 {
+			return nil
+		}
+		if err := tx.Create(&entity.UserPasswordHistory{UserID: user.ID, Password: hash}).Error; err != nil {
+			return errors.WithMessagef(err, "保存历史密码失败")
+		}
+		var expired []int64
+		err = tx.Model(&entity.UserPasswordHistory{}).Where("user_id = ?", user.ID).
+			Order("id desc").Offset(historySize).Pluck("id", &expired).Error
+		if err != nil || len(expired) == 0 {
+			return err
+		}
+		return tx.Where("id in (?)", expired).Delete(&entity.UserPasswordHistory{}).Error
+	})
+}
+
+// verifyLegacyPassword 校验旧版密码, pwd_aes_salt 不为空时密码以 AES 加密保存, 只比较解密后的明文;
+// 未配置时才按原值比较, 避免提交数据库中的密文即可登录
+func (bs *BaseService) verifyLegacyPassword(db *gorm.DB, stored, pwd string) bool {
+	salt, err := bs.GetConfigValue(db, string(entity.PwdAesSalt))
+	if err != nil {
+		return false
+	}
+	if salt == "" {
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(pwd)) == 1
+	}
+	plaintext, err := crypto.Decrypt(stored, salt)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(plaintext), []byte(pwd)) == 1
+}
+
+// LoginFailLimit 登录失败限制, login_fail_count 格式为 `统计窗口(秒) 次数上限`, 如 `300 5`
+type LoginFailLimit struct {
+	Window int64
+	Limit  int64
+}
+
+// UnmarshalText 解析 `300 5` 格式的配置
+func (l *LoginFailLimit) UnmarshalText(text []byte) error {
+	fields := strings.Fields(string(text))
+	if len(fields) != 2 {
+		return errors.Errorf("登录失败次数配置格式错误: %s", text)
+	}
+	window, err1 := strconv.ParseInt(fields[0], 10, 64)
+	limit, err2 := strconv.ParseInt(fields[1], 10, 64)
+	if err1 != nil || err2 != nil || window <= 0 || limit <= 0 {
+		return errors.Errorf("登录失败次数配置格式错误: %s", text)
+	}
+	l.Window, l.Limit = window, limit
+	return nil
+}
+
+// loginFailPolicy 登录失败计数的key、统计窗口(秒)和次数上限, 与 GetConfigValue 一致, 远程配置中没有时读取数据库
+func (bs *BaseService) loginFailPolicy(db *gorm.DB, username string) (string, int64, int64) {
+	policy := LoginFailLimit{Window: defaultLoginFailWindow, Limit: defaultLoginFailLimit}
+	if value, err := bs.GetConfigValue(db, string(entity.LoginFailCount)); err == nil && value != "" {
+		var parsed LoginFailLimit
+		if err := parsed.UnmarshalText([]byte(value)); err == nil {
+			policy = parsed
+		} else {
+			logs.Warnf("%v, 使用默认值", err)
+		}
+	}
+	prefix, err := bs.GetConfigValue(db, string(entity.LoginUserErrorPrefix))
+	if err != nil || prefix == "" {
+		prefix = redisx.ImhLoginUserErrorPrefix
+	}
+	return fmt.Sprintf("%s:%s", prefix, username), policy.Window, policy.Limit
+}
+
+func (bs *BaseService) incrLoginFailed(ctx context.Context, key string, window int64) {
+	if bs.loginStore == nil {
+		return
+	}
+	if err := bs.loginStore.IncrLoginFailedCount(ctx, key, time.Duration(window)*time.Second); err != nil {
+		logs.CtxWarnf(ctx, "累加登录失败次数错误, key:%s, err:%v", key, err)
+	}
+}
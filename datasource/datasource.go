@@ -2,8 +2,14 @@ package datasource
 
 import "context"
 
+// DataSource 数据源, 由 Manager 按类型名创建并管理
 type DataSource interface {
 	Init() error
+	// Equal 是否为同一数据源(地址、认证信息相同), Manager 据此决定是否替换实例
 	Equal(ds DataSource) bool
 	QueryData(ctx context.Context, query interface{}) ([]*MetricPoint, error)
+	// Execute 执行统一模型的查询, 不支持的查询类型返回错误
+	Execute(ctx context.Context, query *Query) (*Result, error)
+	// Ping 探测数据源是否可用, 用于健康检查
+	Ping(ctx context.Context) error
 }
@@ -4,7 +4,6 @@ import (
 	"github.com/olivere/elastic"
 	"github.com/pkg/errors"
 	"github.com/xiehqing/common/pkg/logs"
-	"github.com/xiehqing/common/pkg/tlsx"
 	"golang.org/x/net/context"
 	"io"
 	"net"
@@ -17,6 +16,9 @@ type Client struct {
 	Uris     []string
 	esClient *elastic.Client
 	Version  string
+	config   Config
+	// tlsCloser 停止 TLS 证书热加载, Close 时调用
+	tlsCloser io.Closer
 }
 
 type TraceLog struct{}
@@ -31,7 +33,8 @@ func NewElasticClient(option Config) (*Client, error) {
 		return nil, errors.Errorf("未配置ElasticSearch地址")
 	}
 	ec := &Client{
-		Uris: option.Uris,
+		Uris:   option.Uris,
+		config: option,
 	}
 
 	transport := &http.Transport{
@@ -43,16 +46,16 @@ func NewElasticClient(option Config) (*Client, error) {
 	}
 
 	url := option.Uris[0]
-	if strings.Contains(url, "https") {
-		tlsConfig := tlsx.ClientConfig{
-			InsecureSkipVerify: option.SkipTlsVerify,
-			UseTLS:             true,
-		}
-		cfg, err := tlsConfig.TLSConfig()
+	if strings.Contains(url, "https") || option.TLS.UseTLS {
+		tlsConfig := option.TLS
+		tlsConfig.UseTLS = true
+		tlsConfig.InsecureSkipVerify = tlsConfig.InsecureSkipVerify || option.SkipTlsVerify
+		cfg, closer, err := tlsConfig.TLSConfigWithCloser()
 		if err != nil {
 			return nil, errors.Errorf("初始化ElasticSearch TLS配置失败: %v", err)
 		}
 		transport.TLSClientConfig = cfg
+		ec.tlsCloser = closer
 	}
 
 	options := []elastic.ClientOptionFunc{
@@ -69,6 +72,9 @@ func NewElasticClient(option Config) (*Client, error) {
 	}
 	esClient, err := elastic.NewClient(options...)
 	if err != nil {
+		if ec.tlsCloser != nil {
+			_ = ec.tlsCloser.Close()
+		}
 		return nil, errors.Errorf("初始化ElasticSearch客户端失败: %v", err)
 	}
 	ec.esClient = esClient
@@ -163,3 +169,15 @@ func (ec *Client) Request(ctx context.Context, r *RequestOption) (*elastic.Searc
 	}
 	return result, nil
 }
+
+// Ping 探测ElasticSearch是否可用
+func (ec *Client) Ping(ctx context.Context) error {
+	_, code, err := ec.esClient.Ping(ec.Uris[0]).Do(ctx)
+	if err != nil {
+		return errors.Errorf("探测ElasticSearch失败: %v", err)
+	}
+	if code >= http.StatusBadRequest {
+		return errors.Errorf("探测ElasticSearch失败, 状态码: %d", code)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package elasticsearch
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xiehqing/common/pkg/tlsx"
+)
+
+func TestMutualTLS(t *testing.T) {
+	ca, err := tlsx.NewCA("es-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ca.IssueServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverCert, err := server.TLSCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"version":{"number":"7.10.0"}}`))
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{serverCert}, ClientCAs: ca.Pool(), ClientAuth: tls.RequireAndVerifyClientCert}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	client, err := ca.IssueClient("es-client", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ping := func(cfg tlsx.ClientConfig) error {
+		ec, err := NewElasticClient(Config{Uris: []string{srv.URL}, Timeout: 1000, TLS: cfg})
+		if err != nil {
+			return err
+		}
+		defer ec.Close()
+		return ec.Ping(context.Background())
+	}
+	if err := ping(tlsx.ClientConfig{TLSCA: string(ca.CertPEM), TLSCert: string(client.CertPEM), TLSKey: string(client.KeyPEM)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ping(tlsx.ClientConfig{TLSCA: string(ca.CertPEM)}); err == nil {
+		t.Fatal("expected handshake failure without client certificate")
+	}
+}
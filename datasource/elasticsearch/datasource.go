@@ -0,0 +1,274 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/olivere/elastic"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/datasource"
+	"github.com/xiehqing/common/pkg/util"
+	"golang.org/x/net/context"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Type 数据源类型名
+const Type = "elasticsearch"
+
+const (
+	aggGroup     = "group"
+	aggHistogram = "histogram"
+	aggValue     = "value"
+)
+
+var _ datasource.DataSource = (*Client)(nil)
+
+func init() {
+	datasource.RegisterType(Type, func(config *Config) (datasource.DataSource, error) {
+		c, err := NewElasticClient(*config)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Init(); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+		return c, nil
+	})
+}
+
+// Init 检查连通性
+func (ec *Client) Init() error {
+	return ec.Ping(context.Background())
+}
+
+// Equal 判断是否相同数据源, 比较规整后的完整配置, TLS、超时等任一配置变化都会替换实例
+func (ec *Client) Equal(other datasource.DataSource) bool {
+	o, ok := other.(*Client)
+	if !ok {
+		return false
+	}
+	return reflect.DeepEqual(ec.config.normalize(), o.config.normalize())
+}
+
+// Close 停止客户端, 并停止 TLS 证书热加载
+func (ec *Client) Close() error {
+	ec.esClient.Stop()
+	if ec.tlsCloser != nil {
+		return ec.tlsCloser.Close()
+	}
+	return nil
+}
+
+// QueryData 按 datasource.Query 格式执行 instant 查询
+func (ec *Client) QueryData(ctx context.Context, query interface{}) ([]*datasource.MetricPoint, error) {
+	if query == nil {
+		return nil, errors.Errorf("query is nil")
+	}
+	q, err := util.Convert[datasource.Query](query)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "[elasticsearch] query param convert error")
+	}
+	q.Type = datasource.QueryInstant
+	result, err := ec.Execute(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return result.Points, nil
+}
+
+// Execute 执行统一模型的查询. instant 查询统计 Time 之前 Step(默认 5 分钟)内的文档数或 ValueField 平均值,
+// range 查询按 Step 做 date_histogram 聚合, logs 查询按时间倒序返回原始文档
+func (ec *Client) Execute(ctx context.Context, query *datasource.Query) (*datasource.Result, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	if query.Index == "" {
+		return nil, errors.Errorf("[elasticsearch] 查询索引不得为空")
+	}
+	indices := strings.Split(query.Index, ",")
+	start, end := query.Start, query.End
+	if query.Type == datasource.QueryInstant {
+		lookback := query.Step
+		if lookback <= 0 {
+			lookback = datasource.DefaultLookback
+		}
+		start, end = query.Time.Add(-lookback), query.Time
+	}
+	boolQuery := elastic.NewBoolQuery().Filter(
+		elastic.NewRangeQuery(query.TimeField).Gte(start.UnixMilli()).Lt(end.UnixMilli()).Format("epoch_millis"))
+	if query.Expr != "" {
+		boolQuery = boolQuery.Must(elastic.NewQueryStringQuery(query.Expr).AnalyzeWildcard(true))
+	}
+	service := ec.esClient.Search().Index(indices...).Query(boolQuery).RestTotalHitsAsInt(true).TrackTotalHits(true)
+	result := &datasource.Result{Type: query.Type}
+
+	switch query.Type {
+	case datasource.QueryLogs:
+		resp, err := service.Sort(query.TimeField, false).Size(query.Limit).Do(ctx)
+		if err != nil {
+			return nil, errors.Errorf("查询ElasticSearch失败: %v", err)
+		}
+		result.Logs, result.Total = convertLogs(resp, query.TimeField)
+	case datasource.QueryInstant:
+		if query.GroupBy != "" {
+			service = service.Aggregation(aggGroup, withValue(elastic.NewTermsAggregation().Field(query.GroupBy).Size(query.Limit), query))
+		} else if query.ValueField != "" {
+			service = service.Aggregation(aggValue, elastic.NewAvgAggregation().Field(query.ValueField))
+		}
+		resp, err := service.Size(0).Do(ctx)
+		if err != nil {
+			return nil, errors.Errorf("查询ElasticSearch失败: %v", err)
+		}
+		result.Points = convertPoints(resp, query)
+	case datasource.QueryRange:
+		step := query.Step
+		if step <= 0 {
+			// 未指定步长时按每条序列约 250 个点计算, 最小 1 秒
+			step = max(query.End.Sub(query.Start)/250, time.Second).Truncate(time.Second)
+		}
+		histogram := elastic.NewDateHistogramAggregation().Field(query.TimeField).Interval(formatInterval(step)).
+			MinDocCount(0).ExtendedBounds(start.UnixMilli(), end.UnixMilli()-1)
+		if query.ValueField != "" {
+			histogram = histogram.SubAggregation(aggValue, elastic.NewAvgAggregation().Field(query.ValueField))
+		}
+		if query.GroupBy != "" {
+			service = service.Aggregation(aggGroup, elastic.NewTermsAggregation().Field(query.GroupBy).Size(query.Limit).SubAggregation(aggHistogram, histogram))
+		} else {
+			service = service.Aggregation(aggHistogram, histogram)
+		}
+		resp, err := service.Size(0).Do(ctx)
+		if err != nil {
+			return nil, errors.Errorf("查询ElasticSearch失败: %v", err)
+		}
+		result.Series = convertSeries(resp, query)
+	}
+	return result, nil
+}
+
+// withValue 分组聚合下追加 ValueField 平均值子聚合
+func withValue(terms *elastic.TermsAggregation, query *datasource.Query) *elastic.TermsAggregation {
+	if query.ValueField == "" {
+		return terms
+	}
+	return terms.SubAggregation(aggValue, elastic.NewAvgAggregation().Field(query.ValueField))
+}
+
+// formatInterval 转为 ElasticSearch 的时间间隔格式
+func formatInterval(d time.Duration) string {
+	if d%time.Second == 0 {
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// seriesLabels 序列标签, __name__ 为 ValueField 或 count, 分组时追加分组字段
+func seriesLabels(query *datasource.Query, group interface{}) *datasource.Metric {
+	labels := &datasource.Metric{}
+	name := query.ValueField
+	if name == "" {
+		name = "count"
+	}
+	labels.Set(datasource.MetricNameLabel, name)
+	if query.GroupBy != "" {
+		labels.Set(query.GroupBy, fmt.Sprint(group))
+	}
+	return labels
+}
+
+// bucketValue 桶的值, 有 ValueField 时取平均值, 否则取文档数, 没有数据时返回 false
+func bucketValue(aggs elastic.Aggregations, docCount int64, query *datasource.Query) (float64, bool) {
+	if query.ValueField == "" {
+		return float64(docCount), true
+	}
+	avg, ok := aggs.Avg(aggValue)
+	if !ok || avg.Value == nil {
+		return 0, false
+	}
+	return *avg.Value, true
+}
+
+func convertPoints(resp *elastic.SearchResult, query *datasource.Query) []*datasource.MetricPoint {
+	var points []*datasource.MetricPoint
+	add := func(labels *datasource.Metric, value float64) {
+		mp := &datasource.MetricPoint{Key: labels.String(), Labels: labels}
+		mp.Timestamp = query.Time.Unix()
+		mp.Value = value
+		points = append(points, mp)
+	}
+	if query.GroupBy == "" {
+		if value, ok := bucketValue(resp.Aggregations, resp.TotalHits(), query); ok {
+			add(seriesLabels(query, nil), value)
+		}
+		return points
+	}
+	groups, ok := resp.Aggregations.Terms(aggGroup)
+	if !ok {
+		return points
+	}
+	for _, bucket := range groups.Buckets {
+		if value, ok := bucketValue(bucket.Aggregations, bucket.DocCount, query); ok {
+			add(seriesLabels(query, bucket.Key), value)
+		}
+	}
+	return points
+}
+
+func convertSeries(resp *elastic.SearchResult, query *datasource.Query) []*datasource.MetricSeries {
+	var series []*datasource.MetricSeries
+	add := func(labels *datasource.Metric, aggs elastic.Aggregations) {
+		histogram, ok := aggs.DateHistogram(aggHistogram)
+		if !ok {
+			return
+		}
+		ms := &datasource.MetricSeries{Key: labels.String(), Labels: labels}
+		for _, bucket := range histogram.Buckets {
+			if value, ok := bucketValue(bucket.Aggregations, bucket.DocCount, query); ok {
+				ms.DataPoints = append(ms.DataPoints, &datasource.DataPoint{Timestamp: int64(bucket.Key) / 1000, Value: value})
+			}
+		}
+		series = append(series, ms)
+	}
+	if query.GroupBy == "" {
+		add(seriesLabels(query, nil), resp.Aggregations)
+		return series
+	}
+	groups, ok := resp.Aggregations.Terms(aggGroup)
+	if !ok {
+		return series
+	}
+	for _, bucket := range groups.Buckets {
+		add(seriesLabels(query, bucket.Key), bucket.Aggregations)
+	}
+	return series
+}
+
+func convertLogs(resp *elastic.SearchResult, timeField string) ([]*datasource.LogEntry, int64) {
+	if resp.Hits == nil {
+		return nil, 0
+	}
+	entries := make([]*datasource.LogEntry, 0, len(resp.Hits.Hits))
+	for _, hit := range resp.Hits.Hits {
+		entry := &datasource.LogEntry{Index: hit.Index, ID: hit.Id, Fields: map[string]interface{}{}}
+		if hit.Source != nil {
+			_ = json.Unmarshal(*hit.Source, &entry.Fields)
+		}
+		entry.Time = parseTime(entry.Fields[timeField])
+		entries = append(entries, entry)
+	}
+	return entries, resp.Hits.TotalHits
+}
+
+// parseTime 解析文档中的时间字段, 支持 RFC3339 字符串与毫秒时间戳
+func parseTime(value interface{}) time.Time {
+	switch v := value.(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t
+		}
+	case float64:
+		return time.UnixMilli(int64(v))
+	}
+	return time.Time{}
+}
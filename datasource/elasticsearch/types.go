@@ -5,7 +5,9 @@ import (
 	"github.com/xiehqing/common/datasource/elasticsearch/aggregate"
 	"github.com/xiehqing/common/datasource/elasticsearch/request"
 	"github.com/xiehqing/common/pkg/logs"
+	"github.com/xiehqing/common/pkg/tlsx"
 	"reflect"
+	"strings"
 )
 
 type Config struct {
@@ -16,6 +18,24 @@ type Config struct {
 	SkipTlsVerify  bool     `json:"skipTlsVerify" yaml:"skip-tls-verify" mapstructure:"skip-tls-verify"`
 	Version        string   `json:"version" yaml:"version" mapstructure:"version"`
 	EnableTraceLog bool     `json:"enableTraceLog" yaml:"enable-trace-log" mapstructure:"enable-trace-log"`
+	// TLS 双向认证等TLS配置, 地址为 https 时生效
+	TLS tlsx.ClientConfig `json:"tls" yaml:"tls" mapstructure:"tls"`
+}
+
+// normalize 规整配置, 空切片与 nil 等价, 并合并 https 地址与 SkipTlsVerify 对 TLS 的影响, 用于比较配置是否相同
+func (c Config) normalize() Config {
+	if len(c.Uris) == 0 {
+		c.Uris = nil
+	}
+	if len(c.TLS.TLSAllowedSPIFFEIDs) == 0 {
+		c.TLS.TLSAllowedSPIFFEIDs = nil
+	}
+	if len(c.Uris) > 0 && strings.Contains(c.Uris[0], "https") {
+		c.TLS.UseTLS = true
+	}
+	c.TLS.InsecureSkipVerify = c.TLS.InsecureSkipVerify || c.SkipTlsVerify
+	c.SkipTlsVerify = false
+	return c
 }
 
 var aggregatorCache = map[string]aggregate.Aggregate{}
@@ -0,0 +1,429 @@
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"github.com/xiehqing/common/pkg/util"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Factory 根据配置创建数据源, config 可以是对应类型的配置结构体或 map
+type Factory func(config interface{}) (DataSource, error)
+
+var factories = struct {
+	sync.RWMutex
+	m map[string]Factory
+}{m: map[string]Factory{}}
+
+// Register 注册数据源类型, 重复注册时覆盖. prometheus、elasticsearch 包在导入时自动注册
+func Register(typ string, factory Factory) {
+	factories.Lock()
+	defer factories.Unlock()
+	factories.m[typ] = factory
+}
+
+// RegisterType 注册使用 C 类型配置的数据源, 配置为 map 等其他类型时先转换为 C
+func RegisterType[C any](typ string, create func(config *C) (DataSource, error)) {
+	Register(typ, func(config interface{}) (DataSource, error) {
+		c, ok := config.(*C)
+		if !ok {
+			if v, isValue := config.(C); isValue {
+				c = &v
+			} else {
+				converted, err := util.Convert[C](config)
+				if err != nil {
+					return nil, errors.WithMessagef(err, "转换数据源 %s 配置失败", typ)
+				}
+				c = converted
+			}
+		}
+		return create(c)
+	})
+}
+
+// Types 已注册的数据源类型
+func Types() []string {
+	factories.RLock()
+	defer factories.RUnlock()
+	types := make([]string, 0, len(factories.m))
+	for typ := range factories.m {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New 按类型名创建数据源
+func New(typ string, config interface{}) (DataSource, error) {
+	factories.RLock()
+	factory, ok := factories.m[typ]
+	factories.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("未注册的数据源类型: %s", typ)
+	}
+	ds, err := factory(config)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "创建 %s 数据源失败", typ)
+	}
+	return ds, nil
+}
+
+// Config 数据源配置
+type Config struct {
+	Name     string      `json:"name" yaml:"name" mapstructure:"name"`
+	Type     string      `json:"type" yaml:"type" mapstructure:"type"`
+	Settings interface{} `json:"settings" yaml:"settings" mapstructure:"settings"`
+}
+
+// Status 数据源健康状态
+type Status struct {
+	Name      string        `json:"name"`
+	Type      string        `json:"type"`
+	Healthy   bool          `json:"healthy"`
+	Error     string        `json:"error,omitempty"`
+	Latency   time.Duration `json:"latency"`
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+type managed struct {
+	typ    string
+	key    string // 规范化后的配置, 见 configKey
+	ds     DataSource
+	status Status
+	// refs 正在使用该实例的查询数, retired 实例已被替换或移除, 两者由 Manager.mu 保护.
+	// 被替换的实例在最后一个查询结束后才关闭
+	refs    int
+	retired bool
+}
+
+// Manager 数据源管理器, 按名称缓存数据源实例, 配置变更时热替换, 并定期健康检查
+type Manager struct {
+	mu       sync.RWMutex
+	sources  map[string]*managed
+	interval time.Duration
+	timeout  time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// ManagerOption 管理器选项
+type ManagerOption func(*Manager)
+
+// WithHealthCheckInterval 设置健康检查间隔, 默认 30 秒, 小于等于 0 时不做定期检查
+func WithHealthCheckInterval(interval time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.interval = interval
+	}
+}
+
+// WithHealthCheckTimeout 设置单个数据源健康检查超时时间, 默认 5 秒
+func WithHealthCheckTimeout(timeout time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.timeout = timeout
+	}
+}
+
+// NewManager 创建数据源管理器, 需要定期健康检查时调用 Start
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{
+		sources:  map[string]*managed{},
+		interval: 30 * time.Second,
+		timeout:  5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Put 创建或更新数据源. 配置未变化时直接返回已有实例, 不再创建新实例;
+// 配置变化但新实例与已有实例 Equal 时同样保留已有实例, 否则替换旧实例,
+// 旧实例在进行中的查询结束后关闭; 创建失败时保留已有实例并返回错误
+func (m *Manager) Put(cfg Config) (DataSource, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("数据源名称不能为空")
+	}
+	key := configKey(cfg)
+	m.mu.RLock()
+	if old, ok := m.sources[cfg.Name]; ok && key != "" && old.key == key {
+		m.mu.RUnlock()
+		return old.ds, nil
+	}
+	m.mu.RUnlock()
+	ds, err := New(cfg.Type, cfg.Settings)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	old, ok := m.sources[cfg.Name]
+	if ok && old.typ == cfg.Type && old.ds.Equal(ds) {
+		old.key = key
+		m.mu.Unlock()
+		closeDataSource(ds)
+		return old.ds, nil
+	}
+	m.sources[cfg.Name] = &managed{
+		typ:    cfg.Type,
+		key:    key,
+		ds:     ds,
+		status: Status{Name: cfg.Name, Type: cfg.Type, Healthy: true, CheckedAt: time.Now()},
+	}
+	closeOld := ok && old.retire()
+	m.mu.Unlock()
+	if ok {
+		logs.Infof("数据源 %s 配置变更, 已替换", cfg.Name)
+	}
+	if closeOld {
+		closeDataSource(old.ds)
+	}
+	return ds, nil
+}
+
+// configKey 规范化配置, 结构体与 map 形式的相同配置得到相同结果, 无法序列化时返回空字符串
+func configKey(cfg Config) string {
+	data, err := json.Marshal(cfg.Settings)
+	if err != nil {
+		return ""
+	}
+	// 反序列化为 map 后重新序列化, 使字段按名称排序
+	var settings interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return ""
+	}
+	if data, err = json.Marshal(settings); err != nil {
+		return ""
+	}
+	return cfg.Type + ":" + string(data)
+}
+
+// Apply 按完整配置列表同步数据源, 不在列表中的数据源会被移除. 单个数据源失败不影响其他数据源, 返回第一个错误
+func (m *Manager) Apply(configs []Config) error {
+	var first error
+	names := make(map[string]bool, len(configs))
+	for _, cfg := range configs {
+		names[cfg.Name] = true
+		if _, err := m.Put(cfg); err != nil {
+			logs.Errorf("加载数据源 %s 失败: %v", cfg.Name, err)
+			if first == nil {
+				first = errors.WithMessagef(err, "加载数据源 %s 失败", cfg.Name)
+			}
+		}
+	}
+	for _, name := range m.Names() {
+		if !names[name] {
+			m.Remove(name)
+		}
+	}
+	return first
+}
+
+// Get 按名称获取数据源. 返回的实例被替换或移除后可能随时关闭, 需要在一段时间内持续使用时使用 Acquire
+func (m *Manager) Get(name string) (DataSource, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sources[name]
+	if !ok {
+		return nil, false
+	}
+	return s.ds, true
+}
+
+// Names 所有数据源名称
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.sources))
+	for name := range m.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Remove 移除数据源, 进行中的查询结束后关闭
+func (m *Manager) Remove(name string) {
+	m.mu.Lock()
+	s, ok := m.sources[name]
+	delete(m.sources, name)
+	closeNow := ok && s.retire()
+	m.mu.Unlock()
+	if closeNow {
+		closeDataSource(s.ds)
+	}
+}
+
+// Acquire 获取数据源并持有引用, 使用完毕后必须调用 release. 持有期间数据源被替换或移除时,
+// 旧实例在所有引用释放后才关闭
+func (m *Manager) Acquire(name string) (ds DataSource, release func(), ok bool) {
+	m.mu.Lock()
+	s, ok := m.sources[name]
+	if ok {
+		s.refs++
+	}
+	m.mu.Unlock()
+	if !ok {
+		return nil, nil, false
+	}
+	return s.ds, func() { m.release(s) }, true
+}
+
+// release 释放引用, 已替换的实例在最后一个引用释放时关闭
+func (m *Manager) release(s *managed) {
+	m.mu.Lock()
+	s.refs--
+	closeNow := s.retired && s.refs == 0
+	m.mu.Unlock()
+	if closeNow {
+		closeDataSource(s.ds)
+	}
+}
+
+// retire 标记实例已被替换或移除, 没有引用时返回 true, 由调用方在释放锁后关闭. 调用方需持有 Manager.mu
+func (s *managed) retire() bool {
+	s.retired = true
+	return s.refs == 0
+}
+
+// Execute 在指定数据源上执行查询
+func (m *Manager) Execute(ctx context.Context, name string, query *Query) (*Result, error) {
+	ds, release, ok := m.Acquire(name)
+	if !ok {
+		return nil, errors.Errorf("数据源 %s 不存在", name)
+	}
+	defer release()
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	return ds.Execute(ctx, query)
+}
+
+// Status 数据源最近一次健康检查状态
+func (m *Manager) Status(name string) (Status, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s, ok := m.sources[name]
+	if !ok {
+		return Status{}, false
+	}
+	return s.status, true
+}
+
+// Statuses 所有数据源的健康状态, 按名称排序
+func (m *Manager) Statuses() []Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	statuses := make([]Status, 0, len(m.sources))
+	for _, s := range m.sources {
+		statuses = append(statuses, s.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// CheckHealth 并发检查所有数据源, 状态变化时记录日志
+func (m *Manager) CheckHealth(ctx context.Context) []Status {
+	m.mu.Lock()
+	targets := make(map[string]*managed, len(m.sources))
+	for name, s := range m.sources {
+		s.refs++
+		targets[name] = s
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for name, s := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer m.release(s)
+			checkCtx, cancel := context.WithTimeout(ctx, m.timeout)
+			defer cancel()
+			begin := time.Now()
+			err := s.ds.Ping(checkCtx)
+			status := Status{Name: name, Type: s.typ, Healthy: err == nil, Latency: time.Since(begin), CheckedAt: time.Now()}
+			if err != nil {
+				status.Error = err.Error()
+			}
+			m.mu.Lock()
+			// 检查期间数据源可能已被替换或移除
+			if current, ok := m.sources[name]; ok && current == s {
+				if s.status.Healthy != status.Healthy {
+					if status.Healthy {
+						logs.Infof("数据源 %s 恢复可用", name)
+					} else {
+						logs.Errorf("数据源 %s 不可用: %v", name, err)
+					}
+				}
+				s.status = status
+			}
+			m.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return m.Statuses()
+}
+
+// Start 启动定期健康检查
+func (m *Manager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.interval <= 0 || m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.run(m.stop, m.done)
+}
+
+func (m *Manager) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			m.CheckHealth(context.Background())
+		}
+	}
+}
+
+// Close 停止健康检查并关闭所有数据源, 进行中的查询结束后关闭
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	var closing []*managed
+	for _, s := range m.sources {
+		if s.retire() {
+			closing = append(closing, s)
+		}
+	}
+	m.sources = map[string]*managed{}
+	m.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	for _, s := range closing {
+		closeDataSource(s.ds)
+	}
+	return nil
+}
+
+// closeDataSource 数据源实现 io.Closer 时关闭
+func closeDataSource(ds DataSource) {
+	if c, ok := ds.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			logs.Errorf("关闭数据源失败: %v", err)
+		}
+	}
+}
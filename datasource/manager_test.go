@@ -0,0 +1,114 @@
+package datasource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type fakeConfig struct {
+	Url  string `json:"url"`
+	Down bool   `json:"down"`
+}
+
+type fakeSource struct {
+	config fakeConfig
+	closed bool
+}
+
+func (f *fakeSource) Init() error { return nil }
+
+func (f *fakeSource) Equal(ds DataSource) bool {
+	o, ok := ds.(*fakeSource)
+	return ok && o.config.Url == f.config.Url
+}
+
+func (f *fakeSource) QueryData(ctx context.Context, query interface{}) ([]*MetricPoint, error) {
+	return nil, nil
+}
+
+func (f *fakeSource) Execute(ctx context.Context, query *Query) (*Result, error) {
+	labels := &Metric{}
+	labels.Set("url", f.config.Url)
+	mp := &MetricPoint{Key: labels.String(), Labels: labels}
+	mp.Timestamp = query.Time.Unix()
+	return &Result{Type: query.Type, Points: []*MetricPoint{mp}}, nil
+}
+
+func (f *fakeSource) Ping(ctx context.Context) error {
+	if f.config.Down {
+		return errors.New("down")
+	}
+	return nil
+}
+
+func (f *fakeSource) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	created := 0
+	RegisterType("fake", func(config *fakeConfig) (DataSource, error) {
+		created++
+		return &fakeSource{config: *config}, nil
+	})
+	m := NewManager()
+	defer m.Close()
+
+	a, err := m.Put(Config{Name: "metrics", Type: "fake", Settings: map[string]interface{}{"url": "http://a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 相同数据源保留已有实例
+	same, _ := m.Put(Config{Name: "metrics", Type: "fake", Settings: fakeConfig{Url: "http://a", Down: true}})
+	if same != a {
+		t.Fatal("expected cached instance")
+	}
+	// 配置未变化时不再创建实例
+	if again, _ := m.Put(Config{Name: "metrics", Type: "fake", Settings: &fakeConfig{Url: "http://a", Down: true}}); again != a || created != 2 {
+		t.Fatalf("expected cached instance without creating, created %d", created)
+	}
+	// 地址变化时热替换并关闭旧实例
+	b, _ := m.Put(Config{Name: "metrics", Type: "fake", Settings: &fakeConfig{Url: "http://b", Down: true}})
+	if b == a || !a.(*fakeSource).closed {
+		t.Fatal("expected swapped instance")
+	}
+	result, err := m.Execute(context.Background(), "metrics", &Query{})
+	if err != nil || result.Type != QueryInstant || result.Points[0].Labels.Get("url") != "http://b" {
+		t.Fatalf("unexpected result %+v: %v", result, err)
+	}
+	if _, err := m.Put(Config{Name: "x", Type: "unknown"}); err == nil {
+		t.Fatal("expected unknown type error")
+	}
+
+	statuses := m.CheckHealth(context.Background())
+	if len(statuses) != 1 || statuses[0].Healthy || statuses[0].Error != "down" {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+
+	err = m.Apply([]Config{{Name: "logs", Type: "fake", Settings: map[string]interface{}{"url": "http://c"}}})
+	if err != nil || len(m.Names()) != 1 || !b.(*fakeSource).closed {
+		t.Fatalf("unexpected names %v: %v", m.Names(), err)
+	}
+	if status, ok := m.Status("logs"); !ok || !status.Healthy {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	// 查询进行中时替换, 旧实例在释放引用后才关闭
+	c, release, ok := m.Acquire("logs")
+	if !ok {
+		t.Fatal("expected logs source")
+	}
+	if _, err := m.Put(Config{Name: "logs", Type: "fake", Settings: fakeConfig{Url: "http://d"}}); err != nil {
+		t.Fatal(err)
+	}
+	if c.(*fakeSource).closed {
+		t.Fatal("instance closed while in use")
+	}
+	release()
+	if !c.(*fakeSource).closed {
+		t.Fatal("expected retired instance closed after release")
+	}
+}
@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/gogo/protobuf/proto"
@@ -10,16 +11,30 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/xiehqing/common/datasource"
-	"github.com/xiehqing/common/pkg/crypto"
 	"github.com/xiehqing/common/pkg/httpx"
 	"github.com/xiehqing/common/pkg/logs"
 	"github.com/xiehqing/common/pkg/util"
 	"io"
 	"net"
 	"net/http"
+	"reflect"
 	"time"
 )
 
+// Type 数据源类型名
+const Type = "prometheus"
+
+var _ datasource.DataSource = (*Client)(nil)
+
+func init() {
+	datasource.RegisterType(Type, func(config *Config) (datasource.DataSource, error) {
+		if err := config.Validate(); err != nil {
+			return nil, err
+		}
+		return NewClient(config)
+	})
+}
+
 type Client struct {
 	Url           string
 	client        *httpx.Client
@@ -38,12 +53,8 @@ func NewClient(config *Config) (*Client, error) {
 		customHeaders[k] = v
 	}
 	if config.Username != "" && config.Password != "" {
-		authToken, err := crypto.Base64Crypto.Encrypt(fmt.Sprintf("%s:%s", config.Username, config.Password))
-		if err != nil {
-			logs.Errorf("认证信息编码失败：%v, 请检查配置", err)
-		} else {
-			customHeaders["Authorization"] = fmt.Sprintf("Basic %s", authToken)
-		}
+		authToken := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", config.Username, config.Password)))
+		customHeaders["Authorization"] = fmt.Sprintf("Basic %s", authToken)
 	}
 	c := &Client{
 		config:        config,
@@ -76,24 +87,14 @@ func (cli *Client) Init() error {
 	return nil
 }
 
-// Equal 判断是否相同数据源
+// Equal 判断是否相同数据源, 比较完整配置, 地址、认证、请求头、超时与连接数任一变化都会替换实例
 func (cli *Client) Equal(other datasource.DataSource) bool {
 	otherCli, ok := other.(*Client)
 	if !ok {
 		logs.Errorf("数据源类型不匹配")
 		return false
 	}
-	for k, v := range cli.customHeaders {
-		if otherCli.customHeaders[k] != v {
-			return false
-		}
-	}
-	for k, v := range otherCli.customHeaders {
-		if cli.customHeaders[k] != v {
-			return false
-		}
-	}
-	return cli.Url == otherCli.Url
+	return reflect.DeepEqual(cli.config.normalize(), otherCli.config.normalize())
 }
 
 // FastCheckConnectivity 快速测试连通性
@@ -107,8 +108,20 @@ func FastCheckConnectivity(url, username, password string) error {
 
 // CheckConnectivity 测试连通性
 func (cli *Client) CheckConnectivity() error {
+	return cli.Ping(context.Background())
+}
+
+// Ping 探测数据源是否可用
+func (cli *Client) Ping(ctx context.Context) error {
 	uri := fmt.Sprintf("%s/flags", cli.Url)
-	resp, err := cli.client.Client.Get(uri)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return errors.WithMessagef(err, "测试连通性失败")
+	}
+	for k, v := range cli.customHeaders {
+		req.Header.Set(k, v)
+	}
+	resp, err := cli.client.Client.Do(req)
 	if err != nil {
 		return errors.WithMessagef(err, "测试连通性失败")
 	}
@@ -143,6 +156,40 @@ func (cli *Client) QueryData(ctx context.Context, query interface{}) ([]*datasou
 	return metricPoints, nil
 }
 
+// Execute 执行统一模型的查询, 支持 instant 与 range 查询
+func (cli *Client) Execute(ctx context.Context, query *datasource.Query) (*datasource.Result, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	result := &datasource.Result{Type: query.Type}
+	switch query.Type {
+	case datasource.QueryInstant:
+		step := "2m"
+		if query.Step > 0 {
+			step = model.Duration(query.Step).String()
+		}
+		points, err := cli.QueryCtx(ctx, query.Expr, query.Time, step)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "[prometheus] query error")
+		}
+		result.Points = points
+	case datasource.QueryRange:
+		// 未指定步长时按每条序列约 250 个点计算
+		step := getStepFromDuration(query.End.Sub(query.Start).Seconds() / 250)
+		if query.Step > 0 {
+			step = model.Duration(query.Step).String()
+		}
+		series, err := cli.QueryRangeCtx(ctx, query.Expr, query.Start, query.End, step)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "[prometheus] query range error")
+		}
+		result.Series = series
+	default:
+		return nil, errors.Errorf("[prometheus] 不支持的查询类型: %s", query.Type)
+	}
+	return result, nil
+}
+
 // handlePrometheusResponse 处理Prometheus响应
 func (cli *Client) handlePrometheusResponse(resp *http.Response, body []byte) (model.Value, error) {
 	statusCode := resp.StatusCode
@@ -168,6 +215,11 @@ func (cli *Client) handlePrometheusResponse(resp *http.Response, body []byte) (m
 
 // Query 查询数据
 func (cli *Client) Query(query string, timestamp time.Time, step string) ([]*datasource.MetricPoint, error) {
+	return cli.QueryCtx(context.Background(), query, timestamp, step)
+}
+
+// QueryCtx 查询数据, 请求随 ctx 取消
+func (cli *Client) QueryCtx(ctx context.Context, query string, timestamp time.Time, step string) ([]*datasource.MetricPoint, error) {
 	uri := fmt.Sprintf("%s/query", apiPrefix)
 	opt := httpx.NewRequestOption(
 		httpx.WithQueryParam("query", query),
@@ -177,7 +229,7 @@ func (cli *Client) Query(query string, timestamp time.Time, step string) ([]*dat
 		httpx.WithMethod(http.MethodGet),
 		httpx.WithPath(uri),
 	)
-	resp, err := cli.client.Do(opt)
+	resp, err := cli.client.DoCtx(ctx, opt)
 	if err != nil {
 		return nil, errors.WithMessagef(err, "请求失败")
 	}
@@ -195,6 +247,11 @@ func (cli *Client) Query(query string, timestamp time.Time, step string) ([]*dat
 
 // QueryRange 查询范围数据
 func (cli *Client) QueryRange(query string, start, end time.Time, step string) ([]*datasource.MetricSeries, error) {
+	return cli.QueryRangeCtx(context.Background(), query, start, end, step)
+}
+
+// QueryRangeCtx 查询范围数据, 请求随 ctx 取消
+func (cli *Client) QueryRangeCtx(ctx context.Context, query string, start, end time.Time, step string) ([]*datasource.MetricSeries, error) {
 	uri := fmt.Sprintf("%s/query_range", apiPrefix)
 	opt := httpx.NewRequestOption(
 		httpx.WithQueryParam("query", query),
@@ -205,7 +262,7 @@ func (cli *Client) QueryRange(query string, start, end time.Time, step string) (
 		httpx.WithMethod(http.MethodGet),
 		httpx.WithPath(uri),
 	)
-	resp, err := cli.client.Do(opt)
+	resp, err := cli.client.DoCtx(ctx, opt)
 	if err != nil {
 		return nil, errors.WithMessagef(err, "请求失败")
 	}
@@ -0,0 +1,59 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/xiehqing/common/datasource"
+)
+
+func TestExecute(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flags":
+			w.WriteHeader(http.StatusOK)
+		case "/api/v1/query_range":
+			if r.URL.Query().Get("query") != "up" || r.URL.Query().Get("step") != "1m" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up","job":"node"},"values":[[1760000000,"1"],[1760000060,"0"]]}]}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ds, err := datasource.New(Type, map[string]interface{}{"url": srv.URL, "timeout": 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	end := time.Unix(1760000060, 0)
+	result, err := ds.Execute(context.Background(), &datasource.Query{Type: datasource.QueryRange, Expr: "up", Start: end.Add(-time.Minute), End: end, Step: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Series) != 1 || len(result.Series[0].DataPoints) != 2 || result.Series[0].Labels.Get("job") != "node" {
+		t.Fatalf("unexpected series %+v", result.Series)
+	}
+	if _, err := ds.Execute(context.Background(), &datasource.Query{Type: datasource.QueryLogs, Start: end.Add(-time.Minute)}); err == nil {
+		t.Fatal("expected unsupported logs query")
+	}
+
+	same, err := datasource.New(Type, map[string]interface{}{"url": srv.URL, "timeout": 1000, "headers": map[string]string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := datasource.New(Type, map[string]interface{}{"url": srv.URL, "timeout": 3000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ds.Equal(same) || ds.Equal(changed) {
+		t.Fatal("expected Equal to compare the full config")
+	}
+}
@@ -86,6 +86,18 @@ type Config struct {
 	MaxIdleConnsPerHost int               `json:"maxIdleConnsPerHost"` // 每个主机最大空闲连接数
 }
 
+// normalize 规整配置, 空请求头与 nil 等价, 用于比较配置是否相同
+func (c *Config) normalize() Config {
+	if c == nil {
+		return Config{}
+	}
+	n := *c
+	if len(n.Headers) == 0 {
+		n.Headers = nil
+	}
+	return n
+}
+
 // Validate 校验
 func (c *Config) Validate() error {
 	if c.Url == "" {
@@ -98,6 +110,12 @@ type Range struct {
 	Start time.Time `json:"start"`
 	End   time.Time `json:"end"`
 }
+
+// NewRange 由 util.TimeRange 创建查询范围, 如 util.Calendar 计算的自然周期窗口
+func NewRange(r util.TimeRange) *Range {
+	return &Range{Start: r.StartTime, End: r.EndTime}
+}
+
 type QueryParam struct {
 	Query string     `json:"query"`
 	Time  *time.Time `json:"time"`
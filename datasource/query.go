@@ -0,0 +1,98 @@
+package datasource
+
+import (
+	"github.com/pkg/errors"
+	"time"
+)
+
+// QueryType 查询类型
+type QueryType string
+
+const (
+	// QueryInstant 某一时间点的值, 结果为 Points
+	QueryInstant QueryType = "instant"
+	// QueryRange 一段时间内按步长采样的序列, 结果为 Series
+	QueryRange QueryType = "range"
+	// QueryLogs 一段时间内的原始日志, 结果为 Logs
+	QueryLogs QueryType = "logs"
+)
+
+// DefaultLookback instant 查询在日志类数据源上的默认统计窗口
+const DefaultLookback = 5 * time.Minute
+
+// Query 统一查询模型, 同一面板可以切换 Prometheus、ElasticSearch 等不同数据源
+type Query struct {
+	Type QueryType `json:"type"`
+	// Expr 查询表达式, Prometheus 为 PromQL, ElasticSearch 为 query_string 语法, 为空时匹配全部
+	Expr string `json:"expr"`
+	// Time instant 查询的时间点, 为空时为当前时间
+	Time time.Time `json:"time"`
+	// Start, End range 与 logs 查询的时间范围
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Step range 查询的步长, instant 查询在日志类数据源上的统计窗口, 为空时自动计算
+	Step time.Duration `json:"step"`
+	// Index ElasticSearch 索引, 多个用逗号分隔
+	Index string `json:"index"`
+	// TimeField ElasticSearch 时间字段, 默认 @timestamp
+	TimeField string `json:"timeField"`
+	// ValueField ElasticSearch 指标字段, 按平均值聚合, 为空时统计文档数
+	ValueField string `json:"valueField"`
+	// GroupBy ElasticSearch 分组字段, 每个分组一条序列
+	GroupBy string `json:"groupBy"`
+	// Limit logs 查询的最大条数, range 查询的最大分组数, 默认 100
+	Limit int `json:"limit"`
+}
+
+// Validate 校验并补全默认值
+func (q *Query) Validate() error {
+	switch q.Type {
+	case QueryInstant, "":
+		q.Type = QueryInstant
+		if q.Time.IsZero() {
+			q.Time = time.Now()
+		}
+	case QueryRange, QueryLogs:
+		if q.End.IsZero() {
+			q.End = time.Now()
+		}
+		if q.Start.IsZero() || !q.Start.Before(q.End) {
+			return errors.Errorf("查询时间范围有误: %s - %s", q.Start, q.End)
+		}
+	default:
+		return errors.Errorf("不支持的查询类型: %s", q.Type)
+	}
+	if q.Step < 0 {
+		return errors.Errorf("查询步长有误: %s", q.Step)
+	}
+	if q.TimeField == "" {
+		q.TimeField = "@timestamp"
+	}
+	if q.Limit <= 0 {
+		q.Limit = 100
+	}
+	return nil
+}
+
+// LogEntry 一条日志
+type LogEntry struct {
+	Time   time.Time              `json:"time"`
+	Index  string                 `json:"index,omitempty"`
+	ID     string                 `json:"id,omitempty"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// Result 统一查询结果, 按查询类型填充对应字段
+type Result struct {
+	Type   QueryType       `json:"type"`
+	Points []*MetricPoint  `json:"points,omitempty"`
+	Series []*MetricSeries `json:"series,omitempty"`
+	Logs   []*LogEntry     `json:"logs,omitempty"`
+	// Total logs 查询命中的总数, 可能大于 len(Logs)
+	Total int64 `json:"total,omitempty"`
+}
+
+// Lines range 查询结果转为折线图
+func (r *Result) Lines() []*MetricLine {
+	return ConvertSeriesToLine(r.Series)
+}
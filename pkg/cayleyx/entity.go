@@ -0,0 +1,350 @@
+package cayleyx
+
+import (
+	"fmt"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/voc/rdf"
+	"github.com/pkg/errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// 实体通过 graph 标签映射为四元组:
+//
+//	type Person struct {
+//		ID      string   `graph:"@id"`
+//		Name    string   `graph:"name"`
+//		Age     int      `graph:"age,omitempty"`
+//		Tags    []string `graph:"tag"`
+//		Company string   `graph:"worksAt,ref=company"`
+//	}
+//
+// 节点标识为 "类型/ID", 类型默认为首字母小写的结构体名, 实现 GraphKind 方法时使用其返回值.
+// 普通字段保存为字面量, 带 ref 的字段保存为指向 "ref/字段值" 节点的边, 切片字段保存为多个值(不保证顺序),
+// 未设置 graph 标签的字段不会保存, 没有 @id 标签时使用名为 ID 的字段.
+
+// PredicateType 实体类型使用的谓词
+const PredicateType = quad.IRI(rdf.Type)
+
+// ErrNotFound 节点不存在
+var ErrNotFound = errors.New("graph node not found")
+
+// GraphKinder 自定义实体的类型
+type GraphKinder interface {
+	GraphKind() string
+}
+
+// NodeID 节点标识, 格式为 "类型/ID", 导入的其他 IRI 也可以作为节点标识使用
+type NodeID string
+
+// Node 根据类型与ID生成节点标识
+func Node(kind, id string) NodeID {
+	return NodeID(kind + "/" + id)
+}
+
+// Kind 节点类型, 不是 "类型/ID" 格式时返回空字符串
+func (n NodeID) Kind() string {
+	if i := strings.Index(string(n), "/"); i > 0 {
+		return string(n)[:i]
+	}
+	return ""
+}
+
+// ID 节点ID, 不是 "类型/ID" 格式时返回整个标识
+func (n NodeID) ID() string {
+	if i := strings.Index(string(n), "/"); i > 0 {
+		return string(n)[i+1:]
+	}
+	return string(n)
+}
+
+// IRI 节点对应的四元组值
+func (n NodeID) IRI() quad.IRI {
+	return quad.IRI(n)
+}
+
+// entityField 实体字段与谓词的映射
+type entityField struct {
+	index     []int
+	predicate quad.IRI
+	ref       string
+	omitempty bool
+	multi     bool
+}
+
+// entitySpec 实体类型的映射规则
+type entitySpec struct {
+	typ    reflect.Type
+	kind   string
+	id     []int
+	fields []*entityField
+}
+
+var entityCache sync.Map // map[reflect.Type]*entitySpec
+
+// specOf 解析实体结构体的 graph 标签
+func specOf(t reflect.Type) (*entitySpec, error) {
+	if v, ok := entityCache.Load(t); ok {
+		return v.(*entitySpec), nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("entity must be a struct, got %s", t)
+	}
+	spec := &entitySpec{typ: t, kind: kindOf(t)}
+	if err := collectFields(spec, t, nil); err != nil {
+		return nil, err
+	}
+	if spec.id == nil {
+		if sf, ok := t.FieldByName("ID"); ok {
+			spec.id = sf.Index
+		} else {
+			return nil, errors.Errorf("entity %s has no @id field", t)
+		}
+	}
+	entityCache.Store(t, spec)
+	return spec, nil
+}
+
+func collectFields(spec *entitySpec, t reflect.Type, parent []int) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+		tag, ok := sf.Tag.Lookup("graph")
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && !ok {
+			if err := collectFields(spec, sf.Type, index); err != nil {
+				return err
+			}
+			continue
+		}
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		if parts[0] == "@id" {
+			spec.id = index
+			continue
+		}
+		field := &entityField{index: index, predicate: quad.IRI(parts[0])}
+		for _, opt := range parts[1:] {
+			switch {
+			case opt == "omitempty":
+				field.omitempty = true
+			case strings.HasPrefix(opt, "ref="):
+				field.ref = strings.TrimPrefix(opt, "ref=")
+			default:
+				return errors.Errorf("invalid graph tag option %q on %s.%s", opt, t, sf.Name)
+			}
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() != reflect.Uint8 {
+			field.multi = true
+			ft = ft.Elem()
+		}
+		if _, err := toValue(reflect.Zero(ft)); err != nil {
+			return errors.WithMessagef(err, "unsupported field %s.%s", t, sf.Name)
+		}
+		spec.fields = append(spec.fields, field)
+	}
+	return nil
+}
+
+func kindOf(t reflect.Type) string {
+	if k, ok := reflect.Zero(t).Interface().(GraphKinder); ok {
+		return k.GraphKind()
+	}
+	if k, ok := reflect.New(t).Interface().(GraphKinder); ok {
+		return k.GraphKind()
+	}
+	name := []rune(t.Name())
+	if len(name) > 0 {
+		name[0] = unicode.ToLower(name[0])
+	}
+	return string(name)
+}
+
+// entityValue 返回实体的映射规则与结构体值
+func entityValue(entity interface{}) (*entitySpec, reflect.Value, error) {
+	rv := reflect.ValueOf(entity)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, rv, errors.New("entity is nil")
+		}
+		rv = rv.Elem()
+	}
+	spec, err := specOf(rv.Type())
+	return spec, rv, err
+}
+
+// NodeOf 实体对应的节点标识
+func NodeOf(entity interface{}) (NodeID, error) {
+	spec, rv, err := entityValue(entity)
+	if err != nil {
+		return "", err
+	}
+	return spec.node(rv)
+}
+
+func (s *entitySpec) node(rv reflect.Value) (NodeID, error) {
+	id := fmt.Sprint(rv.FieldByIndex(s.id).Interface())
+	if id == "" || id == "0" {
+		return "", errors.Errorf("entity %s has empty id", s.typ)
+	}
+	return Node(s.kind, id), nil
+}
+
+// predicates 实体管理的谓词, 更新实体时这些谓词的旧值会被替换
+func (s *entitySpec) predicates() map[quad.Value]bool {
+	preds := map[quad.Value]bool{PredicateType: true}
+	for _, f := range s.fields {
+		preds[f.predicate] = true
+	}
+	return preds
+}
+
+// quads 把实体转换为四元组
+func (s *entitySpec) quads(node NodeID, rv reflect.Value) ([]quad.Quad, error) {
+	quads := []quad.Quad{quad.Make(node.IRI(), PredicateType, quad.IRI(s.kind), nil)}
+	for _, f := range s.fields {
+		fv := rv.FieldByIndex(f.index)
+		values := []reflect.Value{fv}
+		if f.multi {
+			values = values[:0]
+			for i := 0; i < fv.Len(); i++ {
+				values = append(values, fv.Index(i))
+			}
+		}
+		for _, v := range values {
+			if (f.omitempty || f.ref != "") && v.IsZero() {
+				continue
+			}
+			var object quad.Value
+			if f.ref != "" {
+				object = Node(f.ref, fmt.Sprint(v.Interface())).IRI()
+			} else {
+				var err error
+				if object, err = toValue(v); err != nil {
+					return nil, err
+				}
+			}
+			quads = append(quads, quad.Make(node.IRI(), f.predicate, object, nil))
+		}
+	}
+	return quads, nil
+}
+
+// load 把节点的出边四元组写入实体
+func (s *entitySpec) load(node NodeID, quads []quad.Quad, rv reflect.Value) error {
+	rv.Set(reflect.Zero(s.typ))
+	if err := assign(rv.FieldByIndex(s.id), quad.String(node.ID())); err != nil {
+		return err
+	}
+	fields := make(map[quad.Value]*entityField, len(s.fields))
+	for _, f := range s.fields {
+		fields[f.predicate] = f
+	}
+	for _, q := range quads {
+		f, ok := fields[q.Predicate]
+		if !ok {
+			continue
+		}
+		value := q.Object
+		if iri, ok := value.(quad.IRI); ok && f.ref != "" {
+			value = quad.String(NodeID(iri).ID())
+		}
+		fv := rv.FieldByIndex(f.index)
+		if f.multi {
+			elem := reflect.New(fv.Type().Elem()).Elem()
+			if err := assign(elem, value); err != nil {
+				return errors.WithMessagef(err, "failed to load %s of %s", f.predicate, node)
+			}
+			fv.Set(reflect.Append(fv, elem))
+		} else if err := assign(fv, value); err != nil {
+			return errors.WithMessagef(err, "failed to load %s of %s", f.predicate, node)
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// toValue 把字段值转换为四元组的字面量
+func toValue(v reflect.Value) (quad.Value, error) {
+	if v.Type() == timeType {
+		return quad.Time(v.Interface().(time.Time)), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return quad.String(v.String()), nil
+	case reflect.Bool:
+		return quad.Bool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return quad.Int(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return quad.Int(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return quad.Float(v.Float()), nil
+	}
+	return nil, errors.Errorf("unsupported type %s", v.Type())
+}
+
+// assign 把四元组的值写入字段, CSV 导入的字符串值会按字段类型解析
+func assign(dst reflect.Value, value quad.Value) error {
+	var native interface{}
+	switch v := value.(type) {
+	case quad.IRI:
+		native = string(v)
+	case quad.BNode:
+		native = string(v)
+	default:
+		native = quad.NativeOf(value)
+	}
+	if native == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(native)
+	if rv.Type().AssignableTo(dst.Type()) {
+		dst.Set(rv)
+		return nil
+	}
+	s, isString := native.(string)
+	if !isString {
+		s = fmt.Sprint(native)
+	}
+	var err error
+	switch {
+	case dst.Type() == timeType:
+		var t time.Time
+		if t, err = time.Parse(time.RFC3339Nano, s); err == nil {
+			dst.Set(reflect.ValueOf(t))
+		}
+	case dst.Kind() == reflect.String:
+		dst.SetString(s)
+	case dst.Kind() == reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			dst.SetBool(b)
+		}
+	case dst.CanInt():
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, 64); err == nil {
+			dst.SetInt(i)
+		}
+	case dst.CanUint():
+		var u uint64
+		if u, err = strconv.ParseUint(s, 10, 64); err == nil {
+			dst.SetUint(u)
+		}
+	case dst.CanFloat():
+		var f float64
+		if f, err = strconv.ParseFloat(s, 64); err == nil {
+			dst.SetFloat(f)
+		}
+	default:
+		err = errors.Errorf("unsupported type %s", dst.Type())
+	}
+	return errors.WithMessagef(err, "cannot assign %v to %s", value, dst.Type())
+}
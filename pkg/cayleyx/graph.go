@@ -0,0 +1,232 @@
+package cayleyx
+
+import (
+	"context"
+	"github.com/cayleygraph/cayley"
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/cayley/graph/path"
+	"github.com/cayleygraph/quad"
+	"github.com/pkg/errors"
+	"reflect"
+	"sort"
+)
+
+// Graph 类型化的图数据库, 实体与关系通过 graph 标签映射为四元组, 所有写操作在一个事务中完成
+type Graph struct {
+	h *cayley.Handle
+}
+
+// NewGraph 根据配置创建图数据库, 支持内存与 BoltDB 后端
+func NewGraph(cfg *Config) (*Graph, error) {
+	h, err := NewCayleyGraph(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Graph{h: h}, nil
+}
+
+// WrapGraph 包装已有的 cayley 图
+func WrapGraph(h *cayley.Handle) *Graph {
+	return &Graph{h: h}
+}
+
+// Handle 底层的 cayley 图, 用于执行自定义查询
+func (g *Graph) Handle() *cayley.Handle {
+	return g.h
+}
+
+// Close 关闭图数据库
+func (g *Graph) Close() error {
+	return g.h.Close()
+}
+
+// Relation 两个节点之间的关系
+type Relation struct {
+	From      NodeID `json:"from"`
+	Predicate string `json:"predicate"`
+	To        NodeID `json:"to"`
+}
+
+func (r Relation) quad() quad.Quad {
+	return quad.Make(r.From.IRI(), quad.IRI(r.Predicate), r.To.IRI(), nil)
+}
+
+// Upsert 新增或更新实体. 实体标签声明的谓词的旧值会被替换, 其他关系与指向实体的边保持不变
+func (g *Graph) Upsert(ctx context.Context, entities ...interface{}) error {
+	tx := graph.NewTransaction()
+	for _, entity := range entities {
+		spec, rv, err := entityValue(entity)
+		if err != nil {
+			return err
+		}
+		node, err := spec.node(rv)
+		if err != nil {
+			return err
+		}
+		quads, err := spec.quads(node, rv)
+		if err != nil {
+			return err
+		}
+		if err := g.replace(ctx, tx, node, spec.predicates(), quads); err != nil {
+			return err
+		}
+	}
+	return g.apply(tx)
+}
+
+// replace 在事务中删除节点指定谓词的出边, 再写入新的四元组
+func (g *Graph) replace(ctx context.Context, tx *graph.Transaction, node NodeID, predicates map[quad.Value]bool, quads []quad.Quad) error {
+	old, err := g.quads(ctx, node, quad.Subject)
+	if err != nil {
+		return err
+	}
+	for _, q := range old {
+		if predicates[q.Predicate] {
+			tx.RemoveQuad(q)
+		}
+	}
+	for _, q := range quads {
+		tx.AddQuad(q)
+	}
+	return nil
+}
+
+// Get 读取实体, dst 为实体结构体的指针, 节点不存在时返回 ErrNotFound
+func (g *Graph) Get(ctx context.Context, id string, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("dst must be a non-nil pointer")
+	}
+	spec, err := specOf(rv.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return g.load(ctx, spec, Node(spec.kind, id), rv.Elem())
+}
+
+func (g *Graph) load(ctx context.Context, spec *entitySpec, node NodeID, rv reflect.Value) error {
+	quads, err := g.quads(ctx, node, quad.Subject)
+	if err != nil {
+		return err
+	}
+	for _, q := range quads {
+		if q.Predicate == PredicateType && q.Object == quad.IRI(spec.kind) {
+			return spec.load(node, quads, rv)
+		}
+	}
+	return errors.Wrap(ErrNotFound, string(node))
+}
+
+// List 读取某类实体的全部节点, 按节点标识排序
+func List[T any](ctx context.Context, g *Graph) ([]*T, error) {
+	spec, err := specOf(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := g.Nodes(ctx, spec.kind)
+	if err != nil {
+		return nil, err
+	}
+	entities := make([]*T, 0, len(nodes))
+	for _, node := range nodes {
+		entity := new(T)
+		if err := g.load(ctx, spec, node, reflect.ValueOf(entity).Elem()); err != nil {
+			return nil, err
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// Nodes 某类型的全部节点标识, 按标识排序
+func (g *Graph) Nodes(ctx context.Context, kind string) ([]NodeID, error) {
+	var nodes []NodeID
+	err := path.StartPath(g.h, quad.IRI(kind)).In(PredicateType).Iterate(ctx).EachValue(g.h, func(v quad.Value) {
+		if iri, ok := v.(quad.IRI); ok {
+			nodes = append(nodes, NodeID(iri))
+		}
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to list %s nodes", kind)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	return nodes, nil
+}
+
+// Delete 删除节点及其全部出边与入边
+func (g *Graph) Delete(ctx context.Context, nodes ...NodeID) error {
+	tx := graph.NewTransaction()
+	for _, node := range nodes {
+		for _, dir := range []quad.Direction{quad.Subject, quad.Object} {
+			quads, err := g.quads(ctx, node, dir)
+			if err != nil {
+				return err
+			}
+			for _, q := range quads {
+				tx.RemoveQuad(q)
+			}
+		}
+	}
+	return g.apply(tx)
+}
+
+// DeleteEntity 删除实体对应的节点及其全部出边与入边
+func (g *Graph) DeleteEntity(ctx context.Context, entities ...interface{}) error {
+	nodes := make([]NodeID, 0, len(entities))
+	for _, entity := range entities {
+		node, err := NodeOf(entity)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+	}
+	return g.Delete(ctx, nodes...)
+}
+
+// Link 添加关系, 已存在的关系会被忽略
+func (g *Graph) Link(ctx context.Context, relations ...Relation) error {
+	tx := graph.NewTransaction()
+	for _, r := range relations {
+		tx.AddQuad(r.quad())
+	}
+	return g.apply(tx)
+}
+
+// Unlink 删除关系, 不存在的关系会被忽略
+func (g *Graph) Unlink(ctx context.Context, relations ...Relation) error {
+	tx := graph.NewTransaction()
+	for _, r := range relations {
+		quads, err := g.quads(ctx, r.From, quad.Subject)
+		if err != nil {
+			return err
+		}
+		for _, q := range quads {
+			if q.Predicate == quad.IRI(r.Predicate) && q.Object == r.To.IRI() {
+				tx.RemoveQuad(q)
+			}
+		}
+	}
+	return g.apply(tx)
+}
+
+// quads 节点在指定方向上的全部四元组
+func (g *Graph) quads(ctx context.Context, node NodeID, dir quad.Direction) ([]quad.Quad, error) {
+	ref := g.h.ValueOf(node.IRI())
+	if ref == nil {
+		return nil, nil
+	}
+	it := g.h.QuadIterator(dir, ref)
+	defer it.Close()
+	var quads []quad.Quad
+	for it.Next(ctx) {
+		quads = append(quads, g.h.Quad(it.Result()))
+	}
+	return quads, errors.WithMessagef(it.Err(), "failed to iterate quads of %s", node)
+}
+
+func (g *Graph) apply(tx *graph.Transaction) error {
+	if len(tx.Deltas) == 0 {
+		return nil
+	}
+	return errors.WithMessage(g.h.ApplyTransaction(tx), "failed to apply graph transaction")
+}
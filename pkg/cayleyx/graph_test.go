@@ -0,0 +1,135 @@
+package cayleyx
+
+import (
+	"bytes"
+	"context"
+	"github.com/cayleygraph/quad/nquads"
+	"github.com/pkg/errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type person struct {
+	ID      string   `graph:"@id"`
+	Name    string   `graph:"name"`
+	Age     int      `graph:"age,omitempty"`
+	Tags    []string `graph:"tag"`
+	Company string   `graph:"worksAt,ref=company"`
+}
+
+type company struct {
+	ID   string `graph:"@id"`
+	Name string `graph:"name"`
+}
+
+func TestGraph(t *testing.T) {
+	backends := map[string]*Config{
+		"memory": {Type: GraphTypeMemory},
+		"bolt":   {Type: GraphTypeBolt, DBPath: filepath.Join(t.TempDir(), "graph.db")},
+	}
+	for name, cfg := range backends {
+		t.Run(name, func(t *testing.T) {
+			if raceEnabled && cfg.Type == GraphTypeBolt {
+				// bolt 使用 unsafe 指针访问 mmap 内存, -race 开启的 checkptr 检查会直接崩溃
+				t.Skip("bolt backend is not supported under -race")
+			}
+			g, err := NewGraph(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+			testGraph(t, g)
+		})
+	}
+}
+
+func testGraph(t *testing.T, g *Graph) {
+	ctx := context.Background()
+	alice := &person{ID: "alice", Name: "Alice", Age: 30, Tags: []string{"go"}, Company: "acme"}
+	if err := g.Upsert(ctx, alice, &person{ID: "bob", Name: "Bob"}, &company{ID: "acme", Name: "Acme"}); err != nil {
+		t.Fatal(err)
+	}
+	alice.Age, alice.Tags = 31, []string{"rust"}
+	if err := g.Upsert(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	var got person
+	if err := g.Get(ctx, "alice", &got); err != nil || !reflect.DeepEqual(&got, alice) {
+		t.Fatalf("unexpected entity %+v: %v", got, err)
+	}
+	if err := g.Get(ctx, "carol", &got); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+
+	// CSV 导入的属性按字段类型解析, 重复的 id 以最后一行为准
+	n, err := g.ImportNodesCSV(ctx, strings.NewReader("id,name,age\ncarol,Caroline,24\ndave,Dave,\ncarol,Carol,25\n"), "person")
+	if err != nil || n != 5 {
+		t.Fatalf("unexpected node import %d: %v", n, err)
+	}
+	if err := g.Get(ctx, "carol", &got); err != nil || got.Age != 25 || got.Name != "Carol" {
+		t.Fatalf("unexpected imported entity %+v: %v", got, err)
+	}
+	edges := "from,predicate,to\nperson/alice,knows,person/bob\nperson/bob,knows,person/carol\nperson/carol,knows,person/dave\n"
+	if _, err := g.ImportEdgesCSV(ctx, strings.NewReader(edges)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.ImportEdgesCSV(ctx, strings.NewReader("from,predicate,to\nperson/dave,knows,person/alice\nperson/dave,,\n")); err == nil {
+		t.Fatal("expected invalid csv error")
+	}
+	people, err := List[person](ctx, g)
+	if err != nil || len(people) != 4 || people[0].ID != "alice" {
+		t.Fatalf("unexpected list %v: %v", people, err)
+	}
+
+	neighbors, err := g.Neighbors(ctx, Node("person", "alice"))
+	if err != nil || !reflect.DeepEqual(neighbors, []NodeID{"company/acme", "person/bob"}) {
+		t.Fatalf("unexpected neighbors %v: %v", neighbors, err)
+	}
+	neighbors, _ = g.Neighbors(ctx, Node("company", "acme"), WithDirection(DirectionIn))
+	if !reflect.DeepEqual(neighbors, []NodeID{"person/alice"}) {
+		t.Fatalf("unexpected in neighbors %v", neighbors)
+	}
+	hops, err := g.KHop(ctx, Node("person", "alice"), 2, WithPredicates("knows"))
+	if err != nil || !reflect.DeepEqual(hops, []Hop{{"person/bob", 1}, {"person/carol", 2}}) {
+		t.Fatalf("unexpected hops %v: %v", hops, err)
+	}
+	path, err := g.ShortestPath(ctx, Node("person", "dave"), Node("person", "alice"), WithDirection(DirectionBoth))
+	if err != nil || !reflect.DeepEqual(path, []NodeID{"person/dave", "person/carol", "person/bob", "person/alice"}) {
+		t.Fatalf("unexpected path %v: %v", path, err)
+	}
+	if _, err := g.ShortestPath(ctx, Node("person", "dave"), Node("person", "alice")); !errors.Is(err, ErrNoPath) {
+		t.Fatalf("expected no path, got %v", err)
+	}
+
+	// 删除节点时同时删除指向它的边
+	if err := g.DeleteEntity(ctx, &person{ID: "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if neighbors, _ := g.Neighbors(ctx, Node("person", "alice"), WithPredicates("knows")); len(neighbors) != 0 {
+		t.Fatalf("expected edges removed, got %v", neighbors)
+	}
+
+	jsonld := `{"@id": "http://example.org/eve", "http://example.org/knows": {"@id": "http://example.org/alice"}}`
+	if n, err := g.ImportJSONLD(ctx, strings.NewReader(jsonld)); err != nil || n != 1 {
+		t.Fatalf("unexpected json-ld import %d: %v", n, err)
+	}
+
+	var buf bytes.Buffer
+	n, err = g.ExportNQuads(ctx, &buf)
+	if err != nil || n != strings.Count(buf.String(), "\n") {
+		t.Fatalf("unexpected export %d: %v", n, err)
+	}
+	if !strings.Contains(buf.String(), `<person/alice> <name> "Alice" .`) {
+		t.Fatalf("unexpected export:\n%s", buf.String())
+	}
+	other, err := NewGraph(&Config{Type: GraphTypeMemory})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if m, err := other.ImportQuads(ctx, nquads.NewReader(&buf, false)); err != nil || m != n {
+		t.Fatalf("unexpected n-quads import %d: %v", m, err)
+	}
+}
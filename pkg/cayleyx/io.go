@@ -0,0 +1,163 @@
+package cayleyx
+
+import (
+	"context"
+	"encoding/csv"
+	"github.com/cayleygraph/cayley/graph"
+	"github.com/cayleygraph/quad"
+	"github.com/cayleygraph/quad/jsonld"
+	"github.com/cayleygraph/quad/nquads"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+)
+
+// 批量导入在一个事务中完成, 任意一行出错时不会写入任何数据, 返回值为实际变更的四元组数量
+
+// ImportNodesCSV 从 CSV 导入 kind 类型的节点. 首行为表头, 必须包含 id 列, 其他列作为属性,
+// 空单元格跳过. 已存在的节点视为更新, 表头中属性的旧值会被替换. 同一 id 出现多次时以最后一行为准
+func (g *Graph) ImportNodesCSV(ctx context.Context, r io.Reader, kind string) (int, error) {
+	header, rows, err := readCSV(r)
+	if err != nil {
+		return 0, err
+	}
+	idCol := columnIndex(header, "id")
+	if idCol < 0 {
+		return 0, errors.New("csv header must contain id column")
+	}
+	predicates := map[quad.Value]bool{PredicateType: true}
+	for i, name := range header {
+		if i != idCol {
+			predicates[quad.IRI(name)] = true
+		}
+	}
+	// replace 读取的是存储中的旧值, 同一事务内重复的 id 会写出多个值, 因此按 id 去重, 以最后一行为准
+	last := make(map[string]int, len(rows))
+	for line, row := range rows {
+		if row[idCol] == "" {
+			return 0, errors.Errorf("csv line %d: empty id", line+2)
+		}
+		last[row[idCol]] = line
+	}
+	tx := graph.NewTransaction()
+	for line, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if last[row[idCol]] != line {
+			continue
+		}
+		node := Node(kind, row[idCol])
+		quads := []quad.Quad{quad.Make(node.IRI(), PredicateType, quad.IRI(kind), nil)}
+		for i, cell := range row {
+			if i != idCol && cell != "" {
+				quads = append(quads, quad.Make(node.IRI(), quad.IRI(header[i]), quad.String(cell), nil))
+			}
+		}
+		if err := g.replace(ctx, tx, node, predicates, quads); err != nil {
+			return 0, err
+		}
+	}
+	return g.commit(tx)
+}
+
+// ImportEdgesCSV 从 CSV 导入关系. 首行为表头, 必须包含 from、predicate、to 三列, 节点为 "类型/ID" 格式
+func (g *Graph) ImportEdgesCSV(ctx context.Context, r io.Reader) (int, error) {
+	header, rows, err := readCSV(r)
+	if err != nil {
+		return 0, err
+	}
+	cols := make([]int, 3)
+	for i, name := range []string{"from", "predicate", "to"} {
+		if cols[i] = columnIndex(header, name); cols[i] < 0 {
+			return 0, errors.Errorf("csv header must contain %s column", name)
+		}
+	}
+	tx := graph.NewTransaction()
+	for line, row := range rows {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		rel := Relation{From: NodeID(row[cols[0]]), Predicate: row[cols[1]], To: NodeID(row[cols[2]])}
+		if rel.From == "" || rel.Predicate == "" || rel.To == "" {
+			return 0, errors.Errorf("csv line %d: from, predicate and to are required", line+2)
+		}
+		tx.AddQuad(rel.quad())
+	}
+	return g.commit(tx)
+}
+
+// ImportJSONLD 导入 JSON-LD 文档
+func (g *Graph) ImportJSONLD(ctx context.Context, r io.Reader) (int, error) {
+	reader := jsonld.NewReader(r)
+	defer reader.Close()
+	return g.ImportQuads(ctx, reader)
+}
+
+// ImportQuads 导入任意格式的四元组, 如 nquads.NewReader
+func (g *Graph) ImportQuads(ctx context.Context, r quad.Reader) (int, error) {
+	tx := graph.NewTransaction()
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		q, err := r.ReadQuad()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, errors.WithMessage(err, "failed to read quad")
+		}
+		tx.AddQuad(q)
+	}
+	return g.commit(tx)
+}
+
+// ExportNQuads 以 N-Quads 格式导出全部四元组, 返回导出的数量
+func (g *Graph) ExportNQuads(ctx context.Context, w io.Writer) (int, error) {
+	it := g.h.QuadsAllIterator()
+	defer it.Close()
+	writer := nquads.NewWriter(w)
+	n := 0
+	for it.Next(ctx) {
+		if err := writer.WriteQuad(g.h.Quad(it.Result())); err != nil {
+			return n, errors.WithMessage(err, "failed to write quad")
+		}
+		n++
+	}
+	if err := it.Err(); err != nil {
+		return n, errors.WithMessage(err, "failed to iterate quads")
+	}
+	return n, errors.WithMessage(writer.Close(), "failed to flush quads")
+}
+
+// commit 提交导入事务, 返回变更的四元组数量
+func (g *Graph) commit(tx *graph.Transaction) (int, error) {
+	if err := g.apply(tx); err != nil {
+		return 0, err
+	}
+	return len(tx.Deltas), nil
+}
+
+func readCSV(r io.Reader) ([]string, [][]string, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to read csv")
+	}
+	if len(records) == 0 {
+		return nil, nil, errors.New("csv is empty")
+	}
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+	return header, records[1:], nil
+}
+
+func columnIndex(header []string, name string) int {
+	for i, h := range header {
+		if strings.EqualFold(h, name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,5 @@
+//go:build !race
+
+package cayleyx
+
+const raceEnabled = false
@@ -0,0 +1,187 @@
+package cayleyx
+
+import (
+	"context"
+	"github.com/cayleygraph/quad"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// ErrNoPath 两个节点之间不存在满足条件的路径
+var ErrNoPath = errors.New("graph path not found")
+
+// Direction 遍历方向
+type Direction int
+
+const (
+	// DirectionOut 沿出边遍历
+	DirectionOut Direction = iota
+	// DirectionIn 沿入边遍历
+	DirectionIn
+	// DirectionBoth 同时沿出边与入边遍历
+	DirectionBoth
+)
+
+// QueryOption 路径查询选项
+type QueryOption func(q *query)
+
+// WithDirection 遍历方向, 默认 DirectionOut
+func WithDirection(dir Direction) QueryOption {
+	return func(q *query) {
+		q.dir = dir
+	}
+}
+
+// WithPredicates 只沿指定谓词的边遍历, 默认遍历全部关系
+func WithPredicates(predicates ...string) QueryOption {
+	return func(q *query) {
+		for _, p := range predicates {
+			q.predicates[quad.IRI(p)] = true
+		}
+	}
+}
+
+// WithMaxDepth 最短路径的最大跳数, 默认不限制
+func WithMaxDepth(depth int) QueryOption {
+	return func(q *query) {
+		q.maxDepth = depth
+	}
+}
+
+type query struct {
+	dir        Direction
+	predicates map[quad.Value]bool
+	maxDepth   int
+}
+
+func newQuery(opts []QueryOption) *query {
+	q := &query{predicates: make(map[quad.Value]bool)}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q
+}
+
+// Hop 多跳查询的结果
+type Hop struct {
+	Node  NodeID `json:"node"`
+	Depth int    `json:"depth"`
+}
+
+// Neighbors 相邻节点, 按节点标识排序. 实体类型与字面量属性不属于关系, 不会被返回
+func (g *Graph) Neighbors(ctx context.Context, node NodeID, opts ...QueryOption) ([]NodeID, error) {
+	return g.neighbors(ctx, node, newQuery(opts))
+}
+
+func (g *Graph) neighbors(ctx context.Context, node NodeID, q *query) ([]NodeID, error) {
+	seen := make(map[NodeID]bool)
+	var nodes []NodeID
+	collect := func(dir quad.Direction, other quad.Direction) error {
+		quads, err := g.quads(ctx, node, dir)
+		if err != nil {
+			return err
+		}
+		for _, qd := range quads {
+			if qd.Predicate == PredicateType || (len(q.predicates) > 0 && !q.predicates[qd.Predicate]) {
+				continue
+			}
+			iri, ok := qd.Get(other).(quad.IRI)
+			if !ok || seen[NodeID(iri)] {
+				continue
+			}
+			seen[NodeID(iri)] = true
+			nodes = append(nodes, NodeID(iri))
+		}
+		return nil
+	}
+	if q.dir != DirectionIn {
+		if err := collect(quad.Subject, quad.Object); err != nil {
+			return nil, err
+		}
+	}
+	if q.dir != DirectionOut {
+		if err := collect(quad.Object, quad.Subject); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i] < nodes[j] })
+	return nodes, nil
+}
+
+// KHop k 跳以内可达的节点, 不包含起点, 按跳数与节点标识排序
+func (g *Graph) KHop(ctx context.Context, start NodeID, k int, opts ...QueryOption) ([]Hop, error) {
+	q := newQuery(opts)
+	visited := map[NodeID]bool{start: true}
+	frontier := []NodeID{start}
+	var hops []Hop
+	for depth := 1; depth <= k && len(frontier) > 0; depth++ {
+		var next []NodeID
+		for _, node := range frontier {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			neighbors, err := g.neighbors(ctx, node, q)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range neighbors {
+				if !visited[n] {
+					visited[n] = true
+					next = append(next, n)
+				}
+			}
+		}
+		sort.Slice(next, func(i, j int) bool { return next[i] < next[j] })
+		for _, n := range next {
+			hops = append(hops, Hop{Node: n, Depth: depth})
+		}
+		frontier = next
+	}
+	return hops, nil
+}
+
+// ShortestPath 两个节点之间跳数最少的路径, 包含起点与终点, 不存在时返回 ErrNoPath
+func (g *Graph) ShortestPath(ctx context.Context, from, to NodeID, opts ...QueryOption) ([]NodeID, error) {
+	q := newQuery(opts)
+	if from == to {
+		return []NodeID{from}, nil
+	}
+	parents := map[NodeID]NodeID{from: ""}
+	frontier := []NodeID{from}
+	for depth := 1; len(frontier) > 0 && (q.maxDepth <= 0 || depth <= q.maxDepth); depth++ {
+		var next []NodeID
+		for _, node := range frontier {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			neighbors, err := g.neighbors(ctx, node, q)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range neighbors {
+				if _, ok := parents[n]; ok {
+					continue
+				}
+				parents[n] = node
+				if n == to {
+					return buildPath(parents, from, to), nil
+				}
+				next = append(next, n)
+			}
+		}
+		frontier = next
+	}
+	return nil, errors.Wrapf(ErrNoPath, "%s -> %s", from, to)
+}
+
+func buildPath(parents map[NodeID]NodeID, from, to NodeID) []NodeID {
+	nodes := []NodeID{to}
+	for n := to; n != from; {
+		n = parents[n]
+		nodes = append(nodes, n)
+	}
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	return nodes
+}
@@ -0,0 +1,69 @@
+package cfg
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// DBSourceOption 数据库配置源选项
+type DBSourceOption func(*DBSource)
+
+// WithTable 配置表名, 默认 system_configs
+func WithTable(table string) DBSourceOption {
+	return func(s *DBSource) {
+		s.table = table
+	}
+}
+
+// WithColumns 键和值的列名, 默认 config_key 和 config_value
+func WithColumns(keyColumn, valueColumn string) DBSourceOption {
+	return func(s *DBSource) {
+		s.keyColumn = keyColumn
+		s.valueColumn = valueColumn
+	}
+}
+
+// DBSource 数据库键值表配置源, 默认读取 auth 模块的 system_configs 表.
+// 不支持变更通知, 由 Remote 定时刷新
+type DBSource struct {
+	db          *gorm.DB
+	table       string
+	keyColumn   string
+	valueColumn string
+}
+
+// NewDBSource 创建数据库配置源
+func NewDBSource(db *gorm.DB, opts ...DBSourceOption) *DBSource {
+	s := &DBSource{db: db, table: "system_configs", keyColumn: "config_key", valueColumn: "config_value"}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Name 配置源名称
+func (s *DBSource) Name() string {
+	return "db:" + s.table
+}
+
+// Load 读取全部配置
+func (s *DBSource) Load(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.WithContext(ctx).Table(s.table).Select(s.keyColumn, s.valueColumn).Rows()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "查询配置表 %s 失败", s.table)
+	}
+	defer rows.Close()
+	values := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, errors.WithMessagef(err, "读取配置表 %s 失败", s.table)
+		}
+		values[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.WithMessagef(err, "读取配置表 %s 失败", s.table)
+	}
+	return values, nil
+}
@@ -0,0 +1,228 @@
+package cfg
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// placeholderPattern ${VAR} 或 ${VAR:default}
+var placeholderPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.\-]*)(?::([^}]*))?\}`)
+
+// ExpandPlaceholders 替换字符串中的 ${VAR:default} 占位符, 环境变量不存在时使用默认值, 没有默认值时返回错误
+func ExpandPlaceholders(s string) (string, error) {
+	var missing []string
+	result := placeholderPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := placeholderPattern.FindStringSubmatch(m)
+		if value, ok := os.LookupEnv(sub[1]); ok {
+			return value
+		}
+		if strings.Contains(m, ":") {
+			return sub[2]
+		}
+		missing = append(missing, sub[1])
+		return m
+	})
+	if len(missing) > 0 {
+		return "", errors.Errorf("环境变量 %s 未设置且没有默认值", strings.Join(missing, ", "))
+	}
+	return result, nil
+}
+
+func expandPlaceholders(settings map[string]interface{}) error {
+	var walk func(value interface{}, path string) (interface{}, error)
+	walk = func(value interface{}, path string) (interface{}, error) {
+		switch v := value.(type) {
+		case string:
+			expanded, err := ExpandPlaceholders(v)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "配置 %s", path)
+			}
+			return expanded, nil
+		case map[string]interface{}:
+			for k, item := range v {
+				expanded, err := walk(item, joinPath(path, k))
+				if err != nil {
+					return nil, err
+				}
+				v[k] = expanded
+			}
+			return v, nil
+		case []interface{}:
+			for i, item := range v {
+				expanded, err := walk(item, path)
+				if err != nil {
+					return nil, err
+				}
+				v[i] = expanded
+			}
+			return v, nil
+		default:
+			return value, nil
+		}
+	}
+	_, err := walk(settings, "")
+	return err
+}
+
+// applyEnv 按配置结构体的 mapstructure 路径查找环境变量并覆盖,
+// 路径中的 . 与 - 转换为 _, 如前缀 APP 时 db.max-open-connections 对应 APP_DB_MAX_OPEN_CONNECTIONS
+func applyEnv(settings map[string]interface{}, prefix string, t reflect.Type) {
+	for _, path := range leafPaths(t, "") {
+		name := prefix + "_" + envName(path)
+		if value, ok := os.LookupEnv(name); ok {
+			setPath(settings, path, value)
+		}
+	}
+}
+
+// EnvName 配置路径对应的环境变量名(不含前缀)
+func envName(path string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(path))
+}
+
+// leafPaths 按 mapstructure 规则列出结构体所有叶子字段的路径
+func leafPaths(t reflect.Type, prefix string) []string {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	var paths []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, squash := fieldKey(field)
+		if name == "-" {
+			continue
+		}
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !isScalarStruct(ft) {
+			if squash {
+				paths = append(paths, leafPaths(ft, prefix)...)
+			} else {
+				paths = append(paths, leafPaths(ft, joinPath(prefix, name))...)
+			}
+			continue
+		}
+		paths = append(paths, joinPath(prefix, name))
+	}
+	return paths
+}
+
+func fieldKey(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("mapstructure")
+	name, opts, _ := strings.Cut(tag, ",")
+	squash := strings.Contains(opts, "squash")
+	if name == "" {
+		name = field.Name
+	}
+	return strings.ToLower(name), squash
+}
+
+// isScalarStruct 作为单个值解码的结构体类型, 如 time.Time
+func isScalarStruct(t reflect.Type) bool {
+	return t.PkgPath() == "time"
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// setPath 按点号分隔的路径设置值, 中间层不存在时创建
+func setPath(settings map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(strings.ToLower(path), ".")
+	m := settings
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[key] = next
+		}
+		m = next
+	}
+	m[keys[len(keys)-1]] = value
+}
+
+// mergeMaps 深度合并, src 覆盖 dst
+func mergeMaps(dst, src map[string]interface{}) {
+	for k, v := range src {
+		k = strings.ToLower(k)
+		if sm, ok := toStringMap(v); ok {
+			if dm, ok := dst[k].(map[string]interface{}); ok {
+				mergeMaps(dm, sm)
+				continue
+			}
+			nm := make(map[string]interface{})
+			mergeMaps(nm, sm)
+			dst[k] = nm
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(m))
+		for k, item := range m {
+			out[strings.ToLower(toString(k))] = item
+		}
+		return out, true
+	default:
+		return nil, false
+	}
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return reflect.ValueOf(v).String()
+}
+
+// flatten 展开为 路径 -> 值
+func flatten(settings map[string]interface{}, prefix string, out map[string]interface{}) {
+	for k, v := range settings {
+		if m, ok := v.(map[string]interface{}); ok {
+			flatten(m, joinPath(prefix, k), out)
+			continue
+		}
+		out[joinPath(prefix, k)] = v
+	}
+}
+
+// changedKeys 比较两份配置, 返回发生变化的路径
+func changedKeys(old, new map[string]interface{}) []string {
+	a, b := make(map[string]interface{}), make(map[string]interface{})
+	flatten(old, "", a)
+	flatten(new, "", b)
+	var keys []string
+	for k, v := range b {
+		if ov, ok := a[k]; !ok || !reflect.DeepEqual(ov, v) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
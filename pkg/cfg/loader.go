@@ -0,0 +1,304 @@
+package cfg
+
+import (
+	"context"
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Preparer 设置默认值的配置, 加载后由内向外依次调用
+type Preparer interface {
+	Prepare()
+}
+
+// Validator 可自校验的配置, 在 Prepare 之后调用
+type Validator interface {
+	Validate() error
+}
+
+// LoaderOption 加载选项
+type LoaderOption func(*loaderOptions)
+
+type loaderOptions struct {
+	files      []string
+	profile    string
+	envPrefix  string
+	defaults   map[string]interface{}
+	flags      *pflag.FlagSet
+	sources    []Source
+	validators []func(interface{}) error
+}
+
+// WithFiles 配置文件, 按顺序合并, 后面的覆盖前面的, 支持 yaml、json、toml 等 viper 支持的格式
+func WithFiles(files ...string) LoaderOption {
+	return func(o *loaderOptions) {
+		o.files = append(o.files, files...)
+	}
+}
+
+// WithProfile 环境名称, 每个配置文件之后会合并同目录下的 名称-环境.扩展名 文件, 如 application-prod.yaml, 文件不存在时忽略
+func WithProfile(profile string) LoaderOption {
+	return func(o *loaderOptions) {
+		o.profile = profile
+	}
+}
+
+// WithEnvPrefix 环境变量前缀, 如 APP 时 APP_DB_MAX_OPEN_CONNECTIONS 覆盖 db.max-open-connections
+func WithEnvPrefix(prefix string) LoaderOption {
+	return func(o *loaderOptions) {
+		o.envPrefix = strings.TrimSuffix(strings.ToUpper(prefix), "_")
+	}
+}
+
+// WithDefaults 默认值, key 使用点号分隔的路径, 如 web.port
+func WithDefaults(defaults map[string]interface{}) LoaderOption {
+	return func(o *loaderOptions) {
+		if o.defaults == nil {
+			o.defaults = make(map[string]interface{})
+		}
+		for k, v := range defaults {
+			o.defaults[k] = v
+		}
+	}
+}
+
+// WithFlags 命令行参数, 只合并显式设置的参数, 参数名使用点号分隔的路径, 如 --web.port=8080
+func WithFlags(flags *pflag.FlagSet) LoaderOption {
+	return func(o *loaderOptions) {
+		o.flags = flags
+	}
+}
+
+// WithRemoteSources 远程配置源, 在配置文件之后、环境变量之前合并, key 使用点号分隔的路径
+func WithRemoteSources(sources ...Source) LoaderOption {
+	return func(o *loaderOptions) {
+		o.sources = append(o.sources, sources...)
+	}
+}
+
+// WithValidator 自定义校验
+func WithValidator[T any](fn func(*T) error) LoaderOption {
+	return func(o *loaderOptions) {
+		o.validators = append(o.validators, func(v interface{}) error {
+			return fn(v.(*T))
+		})
+	}
+}
+
+// Loader 分层配置加载器, 合并顺序为 默认值 < 配置文件 < 环境配置文件 < 远程配置 < 环境变量 < 命令行参数,
+// 合并后解析 ${VAR:default} 占位符, 解码到 T, 依次调用各层配置的 Prepare 和 Validate
+type Loader[T any] struct {
+	opts loaderOptions
+
+	mu       sync.RWMutex
+	current  *T
+	settings map[string]interface{}
+	subs     map[int]func(ChangeEvent[T])
+	nextSub  int
+	watcher  *watcher
+}
+
+// NewLoader 创建配置加载器
+func NewLoader[T any](opts ...LoaderOption) *Loader[T] {
+	l := &Loader[T]{subs: make(map[int]func(ChangeEvent[T]))}
+	for _, opt := range opts {
+		opt(&l.opts)
+	}
+	return l
+}
+
+// Load 使用给定选项加载一次配置
+func Load[T any](opts ...LoaderOption) (*T, error) {
+	return NewLoader[T](opts...).Load()
+}
+
+// Load 加载配置, 成功后替换当前配置
+func (l *Loader[T]) Load() (*T, error) {
+	cfg, settings, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.current, l.settings = cfg, settings
+	l.mu.Unlock()
+	return cfg, nil
+}
+
+// Current 当前配置, 未加载时返回 nil
+func (l *Loader[T]) Current() *T {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.current
+}
+
+// Files 实际参与合并的配置文件
+func (l *Loader[T]) Files() []string {
+	var files []string
+	for _, file := range l.opts.files {
+		files = append(files, file)
+		if profile := profileFile(file, l.opts.profile); profile != "" {
+			files = append(files, profile)
+		}
+	}
+	return files
+}
+
+func (l *Loader[T]) load() (*T, map[string]interface{}, error) {
+	settings := make(map[string]interface{})
+	for key, value := range l.opts.defaults {
+		setPath(settings, key, value)
+	}
+	for _, file := range l.opts.files {
+		values, err := readFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		mergeMaps(settings, values)
+		profile := profileFile(file, l.opts.profile)
+		if profile == "" {
+			continue
+		}
+		if _, err := os.Stat(profile); err != nil {
+			continue
+		}
+		values, err = readFile(profile)
+		if err != nil {
+			return nil, nil, err
+		}
+		mergeMaps(settings, values)
+	}
+	for _, source := range l.opts.sources {
+		values, err := source.Load(context.Background())
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "加载配置源 %s 失败", source.Name())
+		}
+		for key, value := range values {
+			setPath(settings, key, value)
+		}
+	}
+	var zero T
+	if l.opts.envPrefix != "" {
+		applyEnv(settings, l.opts.envPrefix, reflect.TypeOf(zero))
+	}
+	if l.opts.flags != nil {
+		l.opts.flags.Visit(func(f *pflag.Flag) {
+			setPath(settings, f.Name, flagValue(f))
+		})
+	}
+	if err := expandPlaceholders(settings); err != nil {
+		return nil, nil, err
+	}
+	cfg := new(T)
+	if err := decode(settings, cfg); err != nil {
+		return nil, nil, err
+	}
+	if err := prepareAndValidate(reflect.ValueOf(cfg), ""); err != nil {
+		return nil, nil, err
+	}
+	for _, fn := range l.opts.validators {
+		if err := fn(cfg); err != nil {
+			return nil, nil, errors.WithMessage(err, "配置校验失败")
+		}
+	}
+	return cfg, settings, nil
+}
+
+// profileFile 环境配置文件路径, application.yaml -> application-prod.yaml
+func profileFile(file, profile string) string {
+	if profile == "" {
+		return ""
+	}
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "-" + profile + ext
+}
+
+func readFile(file string) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.WithMessagef(err, "读取配置文件失败，配置文件：%s", file)
+	}
+	return v.AllSettings(), nil
+}
+
+func flagValue(f *pflag.Flag) interface{} {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		return sv.GetSlice()
+	}
+	return f.Value.String()
+}
+
+func decode(settings map[string]interface{}, ptr interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		WeaklyTypedInput: true,
+		Result:           ptr,
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(settings); err != nil {
+		return errors.WithMessage(err, "解析配置失败")
+	}
+	return nil
+}
+
+// prepareAndValidate 由内向外调用 Prepare, 再由内向外调用 Validate
+func prepareAndValidate(v reflect.Value, path string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			fv = fv.Addr()
+		}
+		if fv.Kind() != reflect.Ptr || fv.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		name := field.Name
+		if path != "" && !field.Anonymous {
+			name = path + "." + field.Name
+		} else if field.Anonymous {
+			name = path
+		}
+		if err := prepareAndValidate(fv, name); err != nil {
+			return err
+		}
+	}
+	ptr := v.Addr().Interface()
+	if p, ok := ptr.(Preparer); ok {
+		p.Prepare()
+	}
+	if val, ok := ptr.(Validator); ok {
+		if err := val.Validate(); err != nil {
+			if path == "" {
+				return errors.WithMessage(err, "配置校验失败")
+			}
+			return errors.WithMessage(err, fmt.Sprintf("配置 %s 校验失败", path))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package cfg
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+type testDBConfig struct {
+	Host         string        `mapstructure:"host"`
+	Password     string        `mapstructure:"password"`
+	MaxOpenConns int           `mapstructure:"max-open-conns"`
+	Timeout      time.Duration `mapstructure:"timeout"`
+	prepared     int
+}
+
+func (c *testDBConfig) Prepare() {
+	c.prepared++
+	if c.MaxOpenConns == 0 {
+		c.MaxOpenConns = 10
+	}
+}
+
+func (c *testDBConfig) Validate() error {
+	if c.Host == "" {
+		return errors.New("host 不能为空")
+	}
+	return nil
+}
+
+type testConfig struct {
+	Name string       `mapstructure:"name"`
+	Port int          `mapstructure:"port"`
+	Tags []string     `mapstructure:"tags"`
+	DB   testDBConfig `mapstructure:"db"`
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoaderLayers(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "application.yaml")
+	writeFile(t, base, "name: base\nport: 80\ndb:\n  host: localhost\n  password: ${TEST_CFG_DB_PASSWORD:secret}\n  timeout: 3s\n")
+	writeFile(t, filepath.Join(dir, "application-prod.yaml"), "name: prod\ndb:\n  host: db.prod\n")
+	t.Setenv("APP_DB_MAX_OPEN_CONNS", "50")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Int("port", 0, "")
+	flags.StringSlice("tags", nil, "")
+	if err := flags.Parse([]string{"--port=8080", "--tags=a,b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load[testConfig](
+		WithDefaults(map[string]interface{}{"port": 1, "db.timeout": "1s"}),
+		WithFiles(base),
+		WithProfile("prod"),
+		WithEnvPrefix("app"),
+		WithFlags(flags),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "prod" || cfg.DB.Host != "db.prod" {
+		t.Fatalf("profile not merged: %+v", cfg)
+	}
+	if cfg.Port != 8080 || len(cfg.Tags) != 2 {
+		t.Fatalf("flags not merged: %+v", cfg)
+	}
+	if cfg.DB.MaxOpenConns != 50 {
+		t.Fatalf("env not merged: %d", cfg.DB.MaxOpenConns)
+	}
+	if cfg.DB.Password != "secret" || cfg.DB.Timeout != 3*time.Second {
+		t.Fatalf("unexpected db config: %+v", cfg.DB)
+	}
+	if cfg.DB.prepared != 1 {
+		t.Fatalf("Prepare called %d times", cfg.DB.prepared)
+	}
+
+	t.Setenv("TEST_CFG_DB_PASSWORD", "from-env")
+	cfg, err = Load[testConfig](WithFiles(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DB.Password != "from-env" || cfg.DB.MaxOpenConns != 10 {
+		t.Fatalf("unexpected db config: %+v", cfg.DB)
+	}
+}
+
+func TestLoaderValidate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "application.yaml")
+	writeFile(t, file, "name: test\n")
+	if _, err := Load[testConfig](WithFiles(file)); err == nil {
+		t.Fatal("expected validate error")
+	}
+
+	writeFile(t, file, "db:\n  host: ${TEST_CFG_MISSING}\n")
+	if _, err := Load[testConfig](WithFiles(file)); err == nil {
+		t.Fatal("expected placeholder error")
+	}
+
+	writeFile(t, file, "port: 80\ndb:\n  host: localhost\n")
+	_, err := Load[testConfig](WithFiles(file), WithValidator(func(c *testConfig) error {
+		if c.Port < 1024 {
+			return errors.New("port 不能小于 1024")
+		}
+		return nil
+	}))
+	if err == nil {
+		t.Fatal("expected custom validate error")
+	}
+}
+
+func TestLoaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "application.yaml")
+	writeFile(t, file, "port: 80\ndb:\n  host: localhost\n")
+
+	loader := NewLoader[testConfig](WithFiles(file))
+	events := make(chan ChangeEvent[testConfig], 4)
+	unsubscribe := loader.Subscribe(func(e ChangeEvent[testConfig]) {
+		events <- e
+	})
+	defer unsubscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := loader.Watch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer loader.Close()
+
+	writeFile(t, file, "port: 81\ndb:\n  host: localhost\n")
+	select {
+	case e := <-events:
+		if e.Err != nil {
+			t.Fatal(e.Err)
+		}
+		if e.Old.Port != 80 || e.New.Port != 81 || !e.HasChanged("port") || e.HasChanged("db") {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("change event not received")
+	}
+
+	writeFile(t, file, "port: 82\n")
+	select {
+	case e := <-events:
+		if e.Err == nil || e.New != nil {
+			t.Fatalf("expected reload error: %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("error event not received")
+	}
+	if loader.Current().Port != 81 {
+		t.Fatalf("current config replaced after failed reload: %d", loader.Current().Port)
+	}
+}
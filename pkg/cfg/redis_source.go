@@ -0,0 +1,104 @@
+package cfg
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/xiehqing/common/pkg/redisx"
+)
+
+// RedisSourceOption Redis 配置源选项
+type RedisSourceOption func(*RedisSource)
+
+// WithChannel 变更通知的频道, 默认为 hash 的 key 加 :changed
+func WithChannel(channel string) RedisSourceOption {
+	return func(s *RedisSource) {
+		s.channel = channel
+	}
+}
+
+// RedisSource Redis hash 配置源, 每个 field 为一个配置.
+// 通过 Set/Delete 修改时会向频道发布变更消息, 所有订阅的 Remote 随即刷新
+type RedisSource struct {
+	client  redisx.Redis
+	key     string
+	channel string
+}
+
+// NewRedisSource 创建 Redis 配置源
+func NewRedisSource(client redisx.Redis, key string, opts ...RedisSourceOption) *RedisSource {
+	s := &RedisSource{client: client, key: key, channel: key + ":changed"}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Name 配置源名称
+func (s *RedisSource) Name() string {
+	return "redis:" + s.key
+}
+
+// Load 读取全部配置
+func (s *RedisSource) Load(ctx context.Context) (map[string]string, error) {
+	values, err := s.client.HGetAll(ctx, s.key).Result()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "读取 redis 配置 %s 失败", s.key)
+	}
+	return values, nil
+}
+
+// Set 设置配置并发布变更通知
+func (s *RedisSource) Set(ctx context.Context, field, value string) error {
+	if err := s.client.HSet(ctx, s.key, field, value).Err(); err != nil {
+		return errors.WithMessagef(err, "设置 redis 配置 %s 失败", field)
+	}
+	return s.publish(ctx, field)
+}
+
+// Delete 删除配置并发布变更通知
+func (s *RedisSource) Delete(ctx context.Context, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	if err := s.client.HDel(ctx, s.key, fields...).Err(); err != nil {
+		return errors.WithMessagef(err, "删除 redis 配置 %v 失败", fields)
+	}
+	return s.publish(ctx, fields[0])
+}
+
+func (s *RedisSource) publish(ctx context.Context, field string) error {
+	if err := s.client.Publish(ctx, s.channel, field).Err(); err != nil {
+		return errors.WithMessagef(err, "发布 redis 配置变更 %s 失败", field)
+	}
+	return nil
+}
+
+type redisSubscriber interface {
+	Subscribe(ctx context.Context, channels ...string) *redis.PubSub
+}
+
+// Notify 订阅变更频道, 断线后由 go-redis 自动重连
+func (s *RedisSource) Notify(ctx context.Context, fn func()) error {
+	sub, ok := s.client.(redisSubscriber)
+	if !ok {
+		return errors.Errorf("redis 客户端 %T 不支持订阅", s.client)
+	}
+	pubsub := sub.Subscribe(ctx, s.channel)
+	defer pubsub.Close()
+	if _, err := pubsub.Receive(ctx); err != nil {
+		return errors.WithMessagef(err, "订阅 redis 频道 %s 失败", s.channel)
+	}
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			fn()
+		}
+	}
+}
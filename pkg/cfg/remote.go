@@ -0,0 +1,331 @@
+package cfg
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"github.com/xiehqing/common/pkg/safego"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source 远程配置源, 返回全部键值
+type Source interface {
+	Name() string
+	Load(ctx context.Context) (map[string]string, error)
+}
+
+// Notifier 支持变更通知的配置源, Notify 阻塞直到 ctx 结束, 收到变更时调用 fn
+type Notifier interface {
+	Notify(ctx context.Context, fn func()) error
+}
+
+// RemoteOption 远程配置选项
+type RemoteOption func(*Remote)
+
+// WithSources 配置源, 按顺序合并, 后面的覆盖前面的
+func WithSources(sources ...Source) RemoteOption {
+	return func(r *Remote) {
+		r.sources = append(r.sources, sources...)
+	}
+}
+
+// WithRefreshInterval 定时刷新间隔, 用于不支持变更通知的配置源, 默认 30 秒, 小于 0 时不定时刷新
+func WithRefreshInterval(interval time.Duration) RemoteOption {
+	return func(r *Remote) {
+		r.interval = interval
+	}
+}
+
+type decodeKey struct {
+	key string
+	typ reflect.Type
+}
+
+type decodedValue struct {
+	raw   string
+	value interface{}
+	err   error
+}
+
+// Remote 远程配置, 缓存各配置源合并后的键值, 通过 Get 按类型读取, 通过 Watch 订阅变更
+type Remote struct {
+	sources  []Source
+	interval time.Duration
+
+	// refreshMu 串行化刷新, 定时刷新与变更通知并发触发时, 较早加载的配置不会覆盖较新的配置, 回调也按刷新顺序执行
+	refreshMu sync.Mutex
+
+	mu       sync.RWMutex
+	values   map[string]string
+	decoded  map[decodeKey]decodedValue
+	watchers map[string]map[int]func(value string, ok bool)
+	nextID   int
+	cancel   context.CancelFunc
+}
+
+// NewRemote 创建远程配置
+func NewRemote(opts ...RemoteOption) *Remote {
+	r := &Remote{
+		interval: 30 * time.Second,
+		values:   make(map[string]string),
+		decoded:  make(map[decodeKey]decodedValue),
+		watchers: make(map[string]map[int]func(string, bool)),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Start 加载一次配置, 然后开始定时刷新并监听支持变更通知的配置源, ctx 结束或调用 Stop 时停止
+func (r *Remote) Start(ctx context.Context) error {
+	if err := r.Refresh(ctx); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.cancel = cancel
+	r.mu.Unlock()
+	for _, source := range r.sources {
+		n, ok := source.(Notifier)
+		if !ok {
+			continue
+		}
+		name := source.Name()
+		safego.Go(ctx, func() {
+			err := n.Notify(ctx, func() {
+				if err := r.Refresh(ctx); err != nil {
+					logs.CtxWarnf(ctx, "刷新远程配置失败: %v", err)
+				}
+			})
+			if err != nil && ctx.Err() == nil {
+				logs.CtxWarnf(ctx, "监听配置源 %s 失败, 改为定时刷新: %v", name, err)
+			}
+		})
+	}
+	if r.interval > 0 {
+		safego.Go(ctx, func() {
+			ticker := time.NewTicker(r.interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := r.Refresh(ctx); err != nil {
+						logs.CtxWarnf(ctx, "刷新远程配置失败: %v", err)
+					}
+				}
+			}
+		})
+	}
+	return nil
+}
+
+// Stop 停止刷新
+func (r *Remote) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+}
+
+// Refresh 重新加载所有配置源, 任一配置源失败时保留原有配置.
+// 多次刷新串行执行, 变更回调在刷新过程中同步调用, 回调中不能再调用 Refresh
+func (r *Remote) Refresh(ctx context.Context) error {
+	r.refreshMu.Lock()
+	defer r.refreshMu.Unlock()
+	values := make(map[string]string)
+	for _, source := range r.sources {
+		loaded, err := source.Load(ctx)
+		if err != nil {
+			return errors.WithMessagef(err, "加载配置源 %s 失败", source.Name())
+		}
+		for k, v := range loaded {
+			values[k] = v
+		}
+	}
+	type change struct {
+		fn    func(string, bool)
+		value string
+		ok    bool
+	}
+	var changes []change
+	r.mu.Lock()
+	for key, fns := range r.watchers {
+		old, oldOK := r.values[key]
+		value, ok := values[key]
+		if old == value && oldOK == ok {
+			continue
+		}
+		for _, fn := range fns {
+			changes = append(changes, change{fn: fn, value: value, ok: ok})
+		}
+	}
+	for k := range r.decoded {
+		if values[k.key] != r.decoded[k].raw {
+			delete(r.decoded, k)
+		}
+	}
+	r.values = values
+	r.mu.Unlock()
+	for _, c := range changes {
+		c.fn(c.value, c.ok)
+	}
+	return nil
+}
+
+// Value 原始配置值
+func (r *Remote) Value(key string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	value, ok := r.values[key]
+	return value, ok
+}
+
+// Values 全部配置的副本
+func (r *Remote) Values() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	values := make(map[string]string, len(r.values))
+	for k, v := range r.values {
+		values[k] = v
+	}
+	return values
+}
+
+// OnChange 订阅原始配置值变更, ok 为 false 表示配置被删除, 返回取消订阅的函数
+func (r *Remote) OnChange(key string, fn func(value string, ok bool)) func() {
+	r.mu.Lock()
+	id := r.nextID
+	r.nextID++
+	if r.watchers[key] == nil {
+		r.watchers[key] = make(map[int]func(string, bool))
+	}
+	r.watchers[key][id] = fn
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		delete(r.watchers[key], id)
+		if len(r.watchers[key]) == 0 {
+			delete(r.watchers, key)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// Lookup 按类型读取配置, 解码结果按原始值缓存, 配置不存在时 ok 为 false
+func Lookup[T any](r *Remote, key string) (value T, ok bool, err error) {
+	r.mu.RLock()
+	raw, ok := r.values[key]
+	dk := decodeKey{key: key, typ: reflect.TypeOf(&value).Elem()}
+	cached, hit := r.decoded[dk]
+	r.mu.RUnlock()
+	if !ok {
+		return value, false, nil
+	}
+	if hit && cached.raw == raw {
+		if cached.err != nil {
+			return value, true, cached.err
+		}
+		return cached.value.(T), true, nil
+	}
+	value, err = DecodeValue[T](raw)
+	if err != nil {
+		err = errors.WithMessagef(err, "解析配置 %s 失败", key)
+	}
+	r.mu.Lock()
+	if current, exists := r.values[key]; exists && current == raw {
+		r.decoded[dk] = decodedValue{raw: raw, value: value, err: err}
+	}
+	r.mu.Unlock()
+	return value, true, err
+}
+
+// Get 按类型读取配置, 配置不存在或解析失败时返回默认值
+func Get[T any](r *Remote, key string, def T) T {
+	if r == nil {
+		return def
+	}
+	value, ok, err := Lookup[T](r, key)
+	if err != nil {
+		logs.Warnf("%v, 使用默认值 %v", err, def)
+		return def
+	}
+	if !ok {
+		return def
+	}
+	return value
+}
+
+// Watch 订阅配置变更, 回调参数为解析后的值, 配置被删除或解析失败时为默认值, 返回取消订阅的函数
+func Watch[T any](r *Remote, key string, def T, fn func(T)) func() {
+	return r.OnChange(key, func(string, bool) {
+		fn(Get(r, key, def))
+	})
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// DecodeValue 把字符串配置解析为指定类型, 支持基本类型、time.Duration、encoding.TextUnmarshaler, 其余类型按 JSON 解析
+func DecodeValue[T any](raw string) (T, error) {
+	var value T
+	rv := reflect.ValueOf(&value).Elem()
+	if reflect.PointerTo(rv.Type()).Implements(textUnmarshalerType) {
+		err := rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+		return value, err
+	}
+	s := strings.TrimSpace(raw)
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return value, err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return value, err
+			}
+			rv.SetInt(int64(d))
+			break
+		}
+		i, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return value, err
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return value, err
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, rv.Type().Bits())
+		if err != nil {
+			return value, err
+		}
+		rv.SetFloat(f)
+	default:
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			return value, err
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,144 @@
+package cfg
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type testLimit struct {
+	Window int `json:"window"`
+	Limit  int `json:"limit"`
+}
+
+func TestDecodeValue(t *testing.T) {
+	if v, err := DecodeValue[int](" 42 "); err != nil || v != 42 {
+		t.Fatalf("int: %v %v", v, err)
+	}
+	if v, err := DecodeValue[bool]("true"); err != nil || !v {
+		t.Fatalf("bool: %v %v", v, err)
+	}
+	if v, err := DecodeValue[time.Duration]("1m"); err != nil || v != time.Minute {
+		t.Fatalf("duration: %v %v", v, err)
+	}
+	if v, err := DecodeValue[testLimit](`{"window":300,"limit":5}`); err != nil || v.Limit != 5 {
+		t.Fatalf("json: %+v %v", v, err)
+	}
+	if _, err := DecodeValue[int]("abc"); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
+
+func TestRemoteRedisSource(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer client.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := NewRedisSource(client, "configs")
+	if err := source.Set(ctx, "login_fail_count", "5"); err != nil {
+		t.Fatal(err)
+	}
+	remote := NewRemote(WithSources(source), WithRefreshInterval(-1))
+	if err := remote.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Stop()
+
+	if v := Get(remote, "login_fail_count", 3); v != 5 {
+		t.Fatalf("unexpected value %d", v)
+	}
+	if v := Get(remote, "missing", 3); v != 3 {
+		t.Fatalf("unexpected default %d", v)
+	}
+
+	changed := make(chan int, 1)
+	unwatch := Watch(remote, "login_fail_count", 3, func(v int) {
+		changed <- v
+	})
+	defer unwatch()
+
+	// 等待订阅建立
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.PubSubChannels("")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription not established")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := source.Set(ctx, "login_fail_count", "10"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-changed:
+		if v != 10 {
+			t.Fatalf("unexpected changed value %d", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not notified")
+	}
+	if v := Get(remote, "login_fail_count", 3); v != 10 {
+		t.Fatalf("unexpected value %d", v)
+	}
+
+	if err := source.Delete(ctx, "login_fail_count"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case v := <-changed:
+		if v != 3 {
+			t.Fatalf("expected default after delete, got %d", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("delete not notified")
+	}
+}
+
+// versionSource 每次加载返回递增的版本号, 第一次加载较慢
+type versionSource struct {
+	loads int32
+}
+
+func (s *versionSource) Name() string { return "version" }
+
+func (s *versionSource) Load(ctx context.Context) (map[string]string, error) {
+	n := atomic.AddInt32(&s.loads, 1)
+	if n == 1 {
+		time.Sleep(50 * time.Millisecond)
+	}
+	return map[string]string{"version": strconv.Itoa(int(n))}, nil
+}
+
+func TestRemoteRefreshOrder(t *testing.T) {
+	r := NewRemote(WithSources(&versionSource{}))
+	var mu sync.Mutex
+	var seen []string
+	r.OnChange("version", func(value string, ok bool) {
+		mu.Lock()
+		seen = append(seen, value)
+		mu.Unlock()
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.Refresh(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+		time.Sleep(10 * time.Millisecond)
+	}
+	wg.Wait()
+	if v, _ := r.Value("version"); v != "2" || len(seen) != 2 || seen[0] != "1" || seen[1] != "2" {
+		t.Fatalf("unexpected version %s, callbacks %v", v, seen)
+	}
+}
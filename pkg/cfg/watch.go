@@ -0,0 +1,200 @@
+package cfg
+
+import (
+	"context"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"github.com/xiehqing/common/pkg/safego"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watchDebounce 编辑器保存文件时通常产生多个事件, 合并后再重新加载
+const watchDebounce = 200 * time.Millisecond
+
+// ChangeEvent 配置变更事件, Err 不为空时表示重新加载失败, 此时 New 为 nil, 当前配置保持不变
+type ChangeEvent[T any] struct {
+	Old     *T
+	New     *T
+	Changed []string // 发生变化的配置路径, 如 web.port
+	Err     error
+}
+
+// HasChanged 指定路径或其下级路径是否发生变化
+func (e ChangeEvent[T]) HasChanged(path string) bool {
+	for _, key := range e.Changed {
+		if key == path || len(key) > len(path) && key[:len(path)] == path && key[len(path)] == '.' {
+			return true
+		}
+	}
+	return false
+}
+
+type watcher struct {
+	fs   *fsnotify.Watcher
+	done chan struct{}
+	once sync.Once
+}
+
+// Subscribe 订阅配置变更, 返回取消订阅的函数
+func (l *Loader[T]) Subscribe(fn func(ChangeEvent[T])) func() {
+	l.mu.Lock()
+	id := l.nextSub
+	l.nextSub++
+	l.subs[id] = fn
+	l.mu.Unlock()
+	return func() {
+		l.mu.Lock()
+		delete(l.subs, id)
+		l.mu.Unlock()
+	}
+}
+
+// Reload 重新加载配置并通知订阅者, 配置没有变化时不通知
+func (l *Loader[T]) Reload() error {
+	cfg, settings, err := l.load()
+	l.mu.Lock()
+	old, oldSettings := l.current, l.settings
+	var event ChangeEvent[T]
+	if err != nil {
+		event = ChangeEvent[T]{Old: old, Err: err}
+	} else {
+		event = ChangeEvent[T]{Old: old, New: cfg, Changed: changedKeys(oldSettings, settings)}
+		l.current, l.settings = cfg, settings
+	}
+	subs := make([]func(ChangeEvent[T]), 0, len(l.subs))
+	for _, fn := range l.subs {
+		subs = append(subs, fn)
+	}
+	l.mu.Unlock()
+	if err == nil && len(event.Changed) == 0 {
+		return nil
+	}
+	for _, fn := range subs {
+		fn(event)
+	}
+	return err
+}
+
+// Watch 监听配置文件变化并自动重新加载, 未加载时先加载一次. ctx 结束或调用 Close 时停止监听
+func (l *Loader[T]) Watch(ctx context.Context) error {
+	if l.Current() == nil {
+		if _, err := l.Load(); err != nil {
+			return err
+		}
+	}
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, file := range l.Files() {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return errors.WithMessagef(err, "解析配置文件路径失败，配置文件：%s", file)
+		}
+		files[abs] = true
+		dirs[filepath.Dir(abs)] = true
+	}
+	if len(files) == 0 {
+		return errors.New("没有需要监听的配置文件")
+	}
+	fs, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.WithMessage(err, "创建配置文件监听失败")
+	}
+	// 监听目录而不是文件, 以便识别原子替换和 Kubernetes ConfigMap 的软链接切换
+	for dir := range dirs {
+		if err := fs.Add(dir); err != nil {
+			_ = fs.Close()
+			return errors.WithMessagef(err, "监听配置目录失败，目录：%s", dir)
+		}
+	}
+	w := &watcher{fs: fs, done: make(chan struct{})}
+	l.mu.Lock()
+	if l.watcher != nil {
+		l.mu.Unlock()
+		_ = fs.Close()
+		return errors.New("配置文件已在监听中")
+	}
+	l.watcher = w
+	l.mu.Unlock()
+	safego.Go(ctx, func() {
+		l.watch(ctx, w, files)
+	})
+	return nil
+}
+
+// Close 停止监听配置文件
+func (l *Loader[T]) Close() error {
+	l.mu.Lock()
+	w := l.watcher
+	l.watcher = nil
+	l.mu.Unlock()
+	if w == nil {
+		return nil
+	}
+	return w.close()
+}
+
+func (w *watcher) close() error {
+	var err error
+	w.once.Do(func() {
+		close(w.done)
+		err = w.fs.Close()
+	})
+	return err
+}
+
+func (l *Loader[T]) watch(ctx context.Context, w *watcher, files map[string]bool) {
+	defer func() {
+		l.mu.Lock()
+		if l.watcher == w {
+			l.watcher = nil
+		}
+		l.mu.Unlock()
+		_ = w.close()
+	}()
+	var timer *time.Timer
+	reload := make(chan struct{}, 1)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.done:
+			return
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			// Kubernetes ConfigMap 切换时变化的是 ..data 软链接, 其余无关文件忽略
+			if abs, err := filepath.Abs(event.Name); err == nil && !files[abs] && !strings.HasPrefix(filepath.Base(abs), "..") {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(watchDebounce, func() {
+					select {
+					case reload <- struct{}{}:
+					default:
+					}
+				})
+			} else {
+				timer.Reset(watchDebounce)
+			}
+		case <-reload:
+			if err := l.Reload(); err != nil {
+				logs.CtxErrorf(ctx, "重新加载配置失败: %v", err)
+				continue
+			}
+			logs.CtxInfof(ctx, "配置文件已重新加载")
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			logs.CtxErrorf(ctx, "配置文件监听错误: %v", err)
+		}
+	}
+}
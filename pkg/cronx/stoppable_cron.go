@@ -2,6 +2,7 @@ package cron
 
 import (
 	"github.com/robfig/cron/v3"
+	"github.com/xiehqing/common/pkg/util"
 	"golang.org/x/net/context"
 	"sync"
 )
@@ -25,6 +26,15 @@ func (sc *StoppableCron) AddFunc(spec string, cmd func()) (cron.EntryID, error)
 	return sc.cron.AddFunc(spec, cmd)
 }
 
+// AddWorkdayFunc 添加只在工作日执行的任务, 节假日与调休按 calendar 计算
+func (sc *StoppableCron) AddWorkdayFunc(spec string, calendar *util.Calendar, cmd func()) (cron.EntryID, error) {
+	schedule, err := DefaultCronParser.parser.Parse(spec)
+	if err != nil {
+		return 0, err
+	}
+	return sc.cron.Schedule(calendar.WorkdaySchedule(schedule), cron.FuncJob(cmd)), nil
+}
+
 func (sc *StoppableCron) Entry(id cron.EntryID) cron.Entry {
 	for _, entry := range sc.cron.Entries() {
 		if id == entry.ID {
@@ -0,0 +1,256 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"strings"
+)
+
+// Algorithm 认证加密算法
+type Algorithm byte
+
+const (
+	AES256GCM        Algorithm = 1
+	ChaCha20Poly1305 Algorithm = 2
+)
+
+// String 算法名称
+func (a Algorithm) String() string {
+	switch a {
+	case AES256GCM:
+		return "aes-gcm"
+	case ChaCha20Poly1305:
+		return "chacha20-poly1305"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseAlgorithm 解析算法名称
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch strings.ToLower(name) {
+	case "aes-gcm", "aes-256-gcm", "aes256gcm":
+		return AES256GCM, nil
+	case "chacha20-poly1305", "chacha20poly1305":
+		return ChaCha20Poly1305, nil
+	default:
+		return 0, errors.Errorf("不支持的加密算法: %s", name)
+	}
+}
+
+// newAEAD 创建算法对应的 AEAD
+func newAEAD(alg Algorithm, key []byte) (cipher.AEAD, error) {
+	switch alg {
+	case AES256GCM:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		return cipher.NewGCM(block)
+	case ChaCha20Poly1305:
+		return chacha20poly1305.New(key)
+	default:
+		return nil, errors.Errorf("不支持的加密算法: %d", alg)
+	}
+}
+
+const (
+	// formatVersion 密文格式版本
+	formatVersion byte = 1
+	// CipherPrefix 字符串形式密文的前缀, 用于区分旧版 CBC 密文
+	CipherPrefix = "enc:"
+)
+
+// header 密文头, 格式为 version(1) | algorithm(1) | keyIDLen(1) | keyID,
+// 密文头作为附加数据参与认证, 篡改算法或密钥ID都会导致解密失败
+type header struct {
+	alg   Algorithm
+	keyID string
+}
+
+func (h header) marshal() []byte {
+	b := make([]byte, 0, 3+len(h.keyID))
+	b = append(b, formatVersion, byte(h.alg), byte(len(h.keyID)))
+	return append(b, h.keyID...)
+}
+
+func parseHeader(data []byte) (header, int, error) {
+	if len(data) < 3 {
+		return header{}, 0, errors.New("密文长度不足")
+	}
+	if data[0] != formatVersion {
+		return header{}, 0, errors.Errorf("不支持的密文版本: %d", data[0])
+	}
+	n := 3 + int(data[2])
+	if len(data) < n {
+		return header{}, 0, errors.New("密文长度不足")
+	}
+	return header{alg: Algorithm(data[1]), keyID: string(data[3:n])}, n, nil
+}
+
+// Cipher 认证加密器, 每次加密使用随机 nonce, 密文带版本化的密文头,
+// 解密时根据密文头中的算法和密钥ID选择密钥, 因此同一个 Cipher 可以解密密钥环中任意密钥加密的数据
+type Cipher struct {
+	alg     Algorithm
+	keyring *Keyring
+}
+
+// NewCipher 创建认证加密器
+func NewCipher(alg Algorithm, keyring *Keyring) *Cipher {
+	return &Cipher{alg: alg, keyring: keyring}
+}
+
+// NewAESGCM 使用单个密钥创建 AES-256-GCM 加密器, 密钥ID为 default
+func NewAESGCM(key []byte) (*Cipher, error) {
+	return newSingleKeyCipher(AES256GCM, key)
+}
+
+// NewChaCha20Poly1305 使用单个密钥创建 ChaCha20-Poly1305 加密器, 密钥ID为 default
+func NewChaCha20Poly1305(key []byte) (*Cipher, error) {
+	return newSingleKeyCipher(ChaCha20Poly1305, key)
+}
+
+func newSingleKeyCipher(alg Algorithm, key []byte) (*Cipher, error) {
+	keyring := NewKeyring()
+	if err := keyring.Add("default", key); err != nil {
+		return nil, err
+	}
+	return NewCipher(alg, keyring), nil
+}
+
+// Algorithm 加密使用的算法
+func (c *Cipher) Algorithm() Algorithm {
+	return c.alg
+}
+
+// Keyring 密钥环
+func (c *Cipher) Keyring() *Keyring {
+	return c.keyring
+}
+
+// Seal 使用主密钥加密, aad 为附加认证数据, 解密时必须一致
+func (c *Cipher) Seal(plaintext, aad []byte) ([]byte, error) {
+	keyID, key, err := c.keyring.Primary()
+	if err != nil {
+		return nil, err
+	}
+	return seal(c.alg, keyID, key, plaintext, aad)
+}
+
+// Open 解密 Seal 生成的密文
+func (c *Cipher) Open(ciphertext, aad []byte) ([]byte, error) {
+	h, _, err := parseHeader(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := c.keyring.Get(h.keyID)
+	if !ok {
+		return nil, errors.Errorf("密钥 %s 不存在", h.keyID)
+	}
+	return open(key, ciphertext, aad)
+}
+
+// Encrypt 加密, 返回 enc: 前缀的 base64 字符串
+func (c *Cipher) Encrypt(data string) (string, error) {
+	ciphertext, err := c.Seal([]byte(data), nil)
+	if err != nil {
+		return "", err
+	}
+	return CipherPrefix + base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt 解密 Encrypt 生成的字符串
+func (c *Cipher) Decrypt(data string) ([]byte, error) {
+	ciphertext, err := decodeCipherText(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.Open(ciphertext, nil)
+}
+
+// KeyID 获取密文使用的密钥ID
+func KeyID(data string) (string, error) {
+	ciphertext, err := decodeCipherText(data)
+	if err != nil {
+		return "", err
+	}
+	h, _, err := parseHeader(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return h.keyID, nil
+}
+
+// NeedsRotation 密文是否不是由当前主密钥和算法加密, 旧版密文同样需要重新加密
+func (c *Cipher) NeedsRotation(data string) bool {
+	ciphertext, err := decodeCipherText(data)
+	if err != nil {
+		return true
+	}
+	h, _, err := parseHeader(ciphertext)
+	return err != nil || h.alg != c.alg || h.keyID != c.keyring.PrimaryID()
+}
+
+// IsVersioned 是否为带版本头的新格式密文
+func IsVersioned(data string) bool {
+	return strings.HasPrefix(data, CipherPrefix) || strings.HasPrefix(data, EnvelopePrefix)
+}
+
+func decodeCipherText(data string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(data, CipherPrefix)
+	if !ok {
+		return nil, errors.New("密文格式错误, 缺少 enc: 前缀")
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.WithMessage(err, "密文不是有效的base64")
+	}
+	return ciphertext, nil
+}
+
+func seal(alg Algorithm, keyID string, key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := newAEAD(alg, key)
+	if err != nil {
+		return nil, err
+	}
+	head := header{alg: alg, keyID: keyID}.marshal()
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(head)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, head...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, additionalData(head, aad)), nil
+}
+
+func open(key, ciphertext, aad []byte) ([]byte, error) {
+	h, n, err := parseHeader(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(h.alg, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < n+aead.NonceSize()+aead.Overhead() {
+		return nil, errors.New("密文长度不足")
+	}
+	nonce := ciphertext[n : n+aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, ciphertext[n+aead.NonceSize():], additionalData(ciphertext[:n], aad))
+	if err != nil {
+		return nil, errors.New("解密失败, 密钥错误或密文已被篡改")
+	}
+	return plaintext, nil
+}
+
+func additionalData(head, aad []byte) []byte {
+	if len(aad) == 0 {
+		return head
+	}
+	return append(append([]byte(nil), head...), aad...)
+}
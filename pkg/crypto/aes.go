@@ -9,6 +9,9 @@ import (
 
 var DefaultAesSalt = "www.zorktech.com"
 
+// Aes 旧版 AES-CBC 加密, 使用固定密钥作为 IV 且为 Zero 填充, 以 NUL 结尾的明文解密后会被截断, 密文也没有完整性校验.
+//
+// Deprecated: 仅用于兼容前端和历史数据, 新数据请使用 Cipher 或 Envelope, 历史密文可通过 Migrator 迁移
 type Aes struct {
 	Salt string
 }
@@ -5,6 +5,9 @@ import (
 	"fmt"
 )
 
+// Base64 base64 编解码, 只是编码并不提供任何保密性, 不要用于保存密码或密钥.
+//
+// Deprecated: 请直接使用 encoding/base64
 type Base64 struct {
 }
 
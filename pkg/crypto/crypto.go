@@ -8,6 +8,8 @@ type Crypto interface {
 }
 
 var (
+	// Deprecated: base64 不是加密, 请直接使用 encoding/base64
 	Base64Crypto = NewBase64()
-	AesCrypto    = DefaultAes()
+	// Deprecated: 旧版 AES-CBC 加密, 新数据请使用 Cipher 或 Envelope
+	AesCrypto = DefaultAes()
 )
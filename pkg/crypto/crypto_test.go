@@ -0,0 +1,127 @@
+package crypto
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCipherRoundTripAndTamper(t *testing.T) {
+	for _, alg := range []Algorithm{AES256GCM, ChaCha20Poly1305} {
+		keyring := NewKeyring()
+		if err := keyring.Add("k1", NewKey()); err != nil {
+			t.Fatal(err)
+		}
+		c := NewCipher(alg, keyring)
+		plaintext := "sk-secret\x00\x00"
+		a, _ := c.Encrypt(plaintext)
+		b, _ := c.Encrypt(plaintext)
+		if a == b || !strings.HasPrefix(a, CipherPrefix) {
+			t.Fatalf("%s: expected random nonce and prefix, got %s %s", alg, a, b)
+		}
+		got, err := c.Decrypt(a)
+		if err != nil || string(got) != plaintext {
+			t.Fatalf("%s: decrypt = %q, %v", alg, got, err)
+		}
+		raw, _ := decodeCipherText(a)
+		raw[len(raw)-1] ^= 1
+		if _, err := c.Open(raw, nil); err == nil {
+			t.Fatalf("%s: expected tamper detection", alg)
+		}
+		// 密钥轮换后旧密文仍可解密, 但需要重新加密
+		if err := keyring.Rotate("k2"); err != nil {
+			t.Fatal(err)
+		}
+		if !c.NeedsRotation(a) {
+			t.Fatalf("%s: expected rotation for old key", alg)
+		}
+		if got, err := c.Decrypt(a); err != nil || string(got) != plaintext {
+			t.Fatalf("%s: decrypt after rotation = %q, %v", alg, got, err)
+		}
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	salt := NewSalt(16)
+	for _, params := range []KDFParams{DefaultArgon2idParams(), DefaultScryptParams()} {
+		a, err := DeriveKey([]byte("passphrase"), salt, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := DeriveKey([]byte("passphrase"), salt, params)
+		c, _ := DeriveKey([]byte("passphrase"), NewSalt(16), params)
+		if len(a) != KeySize || string(a) != string(b) || string(a) == string(c) {
+			t.Fatalf("%s: unexpected derived keys", params.Algorithm)
+		}
+	}
+}
+
+func TestEnvelopeRewrap(t *testing.T) {
+	kek, _ := NewAESGCM(NewKey())
+	env := NewEnvelope(kek, WithDataKeyMaxUses(2))
+	var ciphertexts []string
+	for i := 0; i < 3; i++ {
+		ct, err := env.Encrypt("password")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(ct, EnvelopePrefix) || !IsVersioned(ct) {
+			t.Fatalf("unexpected envelope ciphertext %s", ct)
+		}
+		ciphertexts = append(ciphertexts, ct)
+	}
+
+	// env:NAME 为环境变量引用, 不是密文
+	t.Setenv("DB_PASS", "from-env")
+	if IsVersioned("env:DB_PASS") || ReadValue("env:DB_PASS") != "from-env" || ReadValue(ciphertexts[0]) != ciphertexts[0] {
+		t.Fatal("environment reference and envelope ciphertext are ambiguous")
+	}
+	if err := kek.Keyring().Rotate("k2"); err != nil {
+		t.Fatal(err)
+	}
+	for _, ct := range ciphertexts {
+		if !env.NeedsRotation(ct) {
+			t.Fatal("expected rotation after kek rotation")
+		}
+		rewrapped, err := env.Rewrap(ct)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if env.NeedsRotation(rewrapped) {
+			t.Fatal("rewrapped ciphertext should use primary kek")
+		}
+		got, err := NewEnvelope(kek).Decrypt(rewrapped)
+		if err != nil || string(got) != "password" {
+			t.Fatalf("decrypt = %q, %v", got, err)
+		}
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	legacy := DefaultAes()
+	old, _ := legacy.Encrypt("api-key")
+	current, _ := NewChaCha20Poly1305(NewKey())
+	m := NewMigrator(legacy, current)
+
+	rows := map[string]string{"1": old, "2": ""}
+	saved := map[string]string{}
+	result, err := m.MigrateAll(context.Background(), func(page int) (map[string]string, error) {
+		if page > 1 {
+			return nil, nil
+		}
+		return rows, nil
+	}, func(id, ciphertext string) error {
+		saved[id] = ciphertext
+		return nil
+	})
+	if err != nil || result.Migrated != 1 || result.Skipped != 1 {
+		t.Fatalf("result = %+v, %v", result, err)
+	}
+	if m.NeedsMigration(saved["1"]) {
+		t.Fatal("migrated ciphertext should not need migration")
+	}
+	got, err := m.Decrypt(saved["1"])
+	if err != nil || string(got) != "api-key" {
+		t.Fatalf("decrypt = %q, %v", got, err)
+	}
+}
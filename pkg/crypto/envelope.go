@@ -0,0 +1,244 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvelopePrefix 信封加密密文的前缀, 与表示环境变量引用的 env:NAME 区分
+const EnvelopePrefix = "envl:"
+
+// dataKeyAAD 包装数据密钥时使用的附加认证数据
+var dataKeyAAD = []byte("crypto/data-key")
+
+// EnvelopeOption 信封加密选项
+type EnvelopeOption func(*Envelope)
+
+// WithDataKeyAlgorithm 数据密钥使用的算法, 默认 AES-256-GCM
+func WithDataKeyAlgorithm(alg Algorithm) EnvelopeOption {
+	return func(e *Envelope) {
+		e.alg = alg
+	}
+}
+
+// WithDataKeyMaxUses 单个数据密钥最多加密的次数, 超过后自动轮换, 默认 1<<20
+func WithDataKeyMaxUses(n int64) EnvelopeOption {
+	return func(e *Envelope) {
+		e.maxUses = n
+	}
+}
+
+// WithDataKeyMaxAge 单个数据密钥的最长使用时间, 超过后自动轮换, 默认24小时
+func WithDataKeyMaxAge(d time.Duration) EnvelopeOption {
+	return func(e *Envelope) {
+		e.maxAge = d
+	}
+}
+
+// Envelope 信封加密, 数据使用随机生成的数据密钥加密, 数据密钥再由主密钥(KEK)加密后随密文一起保存.
+// 数据密钥按次数和时间自动轮换; 主密钥轮换后使用 Rewrap 只重新包装数据密钥, 无需重新加密数据
+type Envelope struct {
+	kek     *Cipher
+	alg     Algorithm
+	maxUses int64
+	maxAge  time.Duration
+
+	mu      sync.Mutex
+	current *dataKey
+	cache   map[string][]byte
+}
+
+type dataKey struct {
+	key     []byte
+	wrapped []byte
+	created time.Time
+	uses    int64
+}
+
+// maxCachedDataKeys 解包后的数据密钥缓存上限
+const maxCachedDataKeys = 1024
+
+// NewEnvelope 创建信封加密, kek 为包装数据密钥的主密钥加密器
+func NewEnvelope(kek *Cipher, opts ...EnvelopeOption) *Envelope {
+	e := &Envelope{
+		kek:     kek,
+		alg:     AES256GCM,
+		maxUses: 1 << 20,
+		maxAge:  24 * time.Hour,
+		cache:   make(map[string][]byte),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Rotate 立即轮换数据密钥, 之后的加密使用新的数据密钥
+func (e *Envelope) Rotate() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.rotate()
+}
+
+func (e *Envelope) rotate() error {
+	key := NewKey()
+	wrapped, err := e.kek.Seal(key, dataKeyAAD)
+	if err != nil {
+		return errors.WithMessage(err, "包装数据密钥失败")
+	}
+	e.current = &dataKey{key: key, wrapped: wrapped, created: time.Now()}
+	return nil
+}
+
+// dataKey 获取当前数据密钥, 超过使用次数或时间时轮换
+func (e *Envelope) dataKey() (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c := e.current
+	if c == nil || c.uses >= e.maxUses || time.Since(c.created) > e.maxAge {
+		if err := e.rotate(); err != nil {
+			return nil, err
+		}
+		c = e.current
+	}
+	c.uses++
+	return c, nil
+}
+
+// Seal 加密, aad 为附加认证数据
+func (e *Envelope) Seal(plaintext, aad []byte) ([]byte, error) {
+	dk, err := e.dataKey()
+	if err != nil {
+		return nil, err
+	}
+	payload, err := seal(e.alg, "", dk.key, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+	return marshalEnvelope(dk.wrapped, payload), nil
+}
+
+// Open 解密 Seal 生成的密文
+func (e *Envelope) Open(ciphertext, aad []byte) ([]byte, error) {
+	wrapped, payload, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	key, err := e.unwrap(wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return open(key, payload, aad)
+}
+
+// Encrypt 加密, 返回 envl: 前缀的 base64 字符串
+func (e *Envelope) Encrypt(data string) (string, error) {
+	ciphertext, err := e.Seal([]byte(data), nil)
+	if err != nil {
+		return "", err
+	}
+	return EnvelopePrefix + base64.RawURLEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt 解密 Encrypt 生成的字符串
+func (e *Envelope) Decrypt(data string) ([]byte, error) {
+	ciphertext, err := decodeEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	return e.Open(ciphertext, nil)
+}
+
+// NeedsRotation 密文的数据密钥是否不是由当前主密钥包装
+func (e *Envelope) NeedsRotation(data string) bool {
+	ciphertext, err := decodeEnvelope(data)
+	if err != nil {
+		return true
+	}
+	wrapped, _, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return true
+	}
+	h, _, err := parseHeader(wrapped)
+	return err != nil || h.keyID != e.kek.Keyring().PrimaryID()
+}
+
+// Rewrap 使用当前主密钥重新包装密文中的数据密钥, 数据部分保持不变
+func (e *Envelope) Rewrap(data string) (string, error) {
+	ciphertext, err := decodeEnvelope(data)
+	if err != nil {
+		return "", err
+	}
+	wrapped, payload, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	key, err := e.unwrap(wrapped)
+	if err != nil {
+		return "", err
+	}
+	rewrapped, err := e.kek.Seal(key, dataKeyAAD)
+	if err != nil {
+		return "", errors.WithMessage(err, "包装数据密钥失败")
+	}
+	return EnvelopePrefix + base64.RawURLEncoding.EncodeToString(marshalEnvelope(rewrapped, payload)), nil
+}
+
+func (e *Envelope) unwrap(wrapped []byte) ([]byte, error) {
+	e.mu.Lock()
+	key, ok := e.cache[string(wrapped)]
+	e.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	key, err := e.kek.Open(wrapped, dataKeyAAD)
+	if err != nil {
+		return nil, errors.WithMessage(err, "解包数据密钥失败")
+	}
+	e.mu.Lock()
+	if len(e.cache) >= maxCachedDataKeys {
+		e.cache = make(map[string][]byte)
+	}
+	e.cache[string(wrapped)] = key
+	e.mu.Unlock()
+	return key, nil
+}
+
+// 信封密文格式: version(1) | wrappedLen(2) | wrapped | payload
+func marshalEnvelope(wrapped, payload []byte) []byte {
+	out := make([]byte, 3, 3+len(wrapped)+len(payload))
+	out[0] = formatVersion
+	binary.BigEndian.PutUint16(out[1:3], uint16(len(wrapped)))
+	out = append(out, wrapped...)
+	return append(out, payload...)
+}
+
+func parseEnvelope(data []byte) (wrapped, payload []byte, err error) {
+	if len(data) < 3 {
+		return nil, nil, errors.New("信封密文长度不足")
+	}
+	if data[0] != formatVersion {
+		return nil, nil, errors.Errorf("不支持的信封密文版本: %d", data[0])
+	}
+	n := 3 + int(binary.BigEndian.Uint16(data[1:3]))
+	if len(data) < n {
+		return nil, nil, errors.New("信封密文长度不足")
+	}
+	return data[3:n], data[n:], nil
+}
+
+func decodeEnvelope(data string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(data, EnvelopePrefix)
+	if !ok {
+		return nil, errors.New("密文格式错误, 缺少 envl: 前缀")
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.WithMessage(err, "密文不是有效的base64")
+	}
+	return ciphertext, nil
+}
@@ -0,0 +1,101 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	KDFArgon2id = "argon2id"
+	KDFScrypt   = "scrypt"
+)
+
+// KDFParams 密钥派生参数
+type KDFParams struct {
+	Algorithm string `json:"algorithm" yaml:"algorithm" mapstructure:"algorithm"` // argon2id 或 scrypt
+	// argon2id 参数
+	Time    uint32 `json:"time" yaml:"time" mapstructure:"time"`
+	Memory  uint32 `json:"memory" yaml:"memory" mapstructure:"memory"` // 单位 KiB
+	Threads uint8  `json:"threads" yaml:"threads" mapstructure:"threads"`
+	// scrypt 参数
+	N int `json:"n" yaml:"n" mapstructure:"n"`
+	R int `json:"r" yaml:"r" mapstructure:"r"`
+	P int `json:"p" yaml:"p" mapstructure:"p"`
+
+	KeyLen uint32 `json:"keyLen" yaml:"key-len" mapstructure:"key-len"`
+}
+
+// DefaultArgon2idParams argon2id 默认参数, 参考 RFC 9106 的第二推荐配置
+func DefaultArgon2idParams() KDFParams {
+	return KDFParams{Algorithm: KDFArgon2id, Time: 3, Memory: 64 * 1024, Threads: 4, KeyLen: KeySize}
+}
+
+// DefaultScryptParams scrypt 默认参数
+func DefaultScryptParams() KDFParams {
+	return KDFParams{Algorithm: KDFScrypt, N: 1 << 15, R: 8, P: 1, KeyLen: KeySize}
+}
+
+// Prepare 设置默认值
+func (p *KDFParams) Prepare() {
+	if p.Algorithm == "" {
+		p.Algorithm = KDFArgon2id
+	}
+	if p.KeyLen == 0 {
+		p.KeyLen = KeySize
+	}
+	switch p.Algorithm {
+	case KDFArgon2id:
+		def := DefaultArgon2idParams()
+		if p.Time == 0 {
+			p.Time = def.Time
+		}
+		if p.Memory == 0 {
+			p.Memory = def.Memory
+		}
+		if p.Threads == 0 {
+			p.Threads = def.Threads
+		}
+	case KDFScrypt:
+		def := DefaultScryptParams()
+		if p.N == 0 {
+			p.N = def.N
+		}
+		if p.R == 0 {
+			p.R = def.R
+		}
+		if p.P == 0 {
+			p.P = def.P
+		}
+	}
+}
+
+// DeriveKey 由口令和盐派生密钥
+func DeriveKey(password, salt []byte, params KDFParams) ([]byte, error) {
+	params.Prepare()
+	if len(salt) == 0 {
+		return nil, errors.New("密钥派生的盐不能为空")
+	}
+	switch params.Algorithm {
+	case KDFArgon2id:
+		return argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, params.KeyLen), nil
+	case KDFScrypt:
+		key, err := scrypt.Key(password, salt, params.N, params.R, params.P, int(params.KeyLen))
+		if err != nil {
+			return nil, errors.WithMessage(err, "scrypt 派生密钥失败")
+		}
+		return key, nil
+	default:
+		return nil, errors.Errorf("不支持的密钥派生算法: %s", params.Algorithm)
+	}
+}
+
+// NewSalt 生成随机盐
+func NewSalt(size int) []byte {
+	salt := make([]byte, size)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+	return salt
+}
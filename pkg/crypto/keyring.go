@@ -0,0 +1,185 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/pkg/errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// KeySize 对称密钥长度, AES-256 与 ChaCha20-Poly1305 均为32字节
+const KeySize = 32
+
+// Keyring 密钥环, 按密钥ID保存多把密钥, 使用主密钥加密, 按密文中的密钥ID解密,
+// 轮换时添加新密钥并设为主密钥即可, 旧密钥保留用于解密历史数据
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	primary string
+}
+
+// NewKeyring 创建密钥环, 第一把添加的密钥默认为主密钥
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string][]byte)}
+}
+
+// NewKey 生成随机密钥
+func NewKey() []byte {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// Add 添加密钥, 密钥ID不能为空且不能超过255字节
+func (k *Keyring) Add(id string, key []byte) error {
+	if id == "" || len(id) > 255 {
+		return errors.Errorf("密钥ID长度必须在1-255之间: %q", id)
+	}
+	if len(key) != KeySize {
+		return errors.Errorf("密钥 %s 长度必须是%d字节, 实际为%d字节", id, KeySize, len(key))
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = append([]byte(nil), key...)
+	if k.primary == "" {
+		k.primary = id
+	}
+	return nil
+}
+
+// SetPrimary 设置主密钥
+func (k *Keyring) SetPrimary(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[id]; !ok {
+		return errors.Errorf("密钥 %s 不存在", id)
+	}
+	k.primary = id
+	return nil
+}
+
+// Rotate 生成新密钥并设为主密钥
+func (k *Keyring) Rotate(id string) error {
+	if err := k.Add(id, NewKey()); err != nil {
+		return err
+	}
+	return k.SetPrimary(id)
+}
+
+// Primary 获取主密钥
+func (k *Keyring) Primary() (string, []byte, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if k.primary == "" {
+		return "", nil, errors.New("密钥环中没有可用的密钥")
+	}
+	return k.primary, k.keys[k.primary], nil
+}
+
+// PrimaryID 主密钥ID
+func (k *Keyring) PrimaryID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+// Get 按ID获取密钥
+func (k *Keyring) Get(id string) ([]byte, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+// IDs 所有密钥ID
+func (k *Keyring) IDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// KeyringConfig 密钥环配置
+type KeyringConfig struct {
+	Algorithm  string            `json:"algorithm" yaml:"algorithm" mapstructure:"algorithm"`    // aes-gcm 或 chacha20-poly1305, 默认 aes-gcm
+	Primary    string            `json:"primary" yaml:"primary" mapstructure:"primary"`          // 主密钥ID, 为空时使用排序后的最后一个
+	Keys       map[string]string `json:"keys" yaml:"keys" mapstructure:"keys"`                   // 密钥ID -> base64编码的32字节密钥, 支持 env:NAME 从环境变量读取
+	Passphrase string            `json:"passphrase" yaml:"passphrase" mapstructure:"passphrase"` // 未配置 Keys 时由口令派生密钥, 支持 env:NAME
+	Salt       string            `json:"salt" yaml:"salt" mapstructure:"salt"`                   // 口令派生使用的盐, 至少16字节
+}
+
+// Prepare 设置默认值
+func (c *KeyringConfig) Prepare() {
+	if c.Algorithm == "" {
+		c.Algorithm = AES256GCM.String()
+	}
+}
+
+// Build 根据配置创建加密器
+func (c *KeyringConfig) Build() (*Cipher, error) {
+	c.Prepare()
+	alg, err := ParseAlgorithm(c.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	keyring := NewKeyring()
+	if len(c.Keys) == 0 {
+		passphrase := ReadValue(c.Passphrase)
+		if passphrase == "" {
+			return nil, errors.New("未配置加密密钥或口令")
+		}
+		if len(c.Salt) < 16 {
+			return nil, errors.New("口令派生密钥的盐至少16字节")
+		}
+		key, err := DeriveKey([]byte(passphrase), []byte(c.Salt), DefaultArgon2idParams())
+		if err != nil {
+			return nil, err
+		}
+		if err := keyring.Add("default", key); err != nil {
+			return nil, err
+		}
+		return NewCipher(alg, keyring), nil
+	}
+	ids := make([]string, 0, len(c.Keys))
+	for id := range c.Keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		key, err := base64.StdEncoding.DecodeString(ReadValue(c.Keys[id]))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "密钥 %s 不是有效的base64", id)
+		}
+		if err := keyring.Add(id, key); err != nil {
+			return nil, err
+		}
+	}
+	primary := c.Primary
+	if primary == "" {
+		primary = ids[len(ids)-1]
+	}
+	if err := keyring.SetPrimary(primary); err != nil {
+		return nil, err
+	}
+	return NewCipher(alg, keyring), nil
+}
+
+// EnvPrefix 配置值引用环境变量的前缀, 与 tlsx 的证书来源一致
+const EnvPrefix = "env:"
+
+// ReadValue 读取配置值, env:NAME 表示从环境变量读取, 其他值原样返回
+func ReadValue(value string) string {
+	if name, ok := strings.CutPrefix(value, EnvPrefix); ok {
+		return os.Getenv(name)
+	}
+	return value
+}
@@ -0,0 +1,108 @@
+package crypto
+
+import (
+	"context"
+	"github.com/pkg/errors"
+)
+
+// RotatableCrypto 可判断密文是否需要重新加密的加密器, Cipher 与 Envelope 均已实现
+type RotatableCrypto interface {
+	Crypto
+	// NeedsRotation 密文是否需要使用当前密钥重新加密
+	NeedsRotation(data string) bool
+}
+
+// Migrator 旧版密文迁移, 加密使用新加密器; 解密时根据前缀识别新旧格式,
+// 旧版 CBC/Zero 填充密文使用 Legacy 解密, 可作为过渡期的 Crypto 使用
+type Migrator struct {
+	Legacy  Crypto
+	Current RotatableCrypto
+}
+
+// NewMigrator 创建迁移器, legacy 为空时使用 DefaultAes
+func NewMigrator(legacy Crypto, current RotatableCrypto) *Migrator {
+	if legacy == nil {
+		legacy = DefaultAes()
+	}
+	return &Migrator{Legacy: legacy, Current: current}
+}
+
+// Encrypt 使用新加密器加密
+func (m *Migrator) Encrypt(data string) (string, error) {
+	return m.Current.Encrypt(data)
+}
+
+// Decrypt 解密新旧两种格式的密文
+func (m *Migrator) Decrypt(data string) ([]byte, error) {
+	if IsVersioned(data) {
+		return m.Current.Decrypt(data)
+	}
+	return m.Legacy.Decrypt(data)
+}
+
+// NeedsMigration 密文是否为旧版格式, 或不是由当前密钥加密
+func (m *Migrator) NeedsMigration(data string) bool {
+	if data == "" {
+		return false
+	}
+	return !IsVersioned(data) || m.Current.NeedsRotation(data)
+}
+
+// Migrate 重新加密单个密文, 不需要迁移时原样返回, changed 为 false
+func (m *Migrator) Migrate(data string) (result string, changed bool, err error) {
+	if !m.NeedsMigration(data) {
+		return data, false, nil
+	}
+	plaintext, err := m.Decrypt(data)
+	if err != nil {
+		return "", false, errors.WithMessage(err, "解密旧密文失败")
+	}
+	result, err = m.Current.Encrypt(string(plaintext))
+	if err != nil {
+		return "", false, errors.WithMessage(err, "重新加密失败")
+	}
+	return result, true, nil
+}
+
+// MigrateResult 批量迁移结果
+type MigrateResult struct {
+	Total    int
+	Migrated int
+	Skipped  int
+	Failed   int
+}
+
+// MigrateAll 批量迁移, load 按页读取密文, 返回空切片表示结束; save 保存迁移后的密文.
+// 单条失败时记录失败数并继续, ctx 取消时返回已完成的结果
+func (m *Migrator) MigrateAll(ctx context.Context, load func(page int) (map[string]string, error), save func(id, ciphertext string) error) (MigrateResult, error) {
+	var result MigrateResult
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		values, err := load(page)
+		if err != nil {
+			return result, errors.WithMessagef(err, "读取第%d页数据失败", page)
+		}
+		if len(values) == 0 {
+			return result, nil
+		}
+		for id, value := range values {
+			result.Total++
+			migrated, changed, err := m.Migrate(value)
+			if err != nil {
+				result.Failed++
+				continue
+			}
+			if !changed {
+				result.Skipped++
+				continue
+			}
+			if err := save(id, migrated); err != nil {
+				result.Failed++
+				continue
+			}
+			result.Migrated++
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package document
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NodeKind 文档节点类型
+type NodeKind string
+
+const (
+	NodeHeading   NodeKind = "heading"
+	NodeParagraph NodeKind = "paragraph"
+	NodeList      NodeKind = "list"
+	NodeTable     NodeKind = "table"
+	NodeImage     NodeKind = "image"
+	NodeCode      NodeKind = "code"
+	NodeQuote     NodeKind = "quote"
+	NodePageBreak NodeKind = "page_break"
+)
+
+// ListItem 列表项, Level 为嵌套层级, 从 0 开始
+type ListItem struct {
+	Text  string `json:"text"`
+	Level int    `json:"level,omitempty"`
+}
+
+// Node 文档节点, 不同类型使用不同的字段
+type Node struct {
+	Kind NodeKind `json:"kind"`
+
+	// Level 标题级别 1-6
+	Level int `json:"level,omitempty"`
+
+	// Text 标题、段落、代码块、引用的文本, 段落中可以包含行内 Markdown
+	Text string `json:"text,omitempty"`
+
+	// Ordered 是否为有序列表
+	Ordered bool `json:"ordered,omitempty"`
+
+	// Items 列表项
+	Items []ListItem `json:"items,omitempty"`
+
+	// Rows 表格内容, 第一行为表头
+	Rows [][]string `json:"rows,omitempty"`
+
+	// Src、Alt 图片地址和说明
+	Src string `json:"src,omitempty"`
+	Alt string `json:"alt,omitempty"`
+
+	// Lang 代码块语言
+	Lang string `json:"lang,omitempty"`
+
+	// Page 所在页码, 从 1 开始, 没有分页的格式为 0
+	Page int `json:"page,omitempty"`
+}
+
+// Metadata 文档元数据
+type Metadata struct {
+	Title    string            `json:"title,omitempty"`
+	Author   string            `json:"author,omitempty"`
+	Subject  string            `json:"subject,omitempty"`
+	Keywords string            `json:"keywords,omitempty"`
+	Created  string            `json:"created,omitempty"`
+	Pages    int               `json:"pages,omitempty"`
+	Extra    map[string]string `json:"extra,omitempty"`
+}
+
+// Document 转换器输出的结构化文档
+type Document struct {
+	Metadata Metadata `json:"metadata"`
+	Nodes    []*Node  `json:"nodes"`
+}
+
+// Append 追加节点
+func (d *Document) Append(nodes ...*Node) {
+	d.Nodes = append(d.Nodes, nodes...)
+}
+
+// Markdown 渲染为 Markdown, 不包含元数据
+func (d *Document) Markdown() string {
+	return d.render(false)
+}
+
+// render 渲染为 Markdown, withMetadata 为 true 时在开头输出 YAML front matter
+func (d *Document) render(withMetadata bool) string {
+	var markdown strings.Builder
+	if withMetadata {
+		markdown.WriteString(d.frontMatter())
+	}
+	for i, node := range d.Nodes {
+		// 分页符前后没有内容时不输出
+		if node.Kind == NodePageBreak && (i == 0 || i == len(d.Nodes)-1 || d.Nodes[i+1].Kind == NodePageBreak) {
+			continue
+		}
+		block := node.Markdown()
+		if block == "" {
+			continue
+		}
+		if markdown.Len() > 0 {
+			markdown.WriteString("\n")
+		}
+		markdown.WriteString(block)
+	}
+	return markdown.String()
+}
+
+func (d *Document) frontMatter() string {
+	var fm strings.Builder
+	field := func(key, value string) {
+		if value != "" {
+			fm.WriteString(key)
+			fm.WriteString(": ")
+			fm.WriteString(value)
+			fm.WriteString("\n")
+		}
+	}
+	field("title", quoteYAML(d.Metadata.Title))
+	field("author", quoteYAML(d.Metadata.Author))
+	field("subject", quoteYAML(d.Metadata.Subject))
+	field("keywords", quoteYAML(d.Metadata.Keywords))
+	field("created", quoteYAML(d.Metadata.Created))
+	if d.Metadata.Pages > 0 {
+		field("pages", strconv.Itoa(d.Metadata.Pages))
+	}
+	keys := make([]string, 0, len(d.Metadata.Extra))
+	for k := range d.Metadata.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		field(k, quoteYAML(d.Metadata.Extra[k]))
+	}
+	if fm.Len() == 0 {
+		return ""
+	}
+	return "---\n" + fm.String() + "---\n"
+}
+
+func quoteYAML(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strconv.Quote(s)
+}
+
+// Markdown 渲染单个节点, 以换行结尾
+func (n *Node) Markdown() string {
+	switch n.Kind {
+	case NodeHeading:
+		level := n.Level
+		if level < 1 {
+			level = 1
+		} else if level > 6 {
+			level = 6
+		}
+		return strings.Repeat("#", level) + " " + singleLine(n.Text) + "\n"
+	case NodeParagraph:
+		if strings.TrimSpace(n.Text) == "" {
+			return ""
+		}
+		return strings.TrimSpace(n.Text) + "\n"
+	case NodeList:
+		var markdown strings.Builder
+		for i, item := range n.Items {
+			markdown.WriteString(strings.Repeat("  ", item.Level))
+			if n.Ordered {
+				markdown.WriteString(strconv.Itoa(i + 1))
+				markdown.WriteString(". ")
+			} else {
+				markdown.WriteString("- ")
+			}
+			markdown.WriteString(singleLine(item.Text))
+			markdown.WriteString("\n")
+		}
+		return markdown.String()
+	case NodeTable:
+		return markdownTable(n.Rows)
+	case NodeImage:
+		if n.Src == "" {
+			return ""
+		}
+		return fmt.Sprintf("![%s](%s)\n", n.Alt, strings.ReplaceAll(n.Src, " ", "%20"))
+	case NodeCode:
+		fence := "```"
+		for strings.Contains(n.Text, fence) {
+			fence += "`"
+		}
+		return fence + n.Lang + "\n" + strings.TrimRight(n.Text, "\n") + "\n" + fence + "\n"
+	case NodeQuote:
+		if strings.TrimSpace(n.Text) == "" {
+			return ""
+		}
+		return "> " + strings.ReplaceAll(strings.TrimSpace(n.Text), "\n", "\n> ") + "\n"
+	case NodePageBreak:
+		return "---\n"
+	default:
+		return ""
+	}
+}
+
+// PlainText 节点的纯文本内容
+func (n *Node) PlainText() string {
+	switch n.Kind {
+	case NodeList:
+		texts := make([]string, len(n.Items))
+		for i, item := range n.Items {
+			texts[i] = item.Text
+		}
+		return strings.Join(texts, "\n")
+	case NodeTable:
+		rows := make([]string, len(n.Rows))
+		for i, row := range n.Rows {
+			rows[i] = strings.Join(row, " ")
+		}
+		return strings.Join(rows, "\n")
+	case NodeImage:
+		return n.Alt
+	default:
+		return n.Text
+	}
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// DocumentConverter 可以输出结构化文档的转换器, 内置转换器都实现了该接口
+type DocumentConverter interface {
+	Converter
+
+	// ToDocument 将文档转换为结构化文档
+	ToDocument(input io.Reader) (*Document, error)
+}
+
+// ToDocument 使用转换器生成结构化文档, 未实现 DocumentConverter 的转换器解析其 Markdown 输出
+func ToDocument(c Converter, input io.Reader) (*Document, error) {
+	if dc, ok := c.(DocumentConverter); ok {
+		return dc.ToDocument(input)
+	}
+	markdown, err := c.ToMarkdown(input)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMarkdown(markdown), nil
+}
+
+// ConvertToDocument 转换文档为结构化文档
+func ConvertToDocument(inputPath string, opts *ConvertOptions) (*Document, error) {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+
+	converter, err := defaultRegistry.ConverterFor(inputPath, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	return ToDocument(converter, file)
+}
+
+// ParseMarkdown 把 Markdown 解析为结构化文档, 识别标题、段落、列表、表格、图片、代码块、引用和分隔线
+func ParseMarkdown(markdown string) *Document {
+	doc := &Document{}
+	reader := NewMarkdownReader(strings.NewReader(markdown))
+	for {
+		node, err := reader.Next()
+		if err != nil {
+			return doc
+		}
+		doc.Append(node)
+	}
+}
@@ -0,0 +1,536 @@
+package document
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultManifestName 输出目录中默认的清单文件名
+const DefaultManifestName = ".convert-manifest.json"
+
+// FileStatus 单个文件的转换结果状态
+type FileStatus string
+
+const (
+	StatusConverted FileStatus = "converted"
+	StatusSkipped   FileStatus = "skipped"
+	StatusFailed    FileStatus = "failed"
+)
+
+// BatchOptions 批量转换选项
+type BatchOptions struct {
+	// Convert 转换选项
+	Convert *ConvertOptions
+
+	// Workers 并发数, 默认为 CPU 核数
+	Workers int
+
+	// Timeout 单个文件的转换超时(含等待空闲 worker 的时间), 0 表示不限制
+	Timeout time.Duration
+
+	// Resume 在输出目录中记录清单, 再次运行时跳过内容没有变化的文件, 默认关闭
+	Resume bool
+
+	// ManifestPath 清单文件路径, 默认为输出目录下的 .convert-manifest.json, 只在 Resume 时使用
+	ManifestPath string
+
+	// Force 忽略清单, 重新转换所有文件
+	Force bool
+
+	// ReportPath JSON 报告的保存路径, 为空时不保存
+	ReportPath string
+
+	// Progress 每个文件处理完成后调用, 多个 worker 会并发调用
+	Progress func(BatchProgress)
+}
+
+// BatchProgress 批量转换进度
+type BatchProgress struct {
+	Total     int         `json:"total"`
+	Done      int         `json:"done"`
+	Converted int         `json:"converted"`
+	Skipped   int         `json:"skipped"`
+	Failed    int         `json:"failed"`
+	Current   *FileResult `json:"current"`
+}
+
+// FileResult 单个文件的转换结果
+type FileResult struct {
+	Input      string       `json:"input"`
+	Output     string       `json:"output"`
+	Type       DocumentType `json:"type,omitempty"`
+	Size       int64        `json:"size"`
+	Hash       string       `json:"hash,omitempty"`
+	Status     FileStatus   `json:"status"`
+	Error      string       `json:"error,omitempty"`
+	DurationMs int64        `json:"duration_ms"`
+}
+
+// BatchReport 批量转换报告
+type BatchReport struct {
+	InputDir   string        `json:"input_dir"`
+	OutputDir  string        `json:"output_dir"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	DurationMs int64         `json:"duration_ms"`
+	Total      int           `json:"total"`
+	Converted  int           `json:"converted"`
+	Skipped    int           `json:"skipped"`
+	Failed     int           `json:"failed"`
+	Files      []*FileResult `json:"files"`
+}
+
+// ChannelProgress 把进度发送到 channel, channel 已满时丢弃, 避免阻塞转换
+func ChannelProgress(ch chan<- BatchProgress) func(BatchProgress) {
+	return func(p BatchProgress) {
+		select {
+		case ch <- p:
+		default:
+		}
+	}
+}
+
+// manifestEntry 清单中记录的已转换文件
+type manifestEntry struct {
+	Hash        string       `json:"hash"`
+	Output      string       `json:"output"`
+	Type        DocumentType `json:"type"`
+	Size        int64        `json:"size"`
+	DurationMs  int64        `json:"duration_ms"`
+	ConvertedAt time.Time    `json:"converted_at"`
+}
+
+// manifest 清单文件, Options 为转换选项的摘要, 选项变化后所有文件都需要重新转换
+type manifest struct {
+	Options string                    `json:"options"`
+	Files   map[string]*manifestEntry `json:"files"`
+}
+
+// BatchConverter 批量转换引擎: 固定数量的 worker 并发转换, 单个文件超时或 panic 不影响其他文件,
+// 开启 Resume 时清单记录已转换文件的内容哈希, 再次运行时跳过没有变化的文件
+type BatchConverter struct {
+	opts *BatchOptions
+	// slots 正在执行的转换, 超时放弃的转换在真正结束前仍占用名额, 保证同时运行的转换不超过 Workers
+	slots chan struct{}
+}
+
+// NewBatchConverter 创建批量转换引擎
+func NewBatchConverter(opts *BatchOptions) *BatchConverter {
+	o := BatchOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Convert == nil {
+		o.Convert = DefaultConvertOptions()
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+	return &BatchConverter{opts: &o, slots: make(chan struct{}, o.Workers)}
+}
+
+// Run 转换 inputDir 下所有支持的文件到 outputDir, 保持目录结构, 输出文件扩展名替换为 .md.
+// 多个文件的输出路径相同(如 a.pdf 与 a.docx)或输出会覆盖源文件时, 这些文件记为失败, 不会互相覆盖.
+// 单个文件失败只记录在报告中, 只有遍历目录、读写清单和报告失败或 ctx 取消时返回错误
+func (b *BatchConverter) Run(ctx context.Context, inputDir, outputDir string) (*BatchReport, error) {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	files, err := b.collect(inputDir, outputDir)
+	if err != nil {
+		return nil, err
+	}
+	m, err := b.loadManifest(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	outputs, conflicts := planOutputs(inputDir, outputDir, files)
+
+	report := &BatchReport{InputDir: inputDir, OutputDir: outputDir, StartedAt: time.Now(), Total: len(files)}
+	var (
+		mu       sync.Mutex
+		progress = BatchProgress{Total: len(files)}
+		lastSave = time.Now()
+		saveErr  error
+	)
+	record := func(result *FileResult, rel string) {
+		mu.Lock()
+		defer mu.Unlock()
+		report.Files = append(report.Files, result)
+		progress.Done++
+		switch result.Status {
+		case StatusConverted:
+			progress.Converted++
+			m.Files[rel] = &manifestEntry{
+				Hash:        result.Hash,
+				Output:      result.Output,
+				Type:        result.Type,
+				Size:        result.Size,
+				DurationMs:  result.DurationMs,
+				ConvertedAt: time.Now(),
+			}
+		case StatusSkipped:
+			progress.Skipped++
+		case StatusFailed:
+			progress.Failed++
+			delete(m.Files, rel)
+		}
+		// 定期保存清单, 中断后重新运行可以跳过已完成的文件
+		if b.opts.Resume && result.Status == StatusConverted && time.Since(lastSave) > 2*time.Second {
+			lastSave = time.Now()
+			if err := b.saveManifest(outputDir, m); err != nil && saveErr == nil {
+				saveErr = err
+			}
+		}
+		if b.opts.Progress != nil {
+			p := progress
+			p.Current = result
+			b.opts.Progress(p)
+		}
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < b.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				rel, _ := filepath.Rel(inputDir, path)
+				if err := conflicts[path]; err != nil {
+					record(&FileResult{Input: path, Output: outputs[path], Status: StatusFailed, Error: err.Error()}, rel)
+					continue
+				}
+				mu.Lock()
+				entry := m.Files[rel]
+				mu.Unlock()
+				record(b.convertFile(ctx, path, outputs[path], entry), rel)
+			}
+		}()
+	}
+	for _, path := range files {
+		select {
+		case jobs <- path:
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	report.FinishedAt = time.Now()
+	report.DurationMs = report.FinishedAt.Sub(report.StartedAt).Milliseconds()
+	report.Converted, report.Skipped, report.Failed = progress.Converted, progress.Skipped, progress.Failed
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].Input < report.Files[j].Input
+	})
+
+	if b.opts.Resume {
+		if err := b.saveManifest(outputDir, m); err != nil {
+			return report, err
+		}
+	}
+	if saveErr != nil {
+		return report, saveErr
+	}
+	if b.opts.ReportPath != "" {
+		if err := writeJSON(b.opts.ReportPath, report); err != nil {
+			return report, fmt.Errorf("保存报告失败: %w", err)
+		}
+	}
+	return report, ctx.Err()
+}
+
+// Estimate 估算转换 inputDir 需要的时间: 没有变化的文件不计入,
+// 清单中有同类型文件的历史耗时时按历史速度估算, 否则使用 EstimateConversionTime
+func (b *BatchConverter) Estimate(inputDir, outputDir string) (time.Duration, error) {
+	files, err := b.collect(inputDir, outputDir)
+	if err != nil {
+		return 0, err
+	}
+	m, err := b.loadManifest(outputDir)
+	if err != nil {
+		return 0, err
+	}
+
+	// 每种类型的历史速度, 字节/毫秒
+	type stat struct{ size, ms int64 }
+	stats := make(map[DocumentType]*stat)
+	for _, e := range m.Files {
+		s, ok := stats[e.Type]
+		if !ok {
+			s = &stat{}
+			stats[e.Type] = s
+		}
+		s.size += e.Size
+		s.ms += e.DurationMs
+	}
+
+	var total int64
+	for _, path := range files {
+		rel, _ := filepath.Rel(inputDir, path)
+		if entry := m.Files[rel]; entry != nil && b.opts.Resume && !b.opts.Force {
+			if hash, err := fileHash(path); err == nil && hash == entry.Hash {
+				continue
+			}
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, err
+		}
+		docType, _ := DetectDocumentType(path)
+		if s := stats[docType]; s != nil && s.size > 0 && s.ms > 0 {
+			total += info.Size() * s.ms / s.size
+			continue
+		}
+		ms, err := EstimateConversionTime(path)
+		if err != nil {
+			return 0, err
+		}
+		total += ms
+	}
+	return time.Duration(total/int64(b.opts.Workers)) * time.Millisecond, nil
+}
+
+// collect 按路径顺序列出需要转换的文件, 跳过位于输入目录中的输出目录
+func (b *BatchConverter) collect(inputDir, outputDir string) ([]string, error) {
+	absOutput, _ := filepath.Abs(outputDir)
+	var files []string
+	err := filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if abs, _ := filepath.Abs(path); abs == absOutput && path != inputDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if defaultRegistry.Supports(path) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("遍历输入目录失败: %w", err)
+	}
+	return files, nil
+}
+
+// convertFile 转换单个文件: 开启 Resume 且内容哈希与清单一致、输出文件存在时跳过,
+// 转换结果先写入临时文件, 成功后再重命名, 超时的转换不会覆盖输出.
+// 转换器不支持取消, 超时只是放弃等待, 转换在后台结束前一直占用 slots 中的名额
+func (b *BatchConverter) convertFile(ctx context.Context, path, output string, entry *manifestEntry) *FileResult {
+	start := time.Now()
+	result := &FileResult{Input: path, Output: output, Status: StatusFailed}
+	defer func() {
+		result.DurationMs = time.Since(start).Milliseconds()
+	}()
+	fail := func(err error) *FileResult {
+		result.Error = err.Error()
+		return result
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fail(err)
+	}
+	result.Size = info.Size()
+	if result.Type, err = DetectDocumentType(path); err != nil {
+		return fail(err)
+	}
+	if result.Hash, err = fileHash(path); err != nil {
+		return fail(err)
+	}
+	if entry != nil && b.opts.Resume && !b.opts.Force && entry.Hash == result.Hash {
+		if _, err := os.Stat(output); err == nil {
+			result.Status = StatusSkipped
+			return result
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		return fail(fmt.Errorf("创建输出子目录失败: %w", err))
+	}
+
+	var timeout <-chan time.Time
+	if b.opts.Timeout > 0 {
+		timer := time.NewTimer(b.opts.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	// 之前超时的转换仍在运行时等待其结束, 避免后台转换越积越多
+	select {
+	case b.slots <- struct{}{}:
+	case <-timeout:
+		return fail(fmt.Errorf("转换超时: %s, 等待仍在运行的超时转换结束", b.opts.Timeout))
+	case <-ctx.Done():
+		return fail(ctx.Err())
+	}
+
+	tmp := fmt.Sprintf("%s.%d.tmp", output, time.Now().UnixNano())
+	var (
+		mu        sync.Mutex
+		finished  bool
+		abandoned bool
+		done      = make(chan error, 1)
+	)
+	go func() {
+		var err error
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("转换时发生 panic: %v", r)
+			}
+			mu.Lock()
+			finished = true
+			if abandoned {
+				os.Remove(tmp)
+			}
+			mu.Unlock()
+			<-b.slots
+			done <- err
+		}()
+		err = Convert(path, tmp, b.opts.Convert)
+	}()
+
+	abandon := func(err error) *FileResult {
+		mu.Lock()
+		abandoned = true
+		if finished {
+			os.Remove(tmp)
+		}
+		mu.Unlock()
+		return fail(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			os.Remove(tmp)
+			return fail(err)
+		}
+	case <-timeout:
+		return abandon(fmt.Errorf("转换超时: %s", b.opts.Timeout))
+	case <-ctx.Done():
+		return abandon(ctx.Err())
+	}
+	if err := os.Rename(tmp, output); err != nil {
+		os.Remove(tmp)
+		return fail(fmt.Errorf("保存输出文件失败: %w", err))
+	}
+	result.Status = StatusConverted
+	return result
+}
+
+func (b *BatchConverter) manifestPath(outputDir string) string {
+	if b.opts.ManifestPath != "" {
+		return b.opts.ManifestPath
+	}
+	return filepath.Join(outputDir, DefaultManifestName)
+}
+
+// optionsDigest 转换选项的摘要
+func (b *BatchConverter) optionsDigest() string {
+	data, _ := json.Marshal(b.opts.Convert)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func (b *BatchConverter) loadManifest(outputDir string) (*manifest, error) {
+	m := &manifest{Options: b.optionsDigest(), Files: make(map[string]*manifestEntry)}
+	data, err := os.ReadFile(b.manifestPath(outputDir))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取清单失败: %w", err)
+	}
+	var saved manifest
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("解析清单失败: %w", err)
+	}
+	if saved.Options == m.Options && saved.Files != nil {
+		m.Files = saved.Files
+	}
+	return m, nil
+}
+
+func (b *BatchConverter) saveManifest(outputDir string, m *manifest) error {
+	if err := writeJSON(b.manifestPath(outputDir), m); err != nil {
+		return fmt.Errorf("保存清单失败: %w", err)
+	}
+	return nil
+}
+
+// writeJSON 先写临时文件再重命名, 避免中断时留下不完整的文件
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func fileHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// outputPath 输出文件路径, 扩展名替换为 .md
+func outputPath(outputDir, rel string) string {
+	return filepath.Join(outputDir, strings.TrimSuffix(rel, filepath.Ext(rel))+".md")
+}
+
+// planOutputs 计算每个文件的输出路径, 并找出输出路径冲突的文件:
+// 多个文件输出到同一路径, 或输出路径是某个待转换的源文件(包括自身)
+func planOutputs(inputDir, outputDir string, files []string) (map[string]string, map[string]error) {
+	outputs := make(map[string]string, len(files))
+	sources := make(map[string]string, len(files))
+	byOutput := make(map[string][]string, len(files))
+	for _, path := range files {
+		rel, _ := filepath.Rel(inputDir, path)
+		outputs[path] = outputPath(outputDir, rel)
+		abs, _ := filepath.Abs(path)
+		sources[abs] = path
+		absOutput, _ := filepath.Abs(outputs[path])
+		byOutput[absOutput] = append(byOutput[absOutput], path)
+	}
+	conflicts := make(map[string]error)
+	for absOutput, paths := range byOutput {
+		if source, ok := sources[absOutput]; ok {
+			for _, path := range paths {
+				conflicts[path] = fmt.Errorf("输出文件 %s 会覆盖源文件 %s, 请使用其他输出目录", absOutput, source)
+			}
+			continue
+		}
+		if len(paths) > 1 {
+			for _, path := range paths {
+				conflicts[path] = fmt.Errorf("输出文件 %s 冲突, 同名文件: %s", absOutput, strings.Join(paths, ", "))
+			}
+		}
+	}
+	return outputs, conflicts
+}
@@ -0,0 +1,150 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubConverter 测试用转换器, 转换时执行 fn
+type stubConverter struct {
+	fn func() string
+}
+
+func (c *stubConverter) ToMarkdown(input io.Reader) (string, error) {
+	return c.fn(), nil
+}
+
+func (c *stubConverter) ToMarkdownFile(inputPath, outputPath string) error {
+	return writeMarkdownFile(c, inputPath, outputPath)
+}
+
+func (c *stubConverter) SupportedTypes() []DocumentType {
+	return nil
+}
+
+func TestBatchConverter(t *testing.T) {
+	for ext, fn := range map[string]func() string{
+		".boom": func() string { panic("malformed input") },
+		".slow": func() string { time.Sleep(2 * time.Second); return "slow" },
+	} {
+		fn := fn
+		if err := Register(Registration{
+			Type:       DocumentType(strings.TrimPrefix(ext, ".")),
+			MIMETypes:  []string{"text/x-" + strings.TrimPrefix(ext, ".")},
+			Extensions: []string{ext},
+			Factory:    func(opts *ConvertOptions) Converter { return &stubConverter{fn: fn} },
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	input := t.TempDir()
+	files := map[string]string{
+		"a.csv":       "name,age\nAlice,30\n",
+		"sub/b.txt":   "hello",
+		"bad.boom":    "x",
+		"slow.slow":   "x",
+		"ignored.bin": "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(input, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 输出目录位于输入目录中, 输出的 .md 不能被再次转换
+	output := filepath.Join(input, "out")
+	reportPath := filepath.Join(t.TempDir(), "report.json")
+
+	var calls int32
+	opts := &BatchOptions{
+		Workers:    2,
+		Timeout:    200 * time.Millisecond,
+		Resume:     true,
+		ReportPath: reportPath,
+		Progress:   func(p BatchProgress) { atomic.AddInt32(&calls, 1) },
+	}
+	report, err := NewBatchConverter(opts).Run(context.Background(), input, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total != 4 || report.Converted != 2 || report.Failed != 2 || calls != 4 {
+		t.Fatalf("unexpected report: %+v, progress calls %d", report, calls)
+	}
+	for _, f := range report.Files {
+		switch filepath.Ext(f.Input) {
+		case ".boom":
+			if !strings.Contains(f.Error, "panic") {
+				t.Fatalf("expected panic error, got %q", f.Error)
+			}
+		case ".slow":
+			if !strings.Contains(f.Error, "超时") {
+				t.Fatalf("expected timeout error, got %q", f.Error)
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(output, "sub", "b.md")); err != nil || !strings.Contains(string(data), "hello") {
+		t.Fatalf("unexpected output %q: %v", data, err)
+	}
+	var saved BatchReport
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Converted != 2 || len(saved.Files) != 4 {
+		t.Fatalf("unexpected saved report: %s", data)
+	}
+
+	// 再次运行时跳过未变化的文件, 修改过的文件重新转换
+	if err := os.WriteFile(filepath.Join(input, "a.csv"), []byte("name,age\nBob,4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report, err = NewBatchConverter(opts).Run(context.Background(), input, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Converted != 1 || report.Skipped != 1 || report.Failed != 2 {
+		t.Fatalf("unexpected rerun report: %+v", report)
+	}
+	if _, err := NewBatchConverter(opts).Estimate(input, output); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchConverterConflicts(t *testing.T) {
+	input := t.TempDir()
+	for name, content := range map[string]string{
+		"a.csv":    "name\nAlice\n",
+		"a.txt":    "alice",
+		"b.txt":    "bob",
+		"notes.md": "# notes",
+	} {
+		if err := os.WriteFile(filepath.Join(input, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 输出到输入目录: a.csv 与 a.txt 输出同名, notes.md 会覆盖自身, 只有 b.txt 可以转换
+	report, err := NewBatchConverter(&BatchOptions{Workers: 2}).Run(context.Background(), input, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Converted != 1 || report.Failed != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if data, err := os.ReadFile(filepath.Join(input, "notes.md")); err != nil || string(data) != "# notes" {
+		t.Fatalf("source file overwritten: %q, %v", data, err)
+	}
+	// 未开启 Resume 时不写清单
+	if _, err := os.Stat(filepath.Join(input, DefaultManifestName)); !os.IsNotExist(err) {
+		t.Fatalf("manifest written without Resume: %v", err)
+	}
+}
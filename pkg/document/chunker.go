@@ -0,0 +1,472 @@
+package document
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenCounter 计算文本的 token 数
+type TokenCounter func(text string) int
+
+// EstimateTokens 估算 token 数, 中日韩文字每字约 1 个 token, 其余字符约 4 字节 1 个 token
+func EstimateTokens(text string) int {
+	wide, other := 0, 0
+	for _, r := range text {
+		if isWide(r) {
+			wide++
+		} else {
+			other += utf8.RuneLen(r)
+		}
+	}
+	return wide + (other+3)/4
+}
+
+// ChunkOptions 分块选项
+type ChunkOptions struct {
+	// MaxTokens 每块的最大 token 数
+	MaxTokens int
+
+	// OverlapTokens 同一章节内相邻两块的重叠 token 数, 不超过 MaxTokens 的一半
+	OverlapTokens int
+
+	// Counter token 计数方法, 为空时使用 EstimateTokens
+	Counter TokenCounter
+
+	// Source 文档标识, 参与生成分块 ID, 通常为文件路径或文档 ID
+	Source string
+}
+
+// DefaultChunkOptions 默认分块选项
+func DefaultChunkOptions() *ChunkOptions {
+	return &ChunkOptions{
+		MaxTokens:     512,
+		OverlapTokens: 64,
+		Counter:       EstimateTokens,
+	}
+}
+
+// Chunk 文档分块
+type Chunk struct {
+	// ID 由文档标识、标题路径和内容计算, 内容不变时 ID 不变
+	ID     string `json:"id"`
+	Index  int    `json:"index"`
+	Source string `json:"source,omitempty"`
+
+	// Headings 所在章节的标题路径, 从一级标题开始
+	Headings []string `json:"headings,omitempty"`
+
+	// Content Markdown 内容, 表格和代码块拆分时会补全表头和代码围栏
+	Content string `json:"content"`
+	Tokens  int    `json:"tokens"`
+
+	// Overlap 开头与上一块重叠的 token 数
+	Overlap int `json:"overlap,omitempty"`
+
+	// Page 第一个节点所在页码
+	Page int `json:"page,omitempty"`
+}
+
+// Breadcrumb 标题路径, 以 " > " 连接
+func (c *Chunk) Breadcrumb() string {
+	return strings.Join(c.Headings, " > ")
+}
+
+// ContextualContent 在内容前加上标题路径, 用于向量化时保留章节上下文
+func (c *Chunk) ContextualContent() string {
+	if len(c.Headings) == 0 {
+		return c.Content
+	}
+	return c.Breadcrumb() + "\n\n" + c.Content
+}
+
+// Chunker 按 token 预算对文档分块: 标题处总是开始新块, 表格、代码块和列表尽量保持完整,
+// 超出预算的节点按行、列表项或句子拆分
+type Chunker struct {
+	opts *ChunkOptions
+}
+
+// NewChunker 创建分块器
+func NewChunker(opts *ChunkOptions) *Chunker {
+	if opts == nil {
+		opts = DefaultChunkOptions()
+	}
+	o := *opts
+	if o.MaxTokens <= 0 {
+		o.MaxTokens = 512
+	}
+	if o.OverlapTokens < 0 {
+		o.OverlapTokens = 0
+	}
+	if o.OverlapTokens > o.MaxTokens/2 {
+		o.OverlapTokens = o.MaxTokens / 2
+	}
+	if o.Counter == nil {
+		o.Counter = EstimateTokens
+	}
+	return &Chunker{opts: &o}
+}
+
+// ChunkDocument 对结构化文档分块
+func (c *Chunker) ChunkDocument(doc *Document) []*Chunk {
+	var chunks []*Chunk
+	i := 0
+	next := func() (*Node, error) {
+		if i >= len(doc.Nodes) {
+			return nil, io.EOF
+		}
+		i++
+		return doc.Nodes[i-1], nil
+	}
+	_ = c.run(next, func(chunk *Chunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	return chunks
+}
+
+// ChunkMarkdown 对 Markdown 文本分块
+func (c *Chunker) ChunkMarkdown(markdown string) []*Chunk {
+	var chunks []*Chunk
+	_ = c.Stream(strings.NewReader(markdown), func(chunk *Chunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	return chunks
+}
+
+// Stream 流式读取 Markdown 并分块, 每生成一块调用一次 emit, emit 返回错误时停止
+func (c *Chunker) Stream(r io.Reader, emit func(*Chunk) error) error {
+	return c.run(NewMarkdownReader(r).Next, emit)
+}
+
+// chunkBlock 块中的一个节点及其 Markdown
+type chunkBlock struct {
+	node     *Node
+	markdown string
+	tokens   int
+}
+
+type chunkState struct {
+	c        *Chunker
+	emit     func(*Chunk) error
+	headings []*Node
+	blocks   []chunkBlock
+	tokens   int
+	overlap  int
+	content  bool // 是否有标题以外的内容
+	index    int
+	seen     map[string]int
+}
+
+func (c *Chunker) run(next func() (*Node, error), emit func(*Chunk) error) error {
+	s := &chunkState{c: c, emit: emit, seen: make(map[string]int)}
+	for {
+		node, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.add(node); err != nil {
+			return err
+		}
+	}
+	return s.flush(false)
+}
+
+func (s *chunkState) add(node *Node) error {
+	switch node.Kind {
+	case NodePageBreak:
+		return nil
+	case NodeHeading:
+		// 新章节开始前输出上一章节的内容, 连续的标题放在同一块中
+		if s.content {
+			if err := s.flush(false); err != nil {
+				return err
+			}
+		}
+		for len(s.headings) > 0 && s.headings[len(s.headings)-1].Level >= node.Level {
+			s.headings = s.headings[:len(s.headings)-1]
+		}
+		s.headings = append(s.headings, node)
+		return s.append(node, false)
+	}
+
+	markdown := node.Markdown()
+	if markdown == "" {
+		return nil
+	}
+	if s.c.opts.Counter(markdown) > s.c.opts.MaxTokens {
+		rest := s.c.opts.MaxTokens - s.c.opts.OverlapTokens - s.c.opts.Counter("\n")
+		first := s.c.opts.MaxTokens - s.tokens - s.separator()
+		if first < s.c.opts.MaxTokens/4 {
+			first = rest
+		}
+		for _, part := range s.c.splitNode(node, first, rest) {
+			if err := s.append(part, true); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return s.append(node, true)
+}
+
+// append 加入节点, 超出预算时先输出当前块, 并把末尾部分内容作为新块的重叠
+func (s *chunkState) append(node *Node, content bool) error {
+	markdown := node.Markdown()
+	tokens := s.c.opts.Counter(markdown)
+	if len(s.blocks) > 0 && s.tokens+s.separator()+tokens > s.c.opts.MaxTokens {
+		if s.content {
+			if err := s.flush(true); err != nil {
+				return err
+			}
+		}
+		// 放不下时丢弃重叠内容, 只有标题时标题保留在标题路径中
+		if s.tokens+s.separator()+tokens > s.c.opts.MaxTokens {
+			s.blocks, s.tokens, s.overlap = nil, 0, 0
+		}
+	}
+	s.tokens += s.separator() + tokens
+	s.blocks = append(s.blocks, chunkBlock{node: node, markdown: markdown, tokens: tokens})
+	s.content = s.content || content
+	return nil
+}
+
+// flush 输出当前块, keepOverlap 为 true 时保留末尾的重叠内容
+func (s *chunkState) flush(keepOverlap bool) error {
+	if len(s.blocks) == 0 {
+		return nil
+	}
+
+	parts := make([]string, len(s.blocks))
+	for i, b := range s.blocks {
+		parts[i] = b.markdown
+	}
+	chunk := &Chunk{
+		Index:   s.index,
+		Source:  s.c.opts.Source,
+		Content: strings.Join(parts, "\n"),
+		Overlap: s.overlap,
+	}
+	chunk.Tokens = s.c.opts.Counter(chunk.Content)
+	for _, h := range s.headings {
+		chunk.Headings = append(chunk.Headings, h.Text)
+	}
+	for _, b := range s.blocks {
+		if b.node.Page > 0 {
+			chunk.Page = b.node.Page
+			break
+		}
+	}
+	chunk.ID = s.id(chunk)
+	s.index++
+
+	var tail []chunkBlock
+	if keepOverlap {
+		tail = s.c.overlapBlocks(s.blocks)
+	}
+	s.blocks, s.tokens, s.overlap, s.content = nil, 0, 0, false
+	for _, b := range tail {
+		s.tokens += s.separator() + b.tokens
+		s.blocks = append(s.blocks, b)
+	}
+	s.overlap = s.tokens
+	return s.emit(chunk)
+}
+
+// separator 下一个节点与已有内容之间空行的 token 数
+func (s *chunkState) separator() int {
+	if len(s.blocks) == 0 {
+		return 0
+	}
+	return s.c.opts.Counter("\n")
+}
+
+// id 对来源、标题路径和内容计算哈希, 同一文档中内容完全相同的块追加序号区分
+func (s *chunkState) id(chunk *Chunk) string {
+	h := sha1.New()
+	h.Write([]byte(chunk.Source))
+	h.Write([]byte{0})
+	h.Write([]byte(chunk.Breadcrumb()))
+	h.Write([]byte{0})
+	h.Write([]byte(chunk.Content))
+	id := hex.EncodeToString(h.Sum(nil))[:16]
+	n := s.seen[id]
+	s.seen[id] = n + 1
+	if n > 0 {
+		return fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
+
+// overlapBlocks 取末尾不超过 OverlapTokens 的内容, 最后一个段落过长时只取结尾的句子
+func (c *Chunker) overlapBlocks(blocks []chunkBlock) []chunkBlock {
+	budget := c.opts.OverlapTokens
+	if budget <= 0 {
+		return nil
+	}
+	var tail []chunkBlock
+	for i := len(blocks) - 1; i >= 0; i-- {
+		b := blocks[i]
+		if b.node.Kind == NodeHeading {
+			break
+		}
+		if b.tokens <= budget {
+			tail = append([]chunkBlock{b}, tail...)
+			budget -= b.tokens
+			continue
+		}
+		if b.node.Kind == NodeParagraph {
+			sentences := splitSentences(b.node.Text)
+			text := ""
+			for j := len(sentences) - 1; j >= 0; j-- {
+				candidate := sentences[j] + text
+				if c.opts.Counter(candidate) > budget {
+					break
+				}
+				text = candidate
+			}
+			if text = strings.TrimSpace(text); text != "" {
+				node := &Node{Kind: NodeParagraph, Text: text, Page: b.node.Page}
+				markdown := node.Markdown()
+				tail = append([]chunkBlock{{node: node, markdown: markdown, tokens: c.opts.Counter(markdown)}}, tail...)
+			}
+		}
+		break
+	}
+	return tail
+}
+
+// splitNode 拆分超出预算的节点: 表格按行并重复表头, 代码块按行, 列表按项, 文本按句子.
+// 第一部分不超过 first, 用于填满当前块, 其余部分不超过 rest, 为重叠内容留出空间
+func (c *Chunker) splitNode(node *Node, first, rest int) []*Node {
+	var parts []*Node
+	limit := first
+	fits := func(n *Node) bool {
+		return c.opts.Counter(n.Markdown()) <= limit
+	}
+	done := func(n *Node) {
+		parts = append(parts, n)
+		limit = rest
+	}
+
+	switch node.Kind {
+	case NodeTable:
+		if len(node.Rows) < 2 {
+			return []*Node{node}
+		}
+		header := node.Rows[0]
+		part := &Node{Kind: NodeTable, Rows: [][]string{header}, Page: node.Page}
+		for _, row := range node.Rows[1:] {
+			candidate := &Node{Kind: NodeTable, Rows: append(append([][]string(nil), part.Rows...), row), Page: node.Page}
+			if len(part.Rows) > 1 && !fits(candidate) {
+				done(part)
+				candidate = &Node{Kind: NodeTable, Rows: [][]string{header, row}, Page: node.Page}
+			}
+			part = candidate
+		}
+		done(part)
+	case NodeCode:
+		var lines []string
+		for _, line := range strings.Split(node.Text, "\n") {
+			candidate := &Node{Kind: NodeCode, Lang: node.Lang, Text: strings.Join(append(lines, line), "\n")}
+			if len(lines) > 0 && !fits(candidate) {
+				done(&Node{Kind: NodeCode, Lang: node.Lang, Text: strings.Join(lines, "\n"), Page: node.Page})
+				lines = nil
+			}
+			lines = append(lines, line)
+		}
+		done(&Node{Kind: NodeCode, Lang: node.Lang, Text: strings.Join(lines, "\n"), Page: node.Page})
+	case NodeList:
+		part := &Node{Kind: NodeList, Ordered: node.Ordered, Page: node.Page}
+		for _, item := range node.Items {
+			candidate := &Node{Kind: NodeList, Ordered: node.Ordered, Items: append(append([]ListItem(nil), part.Items...), item), Page: node.Page}
+			if len(part.Items) > 0 && !fits(candidate) {
+				done(part)
+				candidate = &Node{Kind: NodeList, Ordered: node.Ordered, Items: []ListItem{item}, Page: node.Page}
+			}
+			part = candidate
+		}
+		done(part)
+	case NodeParagraph, NodeQuote:
+		var text string
+		for _, sentence := range splitSentences(node.Text) {
+			for _, piece := range c.splitLong(sentence, rest) {
+				candidate := &Node{Kind: node.Kind, Text: text + piece}
+				if text != "" && !fits(candidate) {
+					done(&Node{Kind: node.Kind, Text: strings.TrimSpace(text), Page: node.Page})
+					text = ""
+				}
+				text += piece
+			}
+		}
+		done(&Node{Kind: node.Kind, Text: strings.TrimSpace(text), Page: node.Page})
+	default:
+		return []*Node{node}
+	}
+	return parts
+}
+
+// splitLong 按字符拆分超出预算的单个句子, 二分查找每段能容纳的最多字符
+func (c *Chunker) splitLong(sentence string, limit int) []string {
+	if c.opts.Counter(sentence) <= limit {
+		return []string{sentence}
+	}
+	offsets := make([]int, 0, len(sentence)+1)
+	for i := range sentence {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(sentence))
+
+	var pieces []string
+	for start := 0; start < len(offsets)-1; {
+		lo, hi := start+1, len(offsets)-1
+		for lo < hi {
+			mid := (lo + hi + 1) / 2
+			if c.opts.Counter(sentence[offsets[start]:offsets[mid]]) <= limit {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		pieces = append(pieces, sentence[offsets[start]:offsets[lo]])
+		start = lo
+	}
+	return pieces
+}
+
+// splitSentences 按句末标点拆分, 保留标点和其后的空白
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	runes := []rune(text)
+	offset := 0
+	for i, r := range runes {
+		offset += utf8.RuneLen(r)
+		if !strings.ContainsRune("。！？；.!?;\n", r) {
+			continue
+		}
+		// 西文句号后需要空白, 避免拆分小数和缩写
+		if strings.ContainsRune(".!?;", r) && i+1 < len(runes) && runes[i+1] != ' ' && runes[i+1] != '\n' {
+			continue
+		}
+		end := offset
+		for end < len(text) && (text[end] == ' ' || text[end] == '\n') {
+			end++
+		}
+		if end > start {
+			sentences = append(sentences, text[start:end])
+			start = end
+		}
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
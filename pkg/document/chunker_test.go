@@ -0,0 +1,99 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkerHeadingsAndBudget(t *testing.T) {
+	var markdown strings.Builder
+	markdown.WriteString("# 手册\n\n## 安装\n\n")
+	for i := 0; i < 30; i++ {
+		markdown.WriteString("这是安装步骤的说明文字。")
+	}
+	markdown.WriteString("\n\n| 参数 | 说明 |\n| --- | --- |\n")
+	for i := 0; i < 40; i++ {
+		markdown.WriteString("| name | 名称参数 |\n")
+	}
+	markdown.WriteString("\n## 使用\n\n```go\n")
+	for i := 0; i < 40; i++ {
+		markdown.WriteString("fmt.Println(\"hello world\")\n")
+	}
+	markdown.WriteString("```\n")
+
+	opts := DefaultChunkOptions()
+	opts.MaxTokens = 100
+	opts.OverlapTokens = 20
+	opts.Source = "manual.md"
+	chunker := NewChunker(opts)
+
+	var streamed []*Chunk
+	if err := chunker.Stream(strings.NewReader(markdown.String()), func(c *Chunk) error {
+		streamed = append(streamed, c)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	chunks := chunker.ChunkMarkdown(markdown.String())
+	if len(chunks) != len(streamed) || len(chunks) < 5 {
+		t.Fatalf("unexpected chunk count %d/%d", len(chunks), len(streamed))
+	}
+
+	ids := make(map[string]bool)
+	for i, c := range chunks {
+		if c.ID != streamed[i].ID || c.Index != i {
+			t.Fatalf("chunk %d is not stable", i)
+		}
+		if ids[c.ID] {
+			t.Fatalf("duplicate id %s", c.ID)
+		}
+		ids[c.ID] = true
+		if c.Tokens > opts.MaxTokens {
+			t.Fatalf("chunk %d has %d tokens:\n%s", i, c.Tokens, c.Content)
+		}
+		switch {
+		case strings.Contains(c.Content, "| name |"):
+			if !strings.HasPrefix(c.Content[strings.Index(c.Content, "|"):], "| 参数 | 说明 |\n| --- | --- |\n") {
+				t.Fatalf("table chunk without header:\n%s", c.Content)
+			}
+			fallthrough
+		case strings.Contains(c.Content, "安装步骤"):
+			if c.Breadcrumb() != "手册 > 安装" {
+				t.Fatalf("unexpected breadcrumb %q", c.Breadcrumb())
+			}
+		case strings.Contains(c.Content, "fmt.Println"):
+			if c.Breadcrumb() != "手册 > 使用" || strings.Count(c.Content, "```") != 2 {
+				t.Fatalf("unexpected code chunk %q:\n%s", c.Breadcrumb(), c.Content)
+			}
+		}
+	}
+	if !strings.HasPrefix(chunks[0].Content, "# 手册\n\n## 安装\n\n") {
+		t.Fatalf("headings should start the first chunk:\n%s", chunks[0].Content)
+	}
+	if chunks[1].Overlap == 0 {
+		t.Fatal("expected overlap between chunks of the same section")
+	}
+}
+
+func TestChunkerDocument(t *testing.T) {
+	doc := &Document{}
+	doc.Append(
+		&Node{Kind: NodeHeading, Level: 1, Text: "A", Page: 1},
+		&Node{Kind: NodeParagraph, Text: "alpha", Page: 1},
+		&Node{Kind: NodePageBreak, Page: 2},
+		&Node{Kind: NodeHeading, Level: 2, Text: "B", Page: 2},
+		&Node{Kind: NodeList, Items: []ListItem{{Text: "x"}, {Text: "y"}}, Page: 2},
+		&Node{Kind: NodeHeading, Level: 1, Text: "C", Page: 3},
+		&Node{Kind: NodeParagraph, Text: "gamma", Page: 3},
+	)
+	chunks := NewChunker(nil).ChunkDocument(doc)
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d", len(chunks))
+	}
+	if chunks[1].Breadcrumb() != "A > B" || chunks[1].Page != 2 || chunks[2].Breadcrumb() != "C" {
+		t.Fatalf("unexpected chunks: %+v %+v", chunks[1], chunks[2])
+	}
+	if chunks[1].ContextualContent() != "A > B\n\n## B\n\n- x\n- y\n" {
+		t.Fatalf("unexpected content %q", chunks[1].ContextualContent())
+	}
+}
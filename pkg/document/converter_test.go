@@ -0,0 +1,182 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func buildZip(t *testing.T, files [][2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		header := &zip.FileHeader{Name: f[0], Method: zip.Deflate}
+		if f[0] == "mimetype" {
+			header.Method = zip.Store
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, f[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+const relNS = `xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+
+func testXLSX(t *testing.T) []byte {
+	return buildZip(t, [][2]string{
+		{"[Content_Types].xml", `<Types/>`},
+		{"xl/workbook.xml", `<workbook ` + relNS + `><sheets><sheet name="人员" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`},
+		{"xl/sharedStrings.xml", `<sst><si><t>姓名</t></si><si><t>年龄</t></si><si><r><t>张</t></r><r><t>三</t></r></si></sst>`},
+		{"xl/worksheets/sheet1.xml", `<worksheet><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+			<row r="2"><c r="A2" t="s"><v>2</v></c><c r="C2" t="inlineStr"><is><t>a|b</t></is></c></row>
+			<row r="3"><c r="B3"><v>30</v></c></row>
+		</sheetData></worksheet>`},
+	})
+}
+
+func TestXLSXConverter(t *testing.T) {
+	markdown, err := NewXLSXConverter(nil).ToMarkdown(bytes.NewReader(testXLSX(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "## 人员\n\n| 姓名 | 年龄 |  |\n| --- | --- | --- |\n| 张三 |  | a\\|b |\n|  | 30 |  |\n"
+	if markdown != want {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+}
+
+func TestCSVConverter(t *testing.T) {
+	markdown, err := NewCSVConverter(nil).ToMarkdown(strings.NewReader("\xEF\xBB\xBFa;b\n1;\"x;y\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if markdown != "| a | b |\n| --- | --- |\n| 1 | x;y |\n" {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+}
+
+func TestPPTXConverter(t *testing.T) {
+	slide := `<p:sld xmlns:p="p" xmlns:a="a"><p:cSld><p:spTree>
+		<p:sp><p:nvSpPr><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr><p:txBody><a:p><a:r><a:t>季度总结</a:t></a:r></a:p></p:txBody></p:sp>
+		<p:sp><p:txBody><a:p><a:r><a:t>收入增长</a:t></a:r></a:p><a:p><a:pPr lvl="1"/><a:r><a:t>华东 </a:t></a:r><a:r><a:t>20%</a:t></a:r></a:p></p:txBody></p:sp>
+	</p:spTree></p:cSld></p:sld>`
+	data := buildZip(t, [][2]string{
+		{"ppt/presentation.xml", `<presentation ` + relNS + `><sldIdLst><sldId id="256" r:id="rId2"/></sldIdLst></presentation>`},
+		{"ppt/_rels/presentation.xml.rels", `<Relationships><Relationship Id="rId2" Target="slides/slide1.xml"/></Relationships>`},
+		{"ppt/slides/slide1.xml", slide},
+	})
+	markdown, err := NewPPTXConverter(nil).ToMarkdown(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if markdown != "## 季度总结\n\n- 收入增长\n  - 华东 20%\n" {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+}
+
+func testEPUB(t *testing.T) []byte {
+	return buildZip(t, [][2]string{
+		{"mimetype", MIMEEPUB},
+		{"META-INF/container.xml", `<container><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"OEBPS/content.opf", `<package xmlns:dc="http://purl.org/dc/elements/1.1/"><metadata><dc:title>示例</dc:title></metadata>
+			<manifest><item id="c1" href="text/ch%201.xhtml" media-type="application/xhtml+xml"/><item id="c2" href="text/ch2.xhtml"/></manifest>
+			<spine><itemref idref="c2"/><itemref idref="c1"/></spine></package>`},
+		{"OEBPS/text/ch 1.xhtml", `<html><body><h2>第二章</h2><p>结尾</p></body></html>`},
+		{"OEBPS/text/ch2.xhtml", `<html><body><h2>第一章</h2><p>开始 <strong>重点</strong></p></body></html>`},
+	})
+}
+
+func TestEPUBConverter(t *testing.T) {
+	markdown, err := NewEPUBConverter(nil).ToMarkdown(bytes.NewReader(testEPUB(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := strings.Index(markdown, "## 第一章"), strings.Index(markdown, "## 第二章")
+	if !strings.HasPrefix(markdown, "# 示例\n") || first < 0 || second < first || !strings.Contains(markdown, "**重点**") {
+		t.Fatalf("unexpected markdown:\n%s", markdown)
+	}
+}
+
+func TestRTFConverter(t *testing.T) {
+	rtf := `{\rtf1\ansi\ansicpg936{\fonttbl{\f0 Arial;}}{\*\generator Test;}` +
+		`\pard Hello \b world\b0\par ` + `\'d6\'d0\'ce\'c4 \u8364?\par ` +
+		`\trowd\intbl A\cell B\cell\row\intbl 1\cell 2\cell\row\pard After\par}`
+	markdown, err := NewRTFConverter(nil).ToMarkdown(strings.NewReader(rtf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Hello world\n中文 €\n\n| A | B |\n| --- | --- |\n| 1 | 2 |\n\nAfter\n"
+	if markdown != want {
+		t.Fatalf("unexpected markdown:\n%q", markdown)
+	}
+}
+
+func TestRegistryDetect(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	cases := []struct {
+		name string
+		data []byte
+		want DocumentType
+	}{
+		{"report.bin", testXLSX(t), TypeXLSX},
+		{"book.zip", testEPUB(t), TypeEPUB},
+		{"page.txt", []byte("<!DOCTYPE html><html><body>hi</body></html>"), TypeText},
+		{"page", []byte("<!DOCTYPE html><html><body>hi</body></html>"), TypeHTML},
+		{"data.csv", []byte("a,b\n1,2\n"), TypeCSV},
+		{"notes.dat", []byte(`{\rtf1 hi}`), TypeRTF},
+		{"fake.pdf", []byte("plain text"), TypeText},
+	}
+	for _, c := range cases {
+		reg, _, err := DefaultRegistry().DetectFile(write(c.name, c.data))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if reg.Type != c.want {
+			t.Fatalf("%s: detected %s, want %s", c.name, reg.Type, c.want)
+		}
+	}
+
+	ole := append([]byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}, make([]byte, 64)...)
+	if _, _, err := DefaultRegistry().DetectFile(write("old.doc", ole)); err == nil {
+		t.Fatal("expected error for legacy doc")
+	}
+
+	r := NewRegistry()
+	err := r.Register(Registration{
+		Type:       "custom",
+		Extensions: []string{"cst"},
+		Sniff:      func(head []byte) bool { return bytes.HasPrefix(head, []byte("CUSTOM")) },
+		Factory:    func(opts *ConvertOptions) Converter { return NewTextConverter(opts) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg, _, err := r.DetectFile(write("x.txt", []byte("CUSTOM data"))); err != nil || reg.Type != "custom" {
+		t.Fatalf("custom sniff: %v %v", reg, err)
+	}
+	if !r.Supports("a.CST") {
+		t.Fatal("custom extension not registered")
+	}
+}
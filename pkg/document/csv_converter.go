@@ -0,0 +1,85 @@
+package document
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CSVConverter CSV 转换器, 输出为 Markdown 表格, 自动识别逗号、分号和制表符分隔
+type CSVConverter struct {
+	opts *ConvertOptions
+}
+
+// NewCSVConverter 创建 CSV 转换器
+func NewCSVConverter(opts *ConvertOptions) *CSVConverter {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+	return &CSVConverter{
+		opts: opts,
+	}
+}
+
+// ToMarkdown 将 CSV 转换为 Markdown
+func (c *CSVConverter) ToMarkdown(input io.Reader) (string, error) {
+	doc, err := c.ToDocument(input)
+	if err != nil {
+		return "", err
+	}
+	return doc.render(c.opts.IncludeMetadata), nil
+}
+
+// ToDocument 将 CSV 转换为只包含一个表格的结构化文档
+func (c *CSVConverter) ToDocument(input io.Reader) (*Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	text := decodeText(data)
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = detectDelimiter(text)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析 CSV 失败: %w", err)
+	}
+
+	doc := &Document{}
+	if rows = trimEmptyRows(rows); len(rows) > 0 {
+		doc.Append(&Node{Kind: NodeTable, Rows: rows})
+	}
+	return doc, nil
+}
+
+// ToMarkdownFile 将 CSV 文件转换为 Markdown 文件
+func (c *CSVConverter) ToMarkdownFile(inputPath, outputPath string) error {
+	return writeMarkdownFile(c, inputPath, outputPath)
+}
+
+// SupportedTypes 返回支持的文档类型
+func (c *CSVConverter) SupportedTypes() []DocumentType {
+	return []DocumentType{TypeCSV}
+}
+
+// detectDelimiter 根据第一行中出现次数最多的分隔符判断
+func detectDelimiter(text string) rune {
+	line, _, _ := strings.Cut(text, "\n")
+	best, count := ',', strings.Count(line, ",")
+	for _, d := range []rune{'\t', ';', '|'} {
+		if n := strings.Count(line, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+// decodeText 去掉 BOM, 非 UTF-8 的内容按 GB18030 解码
+func decodeText(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	return toUTF8(data)
+}
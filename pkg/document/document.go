@@ -15,6 +15,13 @@ const (
 	TypePDF  DocumentType = "pdf"
 	TypeWord DocumentType = "docx"
 	TypeDOC  DocumentType = "doc"
+	TypeXLSX DocumentType = "xlsx"
+	TypeCSV  DocumentType = "csv"
+	TypePPTX DocumentType = "pptx"
+	TypeHTML DocumentType = "html"
+	TypeEPUB DocumentType = "epub"
+	TypeText DocumentType = "txt"
+	TypeRTF  DocumentType = "rtf"
 )
 
 // Converter 文档转换器接口
@@ -42,6 +49,9 @@ type ConvertOptions struct {
 
 	// PreserveFormatting 是否保留格式（加粗、斜体等）
 	PreserveFormatting bool
+
+	// IncludeMetadata 是否在 Markdown 开头输出 YAML 格式的元数据（标题、作者、页数等）
+	IncludeMetadata bool
 }
 
 // DefaultConvertOptions 默认转换选项
@@ -53,19 +63,26 @@ func DefaultConvertOptions() *ConvertOptions {
 	}
 }
 
-// DetectDocumentType 根据文件扩展名检测文档类型
+// DetectDocumentType 根据文件扩展名检测文档类型, 需要按文件内容识别时使用 DetectFile
 func DetectDocumentType(filename string) (DocumentType, error) {
 	ext := strings.ToLower(filepath.Ext(filename))
-	switch ext {
-	case ".pdf":
-		return TypePDF, nil
-	case ".docx":
-		return TypeWord, nil
-	case ".doc":
+	if ext == ".doc" {
 		return TypeDOC, nil
-	default:
+	}
+	reg, ok := defaultRegistry.ByExtension(ext)
+	if !ok {
 		return "", fmt.Errorf("不支持的文档类型: %s", ext)
 	}
+	return reg.Type, nil
+}
+
+// DetectFile 根据文件头识别文档的类型和 MIME 类型
+func DetectFile(path string) (DocumentType, string, error) {
+	reg, mime, err := defaultRegistry.DetectFile(path)
+	if err != nil {
+		return "", mime, err
+	}
+	return reg.Type, mime, nil
 }
 
 // DefaultConvert 转换文档到 Markdown
@@ -76,27 +93,17 @@ func DefaultConvert(inputPath, outputPath string) error {
 	return Convert(inputPath, outputPath, opts)
 }
 
-// Convert 转换文档到 Markdown
+// Convert 转换文档到 Markdown, 按文件内容选择默认注册表中的转换器
 func Convert(inputPath, outputPath string, opts *ConvertOptions) error {
 	if opts == nil {
 		opts = DefaultConvertOptions()
 	}
 
-	docType, err := DetectDocumentType(inputPath)
+	converter, err := defaultRegistry.ConverterFor(inputPath, opts)
 	if err != nil {
 		return err
 	}
 
-	var converter Converter
-	switch docType {
-	case TypePDF:
-		converter = NewPDFConverter(opts)
-	case TypeWord, TypeDOC:
-		converter = NewWordConverter(opts)
-	default:
-		return fmt.Errorf("不支持的文档类型: %s", docType)
-	}
-
 	return converter.ToMarkdownFile(inputPath, outputPath)
 }
 
@@ -106,21 +113,11 @@ func ConvertToString(inputPath string, opts *ConvertOptions) (string, error) {
 		opts = DefaultConvertOptions()
 	}
 
-	docType, err := DetectDocumentType(inputPath)
+	converter, err := defaultRegistry.ConverterFor(inputPath, opts)
 	if err != nil {
 		return "", err
 	}
 
-	var converter Converter
-	switch docType {
-	case TypePDF:
-		converter = NewPDFConverter(opts)
-	case TypeWord, TypeDOC:
-		converter = NewWordConverter(opts)
-	default:
-		return "", fmt.Errorf("不支持的文档类型: %s", docType)
-	}
-
 	file, err := os.Open(inputPath)
 	if err != nil {
 		return "", fmt.Errorf("打开文件失败: %w", err)
@@ -129,3 +126,19 @@ func ConvertToString(inputPath string, opts *ConvertOptions) (string, error) {
 
 	return converter.ToMarkdown(file)
 }
+
+// writeMarkdownFile 读取输入文件, 转换后写入输出文件
+func writeMarkdownFile(c Converter, inputPath, outputPath string) error {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	markdown, err := c.ToMarkdown(file)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(outputPath, []byte(markdown), 0o644)
+}
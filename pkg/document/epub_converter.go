@@ -0,0 +1,135 @@
+package document
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// EPUBConverter EPUB 电子书转换器, 按阅读顺序(spine)转换各章节
+type EPUBConverter struct {
+	opts *ConvertOptions
+}
+
+// NewEPUBConverter 创建 EPUB 转换器
+func NewEPUBConverter(opts *ConvertOptions) *EPUBConverter {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+	return &EPUBConverter{
+		opts: opts,
+	}
+}
+
+type epubPackage struct {
+	Title    []string `xml:"metadata>title"`
+	Creator  []string `xml:"metadata>creator"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef  string `xml:"idref,attr"`
+		Linear string `xml:"linear,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// ToMarkdown 将 EPUB 转换为 Markdown
+func (c *EPUBConverter) ToMarkdown(input io.Reader) (string, error) {
+	doc, err := c.ToDocument(input)
+	if err != nil {
+		return "", err
+	}
+	return doc.render(c.opts.IncludeMetadata), nil
+}
+
+// ToDocument 将 EPUB 转换为结构化文档, 书名和作者同时写入元数据
+func (c *EPUBConverter) ToDocument(input io.Reader) (*Document, error) {
+	zr, err := openZip(input)
+	if err != nil {
+		return nil, err
+	}
+
+	opfPath, err := c.rootFile(zr)
+	if err != nil {
+		return nil, err
+	}
+	var pkg epubPackage
+	if err := readZipXML(zr, opfPath, &pkg); err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		href, err := url.PathUnescape(item.Href)
+		if err != nil {
+			href = item.Href
+		}
+		items[item.ID] = path.Join(path.Dir(opfPath), href)
+	}
+
+	doc := &Document{}
+	if len(pkg.Title) > 0 && strings.TrimSpace(pkg.Title[0]) != "" {
+		doc.Metadata.Title = strings.TrimSpace(pkg.Title[0])
+		doc.Metadata.Author = strings.Join(pkg.Creator, ", ")
+		doc.Append(&Node{Kind: NodeHeading, Level: 1, Text: doc.Metadata.Title})
+		if doc.Metadata.Author != "" {
+			doc.Append(&Node{Kind: NodeParagraph, Text: "作者: " + doc.Metadata.Author})
+		}
+	}
+
+	html := NewHTMLConverter(c.opts)
+	for _, ref := range pkg.Spine {
+		if ref.Linear == "no" {
+			continue
+		}
+		name, ok := items[ref.IDRef]
+		if !ok {
+			continue
+		}
+		data, err := readZipFile(zr, name)
+		if err != nil {
+			return nil, err
+		}
+		chapter, err := html.convert(decodeText(data))
+		if err != nil {
+			return nil, fmt.Errorf("转换章节 %s 失败: %w", name, err)
+		}
+		doc.Append(ParseMarkdown(chapter).Nodes...)
+	}
+
+	return doc, nil
+}
+
+// ToMarkdownFile 将 EPUB 文件转换为 Markdown 文件
+func (c *EPUBConverter) ToMarkdownFile(inputPath, outputPath string) error {
+	return writeMarkdownFile(c, inputPath, outputPath)
+}
+
+// SupportedTypes 返回支持的文档类型
+func (c *EPUBConverter) SupportedTypes() []DocumentType {
+	return []DocumentType{TypeEPUB}
+}
+
+// rootFile 从 META-INF/container.xml 读取 OPF 文件路径
+func (c *EPUBConverter) rootFile(zr *zip.Reader) (string, error) {
+	var container struct {
+		RootFiles []struct {
+			FullPath  string `xml:"full-path,attr"`
+			MediaType string `xml:"media-type,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := readZipXML(zr, "META-INF/container.xml", &container); err != nil {
+		return "", err
+	}
+	for _, rf := range container.RootFiles {
+		if rf.MediaType == "" || rf.MediaType == "application/oebps-package+xml" {
+			return rf.FullPath, nil
+		}
+	}
+	return "", fmt.Errorf("EPUB 缺少 OPF 文件")
+}
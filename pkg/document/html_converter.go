@@ -0,0 +1,78 @@
+package document
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/JohannesKaufmann/html-to-markdown/plugin"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// HTMLConverter HTML 转换器, 使用与 agent 抓取网页相同的 html-to-markdown, 并启用 GFM 表格
+type HTMLConverter struct {
+	opts *ConvertOptions
+}
+
+// NewHTMLConverter 创建 HTML 转换器
+func NewHTMLConverter(opts *ConvertOptions) *HTMLConverter {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+	return &HTMLConverter{
+		opts: opts,
+	}
+}
+
+// ToMarkdown 将 HTML 转换为 Markdown
+func (c *HTMLConverter) ToMarkdown(input io.Reader) (string, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	return c.convert(decodeText(data))
+}
+
+// ToDocument 将 HTML 转换为结构化文档, 由 Markdown 输出解析得到
+func (c *HTMLConverter) ToDocument(input io.Reader) (*Document, error) {
+	markdown, err := c.ToMarkdown(input)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMarkdown(markdown), nil
+}
+
+// ToMarkdownFile 将 HTML 文件转换为 Markdown 文件
+func (c *HTMLConverter) ToMarkdownFile(inputPath, outputPath string) error {
+	return writeMarkdownFile(c, inputPath, outputPath)
+}
+
+// SupportedTypes 返回支持的文档类型
+func (c *HTMLConverter) SupportedTypes() []DocumentType {
+	return []DocumentType{TypeHTML}
+}
+
+func (c *HTMLConverter) convert(html string) (string, error) {
+	converter := md.NewConverter("", true, nil)
+	converter.Remove("script", "style", "noscript")
+	if c.opts.ExtractTables {
+		converter.Use(plugin.Table())
+	}
+	if !c.opts.PreserveImages {
+		converter.Remove("img")
+	}
+	if !c.opts.PreserveFormatting {
+		converter.AddRules(md.Rule{
+			Filter: []string{"b", "strong", "i", "em"},
+			Replacement: func(content string, selec *goquery.Selection, opt *md.Options) *string {
+				return md.String(content)
+			},
+		})
+	}
+	markdown, err := converter.ConvertString(html)
+	if err != nil {
+		return "", fmt.Errorf("转换 HTML 失败: %w", err)
+	}
+	return strings.TrimSpace(markdown) + "\n", nil
+}
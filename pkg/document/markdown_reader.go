@@ -0,0 +1,231 @@
+package document
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeadingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+	mdListPattern      = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	mdImagePattern     = regexp.MustCompile(`^!\[([^\]]*)\]\(([^)\s]*)(?:\s+"[^"]*")?\)$`)
+	mdSeparatorPattern = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?$`)
+	mdBreakPattern     = regexp.MustCompile(`^((-\s*){3,}|(\*\s*){3,}|(_\s*){3,})$`)
+)
+
+// MarkdownReader 逐块读取 Markdown, 每次返回一个节点, 只缓存当前块的内容, 适合处理大文件
+type MarkdownReader struct {
+	r     *bufio.Reader
+	lines []string // 预读的行
+	err   error
+}
+
+// NewMarkdownReader 创建 Markdown 读取器
+func NewMarkdownReader(r io.Reader) *MarkdownReader {
+	return &MarkdownReader{r: bufio.NewReader(r)}
+}
+
+// Next 返回下一个节点, 读取完毕时返回 io.EOF
+func (m *MarkdownReader) Next() (*Node, error) {
+	for {
+		line, ok := m.peek(0)
+		if !ok {
+			if m.err != nil && m.err != io.EOF {
+				return nil, m.err
+			}
+			return nil, io.EOF
+		}
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			m.advance()
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			return m.readCode(trimmed), nil
+		case mdHeadingPattern.MatchString(trimmed):
+			m.advance()
+			sub := mdHeadingPattern.FindStringSubmatch(trimmed)
+			return &Node{Kind: NodeHeading, Level: len(sub[1]), Text: sub[2]}, nil
+		case m.tableStart(0):
+			return m.readTable(), nil
+		case mdBreakPattern.MatchString(trimmed):
+			m.advance()
+			return &Node{Kind: NodePageBreak}, nil
+		case mdImagePattern.MatchString(trimmed):
+			m.advance()
+			sub := mdImagePattern.FindStringSubmatch(trimmed)
+			return &Node{Kind: NodeImage, Alt: sub[1], Src: sub[2]}, nil
+		case strings.HasPrefix(trimmed, ">"):
+			return m.readQuote(), nil
+		case mdListPattern.MatchString(line):
+			return m.readList(), nil
+		default:
+			return m.readParagraph(), nil
+		}
+	}
+}
+
+func (m *MarkdownReader) fill(n int) bool {
+	for len(m.lines) < n && m.err == nil {
+		line, err := m.r.ReadString('\n')
+		if err != nil {
+			m.err = err
+			if line == "" {
+				break
+			}
+		}
+		m.lines = append(m.lines, strings.TrimRight(line, "\r\n"))
+	}
+	return len(m.lines) >= n
+}
+
+func (m *MarkdownReader) peek(i int) (string, bool) {
+	if !m.fill(i + 1) {
+		return "", false
+	}
+	return m.lines[i], true
+}
+
+func (m *MarkdownReader) advance() string {
+	line := m.lines[0]
+	m.lines = m.lines[1:]
+	return line
+}
+
+// tableStart 第 i 行以竖线开头且下一行是表格分隔行
+func (m *MarkdownReader) tableStart(i int) bool {
+	line, _ := m.peek(i)
+	if !strings.HasPrefix(strings.TrimSpace(line), "|") {
+		return false
+	}
+	next, ok := m.peek(i + 1)
+	return ok && mdSeparatorPattern.MatchString(strings.TrimSpace(next))
+}
+
+// blockStart 第 i 行是否开始一个会打断段落的块, 列表项不打断段落
+func (m *MarkdownReader) blockStart(i int) bool {
+	line, _ := m.peek(i)
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" ||
+		strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") ||
+		strings.HasPrefix(trimmed, ">") ||
+		mdHeadingPattern.MatchString(trimmed) ||
+		mdBreakPattern.MatchString(trimmed) ||
+		mdImagePattern.MatchString(trimmed) ||
+		m.tableStart(i)
+}
+
+func (m *MarkdownReader) readCode(opening string) *Node {
+	m.advance()
+	fence := opening[:3]
+	for j := 3; j < len(opening) && opening[j] == fence[0]; j++ {
+		fence += opening[j : j+1]
+	}
+	node := &Node{Kind: NodeCode, Lang: strings.TrimSpace(opening[len(fence):])}
+	var code []string
+	for {
+		line, ok := m.peek(0)
+		if !ok {
+			break
+		}
+		m.advance()
+		if strings.HasPrefix(strings.TrimSpace(line), fence) {
+			break
+		}
+		code = append(code, line)
+	}
+	node.Text = strings.Join(code, "\n")
+	return node
+}
+
+func (m *MarkdownReader) readTable() *Node {
+	rows := [][]string{splitTableRow(strings.TrimSpace(m.advance()))}
+	m.advance()
+	for {
+		line, ok := m.peek(0)
+		if !ok || !strings.HasPrefix(strings.TrimSpace(line), "|") {
+			break
+		}
+		rows = append(rows, splitTableRow(strings.TrimSpace(m.advance())))
+	}
+	return &Node{Kind: NodeTable, Rows: rows}
+}
+
+func (m *MarkdownReader) readQuote() *Node {
+	var quote []string
+	for {
+		line, ok := m.peek(0)
+		if !ok || !strings.HasPrefix(strings.TrimSpace(line), ">") {
+			break
+		}
+		m.advance()
+		quote = append(quote, strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(line), ">"), " "))
+	}
+	return &Node{Kind: NodeQuote, Text: strings.Join(quote, "\n")}
+}
+
+func (m *MarkdownReader) readList() *Node {
+	node := &Node{Kind: NodeList}
+	for {
+		line, ok := m.peek(0)
+		if !ok {
+			break
+		}
+		sub := mdListPattern.FindStringSubmatch(line)
+		if sub == nil {
+			// 缩进的续行属于上一项
+			if strings.TrimSpace(line) != "" && strings.HasPrefix(line, " ") && len(node.Items) > 0 {
+				last := &node.Items[len(node.Items)-1]
+				last.Text += " " + strings.TrimSpace(line)
+				m.advance()
+				continue
+			}
+			break
+		}
+		ordered := sub[2][0] >= '0' && sub[2][0] <= '9'
+		indent := len(strings.ReplaceAll(sub[1], "\t", "    "))
+		if len(node.Items) == 0 {
+			node.Ordered = ordered
+		} else if indent == 0 && ordered != node.Ordered {
+			// 顶层列表类型变化时开始新的列表
+			break
+		}
+		m.advance()
+		node.Items = append(node.Items, ListItem{Text: sub[3], Level: indent / 2})
+	}
+	return node
+}
+
+func (m *MarkdownReader) readParagraph() *Node {
+	paragraph := []string{strings.TrimSpace(m.advance())}
+	for {
+		if _, ok := m.peek(0); !ok || m.blockStart(0) {
+			break
+		}
+		paragraph = append(paragraph, strings.TrimSpace(m.advance()))
+	}
+	return &Node{Kind: NodeParagraph, Text: strings.Join(paragraph, "\n")}
+}
+
+// splitTableRow 拆分表格行, 保留转义的竖线
+func splitTableRow(line string) []string {
+	line = strings.TrimPrefix(strings.TrimSuffix(line, "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && line[i+1] == '|' {
+			cell.WriteByte('|')
+			i++
+			continue
+		}
+		if line[i] == '|' {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(line[i])
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
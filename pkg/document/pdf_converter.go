@@ -1,10 +1,17 @@
 package document
 
 import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
+	"image/png"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/ledongthuc/pdf"
 	"github.com/unidoc/unipdf/v3/extractor"
@@ -26,6 +33,7 @@ func NewPDFConverter(opts *ConvertOptions) *PDFConverter {
 	}
 }
 
+// defaultFirstLineFlag 一级中文编号, 正文字号的短行以此开头时作为标题
 var defaultFirstLineFlag = []string{
 	"一、", "二、", "三、", "四、", "五、", "六、", "七、", "八、", "九、", "十、",
 	"十一、", "十二、", "十三、", "十四、", "十五、", "十六、", "十七、", "十八、", "十九、", "二十、",
@@ -35,6 +43,7 @@ var defaultFirstLineFlag = []string{
 	"二十一. ", "二十二. ", "二十三. ", "二十四. ", "二十五. ", "二十六. ",
 }
 
+// defaultSubFirstLineFlag 二级中文编号
 var defaultSubFirstLineFlag = []string{
 	"（一）", "（二）", "（三）", "（四）", "（五）", "（六）", "（七）", "（八）", "（九）", "（十）",
 	"（十一）", "（十二）", "（十三）", "（十四）", "（十五）", "（十六）", "（十七）", "（十八）", "（十九）", "（二十）",
@@ -44,56 +53,52 @@ var defaultSubFirstLineFlag = []string{
 	"(二十一)", "(二十二)", "(二十三)", "(二十四)", "(二十五)", "(二十六)",
 }
 
-var defaultSecondLineFlag = []string{
-	"1、", "2、", "3、", "4、", "5、", "6、", "7、", "8、", "9、", "10、",
-	"11、", "12、", "13、", "14、", "15、", "16、", "17、", "18、", "19、", "20、",
-	"21、", "22、", "23、", "24、", "25、", "26、", "27、", "28、", "29、", "30、",
-	"1. ", "2. ", "3. ", "4. ", "5. ", "6. ", "7. ", "8. ", "9. ", "10. ",
-	"11. ", "12. ", "13. ", "14. ", "15. ", "16. ", "17. ", "18. ", "19. ", "20. ",
-	"21. ", "22. ", "23. ", "24. ", "25. ", "26. ", "27. ", "28. ", "29. ", "30. ",
+// pdfSource PDF 内容, 两个 PDF 库分别需要 ReaderAt 和 ReadSeeker
+type pdfSource interface {
+	io.ReaderAt
+	io.ReadSeeker
 }
 
-var defaultThirdLineFlag = []string{
-	"A、", "B、", "C、", "D、", "E、", "F、", "G、", "H、", "I、", "J、",
-	"K、", "L、", "M、", "N、", "O、", "P、", "Q、", "R、", "S、", "T、",
-	"U、", "V、", "W、", "X、", "Y、", "Z、",
-	"A.", "B.", "C.", "D.", "E.", "F.", "G.", "H.", "I.", "J.",
-	"K.", "L.", "M.", "N.", "O.", "P.", "Q.", "R.", "S.", "T.",
-	"U.", "V.", "W.", "X.", "Y.", "Z.",
-}
-
-// ToMarkdown 将 PDF 转换为 Markdown
+// ToMarkdown 将 PDF 转换为 Markdown, 图片地址为 ImageOutputDir 下的路径
 func (c *PDFConverter) ToMarkdown(input io.Reader) (string, error) {
-	// 由于 PDF 库通常需要文件路径或 ReadSeeker，这里需要特殊处理
-	// 创建临时文件
-	tmpFile, err := os.CreateTemp("", "pdf-*.pdf")
+	doc, err := c.ToDocument(input)
 	if err != nil {
-		return "", fmt.Errorf("创建临时文件失败: %w", err)
+		return "", err
 	}
-	defer os.Remove(tmpFile.Name())
-	defer tmpFile.Close()
+	return doc.render(c.opts.IncludeMetadata), nil
+}
 
-	// 复制内容到临时文件
-	if _, err := io.Copy(tmpFile, input); err != nil {
-		return "", fmt.Errorf("写入临时文件失败: %w", err)
+// ToDocument 将 PDF 转换为结构化文档
+func (c *PDFConverter) ToDocument(input io.Reader) (*Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("读取 PDF 失败: %w", err)
 	}
+	sum := sha1.Sum(data)
+	return c.convert(bytes.NewReader(data), int64(len(data)), "pdf-"+hex.EncodeToString(sum[:4]))
+}
 
-	// 重置文件指针
-	if _, err := tmpFile.Seek(0, 0); err != nil {
-		return "", fmt.Errorf("重置文件指针失败: %w", err)
+// ToMarkdownFile 将 PDF 文件转换为 Markdown 文件, 图片地址为相对 Markdown 文件的路径
+func (c *PDFConverter) ToMarkdownFile(inputPath, outputPath string) error {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("打开 PDF 文件失败: %w", err)
 	}
+	defer file.Close()
 
-	return c.extractTextFromPDF(tmpFile.Name())
-}
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("获取文件信息失败: %w", err)
+	}
 
-// ToMarkdownFile 将 PDF 文件转换为 Markdown 文件
-func (c *PDFConverter) ToMarkdownFile(inputPath, outputPath string) error {
-	markdown, err := c.extractTextFromPDF(inputPath)
+	prefix := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	doc, err := c.convert(file, info.Size(), prefix)
 	if err != nil {
 		return err
 	}
+	relativeImages(doc, filepath.Dir(outputPath))
 
-	return os.WriteFile(outputPath, []byte(markdown), 0o644)
+	return os.WriteFile(outputPath, []byte(doc.render(c.opts.IncludeMetadata)), 0o644)
 }
 
 // SupportedTypes 返回支持的文档类型
@@ -101,208 +106,278 @@ func (c *PDFConverter) SupportedTypes() []DocumentType {
 	return []DocumentType{TypePDF}
 }
 
-// extractTextFromPDF 从 PDF 文件提取文本并转换为 Markdown
-func (c *PDFConverter) extractTextFromPDF(pdfPath string) (string, error) {
-	// 优先使用 unipdf，功能更强大
-	markdown, err := c.extractWithUnipdf(pdfPath)
-	if err == nil {
-		return markdown, nil
-	}
-
-	// 如果 unipdf 失败，使用 ledongthuc/pdf 作为备用
-	return c.extractWithSimplePDF(pdfPath)
-}
-
-// extractWithUnipdf 使用 unipdf 提取文本
-func (c *PDFConverter) extractWithUnipdf(pdfPath string) (string, error) {
-	file, err := os.Open(pdfPath)
+// convert 按字形位置做版面分析, 解析失败时使用 unipdf 提取纯文本
+func (c *PDFConverter) convert(src pdfSource, size int64, imagePrefix string) (*Document, error) {
+	doc, pages, err := c.readLayout(src, size)
 	if err != nil {
-		return "", fmt.Errorf("打开 PDF 文件失败: %w", err)
-	}
-	defer file.Close()
-
-	pdfReader, err := model.NewPdfReader(file)
-	if err != nil {
-		return "", fmt.Errorf("读取 PDF 文件失败: %w", err)
-	}
-
-	numPages, err := pdfReader.GetNumPages()
-	if err != nil {
-		return "", fmt.Errorf("获取页数失败: %w", err)
+		if _, serr := src.Seek(0, io.SeekStart); serr != nil {
+			return nil, err
+		}
+		fallback, ferr := c.extractWithUnipdf(src)
+		if ferr != nil {
+			return nil, fmt.Errorf("解析 PDF 失败: %w", err)
+		}
+		return fallback, nil
 	}
 
-	var markdown strings.Builder
-	markdown.WriteString(fmt.Sprintf("# PDF 文档\n\n总页数: %d\n\n", numPages))
-
-	for pageNum := 1; pageNum <= numPages; pageNum++ {
-		page, err := pdfReader.GetPage(pageNum)
-		if err != nil {
-			return "", fmt.Errorf("获取第 %d 页失败: %w", pageNum, err)
+	if c.opts.PreserveImages && c.opts.ImageOutputDir != "" {
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("重置文件指针失败: %w", err)
 		}
-
-		ex, err := extractor.New(page)
+		images, err := c.extractImages(src, imagePrefix)
 		if err != nil {
-			return "", fmt.Errorf("创建提取器失败: %w", err)
+			return nil, err
+		}
+		for _, p := range pages {
+			p.images = images[p.number]
 		}
+	}
 
-		text, err := ex.ExtractText()
-		if err != nil {
-			return "", fmt.Errorf("提取文本失败: %w", err)
+	removePageFurniture(pages)
+	layout := newPDFLayout(pages, c.opts.ExtractTables)
+	for i, p := range pages {
+		if i > 0 {
+			doc.Append(&Node{Kind: NodePageBreak, Page: p.number})
 		}
-		if strings.TrimSpace(text) != "" {
-			//markdown.WriteString(fmt.Sprintf("## 第 %d 页\n\n", pageNum))
-			markdown.WriteString(c.formatTextToMarkdown(text))
-			markdown.WriteString("\n\n---\n\n")
+		for _, b := range layout.pageBlocks(p) {
+			doc.Append(b.node)
 		}
 	}
-
-	return markdown.String(), nil
-}
-
-// extractWithSimplePDF 使用 ledongthuc/pdf 提取文本（备用方法）
-func (c *PDFConverter) extractWithSimplePDF(pdfPath string) (string, error) {
-	file, err := os.Open(pdfPath)
-	if err != nil {
-		return "", fmt.Errorf("打开 PDF 文件失败: %w", err)
+	if doc.Metadata.Title == "" {
+		for _, n := range doc.Nodes {
+			if n.Kind == NodeHeading && n.Level == 1 {
+				doc.Metadata.Title = n.Text
+				break
+			}
+		}
 	}
-	defer file.Close()
+	return doc, nil
+}
 
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return "", fmt.Errorf("获取文件信息失败: %w", err)
-	}
+// readLayout 读取元数据和每页的文字行, 所有页面都无法解析时返回错误
+func (c *PDFConverter) readLayout(src io.ReaderAt, size int64) (doc *Document, pages []*pdfPage, err error) {
+	// ledongthuc/pdf 遇到不支持的编码或损坏的内容流时会 panic
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("读取 PDF 失败: %v", r)
+		}
+	}()
 
-	reader, err := pdf.NewReader(file, fileInfo.Size())
+	reader, err := pdf.NewReader(src, size)
 	if err != nil {
-		return "", fmt.Errorf("创建 PDF 读取器失败: %w", err)
+		return nil, nil, fmt.Errorf("创建 PDF 读取器失败: %w", err)
 	}
 
-	numPages := reader.NumPage()
-	var markdown strings.Builder
-	//markdown.WriteString(fmt.Sprintf("# PDF 文档\n\n总页数: %d\n\n", numPages))
-
-	for pageNum := 1; pageNum <= numPages; pageNum++ {
+	info := reader.Trailer().Key("Info")
+	doc = &Document{Metadata: Metadata{
+		Title:    strings.TrimSpace(info.Key("Title").Text()),
+		Author:   strings.TrimSpace(info.Key("Author").Text()),
+		Subject:  strings.TrimSpace(info.Key("Subject").Text()),
+		Keywords: strings.TrimSpace(info.Key("Keywords").Text()),
+		Created:  pdfDate(info.Key("CreationDate").Text()),
+		Pages:    reader.NumPage(),
+	}}
+
+	var lastErr error
+	for pageNum := 1; pageNum <= reader.NumPage(); pageNum++ {
 		page := reader.Page(pageNum)
 		if page.V.IsNull() {
 			continue
 		}
-
-		text, err := page.GetPlainText(nil)
+		texts, err := pageTexts(page)
 		if err != nil {
+			lastErr = fmt.Errorf("解析第 %d 页失败: %w", pageNum, err)
 			continue
 		}
-		all := strings.ReplaceAll(text, "\n", "")
-		all = strings.ReplaceAll(all, "\r", "")
-		all = strings.ReplaceAll(all, "\t", "")
-		all = strings.ReplaceAll(all, "\\n", "")
-		if strings.TrimSpace(all) != "" {
-			//markdown.WriteString(fmt.Sprintf("## 第 %d 页\n\n", pageNum))
-			markdown.WriteString(c.formatTextToMarkdownNew(all))
-			//markdown.WriteString("\n\n---\n\n")
-		}
+		width, height := pageSize(page)
+		pages = append(pages, &pdfPage{
+			number: pageNum,
+			width:  width,
+			height: height,
+			lines:  groupLines(buildSpans(texts)),
+		})
 	}
+	if len(pages) == 0 && lastErr != nil {
+		return nil, nil, lastErr
+	}
+	return doc, pages, nil
+}
 
-	return markdown.String(), nil
+func pageTexts(page pdf.Page) (texts []pdf.Text, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return page.Content().Text, nil
 }
 
-// formatTextToMarkdown 格式化文本为 Markdown
-func (c *PDFConverter) formatTextToMarkdownNew(text string) string {
-	for _, dlf := range defaultFirstLineFlag {
-		if strings.Contains(text, dlf) {
-			text = strings.ReplaceAll(text, dlf, "\n# "+dlf)
+// pageSize 页面宽高, MediaBox 可以从父节点继承
+func pageSize(page pdf.Page) (float64, float64) {
+	for v := page.V; !v.IsNull(); v = v.Key("Parent") {
+		box := v.Key("MediaBox")
+		if box.Kind() == pdf.Array && box.Len() == 4 {
+			return box.Index(2).Float64() - box.Index(0).Float64(), box.Index(3).Float64() - box.Index(1).Float64()
 		}
 	}
-	for _, dlf := range defaultSubFirstLineFlag {
-		if strings.Contains(text, dlf) {
-			text = strings.ReplaceAll(text, dlf, "\n## "+dlf)
-		}
+	return 612, 792
+}
+
+var pdfDatePattern = regexp.MustCompile(`^D?:?(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?`)
+
+// pdfDate 把 PDF 日期 D:YYYYMMDDHHmmSS 转换为 2006-01-02 15:04:05 格式
+func pdfDate(s string) string {
+	m := pdfDatePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return ""
 	}
-	for _, dlf := range defaultSecondLineFlag {
-		if strings.Contains(text, dlf) {
-			text = strings.ReplaceAll(text, dlf, "\n### "+dlf)
+	parts := []string{m[1], "01", "01", "00", "00", "00"}
+	for i := 2; i <= 6; i++ {
+		if m[i] != "" {
+			parts[i-1] = m[i]
 		}
 	}
-	for _, dlf := range defaultThirdLineFlag {
-		if strings.Contains(text, dlf) {
-			text = strings.ReplaceAll(text, dlf, "\n#### "+dlf)
-		}
+	t, err := time.Parse("20060102150405", strings.Join(parts, ""))
+	if err != nil {
+		return ""
 	}
-	return text
+	return t.Format("2006-01-02 15:04:05")
 }
 
-// formatTextToMarkdown 格式化文本为 Markdown
-func (c *PDFConverter) formatTextToMarkdown(text string) string {
-	// 清理多余的空行
-	lines := strings.Split(text, "\n")
-	var result strings.Builder
+// extractImages 导出每页的图片到 ImageOutputDir, 内容相同的图片只保存一次
+func (c *PDFConverter) extractImages(src io.ReadSeeker, prefix string) (images map[int][]pdfImage, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("提取图片失败: %v", r)
+		}
+	}()
 
-	prevEmpty := false
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
+	reader, err := model.NewPdfReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("读取 PDF 文件失败: %w", err)
+	}
+	if encrypted, _ := reader.IsEncrypted(); encrypted {
+		if ok, err := reader.Decrypt(nil); err != nil || !ok {
+			return nil, fmt.Errorf("PDF 文件已加密")
+		}
+	}
+	numPages, err := reader.GetNumPages()
+	if err != nil {
+		return nil, fmt.Errorf("获取页数失败: %w", err)
+	}
+	if err := os.MkdirAll(c.opts.ImageOutputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建图片目录失败: %w", err)
+	}
 
-		if trimmed == "" {
-			if !prevEmpty {
-				result.WriteString("\n")
-				prevEmpty = true
-			}
+	images = make(map[int][]pdfImage)
+	saved := make(map[[sha1.Size]byte]string)
+	for pageNum := 1; pageNum <= numPages; pageNum++ {
+		page, err := reader.GetPage(pageNum)
+		if err != nil {
 			continue
 		}
-
-		prevEmpty = false
-
-		// 检测可能的标题（全大写或以数字开头的短行）
-		if c.opts.PreserveFormatting {
-			if len(trimmed) < 100 {
-				// 可能是标题
-				if isAllUpper(trimmed) || isNumberedLine(trimmed) {
-					result.WriteString("### ")
+		ex, err := extractor.New(page)
+		if err != nil {
+			continue
+		}
+		pageImages, err := ex.ExtractPageImages(nil)
+		if err != nil {
+			continue
+		}
+		for i, mark := range pageImages.Images {
+			// 忽略用于占位和装饰的极小图片
+			if mark.Image == nil || mark.Image.Width < 8 || mark.Image.Height < 8 {
+				continue
+			}
+			img, err := mark.Image.ToGoImage()
+			if err != nil {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img); err != nil {
+				continue
+			}
+			sum := sha1.Sum(buf.Bytes())
+			name, ok := saved[sum]
+			if !ok {
+				name = fmt.Sprintf("%s-p%d-%d.png", prefix, pageNum, i+1)
+				if err := os.WriteFile(filepath.Join(c.opts.ImageOutputDir, name), buf.Bytes(), 0o644); err != nil {
+					return nil, fmt.Errorf("保存图片失败: %w", err)
 				}
+				saved[sum] = name
 			}
+			images[pageNum] = append(images[pageNum], pdfImage{
+				x0:  mark.X,
+				x1:  mark.X + mark.Width,
+				top: mark.Y + mark.Height,
+				src: filepath.ToSlash(filepath.Join(c.opts.ImageOutputDir, name)),
+			})
 		}
-
-		result.WriteString(trimmed)
-		result.WriteString("\n")
 	}
-
-	return result.String()
+	return images, nil
 }
 
-// isAllUpper 检查字符串是否全部大写
-func isAllUpper(s string) bool {
-	hasLetter := false
-	for _, r := range s {
-		if r >= 'a' && r <= 'z' {
-			return false
+// relativeImages 把图片地址改为相对 Markdown 文件所在目录的路径
+func relativeImages(doc *Document, dir string) {
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	for _, n := range doc.Nodes {
+		if n.Kind != NodeImage {
+			continue
 		}
-		if r >= 'A' && r <= 'Z' {
-			hasLetter = true
+		target, err := filepath.Abs(filepath.FromSlash(n.Src))
+		if err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(base, target); err == nil {
+			n.Src = filepath.ToSlash(rel)
 		}
 	}
-	return hasLetter
 }
 
-// isNumberedLine 检查是否是编号行（如 "1. "，"1.1 " 等）
-func isNumberedLine(s string) bool {
-	s = strings.TrimSpace(s)
-	if len(s) == 0 {
-		return false
+// extractWithUnipdf 使用 unipdf 提取纯文本（备用方法）, 按空行拆分段落
+func (c *PDFConverter) extractWithUnipdf(src io.ReadSeeker) (*Document, error) {
+	pdfReader, err := model.NewPdfReader(src)
+	if err != nil {
+		return nil, fmt.Errorf("读取 PDF 文件失败: %w", err)
 	}
 
-	// 检查是否以数字开头
-	firstChar := s[0]
-	if firstChar < '0' || firstChar > '9' {
-		return false
+	numPages, err := pdfReader.GetNumPages()
+	if err != nil {
+		return nil, fmt.Errorf("获取页数失败: %w", err)
 	}
 
-	// 查找第一个空格或句点
-	for i := 1; i < len(s) && i < 10; i++ {
-		if s[i] == '.' || s[i] == ' ' {
-			return true
+	doc := &Document{Metadata: Metadata{Pages: numPages}}
+	for pageNum := 1; pageNum <= numPages; pageNum++ {
+		page, err := pdfReader.GetPage(pageNum)
+		if err != nil {
+			return nil, fmt.Errorf("获取第 %d 页失败: %w", pageNum, err)
 		}
-		if s[i] < '0' || s[i] > '9' {
-			return false
+
+		ex, err := extractor.New(page)
+		if err != nil {
+			return nil, fmt.Errorf("创建提取器失败: %w", err)
+		}
+
+		text, err := ex.ExtractText()
+		if err != nil {
+			return nil, fmt.Errorf("提取文本失败: %w", err)
+		}
+		if pageNum > 1 {
+			doc.Append(&Node{Kind: NodePageBreak, Page: pageNum})
+		}
+		for _, block := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+			var paragraph string
+			for _, line := range strings.Split(block, "\n") {
+				paragraph = joinText(paragraph, strings.TrimSpace(line))
+			}
+			if paragraph != "" {
+				doc.Append(&Node{Kind: NodeParagraph, Text: paragraph, Page: pageNum})
+			}
 		}
 	}
 
-	return false
+	return doc, nil
 }
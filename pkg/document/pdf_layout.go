@@ -0,0 +1,686 @@
+package document
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfSpanGap 同一行内间隔超过字号的该倍数时拆分为不同片段, 用于识别表格列和分栏
+const pdfSpanGap = 1.0
+
+// pdfSpan 同一行内连续的一段文字, 坐标为 PDF 用户空间, 原点在页面左下角
+type pdfSpan struct {
+	buf    strings.Builder
+	text   string
+	x0, x1 float64
+	y      float64
+	size   float64
+	bold   bool
+}
+
+// pdfLine 基线相同的一行文字, 片段按 x 排序
+type pdfLine struct {
+	spans  []*pdfSpan
+	x0, x1 float64
+	y      float64
+	size   float64
+	bold   bool
+}
+
+func newPDFLine(spans []*pdfSpan) *pdfLine {
+	l := &pdfLine{spans: spans, x0: math.MaxFloat64, bold: true}
+	sizes := make(map[float64]int)
+	for _, s := range spans {
+		l.x0 = math.Min(l.x0, s.x0)
+		l.x1 = math.Max(l.x1, s.x1)
+		l.y = math.Max(l.y, s.y)
+		l.bold = l.bold && s.bold
+		sizes[roundSize(s.size)] += utf8.RuneCountInString(s.text)
+	}
+	l.size = mostCommon(sizes)
+	return l
+}
+
+func (l *pdfLine) text() string {
+	parts := make([]string, len(l.spans))
+	for i, s := range l.spans {
+		parts[i] = s.text
+	}
+	return strings.Join(parts, " ")
+}
+
+// pdfImage 页面中的图片, top 为图片上边缘的 y 坐标
+type pdfImage struct {
+	x0, x1 float64
+	top    float64
+	src    string
+}
+
+// pdfPage 单页的版面信息
+type pdfPage struct {
+	number int
+	width  float64
+	height float64
+	lines  []*pdfLine
+	images []pdfImage
+}
+
+// pdfBlock 版面分析得到的内容块, 用于按位置插入图片
+type pdfBlock struct {
+	node   *Node
+	top    float64
+	x0, x1 float64
+}
+
+// buildSpans 把字形合并为片段, 字形按内容流顺序给出, 位置连续的字形属于同一片段
+func buildSpans(texts []pdf.Text) []*pdfSpan {
+	var spans []*pdfSpan
+	var cur *pdfSpan
+	for _, t := range texts {
+		if t.S == "" {
+			continue
+		}
+		size := t.FontSize
+		if size <= 0 {
+			size = 10
+		}
+		w := t.W
+		if w <= 0 {
+			w = estimateWidth(t.S, size)
+		}
+		if cur != nil && math.Abs(t.Y-cur.y) < size*0.3 && t.X > cur.x1-size*0.5 && t.X-cur.x1 < size*pdfSpanGap {
+			if t.X-cur.x1 > size*0.2 && !strings.HasSuffix(cur.buf.String(), " ") {
+				cur.buf.WriteByte(' ')
+			}
+			cur.buf.WriteString(t.S)
+			cur.x1 = math.Max(cur.x1, t.X+w)
+			if strings.TrimSpace(t.S) != "" {
+				cur.bold = cur.bold && isBoldFont(t.Font)
+			}
+			continue
+		}
+		cur = &pdfSpan{x0: t.X, x1: t.X + w, y: t.Y, size: size, bold: isBoldFont(t.Font)}
+		cur.buf.WriteString(t.S)
+		spans = append(spans, cur)
+	}
+
+	result := spans[:0]
+	for _, s := range spans {
+		s.text = strings.Join(strings.Fields(s.buf.String()), " ")
+		if s.text != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// groupLines 按基线把片段分组为行, 行按从上到下排列
+func groupLines(spans []*pdfSpan) []*pdfLine {
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].y > spans[j].y
+	})
+
+	var lines []*pdfLine
+	for i := 0; i < len(spans); {
+		j := i + 1
+		tolerance := spans[i].size * 0.4
+		for j < len(spans) && spans[i].y-spans[j].y < tolerance {
+			tolerance = math.Min(tolerance, spans[j].size*0.4)
+			j++
+		}
+		group := append([]*pdfSpan(nil), spans[i:j]...)
+		sort.SliceStable(group, func(a, b int) bool {
+			return group[a].x0 < group[b].x0
+		})
+		lines = append(lines, newPDFLine(mergeSpans(group)))
+		i = j
+	}
+	return lines
+}
+
+// mergeSpans 合并同一行中间隔较小的片段, 并去掉加粗效果产生的重复片段
+func mergeSpans(spans []*pdfSpan) []*pdfSpan {
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := merged[len(merged)-1]
+		if s.text == last.text && math.Abs(s.x0-last.x0) < s.size*0.5 {
+			continue
+		}
+		gap := s.x0 - last.x1
+		if gap >= math.Min(s.size, last.size)*pdfSpanGap {
+			merged = append(merged, s)
+			continue
+		}
+		if gap > math.Min(s.size, last.size)*0.2 {
+			last.text += " "
+		}
+		last.text += s.text
+		last.x1 = math.Max(last.x1, s.x1)
+		last.bold = last.bold && s.bold
+	}
+	return merged
+}
+
+// columnGutter 查找双栏排版的栏间距, 返回分隔位置的 x 坐标
+func columnGutter(lines []*pdfLine) (float64, bool) {
+	if len(lines) < 6 {
+		return 0, false
+	}
+	minX, maxX := math.MaxFloat64, 0.0
+	for _, l := range lines {
+		minX = math.Min(minX, l.x0)
+		maxX = math.Max(maxX, l.x1)
+	}
+	width := maxX - minX
+	if width < 200 {
+		return 0, false
+	}
+
+	// 统计每个 2pt 宽的区间被多少行覆盖, 栏间距几乎不被覆盖
+	const bin = 2.0
+	cover := make([]int, int(width/bin)+1)
+	for _, l := range lines {
+		for _, s := range l.spans {
+			for b := int((s.x0 - minX) / bin); b <= int((s.x1-minX)/bin) && b < len(cover); b++ {
+				cover[b]++
+			}
+		}
+	}
+	limit := len(lines) / 10
+	if limit < 2 {
+		limit = 2
+	}
+	bestStart, bestEnd := -1, -1
+	for b, start := len(cover)*3/10, -1; b <= len(cover)*7/10; b++ {
+		if b < len(cover)*7/10 && cover[b] <= limit {
+			if start < 0 {
+				start = b
+			}
+			continue
+		}
+		if start >= 0 && b-start > bestEnd-bestStart {
+			bestStart, bestEnd = start, b
+		}
+		start = -1
+	}
+	if bestStart < 0 || float64(bestEnd-bestStart)*bin < 8 {
+		return 0, false
+	}
+	gutter := minX + float64(bestStart+bestEnd)/2*bin
+
+	// 两栏都要有足够多的正文行, 且正文行接近栏宽, 避免把表格当作分栏
+	var left, right, leftWide, rightWide int
+	for _, l := range lines {
+		var hasLeft, hasRight, wideLeft, wideRight bool
+		for _, s := range l.spans {
+			switch {
+			case s.x1 <= gutter:
+				hasLeft = true
+				wideLeft = wideLeft || s.x1-s.x0 > (gutter-minX)*0.6
+			case s.x0 >= gutter:
+				hasRight = true
+				wideRight = wideRight || s.x1-s.x0 > (maxX-gutter)*0.6
+			}
+		}
+		if hasLeft {
+			left++
+			if wideLeft {
+				leftWide++
+			}
+		}
+		if hasRight {
+			right++
+			if wideRight {
+				rightWide++
+			}
+		}
+	}
+	if left*10 < len(lines)*3 || right*10 < len(lines)*3 || leftWide*3 < left || rightWide*3 < right {
+		return 0, false
+	}
+	return gutter, true
+}
+
+// orderLines 按阅读顺序排列行, 双栏时先读左栏再读右栏, 跨栏的行作为分隔
+func orderLines(lines []*pdfLine) []*pdfLine {
+	gutter, ok := columnGutter(lines)
+	if !ok {
+		return lines
+	}
+	var ordered, left, right []*pdfLine
+	flush := func() {
+		ordered = append(ordered, left...)
+		ordered = append(ordered, right...)
+		left, right = nil, nil
+	}
+	for _, l := range lines {
+		var ls, rs []*pdfSpan
+		crossing := false
+		for _, s := range l.spans {
+			switch {
+			case s.x1 <= gutter:
+				ls = append(ls, s)
+			case s.x0 >= gutter:
+				rs = append(rs, s)
+			default:
+				crossing = true
+			}
+		}
+		if crossing {
+			flush()
+			ordered = append(ordered, l)
+			continue
+		}
+		if len(ls) > 0 {
+			left = append(left, newPDFLine(ls))
+		}
+		if len(rs) > 0 {
+			right = append(right, newPDFLine(rs))
+		}
+	}
+	flush()
+	return ordered
+}
+
+var (
+	pageNumberPattern = regexp.MustCompile(`^(第\s*\d+\s*页.*|[-–—]?\s*\d+\s*[-–—]?|\d+\s*/\s*\d+|(?i:page)\s*\d+(\s*(?i:of)\s*\d+)?)$`)
+	digitsPattern     = regexp.MustCompile(`\d+`)
+)
+
+// removePageFurniture 去掉页眉页脚中的页码和每页重复出现的文字
+func removePageFurniture(pages []*pdfPage) {
+	isEdge := func(p *pdfPage, l *pdfLine) bool {
+		zone := p.height * 0.07
+		return l.y > p.height-zone || l.y < zone
+	}
+	repeated := make(map[string]int)
+	for _, p := range pages {
+		seen := make(map[string]bool)
+		for _, l := range p.lines {
+			if !isEdge(p, l) {
+				continue
+			}
+			key := digitsPattern.ReplaceAllString(l.text(), "#")
+			if !seen[key] {
+				seen[key] = true
+				repeated[key]++
+			}
+		}
+	}
+	for _, p := range pages {
+		lines := p.lines[:0]
+		for _, l := range p.lines {
+			if isEdge(p, l) {
+				text := l.text()
+				if pageNumberPattern.MatchString(text) {
+					continue
+				}
+				if n := repeated[digitsPattern.ReplaceAllString(text, "#")]; len(pages) >= 3 && n >= 3 && n*2 >= len(pages) {
+					continue
+				}
+			}
+			lines = append(lines, l)
+		}
+		p.lines = lines
+	}
+}
+
+// pdfLayout 根据全文的字号分布识别标题、段落、列表和表格
+type pdfLayout struct {
+	body   float64
+	levels map[float64]int
+	tables bool
+}
+
+// newPDFLayout 出现最多的字号为正文字号, 明显大于正文的字号按从大到小作为各级标题
+func newPDFLayout(pages []*pdfPage, tables bool) *pdfLayout {
+	sizes := make(map[float64]int)
+	for _, p := range pages {
+		for _, l := range p.lines {
+			for _, s := range l.spans {
+				sizes[roundSize(s.size)] += utf8.RuneCountInString(s.text)
+			}
+		}
+	}
+	a := &pdfLayout{body: mostCommon(sizes), levels: make(map[float64]int), tables: tables}
+
+	var headings []float64
+	for _, p := range pages {
+		for _, l := range p.lines {
+			if l.size >= a.body*1.15 && utf8.RuneCountInString(l.text()) <= 120 {
+				if _, ok := a.levels[l.size]; !ok {
+					a.levels[l.size] = 0
+					headings = append(headings, l.size)
+				}
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(headings)))
+	for i, size := range headings {
+		a.levels[size] = min(i+1, 6)
+	}
+	return a
+}
+
+// headingLevel 字号对应标题级别, 正文字号的短行以中文编号开头或整行加粗时作为下一级标题
+func (a *pdfLayout) headingLevel(l *pdfLine, text string) int {
+	runes := utf8.RuneCountInString(text)
+	if runes == 0 || runes > 120 {
+		return 0
+	}
+	if level, ok := a.levels[l.size]; ok {
+		return level
+	}
+	if runes > 40 || endsSentence(text) || l.size < a.body-0.5 {
+		return 0
+	}
+	base := len(a.levels)
+	switch {
+	case hasAnyPrefix(text, defaultFirstLineFlag):
+		return min(base+1, 6)
+	case hasAnyPrefix(text, defaultSubFirstLineFlag):
+		return min(base+2, 6)
+	case l.bold && !isListLine(text):
+		return min(base+1, 6)
+	}
+	return 0
+}
+
+// pageBlocks 把一页中按阅读顺序排列的行组织为内容块
+func (a *pdfLayout) pageBlocks(p *pdfPage) []*pdfBlock {
+	lines := orderLines(p.lines)
+	rightEdge := columnRightEdges(lines)
+
+	var (
+		blocks    []*pdfBlock
+		para      *pdfBlock
+		list      *pdfBlock
+		listX0    float64
+		itemX0    float64
+		prev      *pdfLine
+		lastLevel int
+	)
+	end := func() {
+		para, list = nil, nil
+	}
+	add := func(node *Node, l *pdfLine) *pdfBlock {
+		node.Page = p.number
+		b := &pdfBlock{node: node, top: l.y + l.size, x0: l.x0, x1: l.x1}
+		blocks = append(blocks, b)
+		return b
+	}
+
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		text := l.text()
+
+		if a.tables && len(l.spans) >= 2 {
+			if rows, n := detectTable(lines[i:]); n > 0 {
+				end()
+				b := add(&Node{Kind: NodeTable, Rows: rows}, l)
+				for _, row := range lines[i : i+n] {
+					b.x0, b.x1 = math.Min(b.x0, row.x0), math.Max(b.x1, row.x1)
+				}
+				i += n - 1
+				prev, lastLevel = nil, 0
+				continue
+			}
+		}
+
+		if level := a.headingLevel(l, text); level > 0 {
+			// 字号相同且紧挨着的多行标题合并为一个
+			if last := len(blocks) - 1; lastLevel == level && prev != nil && prev.y-l.y > 0 && prev.y-l.y < l.size*2 {
+				blocks[last].node.Text = joinText(blocks[last].node.Text, text)
+			} else {
+				end()
+				add(&Node{Kind: NodeHeading, Level: level, Text: text}, l)
+			}
+			prev, lastLevel = l, level
+			continue
+		}
+		lastLevel = 0
+
+		if ordered, rest, ok := listMarker(text); ok {
+			para = nil
+			if list == nil || list.node.Ordered != ordered {
+				list = add(&Node{Kind: NodeList, Ordered: ordered}, l)
+				listX0 = l.x0
+			}
+			level := int(math.Round((l.x0 - listX0) / (l.size * 1.5)))
+			if level < 0 {
+				level = 0
+			}
+			list.node.Items = append(list.node.Items, ListItem{Text: rest, Level: level})
+			list.x1 = math.Max(list.x1, l.x1)
+			itemX0, prev = l.x0, l
+			continue
+		}
+		if list != nil && prev != nil && l.x0 > itemX0+l.size*0.3 && prev.y-l.y > 0 && prev.y-l.y < l.size*1.9 {
+			item := &list.node.Items[len(list.node.Items)-1]
+			item.Text = joinText(item.Text, text)
+			prev = l
+			continue
+		}
+		list = nil
+
+		if para != nil && !breakParagraph(prev, l, rightEdge[prev]) {
+			para.node.Text = joinText(para.node.Text, text)
+			para.x0, para.x1 = math.Min(para.x0, l.x0), math.Max(para.x1, l.x1)
+			prev = l
+			continue
+		}
+		para = add(&Node{Kind: NodeParagraph, Text: text}, l)
+		prev = l
+	}
+
+	// 图片插入到位于其下方且水平方向有重叠的第一个内容块之前
+	images := append([]pdfImage(nil), p.images...)
+	sort.SliceStable(images, func(i, j int) bool {
+		return images[i].top > images[j].top
+	})
+	for _, img := range images {
+		pos := len(blocks)
+		for j, b := range blocks {
+			if b.top < img.top && b.x1 > img.x0 && b.x0 < img.x1 {
+				pos = j
+				break
+			}
+		}
+		b := &pdfBlock{node: &Node{Kind: NodeImage, Src: img.src, Page: p.number}, top: img.top, x0: img.x0, x1: img.x1}
+		blocks = append(blocks[:pos], append([]*pdfBlock{b}, blocks[pos:]...)...)
+	}
+	return blocks
+}
+
+// columnRightEdges 每行所在栏的右边界, 取水平方向与该行重叠的所有行的最大 x1
+func columnRightEdges(lines []*pdfLine) map[*pdfLine]float64 {
+	edges := make(map[*pdfLine]float64, len(lines))
+	for _, l := range lines {
+		edge := l.x1
+		for _, o := range lines {
+			if o.x0 < l.x1 && o.x1 > l.x0 {
+				edge = math.Max(edge, o.x1)
+			}
+		}
+		edges[l] = edge
+	}
+	return edges
+}
+
+// breakParagraph 判断 cur 是否开始新段落: 字号变化、行距过大、首行缩进或上一行以句末标点提前结束
+func breakParagraph(prev, cur *pdfLine, rightEdge float64) bool {
+	if prev == nil || math.Abs(prev.size-cur.size) > 0.5 {
+		return true
+	}
+	gap := prev.y - cur.y
+	if gap <= 0 || gap > cur.size*1.9 {
+		return true
+	}
+	if cur.x0 > prev.x0+cur.size*0.8 {
+		return true
+	}
+	return prev.x1 < rightEdge-cur.size*4 && endsSentence(prev.text())
+}
+
+// detectTable 从 lines[0] 开始查找表格: 至少两行连续的多片段行, 片段的水平区间合并后作为列
+func detectTable(lines []*pdfLine) ([][]string, int) {
+	run := lines[:1]
+	for _, l := range lines[1:] {
+		prev := run[len(run)-1]
+		gap := prev.y - l.y
+		if len(l.spans) < 2 || gap <= 0 || gap > prev.size*3 {
+			break
+		}
+		run = lines[:len(run)+1]
+	}
+	if len(run) < 2 {
+		return nil, 0
+	}
+
+	type column struct{ x0, x1 float64 }
+	var spans []*pdfSpan
+	for _, l := range run {
+		spans = append(spans, l.spans...)
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].x0 < spans[j].x0
+	})
+	var columns []column
+	for _, s := range spans {
+		if n := len(columns); n > 0 && s.x0 < columns[n-1].x1 {
+			columns[n-1].x1 = math.Max(columns[n-1].x1, s.x1)
+			continue
+		}
+		columns = append(columns, column{s.x0, s.x1})
+	}
+	if len(columns) < 2 {
+		return nil, 0
+	}
+
+	rows := make([][]string, len(run))
+	var cells, runes int
+	for i, l := range run {
+		row := make([]string, len(columns))
+		for _, s := range l.spans {
+			col := sort.Search(len(columns), func(k int) bool { return columns[k].x1 > s.x0 })
+			if col == len(columns) {
+				col--
+			}
+			row[col] = joinText(row[col], s.text)
+			cells++
+			runes += utf8.RuneCountInString(s.text)
+		}
+		rows[i] = row
+	}
+	// 单元格平均长度过长时更像是未识别的分栏正文
+	if runes > cells*40 {
+		return nil, 0
+	}
+	return rows, len(run)
+}
+
+var (
+	orderedMarkerPattern = regexp.MustCompile(`^(\d{1,3}(?:[)、]|\.(?:\s|$))|[（(]\d{1,3}[)）]|[A-Za-z](?:、|[.)]\s))\s*`)
+	bulletMarkers        = "•●○◦■□▪▫◆◇►▶✓✔·"
+)
+
+// listMarker 识别列表项的项目符号或编号, 返回去掉标记后的文本
+func listMarker(text string) (ordered bool, rest string, ok bool) {
+	r, size := utf8.DecodeRuneInString(text)
+	if strings.ContainsRune(bulletMarkers, r) {
+		return false, strings.TrimSpace(text[size:]), true
+	}
+	if (r == '-' || r == '*' || r == '–') && strings.HasPrefix(text[size:], " ") {
+		return false, strings.TrimSpace(text[size:]), true
+	}
+	if loc := orderedMarkerPattern.FindStringIndex(text); loc != nil && loc[1] < len(text) {
+		return true, strings.TrimSpace(text[loc[1]:]), true
+	}
+	return false, "", false
+}
+
+func isListLine(text string) bool {
+	_, _, ok := listMarker(text)
+	return ok
+}
+
+// joinText 拼接折行的文本, 西文之间补空格, 中文直接相连, 行尾连字符与小写字母开头的下一行合并
+func joinText(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	last, _ := utf8.DecodeLastRuneInString(a)
+	first, _ := utf8.DecodeRuneInString(b)
+	if last == '-' && len(a) > 1 && unicode.IsLower(first) {
+		prev, _ := utf8.DecodeLastRuneInString(a[:len(a)-1])
+		if unicode.IsLetter(prev) {
+			return a[:len(a)-1] + b
+		}
+	}
+	if isWide(last) || isWide(first) {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// isWide 中日韩文字和全角标点, 拼接时不需要空格
+func isWide(r rune) bool {
+	return r >= 0x2E80 && r <= 0x9FFF || r >= 0xAC00 && r <= 0xD7AF || r >= 0xF900 && r <= 0xFAFF || r >= 0xFF00 && r <= 0xFFEF || r >= 0x3000 && r <= 0x303F
+}
+
+func endsSentence(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(strings.TrimSpace(text))
+	return strings.ContainsRune("。！？；：.!?;:", r)
+}
+
+func hasAnyPrefix(text string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// estimateWidth 字体没有宽度信息时估算文字宽度, 全角字符占一个字号, 其余占半个
+func estimateWidth(s string, size float64) float64 {
+	w := 0.0
+	for _, r := range s {
+		if isWide(r) {
+			w += size
+		} else {
+			w += size * 0.5
+		}
+	}
+	return w
+}
+
+func isBoldFont(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "bold") || strings.Contains(name, "black") || strings.Contains(name, "heavy") ||
+		strings.HasSuffix(name, ",b") || strings.Contains(name, "黑体") || strings.Contains(name, "simhei")
+}
+
+// roundSize 字号按 0.5 取整, 消除缩放带来的微小差异
+func roundSize(size float64) float64 {
+	return math.Round(size*2) / 2
+}
+
+func mostCommon(counts map[float64]int) float64 {
+	best, bestCount := 0.0, -1
+	for v, n := range counts {
+		if n > bestCount || n == bestCount && v < best {
+			best, bestCount = v, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,146 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildPDF 生成每页共用 Helvetica、Helvetica-Bold 两种字体和一张 8x8 图片的 PDF
+func buildPDF(t *testing.T, pages ...string) []byte {
+	t.Helper()
+	var pixels bytes.Buffer
+	zw := zlib.NewWriter(&pixels)
+	zw.Write(bytes.Repeat([]byte{200, 30, 30}, 64))
+	zw.Close()
+
+	widths := "[" + strings.TrimSpace(strings.Repeat("500 ", 95)) + "]"
+	font := "<< /Type /Font /Subtype /Type1 /BaseFont /%s /FirstChar 32 /LastChar 126 /Widths " + widths + " >>"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // Pages
+		fmt.Sprintf(font, "Helvetica"),
+		fmt.Sprintf(font, "Helvetica-Bold"),
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width 8 /Height 8 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", pixels.Len(), pixels.String()),
+		"<< /Title (Test Report) /Author (QA) /CreationDate (D:20240102030405Z) >>",
+	}
+	var kids []string
+	for _, content := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objs)+1))
+		objs = append(objs,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Contents %d 0 R /Resources << /Font << /F1 3 0 R /F2 4 0 R >> /XObject << /Im1 5 0 R >> >> >>", len(objs)+2),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+	objs[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return buf.Bytes()
+}
+
+func pdfText(font string, size, x, y float64, text string) string {
+	return fmt.Sprintf("BT /%s %g Tf %g %g Td (%s) Tj ET\n", font, size, x, y, text)
+}
+
+func TestPDFConverterLayout(t *testing.T) {
+	page1 := pdfText("F2", 20, 72, 720, "Annual Report") +
+		pdfText("F2", 14, 72, 690, "Overview") +
+		pdfText("F1", 10, 72, 660, "This is the first line of body text that runs across the page and") +
+		pdfText("F1", 10, 72, 648, "continues on the next line.") +
+		pdfText("F1", 10, 72, 600, "Name") + pdfText("F1", 10, 200, 600, "Age") + pdfText("F1", 10, 330, 600, "City") +
+		pdfText("F1", 10, 72, 585, "Alice") + pdfText("F1", 10, 200, 585, "30") + pdfText("F1", 10, 330, 585, "Paris") +
+		pdfText("F1", 10, 72, 570, "Bob") + pdfText("F1", 10, 200, 570, "4") + pdfText("F1", 10, 330, 570, "Rome") +
+		"q 100 0 0 50 72 450 cm /Im1 Do Q\n" +
+		pdfText("F1", 10, 72, 420, "- first item") +
+		pdfText("F1", 10, 72, 408, "- second item") +
+		pdfText("F1", 10, 300, 30, "1")
+	var page2 string
+	for i := 0; i < 8; i++ {
+		y := 700 - float64(i)*12
+		page2 += pdfText("F1", 10, 72, y, fmt.Sprintf("Left column line %d with enough words here", i))
+		page2 += pdfText("F1", 10, 320, y, fmt.Sprintf("Right column line %d with enough words too", i))
+	}
+	page2 += pdfText("F1", 10, 300, 30, "2")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(input, buildPDF(t, page1, page2), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultConvertOptions()
+	opts.ImageOutputDir = filepath.Join(dir, "images")
+	opts.IncludeMetadata = true
+	output := filepath.Join(dir, "out", "report.md")
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewPDFConverter(opts).ToMarkdownFile(input, output); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	markdown := string(data)
+
+	want := []string{
+		"---\ntitle: \"Test Report\"\nauthor: \"QA\"\ncreated: \"2024-01-02 03:04:05\"\npages: 2\n---\n\n# Annual Report\n",
+		"## Overview\n",
+		"This is the first line of body text that runs across the page and continues on the next line.\n",
+		"| Name | Age | City |\n| --- | --- | --- |\n| Alice | 30 | Paris |\n| Bob | 4 | Rome |\n",
+		"![](../images/report-p1-1.png)\n",
+		"- first item\n- second item\n",
+		"---\n",
+		"Left column line 0 with enough words here Left column line 1",
+		"Right column line 0 with enough words too Right column line 1",
+	}
+	pos := 0
+	for _, w := range want {
+		i := strings.Index(markdown[pos:], w)
+		if i < 0 {
+			t.Fatalf("missing %q after offset %d in:\n%s", w, pos, markdown)
+		}
+		pos += i + len(w)
+	}
+	if strings.Contains(markdown, "\n1\n") || strings.Contains(markdown, "\n2\n") {
+		t.Fatalf("page numbers not removed:\n%s", markdown)
+	}
+	if _, err := os.Stat(filepath.Join(opts.ImageOutputDir, "report-p1-1.png")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	markdown := "# 标题\n\n第一段\n第二行\n\n- a\n  - b\n1. c\n\n| x | y\\|z |\n| --- | --- |\n| 1 | 2 |\n\n```go\nfmt.Println()\n```\n\n> 引用\n\n![图](a.png)\n"
+	doc := ParseMarkdown(markdown)
+	kinds := make([]NodeKind, len(doc.Nodes))
+	for i, n := range doc.Nodes {
+		kinds[i] = n.Kind
+	}
+	want := []NodeKind{NodeHeading, NodeParagraph, NodeList, NodeList, NodeTable, NodeCode, NodeQuote, NodeImage}
+	if fmt.Sprint(kinds) != fmt.Sprint(want) {
+		t.Fatalf("unexpected nodes: %v", kinds)
+	}
+	if doc.Nodes[2].Items[1].Level != 1 || !doc.Nodes[3].Ordered || doc.Nodes[4].Rows[0][1] != "y|z" || doc.Nodes[5].Lang != "go" {
+		t.Fatalf("unexpected node content: %+v", doc.Nodes)
+	}
+	if got := ParseMarkdown(doc.Markdown()).Markdown(); got != doc.Markdown() {
+		t.Fatalf("render is not stable:\n%s\n%s", doc.Markdown(), got)
+	}
+}
@@ -0,0 +1,252 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// PPTXConverter PowerPoint 转换器, 按幻灯片顺序提取标题和文本
+type PPTXConverter struct {
+	opts *ConvertOptions
+}
+
+// NewPPTXConverter 创建 PowerPoint 转换器
+func NewPPTXConverter(opts *ConvertOptions) *PPTXConverter {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+	return &PPTXConverter{
+		opts: opts,
+	}
+}
+
+// ToMarkdown 将 PowerPoint 转换为 Markdown
+func (c *PPTXConverter) ToMarkdown(input io.Reader) (string, error) {
+	doc, err := c.ToDocument(input)
+	if err != nil {
+		return "", err
+	}
+	return doc.render(c.opts.IncludeMetadata), nil
+}
+
+// ToDocument 将 PowerPoint 转换为结构化文档, 每张幻灯片为一个标题, 正文为列表, 备注为引用
+func (c *PPTXConverter) ToDocument(input io.Reader) (*Document, error) {
+	zr, err := openZip(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var presentation struct {
+		Slides []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sldIdLst>sldId"`
+	}
+	if err := readZipXML(zr, "ppt/presentation.xml", &presentation); err != nil {
+		return nil, err
+	}
+	rels, err := readRelationships(zr, "ppt/_rels/presentation.xml.rels", "ppt")
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Metadata: Metadata{Pages: len(presentation.Slides)}}
+	for i, s := range presentation.Slides {
+		target, ok := rels[s.ID]
+		if !ok {
+			continue
+		}
+		slide, err := c.readSlide(zr, target)
+		if err != nil {
+			return nil, err
+		}
+		title := slide.title
+		if title == "" {
+			title = fmt.Sprintf("幻灯片 %d", i+1)
+		}
+		doc.Append(&Node{Kind: NodeHeading, Level: 2, Text: title, Page: i + 1})
+		if len(slide.paragraphs) > 0 {
+			list := &Node{Kind: NodeList, Page: i + 1}
+			for _, p := range slide.paragraphs {
+				list.Items = append(list.Items, ListItem{Text: p.text, Level: p.level})
+			}
+			doc.Append(list)
+		}
+		for _, table := range slide.tables {
+			doc.Append(&Node{Kind: NodeTable, Rows: table, Page: i + 1})
+		}
+		if notes := c.readNotes(zr, target); notes != "" {
+			doc.Append(&Node{Kind: NodeQuote, Text: notes, Page: i + 1})
+		}
+	}
+
+	return doc, nil
+}
+
+// ToMarkdownFile 将 PowerPoint 文件转换为 Markdown 文件
+func (c *PPTXConverter) ToMarkdownFile(inputPath, outputPath string) error {
+	return writeMarkdownFile(c, inputPath, outputPath)
+}
+
+// SupportedTypes 返回支持的文档类型
+func (c *PPTXConverter) SupportedTypes() []DocumentType {
+	return []DocumentType{TypePPTX}
+}
+
+type pptxParagraph struct {
+	level int
+	text  string
+}
+
+type pptxSlide struct {
+	title      string
+	paragraphs []pptxParagraph
+	tables     [][][]string
+}
+
+// readSlide 逐个读取 xml 节点, 标题占位符中的文本作为标题, 其余段落按缩进级别输出为列表
+func (c *PPTXConverter) readSlide(zr *zip.Reader, name string) (*pptxSlide, error) {
+	data, err := readZipFile(zr, name)
+	if err != nil {
+		return nil, err
+	}
+	slide := &pptxSlide{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	var (
+		isTitle   bool
+		paragraph strings.Builder
+		level     int
+		inText    bool
+		table     [][]string
+		row       []string
+		inCell    bool
+		cell      strings.Builder
+		tableDeep int
+	)
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 %s 失败: %w", name, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "sp":
+				isTitle = false
+			case "ph":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "type" && (attr.Value == "title" || attr.Value == "ctrTitle") {
+						isTitle = true
+					}
+				}
+			case "tbl":
+				tableDeep++
+				table = nil
+			case "tr":
+				row = nil
+			case "tc":
+				inCell = true
+				cell.Reset()
+			case "p":
+				paragraph.Reset()
+				level = 0
+			case "pPr":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "lvl" {
+						level, _ = strconv.Atoi(attr.Value)
+					}
+				}
+			case "t":
+				inText = true
+			case "br":
+				paragraph.WriteString(" ")
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text := strings.TrimSpace(paragraph.String())
+				if text == "" {
+					continue
+				}
+				switch {
+				case inCell:
+					if cell.Len() > 0 {
+						cell.WriteString(" ")
+					}
+					cell.WriteString(text)
+				case isTitle:
+					if slide.title != "" {
+						slide.title += " "
+					}
+					slide.title += text
+				default:
+					slide.paragraphs = append(slide.paragraphs, pptxParagraph{level: level, text: text})
+				}
+			case "tc":
+				inCell = false
+				row = append(row, cell.String())
+			case "tr":
+				table = append(table, row)
+			case "tbl":
+				tableDeep--
+				if tableDeep == 0 && len(table) > 0 {
+					slide.tables = append(slide.tables, table)
+				}
+			case "sp":
+				isTitle = false
+			}
+		}
+	}
+	return slide, nil
+}
+
+// readNotes 读取幻灯片备注, 没有备注时返回空字符串
+func (c *PPTXConverter) readNotes(zr *zip.Reader, slidePath string) string {
+	relsPath := path.Join(path.Dir(slidePath), "_rels", path.Base(slidePath)+".rels")
+	if findZipFile(zr, relsPath) == nil {
+		return ""
+	}
+	var rels struct {
+		Items []struct {
+			Type   string `xml:"Type,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := readZipXML(zr, relsPath, &rels); err != nil {
+		return ""
+	}
+	for _, rel := range rels.Items {
+		if !strings.HasSuffix(rel.Type, "/notesSlide") {
+			continue
+		}
+		notes, err := c.readSlide(zr, path.Join(path.Dir(slidePath), rel.Target))
+		if err != nil {
+			return ""
+		}
+		var lines []string
+		for _, p := range notes.paragraphs {
+			// 备注页中的页码占位符只有数字
+			if strings.Trim(p.text, "0123456789") == "" {
+				continue
+			}
+			lines = append(lines, p.text)
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,350 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 常用 MIME 类型
+const (
+	MIMEPDF      = "application/pdf"
+	MIMEDocx     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MIMEDoc      = "application/msword"
+	MIMEXlsx     = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MIMEPptx     = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	MIMEEPUB     = "application/epub+zip"
+	MIMEHTML     = "text/html"
+	MIMECSV      = "text/csv"
+	MIMEText     = "text/plain"
+	MIMEMarkdown = "text/markdown"
+	MIMERTF      = "application/rtf"
+	MIMEZip      = "application/zip"
+	MIMEOLE      = "application/x-ole-storage"
+	MIMEUnknown  = "application/octet-stream"
+)
+
+// sniffLen 嗅探时读取的文件头长度
+const sniffLen = 4096
+
+// ConverterFactory 根据转换选项创建转换器
+type ConverterFactory func(opts *ConvertOptions) Converter
+
+// Registration 转换器注册信息
+type Registration struct {
+	// Type 文档类型
+	Type DocumentType
+
+	// MIMETypes 支持的 MIME 类型, 第一个为主类型
+	MIMETypes []string
+
+	// Extensions 支持的扩展名, 如 .xlsx
+	Extensions []string
+
+	// Sniff 可选, 根据文件头识别文档, 返回 true 时优先于内置的嗅探结果
+	Sniff func(head []byte) bool
+
+	// Factory 创建转换器
+	Factory ConverterFactory
+}
+
+// Registry 转换器注册表, 按 MIME 类型和扩展名查找转换器
+type Registry struct {
+	mu            sync.RWMutex
+	registrations []*Registration
+	byMIME        map[string]*Registration
+	byExt         map[string]*Registration
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		byMIME: make(map[string]*Registration),
+		byExt:  make(map[string]*Registration),
+	}
+}
+
+var defaultRegistry = newDefaultRegistry()
+
+// DefaultRegistry 默认注册表, 包含所有内置转换器
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Register 向默认注册表注册转换器, 已存在的 MIME 类型和扩展名会被覆盖
+func Register(reg Registration) error {
+	return defaultRegistry.Register(reg)
+}
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	builtin := []Registration{
+		{Type: TypePDF, MIMETypes: []string{MIMEPDF}, Extensions: []string{".pdf"},
+			Factory: func(opts *ConvertOptions) Converter { return NewPDFConverter(opts) }},
+		{Type: TypeWord, MIMETypes: []string{MIMEDocx}, Extensions: []string{".docx"},
+			Factory: func(opts *ConvertOptions) Converter { return NewWordConverter(opts) }},
+		{Type: TypeXLSX, MIMETypes: []string{MIMEXlsx}, Extensions: []string{".xlsx", ".xlsm"},
+			Factory: func(opts *ConvertOptions) Converter { return NewXLSXConverter(opts) }},
+		{Type: TypeCSV, MIMETypes: []string{MIMECSV, "text/tab-separated-values"}, Extensions: []string{".csv", ".tsv"},
+			Factory: func(opts *ConvertOptions) Converter { return NewCSVConverter(opts) }},
+		{Type: TypePPTX, MIMETypes: []string{MIMEPptx}, Extensions: []string{".pptx"},
+			Factory: func(opts *ConvertOptions) Converter { return NewPPTXConverter(opts) }},
+		{Type: TypeHTML, MIMETypes: []string{MIMEHTML, "application/xhtml+xml"}, Extensions: []string{".html", ".htm", ".xhtml"},
+			Factory: func(opts *ConvertOptions) Converter { return NewHTMLConverter(opts) }},
+		{Type: TypeEPUB, MIMETypes: []string{MIMEEPUB}, Extensions: []string{".epub"},
+			Factory: func(opts *ConvertOptions) Converter { return NewEPUBConverter(opts) }},
+		{Type: TypeText, MIMETypes: []string{MIMEText, MIMEMarkdown}, Extensions: []string{".txt", ".md", ".markdown", ".log"},
+			Factory: func(opts *ConvertOptions) Converter { return NewTextConverter(opts) }},
+		{Type: TypeRTF, MIMETypes: []string{MIMERTF, "text/rtf"}, Extensions: []string{".rtf"},
+			Factory: func(opts *ConvertOptions) Converter { return NewRTFConverter(opts) }},
+	}
+	for _, reg := range builtin {
+		if err := r.Register(reg); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// Register 注册转换器, 已存在的 MIME 类型和扩展名会被覆盖
+func (r *Registry) Register(reg Registration) error {
+	if reg.Factory == nil {
+		return fmt.Errorf("转换器 %s 缺少 Factory", reg.Type)
+	}
+	if len(reg.MIMETypes) == 0 && len(reg.Extensions) == 0 {
+		return fmt.Errorf("转换器 %s 至少需要一个 MIME 类型或扩展名", reg.Type)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := &reg
+	r.registrations = append(r.registrations, entry)
+	for _, mime := range reg.MIMETypes {
+		r.byMIME[strings.ToLower(mime)] = entry
+	}
+	for _, ext := range reg.Extensions {
+		r.byExt[normalizeExt(ext)] = entry
+	}
+	return nil
+}
+
+// ByMIME 按 MIME 类型查找
+func (r *Registry) ByMIME(mime string) (*Registration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mime, _, _ = strings.Cut(strings.ToLower(mime), ";")
+	reg, ok := r.byMIME[strings.TrimSpace(mime)]
+	return reg, ok
+}
+
+// ByExtension 按扩展名查找
+func (r *Registry) ByExtension(ext string) (*Registration, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.byExt[normalizeExt(ext)]
+	return reg, ok
+}
+
+// Extensions 所有已注册的扩展名
+func (r *Registry) Extensions() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	exts := make([]string, 0, len(r.byExt))
+	for ext := range r.byExt {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// Supports 是否存在可以处理该文件名的转换器
+func (r *Registry) Supports(filename string) bool {
+	_, ok := r.ByExtension(filepath.Ext(filename))
+	return ok
+}
+
+// Detect 识别文档并返回对应的注册信息. 优先使用文件头嗅探结果,
+// 嗅探只能得到通用类型(纯文本、zip 等)时再参考扩展名, 因此改了扩展名的文件也能正确识别
+func (r *Registry) Detect(filename string, data io.ReaderAt, size int64) (*Registration, string, error) {
+	head := make([]byte, sniffLen)
+	n, err := data.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, "", fmt.Errorf("读取文件头失败: %w", err)
+	}
+	head = head[:n]
+
+	r.mu.RLock()
+	for _, reg := range r.registrations {
+		if reg.Sniff != nil && reg.Sniff(head) {
+			r.mu.RUnlock()
+			return reg, primaryMIME(reg), nil
+		}
+	}
+	r.mu.RUnlock()
+
+	mime := SniffMIME(head)
+	if mime == MIMEZip {
+		mime = sniffZip(data, size)
+	}
+	extReg, extOK := r.ByExtension(filepath.Ext(filename))
+	// 文本类文件(csv、markdown、html 等)的嗅探结果不够可靠, 以扩展名为准
+	if extOK && strings.HasPrefix(mime, "text/") && compatibleMIME(MIMEText, extReg) {
+		return extReg, primaryMIME(extReg), nil
+	}
+	if !isGenericMIME(mime) {
+		if reg, ok := r.ByMIME(mime); ok {
+			return reg, mime, nil
+		}
+		return nil, mime, unsupportedError(filename, mime)
+	}
+	if extOK && compatibleMIME(mime, extReg) {
+		return extReg, primaryMIME(extReg), nil
+	}
+	if reg, ok := r.ByMIME(mime); ok {
+		return reg, mime, nil
+	}
+	return nil, mime, unsupportedError(filename, mime)
+}
+
+// DetectFile 识别文件
+func (r *Registry) DetectFile(path string) (*Registration, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, "", fmt.Errorf("读取文件信息失败: %w", err)
+	}
+	return r.Detect(path, file, info.Size())
+}
+
+// ConverterFor 识别文件并创建对应的转换器
+func (r *Registry) ConverterFor(path string, opts *ConvertOptions) (Converter, error) {
+	reg, _, err := r.DetectFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return reg.Factory(opts), nil
+}
+
+// SniffMIME 根据文件头判断 MIME 类型, zip 容器返回 MIMEZip, 需要结合 zip 目录进一步判断
+func SniffMIME(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return MIMEPDF
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		// EPUB 规范要求第一个条目是未压缩的 mimetype
+		if len(head) >= 58 && string(head[30:38]) == "mimetype" && string(head[38:58]) == MIMEEPUB {
+			return MIMEEPUB
+		}
+		return MIMEZip
+	case bytes.HasPrefix(head, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}):
+		return MIMEOLE
+	case bytes.HasPrefix(head, []byte(`{\rtf`)):
+		return MIMERTF
+	}
+	text := bytes.TrimPrefix(head, []byte("\xEF\xBB\xBF"))
+	if mime := http.DetectContentType(text); strings.HasPrefix(mime, MIMEHTML) {
+		return MIMEHTML
+	}
+	if isText(text) {
+		return MIMEText
+	}
+	return MIMEUnknown
+}
+
+// sniffZip 根据 zip 目录判断 Office Open XML 和 EPUB
+func sniffZip(data io.ReaderAt, size int64) string {
+	zr, err := zip.NewReader(data, size)
+	if err != nil {
+		return MIMEZip
+	}
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "word/document.xml":
+			return MIMEDocx
+		case f.Name == "xl/workbook.xml":
+			return MIMEXlsx
+		case f.Name == "ppt/presentation.xml":
+			return MIMEPptx
+		case f.Name == "META-INF/container.xml":
+			return MIMEEPUB
+		}
+	}
+	return MIMEZip
+}
+
+// isText 文件头中没有控制字符, 并且是合法的 UTF-8 或可能是 GBK 等多字节编码
+func isText(head []byte) bool {
+	if len(head) == 0 {
+		return true
+	}
+	// 截断的多字节字符不影响判断
+	if !utf8.Valid(head) && len(head) >= sniffLen {
+		head = head[:len(head)-utf8.UTFMax]
+	}
+	for _, b := range head {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' {
+			return false
+		}
+	}
+	return true
+}
+
+func isGenericMIME(mime string) bool {
+	return mime == MIMEText || mime == MIMEZip || mime == MIMEUnknown || mime == MIMEOLE
+}
+
+// compatibleMIME 嗅探得到通用类型时, 扩展名对应的转换器是否可能处理
+func compatibleMIME(mime string, reg *Registration) bool {
+	switch mime {
+	case MIMEText:
+		for _, m := range reg.MIMETypes {
+			if strings.HasPrefix(m, "text/") || strings.Contains(m, "json") || strings.Contains(m, "xml") {
+				return true
+			}
+		}
+		return false
+	case MIMEOLE:
+		// 旧版 Office 二进制格式(.doc/.xls/.ppt)只能交给第三方注册的转换器处理
+		for _, m := range reg.MIMETypes {
+			if m == MIMEDoc || m == "application/vnd.ms-excel" || m == "application/vnd.ms-powerpoint" {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func unsupportedError(filename, mime string) error {
+	if mime == MIMEOLE {
+		return fmt.Errorf("不支持的文档类型: %s, 旧版 Office 格式(.doc/.xls/.ppt)请另存为新格式后再转换", filename)
+	}
+	return fmt.Errorf("不支持的文档类型: %s (%s)", filename, mime)
+}
+
+func primaryMIME(reg *Registration) string {
+	if len(reg.MIMETypes) > 0 {
+		return reg.MIMETypes[0]
+	}
+	return MIMEUnknown
+}
+
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
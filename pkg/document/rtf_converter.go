@@ -0,0 +1,346 @@
+package document
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// RTFConverter RTF 转换器, 提取正文文本和表格, 忽略字体、样式、图片等控制信息
+type RTFConverter struct {
+	opts *ConvertOptions
+}
+
+// NewRTFConverter 创建 RTF 转换器
+func NewRTFConverter(opts *ConvertOptions) *RTFConverter {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+	return &RTFConverter{
+		opts: opts,
+	}
+}
+
+// ToMarkdown 将 RTF 转换为 Markdown
+func (c *RTFConverter) ToMarkdown(input io.Reader) (string, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	if !strings.HasPrefix(string(data), `{\rtf`) {
+		return "", fmt.Errorf("不是有效的 RTF 文件")
+	}
+	p := &rtfParser{data: data, encoding: charmap.Windows1252, uc: 1}
+	p.parse()
+	return strings.TrimSpace(CleanMarkdown(p.out.String())) + "\n", nil
+}
+
+// ToDocument 将 RTF 转换为结构化文档, 由 Markdown 输出解析得到
+func (c *RTFConverter) ToDocument(input io.Reader) (*Document, error) {
+	markdown, err := c.ToMarkdown(input)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMarkdown(markdown), nil
+}
+
+// ToMarkdownFile 将 RTF 文件转换为 Markdown 文件
+func (c *RTFConverter) ToMarkdownFile(inputPath, outputPath string) error {
+	return writeMarkdownFile(c, inputPath, outputPath)
+}
+
+// SupportedTypes 返回支持的文档类型
+func (c *RTFConverter) SupportedTypes() []DocumentType {
+	return []DocumentType{TypeRTF}
+}
+
+// rtfSkipDestinations 不包含正文的目标组
+var rtfSkipDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "info": true, "pict": true,
+	"object": true, "header": true, "headerl": true, "headerr": true, "headerf": true,
+	"footer": true, "footerl": true, "footerr": true, "footerf": true, "listtable": true,
+	"listoverridetable": true, "rsidtbl": true, "generator": true, "xmlnstbl": true,
+	"themedata": true, "colorschememapping": true, "latentstyles": true, "datastore": true,
+	"fldinst": true, "filetbl": true, "revtbl": true, "mmathPr": true, "bkmkstart": true,
+	"bkmkend": true, "pgdsctbl": true, "nonshppict": true, "template": true,
+}
+
+type rtfState struct {
+	skip bool
+	uc   int
+}
+
+type rtfParser struct {
+	data     []byte
+	pos      int
+	encoding encoding.Encoding
+	uc       int
+	skip     bool
+	stack    []rtfState
+	pending  []byte // 待解码的 \'hh 字节, 双字节编码需要两个字节一起解码
+
+	out      strings.Builder
+	inRow    bool
+	cell     strings.Builder
+	cells    []string
+	rowCount int
+}
+
+func (p *rtfParser) parse() {
+	// 紧跟 \uN 之后需要跳过的替代字符数
+	skipChars := 0
+	for p.pos < len(p.data) {
+		ch := p.data[p.pos]
+		switch ch {
+		case '{':
+			p.flush()
+			p.stack = append(p.stack, rtfState{skip: p.skip, uc: p.uc})
+			p.pos++
+		case '}':
+			p.flush()
+			if n := len(p.stack); n > 0 {
+				p.skip, p.uc = p.stack[n-1].skip, p.stack[n-1].uc
+				p.stack = p.stack[:n-1]
+			}
+			p.pos++
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.data) {
+				return
+			}
+			next := p.data[p.pos]
+			switch {
+			case next == '\'':
+				if p.pos+2 < len(p.data) {
+					b, err := strconv.ParseUint(string(p.data[p.pos+1:p.pos+3]), 16, 8)
+					p.pos += 3
+					if err != nil || p.skip {
+						continue
+					}
+					if skipChars > 0 {
+						skipChars--
+						continue
+					}
+					p.pending = append(p.pending, byte(b))
+				} else {
+					p.pos = len(p.data)
+				}
+			case next == '*':
+				p.skip = true
+				p.pos++
+			case next == '\\' || next == '{' || next == '}':
+				p.text(string(next))
+				p.pos++
+			case next == '~':
+				p.text(" ")
+				p.pos++
+			case next == '_':
+				p.text("-")
+				p.pos++
+			case next == '\n' || next == '\r':
+				p.control("par", 0, false)
+				p.pos++
+			case isASCIILetter(next):
+				word, param, hasParam := p.readControlWord()
+				if word == "u" && hasParam {
+					if param < 0 {
+						param += 65536
+					}
+					p.flush()
+					if !p.skip {
+						p.text(string(rune(param)))
+					}
+					skipChars = p.uc
+					continue
+				}
+				p.control(word, param, hasParam)
+			default:
+				p.pos++
+			}
+		case '\r', '\n':
+			p.pos++
+		default:
+			if skipChars > 0 {
+				skipChars--
+				p.pos++
+				continue
+			}
+			start := p.pos
+			for p.pos < len(p.data) && !strings.ContainsRune("{}\\\r\n", rune(p.data[p.pos])) {
+				p.pos++
+			}
+			if !p.skip {
+				p.flush()
+				p.text(string(p.data[start:p.pos]))
+			}
+		}
+	}
+	p.flush()
+	p.endTable()
+}
+
+func (p *rtfParser) readControlWord() (string, int, bool) {
+	start := p.pos
+	for p.pos < len(p.data) && isASCIILetter(p.data[p.pos]) {
+		p.pos++
+	}
+	word := string(p.data[start:p.pos])
+	numStart := p.pos
+	if p.pos < len(p.data) && p.data[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	param, err := strconv.Atoi(string(p.data[numStart:p.pos]))
+	hasParam := err == nil
+	// 控制字后的一个空格是分隔符
+	if p.pos < len(p.data) && p.data[p.pos] == ' ' {
+		p.pos++
+	}
+	return word, param, hasParam
+}
+
+func (p *rtfParser) control(word string, param int, hasParam bool) {
+	if rtfSkipDestinations[word] {
+		p.skip = true
+		return
+	}
+	switch word {
+	case "ansicpg":
+		p.encoding = codePageEncoding(param)
+	case "uc":
+		if hasParam {
+			p.uc = param
+		}
+	}
+	if p.skip {
+		return
+	}
+	p.flush()
+	switch word {
+	case "par", "line":
+		if p.inRow {
+			p.cell.WriteString(" ")
+		} else {
+			p.text("\n")
+		}
+	case "sect", "page":
+		p.text("\n\n")
+	case "tab":
+		p.text("\t")
+	case "intbl":
+		p.inRow = true
+	case "cell":
+		p.cells = append(p.cells, strings.TrimSpace(p.cell.String()))
+		p.cell.Reset()
+	case "row":
+		p.writeRow()
+	case "emdash":
+		p.text("—")
+	case "endash":
+		p.text("–")
+	case "bullet":
+		p.text("•")
+	case "lquote":
+		p.text("‘")
+	case "rquote":
+		p.text("’")
+	case "ldblquote":
+		p.text("“")
+	case "rdblquote":
+		p.text("”")
+	}
+}
+
+// text 输出文本, 表格行内的文本写入当前单元格
+func (p *rtfParser) text(s string) {
+	if p.skip || s == "" {
+		return
+	}
+	if p.inRow {
+		p.cell.WriteString(s)
+		return
+	}
+	if p.rowCount > 0 && strings.TrimSpace(s) != "" {
+		p.endTable()
+	}
+	p.out.WriteString(s)
+}
+
+// flush 解码累积的 \'hh 字节
+func (p *rtfParser) flush() {
+	if len(p.pending) == 0 {
+		return
+	}
+	data := p.pending
+	p.pending = nil
+	decoded, err := p.encoding.NewDecoder().Bytes(data)
+	if err != nil {
+		decoded = data
+	}
+	p.text(string(decoded))
+}
+
+func (p *rtfParser) writeRow() {
+	if len(p.cells) == 0 && p.cell.Len() > 0 {
+		p.cells = append(p.cells, strings.TrimSpace(p.cell.String()))
+	}
+	cells := p.cells
+	p.cells, p.inRow = nil, false
+	p.cell.Reset()
+	if len(cells) == 0 {
+		return
+	}
+	if p.rowCount == 0 {
+		p.out.WriteString("\n")
+	}
+	p.out.WriteString("|")
+	for _, cell := range cells {
+		p.out.WriteString(" ")
+		p.out.WriteString(escapeTableCell(cell))
+		p.out.WriteString(" |")
+	}
+	p.out.WriteString("\n")
+	if p.rowCount == 0 {
+		p.out.WriteString("|")
+		p.out.WriteString(strings.Repeat(" --- |", len(cells)))
+		p.out.WriteString("\n")
+	}
+	p.rowCount++
+}
+
+func (p *rtfParser) endTable() {
+	if p.rowCount > 0 {
+		p.rowCount = 0
+		p.out.WriteString("\n")
+	}
+}
+
+func isASCIILetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// codePageEncoding \ansicpg 对应的字符编码
+func codePageEncoding(cp int) encoding.Encoding {
+	switch cp {
+	case 936:
+		return simplifiedchinese.GBK
+	case 54936:
+		return simplifiedchinese.GB18030
+	case 950:
+		return traditionalchinese.Big5
+	case 1250:
+		return charmap.Windows1250
+	case 1251:
+		return charmap.Windows1251
+	default:
+		return charmap.Windows1252
+	}
+}
@@ -0,0 +1,68 @@
+package document
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// TextConverter 纯文本和 Markdown 转换器, 统一换行符并转换为 UTF-8
+type TextConverter struct {
+	opts *ConvertOptions
+}
+
+// NewTextConverter 创建纯文本转换器
+func NewTextConverter(opts *ConvertOptions) *TextConverter {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+	return &TextConverter{
+		opts: opts,
+	}
+}
+
+// ToMarkdown 将纯文本转换为 Markdown
+func (c *TextConverter) ToMarkdown(input io.Reader) (string, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	text := decodeText(data)
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	return strings.TrimSpace(CleanMarkdown(text)) + "\n", nil
+}
+
+// ToDocument 将纯文本转换为结构化文档, 由 Markdown 输出解析得到
+func (c *TextConverter) ToDocument(input io.Reader) (*Document, error) {
+	markdown, err := c.ToMarkdown(input)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMarkdown(markdown), nil
+}
+
+// ToMarkdownFile 将纯文本文件转换为 Markdown 文件
+func (c *TextConverter) ToMarkdownFile(inputPath, outputPath string) error {
+	return writeMarkdownFile(c, inputPath, outputPath)
+}
+
+// SupportedTypes 返回支持的文档类型
+func (c *TextConverter) SupportedTypes() []DocumentType {
+	return []DocumentType{TypeText}
+}
+
+// toUTF8 非 UTF-8 的内容按 GB18030 解码, 兼容 Windows 下保存的中文文本
+func toUTF8(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(data)
+	if err != nil {
+		return strings.ToValidUTF8(string(data), "�")
+	}
+	return string(decoded)
+}
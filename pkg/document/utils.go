@@ -1,62 +1,30 @@
 package document
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
-// BatchConvert 批量转换文档
+// BatchConvert 批量转换文档, 打印每个文件的转换结果, 单个文件失败时继续处理其他文件.
+// 需要并发、超时、跳过未变化文件或转换报告时使用 BatchConverter
 func BatchConvert(inputDir, outputDir string, opts *ConvertOptions) error {
-	if opts == nil {
-		opts = DefaultConvertOptions()
-	}
-
-	// 确保输出目录存在
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
-	}
-
-	// 遍历输入目录
-	return filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		// 检查是否是支持的文档类型
-		ext := strings.ToLower(filepath.Ext(path))
-		if ext != ".pdf" && ext != ".docx" && ext != ".doc" {
-			return nil
-		}
-
-		// 计算相对路径
-		relPath, err := filepath.Rel(inputDir, path)
-		if err != nil {
-			return fmt.Errorf("计算相对路径失败: %w", err)
-		}
-
-		// 生成输出文件路径
-		outputPath := filepath.Join(outputDir, strings.TrimSuffix(relPath, ext)+".md")
-
-		// 确保输出文件的目录存在
-		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-			return fmt.Errorf("创建输出子目录失败: %w", err)
-		}
-
-		// 转换文档
-		fmt.Printf("正在转换: %s -> %s\n", path, outputPath)
-		if err := Convert(path, outputPath, opts); err != nil {
-			fmt.Printf("转换失败: %s, 错误: %v\n", path, err)
-			return nil // 继续处理其他文件
-		}
-
-		return nil
+	converter := NewBatchConverter(&BatchOptions{
+		Convert: opts,
+		Progress: func(p BatchProgress) {
+			switch p.Current.Status {
+			case StatusConverted:
+				fmt.Printf("[%d/%d] 转换完成: %s -> %s\n", p.Done, p.Total, p.Current.Input, p.Current.Output)
+			case StatusSkipped:
+				fmt.Printf("[%d/%d] 未变化, 跳过: %s\n", p.Done, p.Total, p.Current.Input)
+			case StatusFailed:
+				fmt.Printf("[%d/%d] 转换失败: %s, 错误: %s\n", p.Done, p.Total, p.Current.Input, p.Current.Error)
+			}
+		},
 	})
+	_, err := converter.Run(context.Background(), inputDir, outputDir)
+	return err
 }
 
 // ValidateFile 验证文件是否可以转换
@@ -81,7 +49,7 @@ func ValidateFile(filePath string) error {
 	}
 
 	// 检查文件类型
-	_, err = DetectDocumentType(filePath)
+	_, _, err = defaultRegistry.DetectFile(filePath)
 	if err != nil {
 		return err
 	}
@@ -139,6 +107,8 @@ func CleanMarkdown(markdown string) string {
 }
 
 // SplitMarkdownByHeadings 按标题分割 Markdown
+//
+// Deprecated: 没有大小控制, 用于检索时使用 Chunker
 func SplitMarkdownByHeadings(markdown string) []map[string]string {
 	lines := strings.Split(markdown, "\n")
 	var sections []map[string]string
@@ -183,7 +153,7 @@ func SplitMarkdownByHeadings(markdown string) []map[string]string {
 	return sections
 }
 
-// EstimateConversionTime 估算转换时间（毫秒）
+// EstimateConversionTime 按文件大小估算转换时间（毫秒）, 有转换历史时使用 BatchConverter.Estimate
 func EstimateConversionTime(filePath string) (int64, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"baliance.com/gooxml/document"
@@ -27,57 +26,66 @@ func NewWordConverter(opts *ConvertOptions) *WordConverter {
 
 // ToMarkdown 将 Word 文档转换为 Markdown
 func (c *WordConverter) ToMarkdown(input io.Reader) (string, error) {
+	doc, err := c.ToDocument(input)
+	if err != nil {
+		return "", err
+	}
+	return doc.render(c.opts.IncludeMetadata), nil
+}
+
+// ToDocument 将 Word 文档转换为结构化文档
+func (c *WordConverter) ToDocument(input io.Reader) (*Document, error) {
 	// gooxml 需要文件路径，所以需要创建临时文件
 	tmpFile, err := os.CreateTemp("", "word-*.docx")
 	if err != nil {
-		return "", fmt.Errorf("创建临时文件失败: %w", err)
+		return nil, fmt.Errorf("创建临时文件失败: %w", err)
 	}
 	defer os.Remove(tmpFile.Name())
 	defer tmpFile.Close()
 
 	// 复制内容到临时文件
 	if _, err := io.Copy(tmpFile, input); err != nil {
-		return "", fmt.Errorf("写入临时文件失败: %w", err)
+		return nil, fmt.Errorf("写入临时文件失败: %w", err)
 	}
 	tmpFile.Close()
 
-	return c.extractTextFromWord(tmpFile.Name())
+	return c.readDocument(tmpFile.Name())
 }
 
 // ToMarkdownFile 将 Word 文件转换为 Markdown 文件
 func (c *WordConverter) ToMarkdownFile(inputPath, outputPath string) error {
-	markdown, err := c.extractTextFromWord(inputPath)
+	doc, err := c.readDocument(inputPath)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(outputPath, []byte(markdown), 0o644)
+	return os.WriteFile(outputPath, []byte(doc.render(c.opts.IncludeMetadata)), 0o644)
 }
 
 // SupportedTypes 返回支持的文档类型
 func (c *WordConverter) SupportedTypes() []DocumentType {
-	return []DocumentType{TypeWord, TypeDOC}
+	return []DocumentType{TypeWord}
 }
 
-// extractTextFromWord 从 Word 文档提取文本并转换为 Markdown
-func (c *WordConverter) extractTextFromWord(docPath string) (string, error) {
-	// 检查文件扩展名
-	ext := strings.ToLower(filepath.Ext(docPath))
-	if ext != ".docx" {
-		return "", fmt.Errorf("仅支持 .docx 格式，不支持旧的 .doc 格式")
+// readDocument 读取 Word 文档的段落和表格
+func (c *WordConverter) readDocument(docPath string) (*Document, error) {
+	// 按文件内容检查格式, 旧的 .doc 为 OLE 复合文档
+	if _, mime, err := defaultRegistry.DetectFile(docPath); mime != MIMEDocx {
+		if err == nil {
+			err = fmt.Errorf("不是 .docx 文档: %s", mime)
+		}
+		return nil, err
 	}
 
-	doc, err := document.Open(docPath)
+	wordDoc, err := document.Open(docPath)
 	if err != nil {
-		return "", fmt.Errorf("打开 Word 文档失败: %w", err)
+		return nil, fmt.Errorf("打开 Word 文档失败: %w", err)
 	}
-	//defer doc.Close()
 
-	var markdown strings.Builder
-	markdown.WriteString("# Word 文档\n\n")
+	doc := &Document{}
 
 	// 提取段落
-	for _, para := range doc.Paragraphs() {
+	for _, para := range wordDoc.Paragraphs() {
 		text := c.extractParagraphText(para)
 		if strings.TrimSpace(text) == "" {
 			continue
@@ -86,32 +94,31 @@ func (c *WordConverter) extractTextFromWord(docPath string) (string, error) {
 		// 根据样式判断是否是标题
 		style := para.Style()
 		if style != "" && strings.Contains(strings.ToLower(style), "heading") {
-			// 提取标题级别
-			level := c.extractHeadingLevel(style)
-			markdown.WriteString(strings.Repeat("#", level))
-			markdown.WriteString(" ")
-			markdown.WriteString(text)
-			markdown.WriteString("\n\n")
+			doc.Append(&Node{Kind: NodeHeading, Level: c.extractHeadingLevel(style), Text: text})
 		} else {
-			markdown.WriteString(text)
-			markdown.WriteString("\n\n")
+			doc.Append(&Node{Kind: NodeParagraph, Text: text})
 		}
 	}
 
 	// 提取表格
 	if c.opts.ExtractTables {
-		tables := doc.Tables()
+		tables := wordDoc.Tables()
 		if len(tables) > 0 {
-			markdown.WriteString("\n## 表格\n\n")
+			doc.Append(&Node{Kind: NodeHeading, Level: 2, Text: "表格"})
 			for i, table := range tables {
-				markdown.WriteString(fmt.Sprintf("### 表格 %d\n\n", i+1))
-				markdown.WriteString(c.extractTable(table))
-				markdown.WriteString("\n\n")
+				rows := c.extractTable(table)
+				if len(rows) == 0 {
+					continue
+				}
+				doc.Append(
+					&Node{Kind: NodeHeading, Level: 3, Text: fmt.Sprintf("表格 %d", i+1)},
+					&Node{Kind: NodeTable, Rows: rows},
+				)
 			}
 		}
 	}
 
-	return markdown.String(), nil
+	return doc, nil
 }
 
 // extractParagraphText 提取段落文本，保留格式
@@ -193,52 +200,17 @@ func (c *WordConverter) extractHeadingLevel(styleName string) int {
 	return 2 // 默认为二级标题
 }
 
-// extractTable 提取表格为 Markdown
-func (c *WordConverter) extractTable(table document.Table) string {
-	rows := table.Rows()
-	if len(rows) == 0 {
-		return ""
-	}
-
-	var markdown strings.Builder
-
-	// 提取第一行作为表头
-	headerRow := rows[0]
-	cells := headerRow.Cells()
-
-	// 表头
-	markdown.WriteString("|")
-	for _, cell := range cells {
-		cellText := c.extractCellText(cell)
-		markdown.WriteString(" ")
-		markdown.WriteString(cellText)
-		markdown.WriteString(" |")
-	}
-	markdown.WriteString("\n")
-
-	// 分隔符
-	markdown.WriteString("|")
-	for range cells {
-		markdown.WriteString(" --- |")
-	}
-	markdown.WriteString("\n")
-
-	// 数据行
-	for i := 1; i < len(rows); i++ {
-		row := rows[i]
-		cells := row.Cells()
-
-		markdown.WriteString("|")
-		for _, cell := range cells {
-			cellText := c.extractCellText(cell)
-			markdown.WriteString(" ")
-			markdown.WriteString(cellText)
-			markdown.WriteString(" |")
+// extractTable 提取表格内容, 第一行作为表头
+func (c *WordConverter) extractTable(table document.Table) [][]string {
+	var rows [][]string
+	for _, row := range table.Rows() {
+		var cells []string
+		for _, cell := range row.Cells() {
+			cells = append(cells, c.extractCellText(cell))
 		}
-		markdown.WriteString("\n")
+		rows = append(rows, cells)
 	}
-
-	return markdown.String()
+	return rows
 }
 
 // extractCellText 提取单元格文本
@@ -0,0 +1,364 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// XLSXConverter Excel 转换器, 每个工作表输出为一个 Markdown 表格
+type XLSXConverter struct {
+	opts *ConvertOptions
+}
+
+// NewXLSXConverter 创建 Excel 转换器
+func NewXLSXConverter(opts *ConvertOptions) *XLSXConverter {
+	if opts == nil {
+		opts = DefaultConvertOptions()
+	}
+	return &XLSXConverter{
+		opts: opts,
+	}
+}
+
+// ToMarkdown 将 Excel 转换为 Markdown
+func (c *XLSXConverter) ToMarkdown(input io.Reader) (string, error) {
+	doc, err := c.ToDocument(input)
+	if err != nil {
+		return "", err
+	}
+	return doc.render(c.opts.IncludeMetadata), nil
+}
+
+// ToDocument 将 Excel 转换为结构化文档, 每个工作表为一个标题和一个表格
+func (c *XLSXConverter) ToDocument(input io.Reader) (*Document, error) {
+	zr, err := openZip(input)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedStrings, err := c.readSharedStrings(zr)
+	if err != nil {
+		return nil, err
+	}
+	sheets, err := c.readSheets(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+	for _, sheet := range sheets {
+		rows, err := c.readSheet(zr, sheet.path, sharedStrings)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		doc.Append(
+			&Node{Kind: NodeHeading, Level: 2, Text: sheet.name},
+			&Node{Kind: NodeTable, Rows: rows},
+		)
+	}
+
+	return doc, nil
+}
+
+// ToMarkdownFile 将 Excel 文件转换为 Markdown 文件
+func (c *XLSXConverter) ToMarkdownFile(inputPath, outputPath string) error {
+	return writeMarkdownFile(c, inputPath, outputPath)
+}
+
+// SupportedTypes 返回支持的文档类型
+func (c *XLSXConverter) SupportedTypes() []DocumentType {
+	return []DocumentType{TypeXLSX}
+}
+
+type xlsxSheet struct {
+	name string
+	path string
+}
+
+// readSheets 按工作簿中的顺序读取工作表名称和路径
+func (c *XLSXConverter) readSheets(zr *zip.Reader) ([]xlsxSheet, error) {
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := readZipXML(zr, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	rels, err := readRelationships(zr, "xl/_rels/workbook.xml.rels", "xl")
+	if err != nil {
+		return nil, err
+	}
+	sheets := make([]xlsxSheet, 0, len(workbook.Sheets))
+	for _, s := range workbook.Sheets {
+		target, ok := rels[s.ID]
+		if !ok {
+			continue
+		}
+		sheets = append(sheets, xlsxSheet{name: s.Name, path: target})
+	}
+	return sheets, nil
+}
+
+// readSharedStrings 读取共享字符串表, 富文本单元格由多个 r/t 组成
+func (c *XLSXConverter) readSharedStrings(zr *zip.Reader) ([]string, error) {
+	var sst struct {
+		Items []struct {
+			T    string   `xml:"t"`
+			Runs []string `xml:"r>t"`
+		} `xml:"si"`
+	}
+	if findZipFile(zr, "xl/sharedStrings.xml") == nil {
+		return nil, nil
+	}
+	if err := readZipXML(zr, "xl/sharedStrings.xml", &sst); err != nil {
+		return nil, err
+	}
+	values := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		values[i] = item.T + strings.Join(item.Runs, "")
+	}
+	return values, nil
+}
+
+// readSheet 读取工作表为二维数组, 按单元格引用定位, 空行和空列被保留为空字符串
+func (c *XLSXConverter) readSheet(zr *zip.Reader, name string, sharedStrings []string) ([][]string, error) {
+	var sheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline string   `xml:"is>t"`
+				Runs   []string `xml:"is>r>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := readZipXML(zr, name, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, row := range sheet.Rows {
+		rowIndex := row.R - 1
+		if rowIndex < 0 {
+			rowIndex = i
+		}
+		for len(rows) <= rowIndex {
+			rows = append(rows, nil)
+		}
+		for j, cell := range row.Cells {
+			col := j
+			if cell.Ref != "" {
+				if n, ok := columnIndex(cell.Ref); ok {
+					col = n
+				}
+			}
+			var value string
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+				if err == nil && idx >= 0 && idx < len(sharedStrings) {
+					value = sharedStrings[idx]
+				}
+			case "inlineStr":
+				value = cell.Inline + strings.Join(cell.Runs, "")
+			case "b":
+				if cell.Value == "1" {
+					value = "TRUE"
+				} else {
+					value = "FALSE"
+				}
+			default:
+				value = cell.Value
+			}
+			for len(rows[rowIndex]) <= col {
+				rows[rowIndex] = append(rows[rowIndex], "")
+			}
+			rows[rowIndex][col] = value
+		}
+	}
+	return trimEmptyRows(rows), nil
+}
+
+// columnIndex 单元格引用的列号, 如 B3 -> 1
+func columnIndex(ref string) (int, bool) {
+	n := 0
+	i := 0
+	for ; i < len(ref); i++ {
+		ch := ref[i]
+		if ch >= 'a' && ch <= 'z' {
+			ch -= 'a' - 'A'
+		}
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		n = n*26 + int(ch-'A'+1)
+	}
+	if i == 0 {
+		return 0, false
+	}
+	return n - 1, true
+}
+
+// trimEmptyRows 去掉首尾的空行
+func trimEmptyRows(rows [][]string) [][]string {
+	isEmpty := func(row []string) bool {
+		for _, v := range row {
+			if strings.TrimSpace(v) != "" {
+				return false
+			}
+		}
+		return true
+	}
+	for len(rows) > 0 && isEmpty(rows[0]) {
+		rows = rows[1:]
+	}
+	for len(rows) > 0 && isEmpty(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
+
+// markdownTable 二维数组转换为 Markdown 表格, 第一行作为表头
+func markdownTable(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	if width == 0 {
+		return ""
+	}
+
+	var markdown strings.Builder
+	writeRow := func(row []string) {
+		markdown.WriteString("|")
+		for i := 0; i < width; i++ {
+			var cell string
+			if i < len(row) {
+				cell = escapeTableCell(row[i])
+			}
+			markdown.WriteString(" ")
+			markdown.WriteString(cell)
+			markdown.WriteString(" |")
+		}
+		markdown.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	markdown.WriteString("|")
+	for i := 0; i < width; i++ {
+		markdown.WriteString(" --- |")
+	}
+	markdown.WriteString("\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return markdown.String()
+}
+
+// escapeTableCell 转义表格单元格中的竖线和换行
+func escapeTableCell(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	s = strings.ReplaceAll(s, "\n", "<br>")
+	return s
+}
+
+// openZip 读取全部内容并打开 zip
+func openZip(input io.Reader) (*zip.Reader, error) {
+	if ra, ok := input.(interface {
+		io.ReaderAt
+		Stat() (os.FileInfo, error)
+	}); ok {
+		if info, err := ra.Stat(); err == nil {
+			return zip.NewReader(ra, info.Size())
+		}
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("打开压缩文件失败: %w", err)
+	}
+	return zr, nil
+}
+
+func findZipFile(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// readZipFile 读取 zip 中的文件
+func readZipFile(zr *zip.Reader, name string) ([]byte, error) {
+	f := findZipFile(zr, name)
+	if f == nil {
+		return nil, fmt.Errorf("文件中缺少 %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// readZipXML 读取并解析 zip 中的 xml 文件
+func readZipXML(zr *zip.Reader, name string, v interface{}) error {
+	data, err := readZipFile(zr, name)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析 %s 失败: %w", name, err)
+	}
+	return nil
+}
+
+// readRelationships 读取 Office Open XML 关系文件, 返回 关系ID -> zip 内的路径
+func readRelationships(zr *zip.Reader, name, baseDir string) (map[string]string, error) {
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := readZipXML(zr, name, &rels); err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(rels.Items))
+	for _, rel := range rels.Items {
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join(baseDir, target)
+		}
+		result[rel.ID] = target
+	}
+	return result, nil
+}
@@ -0,0 +1,442 @@
+package hertzx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/xiehqing/common/pkg/hertzx/middleware"
+	"github.com/xiehqing/common/pkg/ormx"
+	"github.com/xiehqing/common/pkg/resp"
+	"github.com/xiehqing/common/pkg/util"
+	"github.com/xiehqing/common/pkg/validate"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 绑定相关的结构体标签
+//
+//	path:"id"          路径参数
+//	query:"pageNo"     查询参数
+//	header:"X-Token"   请求头
+//	form:"name"        表单参数(含 multipart)
+//	json:"name"        JSON 请求体, 请求体整体反序列化
+//	default:"10"       参数缺失时的默认值
+//	layout:"2006-01-02" 时间类型的解析格式, 默认 2006-01-02 15:04:05
+//	validate:"required,min=1,max=100,enum=asc|desc"
+//	validate:"max=64,regex=^\w+$"  regex 规则必须放在最后, 正则表达式中可以包含逗号
+const (
+	tagPath     = "path"
+	tagQuery    = "query"
+	tagHeader   = "header"
+	tagForm     = "form"
+	tagJSON     = "json"
+	tagDefault  = "default"
+	tagLayout   = "layout"
+	tagValidate = "validate"
+
+	defaultTimeLayout = "2006-01-02 15:04:05"
+)
+
+// FieldError 字段错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors 字段错误集合
+type FieldErrors []FieldError
+
+func (fe FieldErrors) Error() string {
+	var sb strings.Builder
+	for i, e := range fe {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(e.Field)
+		sb.WriteString(": ")
+		sb.WriteString(e.Message)
+	}
+	return sb.String()
+}
+
+// toStatusError 转换为 BAD_REQUEST 错误, 各字段错误放入 Extra, 同一字段的多个错误使用 "; " 连接
+func (fe FieldErrors) toStatusError() *resp.StatusError {
+	se := resp.ErrBadRequest.New().WithMessage("请求参数错误: " + fe.Error())
+	for field, msgs := range fe.ByField() {
+		se.WithExtra(field, strings.Join(msgs, "; "))
+	}
+	return se
+}
+
+// ByField 按字段分组的错误信息
+func (fe FieldErrors) ByField() map[string][]string {
+	m := make(map[string][]string, len(fe))
+	for _, e := range fe {
+		m[e.Field] = append(m[e.Field], e.Message)
+	}
+	return m
+}
+
+// Bind 根据结构体标签从路径、查询参数、请求头、表单与JSON请求体中绑定参数并校验
+// 返回的错误为 *resp.StatusError, 包含所有字段的错误信息
+func Bind[T any](c *app.RequestContext) (*T, error) {
+	t := new(T)
+	if err := BindTo(c, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// MustBind 绑定参数, 失败时直接输出错误响应并返回 false
+func MustBind[T any](ctx context.Context, c *app.RequestContext) (*T, bool) {
+	t, err := Bind[T](c)
+	if err != nil {
+		middleware.WriteError(ctx, c, err)
+		return nil, false
+	}
+	return t, true
+}
+
+// BindTo 绑定参数到指定结构体指针
+func BindTo(c *app.RequestContext, ptr interface{}) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return resp.ErrInternal.New().WithMessage("绑定目标必须为结构体指针")
+	}
+	fields, err := getStructFields(rv.Elem().Type())
+	if err != nil {
+		return resp.ErrInternal.Wrap(err).WithMessage("绑定目标定义错误")
+	}
+	var errs FieldErrors
+	body := c.Request.Body()
+	if len(body) > 0 && strings.Contains(string(c.ContentType()), "json") {
+		if err := json.Unmarshal(body, ptr); err != nil {
+			errs = append(errs, FieldError{Field: "body", Message: fmt.Sprintf("JSON格式错误: %v", err)})
+			return errs.toStatusError()
+		}
+	}
+	b := &binder{c: c}
+	for _, f := range fields {
+		errs = append(errs, b.bindField(rv.Elem(), f)...)
+	}
+	if len(errs) > 0 {
+		return errs.toStatusError()
+	}
+	return nil
+}
+
+type binder struct {
+	c *app.RequestContext
+}
+
+// bindField 绑定并校验单个字段
+func (b *binder) bindField(root reflect.Value, f *fieldInfo) FieldErrors {
+	fv := fieldByIndex(root, f.index)
+	values, present := b.lookup(f)
+	if present && isBlank(values) && !isStringType(f.typ) {
+		// ?pageNo= 这类空参数对非字符串字段视为缺失
+		present = false
+	}
+	if !present && f.defaultValue != "" && fv.IsZero() {
+		values, present = []string{f.defaultValue}, true
+	}
+	if present && len(values) > 0 {
+		if err := setValue(fv, values, f.layout); err != nil {
+			return FieldErrors{{Field: f.name, Message: err.Error()}}
+		}
+	}
+	return validateField(f, fv)
+}
+
+// fieldByIndex 与 reflect.Value.FieldByIndex 相同, 途经为 nil 的嵌入结构体指针时先分配
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// lookup 按 path、query、header、form 的顺序查找参数
+func (b *binder) lookup(f *fieldInfo) ([]string, bool) {
+	if f.path != "" {
+		if v, ok := b.c.Params.Get(f.path); ok {
+			return []string{v}, true
+		}
+	}
+	if f.query != "" {
+		var vs []string
+		b.c.QueryArgs().VisitAll(func(key, value []byte) {
+			if string(key) == f.query {
+				vs = append(vs, string(value))
+			}
+		})
+		if len(vs) > 0 {
+			return vs, true
+		}
+	}
+	if f.header != "" {
+		if v := b.c.GetHeader(f.header); len(v) > 0 {
+			return []string{string(v)}, true
+		}
+	}
+	if f.form != "" {
+		var vs []string
+		b.c.PostArgs().VisitAll(func(key, value []byte) {
+			if string(key) == f.form {
+				vs = append(vs, string(value))
+			}
+		})
+		if len(vs) == 0 {
+			if mf, err := b.c.MultipartForm(); err == nil && mf != nil {
+				vs = mf.Value[f.form]
+			}
+		}
+		if len(vs) > 0 {
+			return vs, true
+		}
+	}
+	return nil, false
+}
+
+// setValue 将字符串参数转换并设置到字段
+func setValue(fv reflect.Value, values []string, layout string) error {
+	if fv.Kind() == reflect.Ptr {
+		nv := reflect.New(fv.Type().Elem())
+		if err := setValue(nv.Elem(), values, layout); err != nil {
+			return err
+		}
+		fv.Set(nv)
+		return nil
+	}
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		// 支持重复参数与逗号分隔两种写法
+		var items []string
+		for _, v := range values {
+			items = append(items, strings.Split(v, ",")...)
+		}
+		slice := reflect.MakeSlice(fv.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setValue(slice.Index(i), []string{strings.TrimSpace(item)}, layout); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return setScalar(fv, values[0], layout)
+}
+
+func setScalar(fv reflect.Value, s string, layout string) error {
+	if fv.Type() == reflect.TypeOf(time.Time{}) {
+		if layout == "" {
+			layout = defaultTimeLayout
+		}
+		t, err := util.ParseTime(layout, s)
+		if err != nil {
+			return fmt.Errorf("时间格式错误, 格式应为 %s", layout)
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("必须为布尔值")
+		}
+		fv.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("时长格式错误")
+			}
+			fv.SetInt(int64(d))
+			return nil
+		}
+		v, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("必须为整数")
+		}
+		fv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("必须为非负整数")
+		}
+		fv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("必须为数字")
+		}
+		fv.SetFloat(v)
+	default:
+		return fmt.Errorf("不支持的参数类型 %s", fv.Type())
+	}
+	return nil
+}
+
+// fieldInfo 结构体字段的绑定信息
+type fieldInfo struct {
+	index        []int
+	name         string
+	jsonName     string
+	typ          reflect.Type
+	path         string
+	query        string
+	header       string
+	form         string
+	defaultValue string
+	layout       string
+	rules        []validate.Rule
+}
+
+// structFields 结构体的字段绑定信息, 标签解析失败时记录错误
+type structFields struct {
+	fields []*fieldInfo
+	err    error
+}
+
+var fieldCache sync.Map // map[reflect.Type]*structFields
+
+// getStructFields 解析结构体字段, 匿名嵌入的结构体与结构体指针字段会被展开,
+// 嵌入的结构体指针为 nil 时绑定前自动分配
+func getStructFields(t reflect.Type) ([]*fieldInfo, error) {
+	if v, ok := fieldCache.Load(t); ok {
+		sf := v.(*structFields)
+		return sf.fields, sf.err
+	}
+	fields, err := collectFields(t, nil, nil)
+	fieldCache.Store(t, &structFields{fields: fields, err: err})
+	return fields, err
+}
+
+func collectFields(t reflect.Type, parent []int, visiting []reflect.Type) ([]*fieldInfo, error) {
+	for _, v := range visiting {
+		if v == t {
+			return nil, fmt.Errorf("%s 循环嵌入了自身", t.Name())
+		}
+	}
+	visiting = append(visiting, t)
+	var fields []*fieldInfo
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+		if sf.Anonymous {
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr && embedded.Elem().Kind() == reflect.Struct {
+				// 未导出类型的指针无法分配, 其中的字段也就无法绑定
+				if !sf.IsExported() {
+					return nil, fmt.Errorf("%s 嵌入的 %s 为未导出类型的指针, 无法绑定", t.Name(), embedded)
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				embeddedFields, err := collectFields(embedded, index, visiting)
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, embeddedFields...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		rules, err := validate.Parse(sf.Tag.Get(tagValidate))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s 的 validate 标签错误: %v", t.Name(), sf.Name, err)
+		}
+		f := &fieldInfo{
+			index:        index,
+			typ:          sf.Type,
+			path:         tagName(sf, tagPath),
+			query:        tagName(sf, tagQuery),
+			header:       tagName(sf, tagHeader),
+			form:         tagName(sf, tagForm),
+			defaultValue: sf.Tag.Get(tagDefault),
+			layout:       sf.Tag.Get(tagLayout),
+			rules:        rules,
+		}
+		if sf.Tag.Get(tagJSON) != "-" {
+			f.jsonName = firstNonEmpty(tagName(sf, tagJSON), sf.Name)
+		}
+		f.name = firstNonEmpty(f.path, f.query, f.header, f.form, tagName(sf, tagJSON), sf.Name)
+		if f.path == "" && f.query == "" && f.header == "" && f.form == "" && len(f.rules) == 0 && f.defaultValue == "" {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// isBlank 参数值全部为空
+func isBlank(values []string) bool {
+	for _, v := range values {
+		if v != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// isStringType 字段是否为字符串或字符串指针、切片
+func isStringType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String
+}
+
+func tagName(sf reflect.StructField, key string) string {
+	name := strings.Split(sf.Tag.Get(key), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// PageParam 可嵌入的分页参数
+type PageParam struct {
+	Keyword   string `json:"keyword" query:"keyword"`
+	PageNo    int    `json:"pageNo" query:"pageNo" default:"1"`
+	PageSize  int    `json:"pageSize" query:"pageSize" default:"10"`
+	SortField string `json:"sortField" query:"sortField" default:"updated_at" validate:"regex=^[A-Za-z_][A-Za-z0-9_.]*$"`
+	SortOrder string `json:"sortOrder" query:"sortOrder" default:"desc"`
+}
+
+// Pageable 转换为分页对象, 页码、页大小不大于 0 时使用默认值,
+// 排序字段为空时按 updated_at, 排序方向不合法时按 desc 处理
+func (p PageParam) Pageable() ormx.Pageable {
+	sortField := p.SortField
+	if sortField == "" {
+		sortField = "updated_at"
+	}
+	sortOrder := strings.ToLower(p.SortOrder)
+	if sortOrder != "asc" && sortOrder != "desc" {
+		sortOrder = "desc"
+	}
+	return ormx.PageRequest(p.PageNo, p.PageSize, sortField, sortOrder)
+}
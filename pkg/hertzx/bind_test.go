@@ -0,0 +1,134 @@
+package hertzx
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/route/param"
+	"github.com/xiehqing/common/pkg/resp"
+)
+
+type listUserReq struct {
+	PageParam
+	TenantID int64      `path:"tenantId" validate:"required"`
+	Status   []string   `query:"status" validate:"enum=normal|locked"`
+	Start    *time.Time `query:"start" layout:"2006-01-02"`
+	Token    string     `header:"X-Token" validate:"required"`
+	Name     string     `json:"name" validate:"max=4"`
+}
+
+func TestBind(t *testing.T) {
+	c := app.NewContext(0)
+	c.Params = param.Params{{Key: "tenantId", Value: "12"}}
+	c.Request.SetRequestURI("/tenants/12/users?pageSize=20&status=normal,locked&start=2025-01-02")
+	c.Request.Header.Set("X-Token", "abc")
+	c.Request.Header.SetContentTypeBytes([]byte("application/json"))
+	c.Request.SetBodyString(`{"name":"张三"}`)
+
+	req, err := Bind[listUserReq](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.TenantID != 12 || req.PageNo != 1 || req.PageSize != 20 || req.SortOrder != "desc" {
+		t.Fatalf("unexpected bind result: %+v", req)
+	}
+	if len(req.Status) != 2 || req.Start == nil || req.Start.Day() != 2 || req.Name != "张三" {
+		t.Fatalf("unexpected bind result: %+v", req)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	c := app.NewContext(0)
+	c.Request.SetRequestURI("/users?pageNo=x&pageSize=abc&status=deleted&sortField=id;drop")
+
+	_, err := Bind[listUserReq](c)
+	se := resp.FromError(err)
+	if se == nil || se.Key() != resp.ErrBadRequest.Key {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+	for _, field := range []string{"pageNo", "pageSize", "status", "sortField", "tenantId", "X-Token"} {
+		if _, ok := se.Extra()[field]; !ok {
+			t.Errorf("expected error for field %s, got %v", field, se.Extra())
+		}
+	}
+}
+
+type PagedBase struct {
+	PageParam
+	TenantID int64 `query:"tenantId" validate:"required"`
+}
+
+type embeddedPtrReq struct {
+	*PagedBase
+	Name string `query:"name" validate:"min=2,regex=^[a-z]+$"`
+}
+
+type unexportedPtrReq struct {
+	*listUserReq
+}
+
+func TestBindEmbeddedPtr(t *testing.T) {
+	c := app.NewContext(0)
+	c.Request.SetRequestURI("/users?tenantId=3&pageSize=20&name=ab")
+
+	req, err := Bind[embeddedPtrReq](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.PagedBase == nil || req.TenantID != 3 || req.PageNo != 1 || req.PageSize != 20 || req.Name != "ab" {
+		t.Fatalf("unexpected bind result: %+v", req)
+	}
+
+	// 同一字段的多个错误都保留在 Extra 中
+	c.Request.SetRequestURI("/users?name=A")
+	_, err = Bind[embeddedPtrReq](c)
+	se := resp.FromError(err)
+	if se == nil || se.Extra()["name"] != "长度不能小于 2; 格式不正确" || se.Extra()["tenantId"] == "" {
+		t.Fatalf("unexpected error: %v, extra %v", err, se.Extra())
+	}
+
+	_, err = Bind[unexportedPtrReq](c)
+	if se := resp.FromError(err); se == nil || se.Key() != resp.ErrInternal.Key {
+		t.Fatalf("expected internal error for unexported embedded pointer, got %v", err)
+	}
+}
+
+type badRegexReq struct {
+	Name string `query:"name" validate:"regex=[a-"`
+}
+
+func TestBindLenient(t *testing.T) {
+	c := app.NewContext(0)
+	c.Request.SetRequestURI("/users?pageNo=&pageSize=5000&sortField=")
+
+	p, err := Bind[PageParam](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageable := p.Pageable()
+	if pageable.PageNo != 1 || pageable.PageSize != 5000 || pageable.Sortable.SortField != "updated_at" || pageable.Sortable.SortOrder != "desc" {
+		t.Fatalf("unexpected pageable: %+v %+v", pageable, pageable.Sortable)
+	}
+
+	c.Request.SetRequestURI("/users?pageNo=0")
+	pageable, err = ParsePageable(c)
+	if err != nil || pageable.PageNo != 1 || pageable.PageSize != 10 || pageable.Sortable.SortField != "updated_at" {
+		t.Fatalf("unexpected pageable: %+v, %v", pageable, err)
+	}
+
+	c.Request.SetRequestURI("/users?pageNo=x")
+	if _, err = ParsePageable(c); resp.FromError(err).Key() != resp.ErrBadRequest.Key {
+		t.Fatalf("expected bad request, got %v", err)
+	}
+	_, err = Bind[PageParam](c)
+	if se := resp.FromError(err); se == nil || strings.Count(se.Error(), "必须为整数") != 1 {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = Bind[badRegexReq](c)
+	if se := resp.FromError(err); se == nil || se.Key() != resp.ErrInternal.Key {
+		t.Fatalf("expected internal error for bad regex, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package hertzx
+
+import (
+	"context"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/redisx"
+	"gorm.io/gorm"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HealthCheck 健康检查函数, 返回nil表示检查通过
+type HealthCheck func(ctx context.Context) error
+
+// Pinger 可探活的客户端, 如 elasticsearch.Client
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// CheckResult 单项检查结果
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthReport 健康检查报告
+type HealthReport struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckResult `json:"checks,omitempty"`
+}
+
+const (
+	HealthStatusUp   = "UP"
+	HealthStatusDown = "DOWN"
+)
+
+// StatusCode 返回检查结果对应的HTTP状态码
+func (r *HealthReport) StatusCode() int {
+	if r.Status == HealthStatusUp {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
+
+// HealthRegistry 健康检查注册表
+type HealthRegistry struct {
+	mu       sync.RWMutex
+	checks   map[string]HealthCheck
+	timeout  time.Duration
+	shutdown atomic.Bool
+}
+
+// NewHealthRegistry 创建健康检查注册表, timeout 为单项检查超时时间
+func NewHealthRegistry(timeout time.Duration) *HealthRegistry {
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	return &HealthRegistry{
+		checks:  make(map[string]HealthCheck),
+		timeout: timeout,
+	}
+}
+
+// Register 注册检查项, 同名检查项会被覆盖
+func (hr *HealthRegistry) Register(name string, check HealthCheck) {
+	if check == nil {
+		return
+	}
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	hr.checks[name] = check
+}
+
+// Unregister 移除检查项
+func (hr *HealthRegistry) Unregister(name string) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	delete(hr.checks, name)
+}
+
+// MarkShutdown 标记服务正在关闭, 之后的检查均返回 DOWN
+func (hr *HealthRegistry) MarkShutdown() {
+	hr.shutdown.Store(true)
+}
+
+// Check 并发执行所有检查项
+func (hr *HealthRegistry) Check(ctx context.Context) *HealthReport {
+	report := &HealthReport{Status: HealthStatusUp, Checks: make(map[string]*CheckResult)}
+	if hr.shutdown.Load() {
+		report.Status = HealthStatusDown
+		report.Checks["shutdown"] = &CheckResult{Status: HealthStatusDown, Error: "server is shutting down"}
+		return report
+	}
+	hr.mu.RLock()
+	names := make([]string, 0, len(hr.checks))
+	for name := range hr.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]HealthCheck, 0, len(names))
+	for _, name := range names {
+		checks = append(checks, hr.checks[name])
+	}
+	hr.mu.RUnlock()
+
+	results := make([]*CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = hr.runCheck(ctx, checks[i])
+		}(i)
+	}
+	wg.Wait()
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != HealthStatusUp {
+			report.Status = HealthStatusDown
+		}
+	}
+	return report
+}
+
+func (hr *HealthRegistry) runCheck(ctx context.Context, check HealthCheck) (result *CheckResult) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, hr.timeout)
+	defer cancel()
+	defer func() {
+		if e := recover(); e != nil {
+			result = &CheckResult{Status: HealthStatusDown, Error: errors.Errorf("panic: %v", e).Error()}
+		}
+		result.Duration = time.Since(start).String()
+	}()
+	if err := check(ctx); err != nil {
+		return &CheckResult{Status: HealthStatusDown, Error: err.Error()}
+	}
+	return &CheckResult{Status: HealthStatusUp}
+}
+
+// Handler 健康检查处理器, 检查失败时返回 503
+func (hr *HealthRegistry) Handler() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		report := hr.Check(ctx)
+		c.JSON(report.StatusCode(), report)
+	}
+}
+
+// DBCheck 数据库连通性检查
+func DBCheck(db *gorm.DB) HealthCheck {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// RedisCheck redis连通性检查
+func RedisCheck(r redisx.Redis) HealthCheck {
+	return func(ctx context.Context) error {
+		return r.Ping(ctx).Err()
+	}
+}
+
+// PingCheck 通用探活检查, 可用于 elasticsearch.Client
+func PingCheck(p Pinger) HealthCheck {
+	return p.Ping
+}
+
+// ElasticsearchCheck elasticsearch连通性检查
+func ElasticsearchCheck(es Pinger) HealthCheck {
+	return PingCheck(es)
+}
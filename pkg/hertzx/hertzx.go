@@ -1,13 +1,19 @@
 package hertzx
 
 import (
+	"context"
 	"fmt"
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
 	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/network/standard"
 	"github.com/hertz-contrib/cors"
+	"github.com/pkg/errors"
 	"github.com/xiehqing/common/pkg/hertzx/middleware"
+	"github.com/xiehqing/common/pkg/logs"
 	"github.com/xiehqing/common/pkg/resp"
+	"github.com/xiehqing/common/pkg/tlsx"
+	"io"
 	"net/http"
 	"time"
 )
@@ -21,6 +27,59 @@ type WebConfig struct {
 	IdleTimeout         int    `json:"idleTimeout" yaml:"idle-timeout" mapstructure:"idle-timeout"`    // 空闲超时时间，默认 120s
 	ShutdownTimeout     int    `json:"shutdownTimeout" yaml:"shutdown-timeout" mapstructure:"shutdown-timeout"`
 	EnableAPIForService bool   `json:"enableAPIForService" yaml:"enable-api-for-service" mapstructure:"enable-api-for-service"`
+
+	Cors            CorsConfig        `json:"cors" yaml:"cors" mapstructure:"cors"`
+	TLS             tlsx.ServerConfig `json:"tls" yaml:"tls" mapstructure:"tls"`
+	EnablePprof     bool              `json:"enablePprof" yaml:"enable-pprof" mapstructure:"enable-pprof"`               // 是否开启pprof
+	PprofPrefix     string            `json:"pprofPrefix" yaml:"pprof-prefix" mapstructure:"pprof-prefix"`               // pprof路由前缀，默认 /debug/pprof
+	HealthPath      string            `json:"healthPath" yaml:"health-path" mapstructure:"health-path"`                  // 存活检查路由，默认 /healthz
+	ReadyPath       string            `json:"readyPath" yaml:"ready-path" mapstructure:"ready-path"`                     // 就绪检查路由，默认 /readyz
+	RequestIDHeader string            `json:"requestIdHeader" yaml:"request-id-header" mapstructure:"request-id-header"` // 请求ID请求头，默认 X-Request-ID
+	OpenAPI         OpenAPIConfig     `json:"openapi" yaml:"openapi" mapstructure:"openapi"`                             // OpenAPI 文档
+}
+
+// CorsConfig 跨域配置, AllowOrigins 为空或包含 * 时允许所有来源
+type CorsConfig struct {
+	Disabled         bool     `json:"disabled" yaml:"disabled" mapstructure:"disabled"`
+	AllowOrigins     []string `json:"allowOrigins" yaml:"allow-origins" mapstructure:"allow-origins"`
+	AllowMethods     []string `json:"allowMethods" yaml:"allow-methods" mapstructure:"allow-methods"`
+	AllowHeaders     []string `json:"allowHeaders" yaml:"allow-headers" mapstructure:"allow-headers"`
+	ExposeHeaders    []string `json:"exposeHeaders" yaml:"expose-headers" mapstructure:"expose-headers"`
+	AllowCredentials bool     `json:"allowCredentials" yaml:"allow-credentials" mapstructure:"allow-credentials"`
+	MaxAge           int      `json:"maxAge" yaml:"max-age" mapstructure:"max-age"` // 预检请求缓存时间，单位秒
+}
+
+// build 构建hertz跨域配置
+func (c CorsConfig) build() cors.Config {
+	corsCfg := cors.DefaultConfig()
+	allowAll := len(c.AllowOrigins) == 0
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			allowAll = true
+			break
+		}
+	}
+	if allowAll {
+		corsCfg.AllowAllOrigins = true
+	} else {
+		corsCfg.AllowOrigins = c.AllowOrigins
+		corsCfg.AllowWildcard = true
+	}
+	if len(c.AllowMethods) > 0 {
+		corsCfg.AllowMethods = c.AllowMethods
+	}
+	if len(c.AllowHeaders) > 0 {
+		corsCfg.AllowHeaders = c.AllowHeaders
+	} else {
+		corsCfg.AllowHeaders = []string{"*"}
+	}
+	corsCfg.ExposeHeaders = c.ExposeHeaders
+	// 允许所有来源时浏览器不接受携带凭证
+	corsCfg.AllowCredentials = c.AllowCredentials && !allowAll
+	if c.MaxAge > 0 {
+		corsCfg.MaxAge = time.Duration(c.MaxAge) * time.Second
+	}
+	return corsCfg
 }
 
 func (cfg *WebConfig) Prepare() {
@@ -45,9 +104,23 @@ func (cfg *WebConfig) Prepare() {
 	if cfg.ShutdownTimeout == 0 {
 		cfg.ShutdownTimeout = 10 * 1000
 	}
+	if cfg.PprofPrefix == "" {
+		cfg.PprofPrefix = "/debug/pprof"
+	}
+	if cfg.HealthPath == "" {
+		cfg.HealthPath = "/healthz"
+	}
+	if cfg.ReadyPath == "" {
+		cfg.ReadyPath = "/readyz"
+	}
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = middleware.DefaultRequestIDHeader
+	}
+	cfg.OpenAPI.Prepare()
 }
 
-func WebEngine(cfg WebConfig) *server.Hertz {
+// engineOptions 根据配置构建hertz启动参数, 返回的 io.Closer 用于在服务关闭时停止证书热加载
+func engineOptions(cfg WebConfig) ([]config.Option, io.Closer, error) {
 	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
 	opts := []config.Option{
 		server.WithHostPorts(addr),
@@ -57,36 +130,62 @@ func WebEngine(cfg WebConfig) *server.Hertz {
 		server.WithIdleTimeout(time.Duration(cfg.IdleTimeout) * time.Millisecond),
 		server.WithExitWaitTime(time.Duration(cfg.ShutdownTimeout) * time.Millisecond),
 	}
-	hertz := server.Default(opts...)
+	tlsConfig, closer, err := cfg.TLS.TLSConfigWithCloser()
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "初始化Web服务TLS配置失败")
+	}
+	if tlsConfig != nil {
+		// netpoll 不支持 TLS, 显式使用标准库网络层, 避免 TLS 配置被忽略后以明文监听
+		opts = append(opts, server.WithTLS(tlsConfig), server.WithTransport(standard.NewTransporter))
+	}
+	return opts, closer, nil
+}
 
-	corsCfg := cors.DefaultConfig()
-	corsCfg.AllowAllOrigins = true
-	corsCfg.AllowHeaders = []string{"*"}
+// closeOnShutdown 服务关闭时关闭 closer
+func closeOnShutdown(hertz *server.Hertz, closer io.Closer) {
+	hertz.OnShutdown = append(hertz.OnShutdown, func(ctx context.Context) {
+		if err := closer.Close(); err != nil {
+			logs.Errorf("failed to close web server tls config: %v", err)
+		}
+	})
+}
 
-	hertz.Use(middleware.SetLogIdMW())
-	hertz.Use(cors.New(corsCfg))
+// useDefaultMiddlewares 注册默认中间件
+func useDefaultMiddlewares(hertz *server.Hertz, cfg WebConfig) {
+	hertz.Use(middleware.SetRequestIdMW(cfg.RequestIDHeader))
+	if !cfg.Cors.Disabled {
+		hertz.Use(cors.New(cfg.Cors.build()))
+	}
 	hertz.Use(middleware.AccessLogMW())
+	hertz.Use(middleware.ErrorHandlerMW())
+}
+
+func WebEngine(cfg WebConfig) *server.Hertz {
+	cfg.Prepare()
+	opts, closer, err := engineOptions(cfg)
+	if err != nil {
+		logs.Fatalf("failed to initial web engine: %v", err)
+	}
+	hertz := server.Default(opts...)
+	closeOnShutdown(hertz, closer)
+	useDefaultMiddlewares(hertz, cfg)
 	return hertz
 }
 
+// StartWebServer 启动Web服务并阻塞直到收到退出信号, 返回的函数用于执行额外的清理动作
+// Deprecated: 使用 NewServer 与 Server.Run, 支持健康检查与有序关闭
 func StartWebServer(hertz *server.Hertz) func() {
 	hertz.Spin()
 	return func() {}
 }
 
 func Bad(c *app.RequestContext, message string) {
-	c.AbortWithStatusJSON(http.StatusBadRequest, resp.Response{
-		Code:    resp.BadRequest,
-		Message: message,
-	})
+	abortWithCode(c, resp.ErrBadRequest, message)
 }
 
 // Badf 返回错误信息
 func Badf(c *app.RequestContext, format string, args ...interface{}) {
-	c.AbortWithStatusJSON(http.StatusBadRequest, resp.Response{
-		Code:    resp.BadRequest,
-		Message: fmt.Sprintf(format, args...),
-	})
+	abortWithCode(c, resp.ErrBadRequest, fmt.Sprintf(format, args...))
 }
 
 // OK 返回成功信息
@@ -103,29 +202,46 @@ func Msg(c *app.RequestContext, data string) {
 }
 
 func Msgf(c *app.RequestContext, format string, args ...interface{}) {
-	c.JSON(http.StatusOK, resp.Message(fmt.Sprintf(format, args)))
+	c.JSON(http.StatusOK, resp.Message(fmt.Sprintf(format, args...)))
 }
 
+// Error 返回操作失败信息, HTTP状态码为 500
 func Error(c *app.RequestContext, message string) {
-	c.JSON(http.StatusOK, resp.Error(resp.Failed, message))
+	abortWithCode(c, resp.ErrFailed, message)
 }
 
 func Errorf(c *app.RequestContext, format string, args ...interface{}) {
-	c.JSON(http.StatusOK, resp.Error(resp.Failed, fmt.Sprintf(format, args)))
+	abortWithCode(c, resp.ErrFailed, fmt.Sprintf(format, args...))
+}
+
+// Fail 根据错误码返回错误信息, 支持 pkg/errors 包装的错误, 未注册错误码的错误按内部错误处理
+func Fail(ctx context.Context, c *app.RequestContext, err error) {
+	middleware.WriteError(ctx, c, err)
 }
 
+// Abort 以指定HTTP状态码终止请求, 若该状态码已注册为错误码则附带字符串错误码
 func Abort(c *app.RequestContext, code int, message string) {
-	c.AbortWithStatusJSON(code, resp.Message(message))
+	r := resp.Error(resp.ResponseCode(code), message)
+	if ec, ok := resp.LookupCode(int64(code)); ok {
+		r.ErrorCode = ec.Key
+	}
+	c.AbortWithStatusJSON(code, r)
 }
 
 func Abortf(c *app.RequestContext, code int, format string, args ...interface{}) {
-	c.AbortWithStatusJSON(code, resp.Message(fmt.Sprintf(format, args)))
+	Abort(c, code, fmt.Sprintf(format, args...))
 }
 
 func Unauthorized(c *app.RequestContext, message string) {
-	Abort(c, 401, message)
+	Abort(c, http.StatusUnauthorized, message)
 }
 
 func Unauthorizedf(c *app.RequestContext, format string, args ...interface{}) {
-	Abortf(c, 401, format, args...)
+	Abortf(c, http.StatusUnauthorized, format, args...)
+}
+
+func abortWithCode(c *app.RequestContext, ec *resp.ErrorCode, message string) {
+	r := resp.Error(resp.ResponseCode(ec.Code), message)
+	r.ErrorCode = ec.Key
+	c.AbortWithStatusJSON(ec.HTTPStatus, r)
 }
@@ -0,0 +1,187 @@
+package hertzx
+
+import (
+	"context"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/adaptor"
+	mcpserver "github.com/mark3labs/mcp-go/server"
+	"github.com/xiehqing/common/pkg/logs"
+	"github.com/xiehqing/common/pkg/mcpx"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MCPOption MCP 挂载选项
+type MCPOption func(m *mcpMount)
+
+// WithMCPPrefix 路由前缀, 默认 /mcp.
+// Streamable-HTTP 挂载在前缀本身, SSE 挂载在 前缀/sse 与 前缀/message
+func WithMCPPrefix(prefix string) MCPOption {
+	return func(m *mcpMount) {
+		m.prefix = prefix
+	}
+}
+
+// WithMCPMiddlewares MCP 路由额外使用的中间件, 如业务的认证中间件, 在全局中间件之后执行
+func WithMCPMiddlewares(mws ...app.HandlerFunc) MCPOption {
+	return func(m *mcpMount) {
+		m.middlewares = append(m.middlewares, mws...)
+	}
+}
+
+// WithMCPTransports 挂载的传输方式, 可选 mcpx.TransportSSE、mcpx.TransportStreamableHTTP, 默认两者都挂载
+func WithMCPTransports(transports ...string) MCPOption {
+	return func(m *mcpMount) {
+		m.transports = transports
+	}
+}
+
+// WithMCPHeartbeat 长连接的心跳间隔, 默认 15s. hertz 无法感知客户端断开, 依靠心跳写入失败来释放会话
+func WithMCPHeartbeat(interval time.Duration) MCPOption {
+	return func(m *mcpMount) {
+		m.heartbeat = interval
+	}
+}
+
+type mcpMount struct {
+	prefix      string
+	middlewares []app.HandlerFunc
+	transports  []string
+	heartbeat   time.Duration
+
+	mu      sync.Mutex
+	nextID  int64
+	streams map[int64]context.CancelFunc
+}
+
+func (m *mcpMount) enabled(transport string) bool {
+	if len(m.transports) == 0 {
+		return true
+	}
+	for _, t := range m.transports {
+		if t == transport {
+			return true
+		}
+	}
+	return false
+}
+
+// MountMCP 把 MCP 服务的 SSE 与 Streamable-HTTP 处理器挂载到 hertz 路由上, 与其他接口共用端口、TLS、
+// 请求ID、访问日志与中间件, 工具处理函数的 ctx 中可以获取请求ID与认证信息.
+// mcp 设置了 Authenticator 时使用它校验 Bearer 令牌; hertz 关闭时 MCP 拒绝新的工具调用,
+// 等待处理中的调用完成后再断开 SSE 长连接. mcp 不需要再调用 Start
+func MountMCP(h *server.Hertz, mcp *mcpx.Server, opts ...MCPOption) {
+	m := &mcpMount{prefix: "/mcp", heartbeat: 15 * time.Second, streams: make(map[int64]context.CancelFunc)}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	mws := m.middlewares
+	if auth := mcp.Authenticator(); auth != nil {
+		mws = append([]app.HandlerFunc{mcpAuthMW(auth)}, mws...)
+	}
+	g := h.Group(m.prefix, mws...)
+
+	var sse *mcpserver.SSEServer
+	if m.enabled(mcpx.TransportSSE) {
+		sseOpts := []mcpserver.SSEOption{mcpserver.WithStaticBasePath(m.prefix)}
+		if m.heartbeat > 0 {
+			sseOpts = append(sseOpts, mcpserver.WithKeepAlive(true), mcpserver.WithKeepAliveInterval(m.heartbeat))
+		}
+		sse = mcpserver.NewSSEServer(mcp.MCP(), sseOpts...)
+		g.GET("/sse", m.handle(sse.SSEHandler()))
+		g.POST("/message", m.handle(sse.MessageHandler()))
+	}
+	if m.enabled(mcpx.TransportStreamableHTTP) {
+		streamable := mcpserver.NewStreamableHTTPServer(mcp.MCP(),
+			mcpserver.WithEndpointPath(m.prefix),
+			mcpserver.WithStateLess(mcp.Config().Stateless),
+			mcpserver.WithHeartbeatInterval(m.heartbeat),
+		)
+		handler := m.handle(streamable)
+		g.POST("", handler)
+		g.GET("", handler)
+		g.DELETE("", handler)
+	}
+
+	h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+		if err := mcp.Shutdown(ctx); err != nil {
+			logs.Errorf("停止Mcp服务失败：%v", err)
+		}
+		if sse != nil {
+			sse.Shutdown(ctx)
+		}
+		m.closeStreams()
+	})
+}
+
+// handle 把 net/http 处理器转换为 hertz 处理器. 每个请求使用可取消的 context,
+// 写入失败(客户端已断开)或服务关闭时取消, 使长连接的处理器及时退出
+func (m *mcpMount) handle(handler http.Handler) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		id := m.addStream(cancel)
+		defer m.removeStream(id)
+		adaptor.HertzHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(&cancelOnErrorWriter{ResponseWriter: w, cancel: cancel}, r)
+		}))(ctx, c)
+	}
+}
+
+func (m *mcpMount) addStream(cancel context.CancelFunc) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	m.streams[m.nextID] = cancel
+	return m.nextID
+}
+
+func (m *mcpMount) removeStream(id int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.streams, id)
+}
+
+func (m *mcpMount) closeStreams() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, cancel := range m.streams {
+		cancel()
+	}
+}
+
+// cancelOnErrorWriter 写入失败时取消请求的 context
+type cancelOnErrorWriter struct {
+	http.ResponseWriter
+	cancel context.CancelFunc
+}
+
+func (w *cancelOnErrorWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	if err != nil {
+		w.cancel()
+	}
+	return n, err
+}
+
+func (w *cancelOnErrorWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// mcpAuthMW 使用 mcpx.Authenticator 校验 Bearer 令牌, 认证信息通过 mcpx.AccessFromContext 获取
+func mcpAuthMW(auth mcpx.Authenticator) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		details, err := auth(ctx, mcpx.BearerToken(string(c.GetHeader("Authorization"))))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			Unauthorized(c, "无效的访问令牌")
+			return
+		}
+		c.Next(mcpx.WithAccess(ctx, details))
+	}
+}
@@ -0,0 +1,125 @@
+package hertzx
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/xiehqing/common/pkg/hertzx/middleware"
+	"github.com/xiehqing/common/pkg/jwtx"
+	"github.com/xiehqing/common/pkg/mcpx"
+)
+
+type whoamiResult struct {
+	RequestID string `json:"requestId"`
+	Caller    string `json:"caller"`
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func callWhoami(t *testing.T, c *client.Client) whoamiResult {
+	t.Helper()
+	ctx := context.Background()
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatal(err)
+	}
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "whoami"
+	result, err := c.CallTool(ctx, req)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected result %+v: %v", result, err)
+	}
+	structured := result.StructuredContent.(map[string]interface{})
+	return whoamiResult{RequestID: structured["requestId"].(string), Caller: structured["caller"].(string)}
+}
+
+func TestMountMCP(t *testing.T) {
+	j := &jwtx.Jwt{SigningKey: "secret", AccessExpired: 10, RefreshExpired: 10}
+	td, err := j.CreateTokens("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := mcpx.NewServer(&mcpx.Config{Name: "test", Version: "1.0", WithToolCapabilities: true},
+		mcpx.WithAuthenticator(mcpx.JwtAuthenticator(j, "")))
+	if err := mcpx.AddTool(m.MCP(), "whoami", "当前调用者", func(ctx context.Context, in *struct{}) (whoamiResult, error) {
+		access, _ := mcpx.AccessFromContext(ctx)
+		return whoamiResult{RequestID: middleware.GetRequestID(ctx), Caller: access.UserIdentity}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	port := freePort(t)
+	h := WebEngine(WebConfig{Host: "127.0.0.1", Port: port})
+	MountMCP(h, m, WithMCPHeartbeat(100*time.Millisecond))
+	go h.Run()
+	base := fmt.Sprintf("http://127.0.0.1:%d", port)
+	// 不复用连接, hertz 关闭时只需要等待 MCP 长连接
+	hc := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	for i := 0; ; i++ {
+		if _, err := hc.Get(base + "/mcp/sse"); err == nil {
+			break
+		} else if i > 50 {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	resp, err := hc.Post(base+"/mcp", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("X-Request-ID") == "" {
+		t.Fatalf("expected 401 with request id, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + td.AccessToken, "X-Request-ID": "req-1"}
+	streamable, err := client.NewStreamableHttpClient(base+"/mcp",
+		transport.WithHTTPHeaders(headers), transport.WithHTTPBasicClient(hc), transport.WithContinuousListening())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer streamable.Close()
+	if got := callWhoami(t, streamable); got != (whoamiResult{RequestID: "req-1", Caller: "alice"}) {
+		t.Fatalf("unexpected streamable result: %+v", got)
+	}
+
+	sse, err := client.NewSSEMCPClient(base+"/mcp/sse", transport.WithHeaders(headers), transport.WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sse.Close()
+	if err := sse.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := callWhoami(t, sse); got.Caller != "alice" {
+		t.Fatalf("unexpected sse result: %+v", got)
+	}
+
+	// SSE 与 Streamable-HTTP 的长连接不能阻塞关闭
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("shutdown took %s", elapsed)
+	}
+}
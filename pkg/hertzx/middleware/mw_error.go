@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/xiehqing/common/pkg/logs"
+	"github.com/xiehqing/common/pkg/resp"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// ErrorHandlerMW 统一错误处理中间件
+// 处理器通过 c.Error(err) 返回错误或发生 panic 时, 统一转换为 resp.Response 输出, 5xx 错误会打印堆栈
+func ErrorHandlerMW() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		defer func() {
+			if r := recover(); r != nil {
+				logs.CtxErrorf(ctx, "[Recovery] panic: %v\n%s", r, string(debug.Stack()))
+				WriteError(ctx, c, resp.ErrInternal.Wrap(fmt.Errorf("panic: %v", r)))
+			}
+		}()
+		c.Next(ctx)
+		if len(c.Errors) == 0 {
+			return
+		}
+		if c.Response.StatusCode() != http.StatusOK || len(c.Response.Body()) > 0 {
+			// 处理器已自行输出响应, 仅记录错误
+			logs.CtxWarnf(ctx, "%s", c.Errors.String())
+			return
+		}
+		WriteError(ctx, c, c.Errors.Last().Err)
+	}
+}
+
+// WriteError 将错误转换为统一响应并终止请求
+func WriteError(ctx context.Context, c *app.RequestContext, err error) {
+	se := resp.FromError(err)
+	if se == nil {
+		return
+	}
+	status := se.HTTPStatus()
+	if status >= http.StatusInternalServerError {
+		logs.CtxErrorf(ctx, "%+v", err)
+	} else {
+		logs.CtxDebugf(ctx, "%v", err)
+	}
+	r := resp.ErrorResponse(se, RequestLang(c))
+	r.RequestID = GetRequestID(ctx)
+	c.AbortWithStatusJSON(status, r)
+}
+
+// RequestLang 根据 Accept-Language 请求头获取语言, 未指定时使用默认语言
+func RequestLang(c *app.RequestContext) string {
+	accept := string(c.GetHeader("Accept-Language"))
+	if accept == "" {
+		return resp.DefaultLang
+	}
+	first := strings.Split(accept, ",")[0]
+	first = strings.Split(first, ";")[0]
+	if first == "" || first == "*" {
+		return resp.DefaultLang
+	}
+	return first
+}
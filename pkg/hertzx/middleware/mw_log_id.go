@@ -6,12 +6,41 @@ import (
 	"github.com/google/uuid"
 )
 
+// DefaultRequestIDHeader 默认请求ID请求头
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// LogIDKey 日志ID在context中的key, 与 logs 包保持一致
+const LogIDKey = "log-id"
+
 func SetLogIdMW() app.HandlerFunc {
+	return SetRequestIdMW(DefaultRequestIDHeader)
+}
+
+// SetRequestIdMW 设置请求ID, 优先沿用上游传入的请求ID, 并写入context与响应头
+func SetRequestIdMW(header string) app.HandlerFunc {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
 	return func(ctx context.Context, c *app.RequestContext) {
-		logID := uuid.New().String()
-		ctx = context.WithValue(ctx, "log-id", logID)
+		logID := string(c.GetHeader(header))
+		if logID == "" {
+			logID = uuid.New().String()
+		}
+		ctx = context.WithValue(ctx, LogIDKey, logID)
 
 		c.Header("X-Log-ID", logID)
+		c.Header(header, logID)
 		c.Next(ctx)
 	}
 }
+
+// GetRequestID 获取当前请求ID
+func GetRequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if v, ok := ctx.Value(LogIDKey).(string); ok {
+		return v
+	}
+	return ""
+}
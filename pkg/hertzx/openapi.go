@@ -0,0 +1,671 @@
+package hertzx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/invopop/jsonschema"
+	"github.com/xiehqing/common/pkg/logs"
+	"github.com/xiehqing/common/pkg/validate"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认的安全方案名称
+const (
+	SecurityBearer = "bearerAuth"
+	SecurityAPIKey = "apiKeyAuth"
+)
+
+// OpenAPIConfig OpenAPI 文档配置
+type OpenAPIConfig struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled" mapstructure:"enabled"`
+	Title        string `json:"title" yaml:"title" mapstructure:"title"`                          // 文档标题，默认 API
+	Version      string `json:"version" yaml:"version" mapstructure:"version"`                    // 接口版本，默认 1.0.0
+	Description  string `json:"description" yaml:"description" mapstructure:"description"`        // 文档描述
+	DocPath      string `json:"docPath" yaml:"doc-path" mapstructure:"doc-path"`                  // 文档路由，默认 /openapi.json
+	UIPath       string `json:"uiPath" yaml:"ui-path" mapstructure:"ui-path"`                     // Swagger UI 路由，默认 /swagger
+	SwaggerUICDN string `json:"swaggerUiCdn" yaml:"swagger-ui-cdn" mapstructure:"swagger-ui-cdn"` // Swagger UI 静态资源地址
+}
+
+func (c *OpenAPIConfig) Prepare() {
+	if c.Title == "" {
+		c.Title = "API"
+	}
+	if c.Version == "" {
+		c.Version = "1.0.0"
+	}
+	if c.DocPath == "" {
+		c.DocPath = "/openapi.json"
+	}
+	if c.UIPath == "" {
+		c.UIPath = "/swagger"
+	}
+	if c.SwaggerUICDN == "" {
+		c.SwaggerUICDN = "https://unpkg.com/swagger-ui-dist@5"
+	}
+}
+
+// SecurityScheme 安全方案
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type envelopeKind int
+
+const (
+	envelopeData envelopeKind = iota // resp.Response, data 为响应类型
+	envelopePage                     // resp.Response, data 为 resp.PageEntity
+	envelopeNone                     // 不包装
+)
+
+// routeSpec 路由的文档描述
+type routeSpec struct {
+	summary     string
+	description string
+	operationID string
+	tags        []string
+	request     reflect.Type
+	response    reflect.Type
+	envelope    envelopeKind
+	security    []string
+	deprecated  bool
+	hidden      bool
+}
+
+// RouteOption 路由文档选项
+type RouteOption func(s *routeSpec)
+
+// WithSummary 接口摘要
+func WithSummary(summary string) RouteOption {
+	return func(s *routeSpec) {
+		s.summary = summary
+	}
+}
+
+// WithDescription 接口描述
+func WithDescription(description string) RouteOption {
+	return func(s *routeSpec) {
+		s.description = description
+	}
+}
+
+// WithOperationID 接口唯一标识
+func WithOperationID(id string) RouteOption {
+	return func(s *routeSpec) {
+		s.operationID = id
+	}
+}
+
+// WithTags 接口分组标签
+func WithTags(tags ...string) RouteOption {
+	return func(s *routeSpec) {
+		s.tags = append(s.tags, tags...)
+	}
+}
+
+// WithRequest 请求参数类型, 按 Bind 的结构体标签生成路径、查询、请求头参数与请求体
+func WithRequest[T any]() RouteOption {
+	return func(s *routeSpec) {
+		s.request = typeOf[T]()
+	}
+}
+
+// WithResponse 响应数据类型, 文档中包装为 resp.Response
+func WithResponse[T any]() RouteOption {
+	return func(s *routeSpec) {
+		s.response, s.envelope = typeOf[T](), envelopeData
+	}
+}
+
+// WithPageResponse 分页响应数据类型, 文档中包装为 resp.Response 与 resp.PageEntity
+func WithPageResponse[T any]() RouteOption {
+	return func(s *routeSpec) {
+		s.response, s.envelope = typeOf[T](), envelopePage
+	}
+}
+
+// WithRawResponse 响应类型, 不做包装
+func WithRawResponse[T any]() RouteOption {
+	return func(s *routeSpec) {
+		s.response, s.envelope = typeOf[T](), envelopeNone
+	}
+}
+
+// WithAuth 接口需要认证, 未指定时使用 Bearer 认证
+func WithAuth(schemes ...string) RouteOption {
+	return func(s *routeSpec) {
+		if len(schemes) == 0 {
+			schemes = []string{SecurityBearer}
+		}
+		s.security = schemes
+	}
+}
+
+// WithoutAuth 接口无需认证, 用于覆盖分组上的认证设置
+func WithoutAuth() RouteOption {
+	return func(s *routeSpec) {
+		s.security = nil
+	}
+}
+
+// WithDeprecated 标记接口已废弃
+func WithDeprecated() RouteOption {
+	return func(s *routeSpec) {
+		s.deprecated = true
+	}
+}
+
+// WithHidden 不在文档中展示
+func WithHidden() RouteOption {
+	return func(s *routeSpec) {
+		s.hidden = true
+	}
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// OpenAPI 根据路由注册信息生成 OpenAPI 3.1 文档
+type OpenAPI struct {
+	cfg       OpenAPIConfig
+	mu        sync.Mutex
+	reflector *jsonschema.Reflector
+	schemas   map[string]*jsonschema.Schema
+	paths     map[string]map[string]*operation
+	security  map[string]*SecurityScheme
+	cache     []byte
+}
+
+// NewOpenAPI 创建 OpenAPI 文档, 默认包含 Bearer(JWT) 与 X-API-Key 两种安全方案
+func NewOpenAPI(cfg OpenAPIConfig) *OpenAPI {
+	cfg.Prepare()
+	o := &OpenAPI{
+		cfg: cfg,
+		reflector: &jsonschema.Reflector{
+			Anonymous:                  true,
+			AllowAdditionalProperties:  true,
+			RequiredFromJSONSchemaTags: true,
+		},
+		schemas:  map[string]*jsonschema.Schema{},
+		paths:    map[string]map[string]*operation{},
+		security: map[string]*SecurityScheme{},
+	}
+	o.security[SecurityBearer] = &SecurityScheme{Type: "http", Scheme: "bearer", BearerFormat: "JWT"}
+	o.security[SecurityAPIKey] = &SecurityScheme{Type: "apiKey", In: "header", Name: "X-API-Key"}
+	return o
+}
+
+// Config 获取文档配置
+func (o *OpenAPI) Config() OpenAPIConfig {
+	return o.cfg
+}
+
+// AddSecurityScheme 添加或覆盖安全方案
+func (o *OpenAPI) AddSecurityScheme(name string, scheme SecurityScheme) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.security[name] = &scheme
+	o.cache = nil
+}
+
+// AddOperation 记录一个接口, path 使用 hertz 的路由格式, 如 /users/:id
+func (o *OpenAPI) AddOperation(method, path string, opts ...RouteOption) {
+	spec := &routeSpec{}
+	for _, opt := range opts {
+		opt(spec)
+	}
+	if spec.hidden {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	docPath, pathParams := convertPath(path)
+	op := o.buildOperation(strings.ToUpper(method), pathParams, spec)
+	if o.paths[docPath] == nil {
+		o.paths[docPath] = map[string]*operation{}
+	}
+	o.paths[docPath][strings.ToLower(method)] = op
+	o.cache = nil
+}
+
+// Spec 生成 JSON 格式的文档
+func (o *OpenAPI) Spec() ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cache != nil {
+		return o.cache, nil
+	}
+	doc := document{
+		OpenAPI: "3.1.0",
+		Info:    info{Title: o.cfg.Title, Version: o.cfg.Version, Description: o.cfg.Description},
+		Paths:   o.paths,
+		Components: components{
+			Schemas:         o.schemas,
+			SecuritySchemes: o.security,
+		},
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	// jsonschema 生成的引用指向 $defs, 文档中统一放在 components.schemas
+	o.cache = bytes.ReplaceAll(data, []byte(`"#/$defs/`), []byte(`"#/components/schemas/`))
+	return o.cache, nil
+}
+
+// Register 注册文档与 Swagger UI 路由
+func (o *OpenAPI) Register(r route.IRoutes) {
+	r.GET(o.cfg.DocPath, func(ctx context.Context, c *app.RequestContext) {
+		data, err := o.Spec()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	})
+	r.GET(o.cfg.UIPath, func(ctx context.Context, c *app.RequestContext) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage(o.cfg)))
+	})
+}
+
+func (o *OpenAPI) buildOperation(method string, pathParams []string, spec *routeSpec) *operation {
+	op := &operation{
+		Tags:        spec.tags,
+		Summary:     spec.summary,
+		Description: spec.description,
+		OperationID: spec.operationID,
+		Deprecated:  spec.deprecated,
+		Responses:   map[string]*response{},
+	}
+	declared := map[string]bool{}
+	if spec.request != nil {
+		op.Parameters, op.RequestBody = o.requestSchema(method, spec.request)
+		for _, p := range op.Parameters {
+			if p.In == "path" {
+				declared[p.Name] = true
+			}
+		}
+	}
+	for _, name := range pathParams {
+		if !declared[name] {
+			op.Parameters = append(op.Parameters, &parameter{
+				Name: name, In: "path", Required: true, Schema: &jsonschema.Schema{Type: "string"},
+			})
+		}
+	}
+	for _, name := range spec.security {
+		op.Security = append(op.Security, map[string][]string{name: {}})
+	}
+
+	var data *jsonschema.Schema
+	if spec.response != nil {
+		data = o.reflect(spec.response)
+	}
+	body := data
+	switch spec.envelope {
+	case envelopeData:
+		body = envelopeSchema(data)
+	case envelopePage:
+		body = envelopeSchema(pageSchema(data))
+	}
+	op.Responses["200"] = &response{Description: "OK", Content: map[string]*mediaType{"application/json": {Schema: body}}}
+	op.Responses["default"] = &response{
+		Description: "错误响应",
+		Content:     map[string]*mediaType{"application/json": {Schema: envelopeSchema(nil)}},
+	}
+	return op
+}
+
+// reflect 生成类型的 schema, 结构体定义统一放入 components.schemas
+func (o *OpenAPI) reflect(t reflect.Type) *jsonschema.Schema {
+	s := o.reflector.ReflectFromType(t)
+	o.mergeDefinitions(s)
+	return s
+}
+
+func (o *OpenAPI) mergeDefinitions(s *jsonschema.Schema) {
+	for name, def := range s.Definitions {
+		o.schemas[name] = def
+	}
+	s.Definitions = nil
+	s.Version = ""
+}
+
+// requestSchema 根据绑定标签生成参数与请求体
+func (o *OpenAPI) requestSchema(method string, t reflect.Type) ([]*parameter, *requestBody) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, &requestBody{Required: true, Content: map[string]*mediaType{"application/json": {Schema: o.reflect(t)}}}
+	}
+	fields, err := getStructFields(t)
+	if err != nil {
+		logs.Errorf("生成接口 %s 参数文档失败: %v", t, err)
+	}
+	var params []*parameter
+	var formProps []*fieldInfo
+	bound := map[string]bool{}
+	for _, f := range fields {
+		in, name := "", ""
+		switch {
+		case f.path != "":
+			in, name = "path", f.path
+		case f.query != "":
+			in, name = "query", f.query
+		case f.header != "":
+			in, name = "header", f.header
+		case f.form != "":
+			formProps = append(formProps, f)
+		}
+		if in == "" {
+			continue
+		}
+		if f.jsonName != "" {
+			bound[f.jsonName] = true
+		}
+		params = append(params, &parameter{
+			Name:     name,
+			In:       in,
+			Required: in == "path" || validate.Has(f.rules, "required"),
+			Schema:   fieldSchema(f),
+		})
+	}
+
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodDelete {
+		return params, nil
+	}
+	if len(formProps) > 0 {
+		return params, formBody(formProps)
+	}
+
+	// 请求体使用展开的结构体 schema, 去掉已作为参数的字段, 并补充校验规则
+	r := *o.reflector
+	r.ExpandedStruct = true
+	body := r.ReflectFromType(t)
+	o.mergeDefinitions(body)
+	rules := map[string]*fieldInfo{}
+	for _, f := range fields {
+		if f.jsonName != "" && !bound[f.jsonName] && len(f.rules) > 0 {
+			rules[f.jsonName] = f
+		}
+	}
+	if body.Properties == nil {
+		return params, nil
+	}
+	props := jsonschema.NewProperties()
+	required := body.Required
+	for pair := body.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		if bound[pair.Key] {
+			continue
+		}
+		prop := pair.Value
+		if f, ok := rules[pair.Key]; ok {
+			cp := *prop
+			prop = &cp
+			applyRules(prop, f)
+			if validate.Has(f.rules, "required") {
+				required = append(required, pair.Key)
+			}
+		}
+		props.Set(pair.Key, prop)
+	}
+	if props.Len() == 0 {
+		return params, nil
+	}
+	body.Properties = props
+	body.Required = filterRequired(required, func(name string) bool {
+		_, ok := props.Get(name)
+		return ok
+	})
+	return params, &requestBody{Required: true, Content: map[string]*mediaType{"application/json": {Schema: body}}}
+}
+
+// formBody 表单请求体, 包含文件字段时使用 multipart/form-data
+func formBody(fields []*fieldInfo) *requestBody {
+	s := &jsonschema.Schema{Type: "object", Properties: jsonschema.NewProperties()}
+	contentType := "application/x-www-form-urlencoded"
+	for _, f := range fields {
+		prop := fieldSchema(f)
+		if prop.Format == "binary" || (prop.Items != nil && prop.Items.Format == "binary") {
+			contentType = "multipart/form-data"
+		}
+		s.Properties.Set(f.form, prop)
+		if validate.Has(f.rules, "required") {
+			s.Required = append(s.Required, f.form)
+		}
+	}
+	return &requestBody{Required: true, Content: map[string]*mediaType{contentType: {Schema: s}}}
+}
+
+// filterRequired 去重并去掉不存在的属性
+func filterRequired(required []string, exists func(name string) bool) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, name := range required {
+		if !seen[name] && exists(name) {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+var fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+
+// fieldSchema 根据字段类型、默认值与校验规则生成参数 schema
+func fieldSchema(f *fieldInfo) *jsonschema.Schema {
+	s := typeSchema(f.typ)
+	if f.layout != "" {
+		s.Format = ""
+		s.Description = "格式: " + f.layout
+	}
+	if f.defaultValue != "" {
+		s.Default = defaultValue(s.Type, f.defaultValue)
+	}
+	applyRules(s, f)
+	return s
+}
+
+func typeSchema(t reflect.Type) *jsonschema.Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return &jsonschema.Schema{Type: "string", Format: "date-time"}
+	case t == reflect.TypeOf(time.Duration(0)):
+		return &jsonschema.Schema{Type: "string", Description: "时长, 如 1h30m"}
+	case t == fileHeaderType:
+		return &jsonschema.Schema{Type: "string", Format: "binary"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonschema.Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonschema.Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonschema.Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonschema.Schema{Type: "array", Items: typeSchema(t.Elem())}
+	default:
+		return &jsonschema.Schema{Type: "string"}
+	}
+}
+
+func defaultValue(typ, v string) interface{} {
+	switch typ {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return json.Number(v)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+// applyRules 将 validate 标签转换为 schema 约束
+func applyRules(s *jsonschema.Schema, f *fieldInfo) {
+	target := s
+	if s.Type == "array" && s.Items != nil {
+		target = s.Items
+	}
+	for _, r := range f.rules {
+		switch r.Name {
+		case "min", "max":
+			applyLimit(s, r.Name == "min", r.Param, r.Limit)
+		case "regex":
+			target.Pattern = r.Param
+		case "enum":
+			target.Enum = nil
+			for _, e := range r.Enums {
+				target.Enum = append(target.Enum, e)
+			}
+		case "date":
+			target.Description = "格式: " + r.Param
+		}
+	}
+}
+
+func applyLimit(s *jsonschema.Schema, isMin bool, param string, n float64) {
+	size := uint64(0)
+	if n > 0 {
+		size = uint64(n)
+	}
+	switch s.Type {
+	case "integer", "number":
+		if isMin {
+			s.Minimum = json.Number(param)
+		} else {
+			s.Maximum = json.Number(param)
+		}
+	case "string":
+		if isMin {
+			s.MinLength = &size
+		} else {
+			s.MaxLength = &size
+		}
+	case "array":
+		if isMin {
+			s.MinItems = &size
+		} else {
+			s.MaxItems = &size
+		}
+	case "object":
+		if isMin {
+			s.MinProperties = &size
+		} else {
+			s.MaxProperties = &size
+		}
+	}
+}
+
+// envelopeSchema resp.Response 包装
+func envelopeSchema(data *jsonschema.Schema) *jsonschema.Schema {
+	props := jsonschema.NewProperties()
+	props.Set("code", &jsonschema.Schema{Type: "integer", Description: "业务状态码, 0 表示成功"})
+	props.Set("message", &jsonschema.Schema{Type: "string"})
+	if data == nil {
+		data = &jsonschema.Schema{Type: "null"}
+	}
+	props.Set("data", data)
+	props.Set("errorCode", &jsonschema.Schema{Type: "string", Description: "错误码标识"})
+	props.Set("requestId", &jsonschema.Schema{Type: "string"})
+	props.Set("extra", &jsonschema.Schema{Type: "object", AdditionalProperties: &jsonschema.Schema{Type: "string"}})
+	return &jsonschema.Schema{Type: "object", Properties: props, Required: []string{"code", "message"}}
+}
+
+// pageSchema resp.PageEntity 包装
+func pageSchema(item *jsonschema.Schema) *jsonschema.Schema {
+	props := jsonschema.NewProperties()
+	props.Set("total", &jsonschema.Schema{Type: "integer"})
+	props.Set("content", &jsonschema.Schema{Type: "array", Items: item})
+	props.Set("pageNo", &jsonschema.Schema{Type: "integer"})
+	props.Set("pageSize", &jsonschema.Schema{Type: "integer"})
+	return &jsonschema.Schema{Type: "object", Properties: props, Required: []string{"total", "content", "pageNo", "pageSize"}}
+}
+
+// convertPath 将 hertz 路由 /users/:id/*path 转换为 /users/{id}/{path}
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			name := seg[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+type document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components components                       `json:"components"`
+}
+
+type info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type components struct {
+	Schemas         map[string]*jsonschema.Schema `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme    `json:"securitySchemes,omitempty"`
+}
+
+type operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []*parameter          `json:"parameters,omitempty"`
+	RequestBody *requestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type parameter struct {
+	Name     string             `json:"name"`
+	In       string             `json:"in"`
+	Required bool               `json:"required,omitempty"`
+	Schema   *jsonschema.Schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Description string                `json:"description"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *jsonschema.Schema `json:"schema"`
+}
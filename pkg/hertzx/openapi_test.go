@@ -0,0 +1,83 @@
+package hertzx
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+)
+
+type userVO struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type createUserReq struct {
+	TenantID int64  `path:"tenantId"`
+	Name     string `json:"name" validate:"required,max=32"`
+	Role     string `json:"role" validate:"enum=admin|user"`
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	h := server.New()
+	doc := NewOpenAPI(OpenAPIConfig{Title: "demo"})
+	noop := func(ctx context.Context, c *app.RequestContext) {}
+
+	r := NewRouter(doc, &h.RouterGroup).Group("/tenants/:tenantId").With(WithTags("user"), WithAuth())
+	r.GET("/users", noop, WithRequest[listUserReq](), WithPageResponse[userVO]())
+	r.POST("/users", noop, WithRequest[createUserReq](), WithResponse[userVO]())
+
+	data, err := doc.Spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Parameters []struct {
+				Name     string `json:"name"`
+				In       string `json:"in"`
+				Required bool   `json:"required"`
+			} `json:"parameters"`
+			RequestBody *struct {
+				Content map[string]struct {
+					Schema struct {
+						Required   []string                   `json:"required"`
+						Properties map[string]json.RawMessage `json:"properties"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatal(err)
+	}
+	list, ok := spec.Paths["/tenants/{tenantId}/users"]["get"]
+	if !ok || spec.OpenAPI != "3.1.0" {
+		t.Fatalf("missing list operation: %s", data)
+	}
+	params := map[string]string{}
+	for _, p := range list.Parameters {
+		params[p.Name] = p.In
+	}
+	if params["tenantId"] != "path" || params["pageNo"] != "query" || params["X-Token"] != "header" {
+		t.Fatalf("unexpected parameters: %v", params)
+	}
+	if len(list.Security) != 1 || list.RequestBody != nil {
+		t.Fatalf("unexpected list operation: %s", data)
+	}
+	create := spec.Paths["/tenants/{tenantId}/users"]["post"]
+	body := create.RequestBody.Content["application/json"].Schema
+	if _, ok := body.Properties["TenantID"]; ok || len(body.Required) != 1 || body.Required[0] != "name" {
+		t.Fatalf("unexpected request body: %s", data)
+	}
+	if _, ok := spec.Components.Schemas["userVO"]; !ok {
+		t.Fatalf("missing component schema: %s", data)
+	}
+}
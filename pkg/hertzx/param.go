@@ -98,24 +98,11 @@ func QueryDatePtr(c *app.RequestContext, paramName string) (*time.Time, error) {
 	return v, nil
 }
 
-// ParsePageable 解析分页参数
+// ParsePageable 解析分页参数, 按 PageParam 的标签绑定查询参数后转换为分页对象
 func ParsePageable(c *app.RequestContext) (ormx.Pageable, error) {
-	pageNo, err := QueryInt(c, "pageNo")
-	pageable := ormx.Pageable{}
-	if err != nil {
-		return pageable, errors.WithMessagef(err, "参数 pageNo 不合法")
+	var p PageParam
+	if err := BindTo(c, &p); err != nil {
+		return ormx.Pageable{}, err
 	}
-	pageSize, err := QueryInt(c, "pageSize")
-	if err != nil {
-		return pageable, errors.WithMessagef(err, "参数 pageSize 不合法")
-	}
-	sortField := c.DefaultQuery("sortField", "updated_at")
-	if sortField == "" {
-		sortField = "updated_at"
-	}
-	sortOrder := c.DefaultQuery("sortOrder", "desc")
-	if sortOrder != "asc" && sortOrder != "desc" {
-		sortOrder = "desc"
-	}
-	return ormx.PageRequest(pageNo, pageSize, sortField, sortOrder), nil
+	return p.Pageable(), nil
 }
@@ -0,0 +1,28 @@
+package hertzx
+
+import (
+	"github.com/cloudwego/hertz/pkg/common/adaptor"
+	"github.com/cloudwego/hertz/pkg/route"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+var pprofProfiles = []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"}
+
+// RegisterPprof 注册pprof路由
+func RegisterPprof(r route.IRoutes, prefix string) {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix == "" {
+		prefix = "/debug/pprof"
+	}
+	r.GET(prefix+"/", adaptor.HertzHandler(http.HandlerFunc(pprof.Index)))
+	r.GET(prefix+"/cmdline", adaptor.HertzHandler(http.HandlerFunc(pprof.Cmdline)))
+	r.GET(prefix+"/profile", adaptor.HertzHandler(http.HandlerFunc(pprof.Profile)))
+	r.POST(prefix+"/symbol", adaptor.HertzHandler(http.HandlerFunc(pprof.Symbol)))
+	r.GET(prefix+"/symbol", adaptor.HertzHandler(http.HandlerFunc(pprof.Symbol)))
+	r.GET(prefix+"/trace", adaptor.HertzHandler(http.HandlerFunc(pprof.Trace)))
+	for _, name := range pprofProfiles {
+		r.GET(prefix+"/"+name, adaptor.HertzHandler(pprof.Handler(name)))
+	}
+}
@@ -0,0 +1,82 @@
+package hertzx
+
+import (
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/route"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// Router 带文档描述的路由注册器, 注册路由的同时记录到 OpenAPI 文档
+// doc 为空时仅注册路由
+type Router struct {
+	group *route.RouterGroup
+	doc   *OpenAPI
+	opts  []RouteOption
+}
+
+// NewRouter 基于hertz路由分组创建路由注册器, 如 NewRouter(doc, &h.RouterGroup)
+func NewRouter(doc *OpenAPI, group *route.RouterGroup) *Router {
+	return &Router{group: group, doc: doc}
+}
+
+// Group 创建子分组, 继承当前分组的文档选项
+func (r *Router) Group(prefix string, handlers ...app.HandlerFunc) *Router {
+	return &Router{group: r.group.Group(prefix, handlers...), doc: r.doc, opts: r.opts}
+}
+
+// With 返回附加了默认文档选项的路由注册器, 如统一的标签与认证方式
+func (r *Router) With(opts ...RouteOption) *Router {
+	merged := make([]RouteOption, 0, len(r.opts)+len(opts))
+	merged = append(merged, r.opts...)
+	merged = append(merged, opts...)
+	return &Router{group: r.group, doc: r.doc, opts: merged}
+}
+
+// Use 为当前分组添加中间件
+func (r *Router) Use(handlers ...app.HandlerFunc) *Router {
+	r.group.Use(handlers...)
+	return r
+}
+
+// RouterGroup 获取底层的hertz路由分组
+func (r *Router) RouterGroup() *route.RouterGroup {
+	return r.group
+}
+
+// Handle 注册路由并记录文档
+func (r *Router) Handle(method, relativePath string, handler app.HandlerFunc, opts ...RouteOption) {
+	r.group.Handle(method, relativePath, handler)
+	if r.doc == nil {
+		return
+	}
+	fullPath := path.Join(r.group.BasePath(), relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(fullPath, "/") {
+		fullPath += "/"
+	}
+	all := make([]RouteOption, 0, len(r.opts)+len(opts))
+	all = append(all, r.opts...)
+	all = append(all, opts...)
+	r.doc.AddOperation(method, fullPath, all...)
+}
+
+func (r *Router) GET(relativePath string, handler app.HandlerFunc, opts ...RouteOption) {
+	r.Handle(http.MethodGet, relativePath, handler, opts...)
+}
+
+func (r *Router) POST(relativePath string, handler app.HandlerFunc, opts ...RouteOption) {
+	r.Handle(http.MethodPost, relativePath, handler, opts...)
+}
+
+func (r *Router) PUT(relativePath string, handler app.HandlerFunc, opts ...RouteOption) {
+	r.Handle(http.MethodPut, relativePath, handler, opts...)
+}
+
+func (r *Router) PATCH(relativePath string, handler app.HandlerFunc, opts ...RouteOption) {
+	r.Handle(http.MethodPatch, relativePath, handler, opts...)
+}
+
+func (r *Router) DELETE(relativePath string, handler app.HandlerFunc, opts ...RouteOption) {
+	r.Handle(http.MethodDelete, relativePath, handler, opts...)
+}
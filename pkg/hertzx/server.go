@@ -0,0 +1,168 @@
+package hertzx
+
+import (
+	"context"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/xiehqing/common/pkg/logs"
+	"time"
+)
+
+// 常用关闭顺序, 数值越小越先执行
+const (
+	ShutdownOrderScheduler = 100 // 定时任务等后台任务
+	ShutdownOrderClient    = 200 // 下游客户端, 如 MCP、HTTP 客户端
+	ShutdownOrderCache     = 300 // 缓存连接, 如 Redis
+	ShutdownOrderDatabase  = 400 // 数据库连接池
+)
+
+// Server Web服务, 在hertz基础上提供健康检查、pprof与有序关闭能力
+type Server struct {
+	cfg       WebConfig
+	hertz     *server.Hertz
+	liveness  *HealthRegistry
+	readiness *HealthRegistry
+	hooks     *ShutdownHooks
+	openapi   *OpenAPI
+
+	hertzOptions []config.Option
+	middlewares  []app.HandlerFunc
+}
+
+// ServerOption 服务选项
+type ServerOption func(s *Server)
+
+// WithHertzOptions 追加hertz启动参数
+func WithHertzOptions(opts ...config.Option) ServerOption {
+	return func(s *Server) {
+		s.hertzOptions = append(s.hertzOptions, opts...)
+	}
+}
+
+// WithMiddlewares 追加中间件, 在默认中间件之后执行
+func WithMiddlewares(mws ...app.HandlerFunc) ServerOption {
+	return func(s *Server) {
+		s.middlewares = append(s.middlewares, mws...)
+	}
+}
+
+// WithLivenessCheck 添加存活检查项
+func WithLivenessCheck(name string, check HealthCheck) ServerOption {
+	return func(s *Server) {
+		s.liveness.Register(name, check)
+	}
+}
+
+// WithReadinessCheck 添加就绪检查项
+func WithReadinessCheck(name string, check HealthCheck) ServerOption {
+	return func(s *Server) {
+		s.readiness.Register(name, check)
+	}
+}
+
+// WithShutdownHook 添加关闭钩子
+func WithShutdownHook(name string, order int, hook ShutdownHook) ServerOption {
+	return func(s *Server) {
+		s.hooks.Register(name, order, hook)
+	}
+}
+
+// NewServer 根据配置创建Web服务
+func NewServer(cfg WebConfig, opts ...ServerOption) (*Server, error) {
+	cfg.Prepare()
+	s := &Server{
+		cfg:       cfg,
+		liveness:  NewHealthRegistry(0),
+		readiness: NewHealthRegistry(0),
+		hooks:     NewShutdownHooks(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	hertzOpts, closer, err := engineOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	hertzOpts = append(hertzOpts, s.hertzOptions...)
+	s.hertz = server.Default(hertzOpts...)
+	closeOnShutdown(s.hertz, closer)
+	useDefaultMiddlewares(s.hertz, cfg)
+	s.hertz.Use(s.middlewares...)
+
+	s.hertz.GET(cfg.HealthPath, s.liveness.Handler())
+	s.hertz.HEAD(cfg.HealthPath, s.liveness.Handler())
+	s.hertz.GET(cfg.ReadyPath, s.readiness.Handler())
+	s.hertz.HEAD(cfg.ReadyPath, s.readiness.Handler())
+	if cfg.EnablePprof {
+		RegisterPprof(s.hertz, cfg.PprofPrefix)
+	}
+	if cfg.OpenAPI.Enabled {
+		s.openapi = NewOpenAPI(cfg.OpenAPI)
+		s.openapi.Register(s.hertz)
+	}
+	// 开始关闭时先将就绪状态置为不可用, 使负载均衡尽快摘除流量
+	s.hertz.OnShutdown = append(s.hertz.OnShutdown, func(ctx context.Context) {
+		s.readiness.MarkShutdown()
+	})
+	return s, nil
+}
+
+// Engine 获取hertz实例, 用于注册路由
+func (s *Server) Engine() *server.Hertz {
+	return s.hertz
+}
+
+// Router 获取根路由注册器, 开启 OpenAPI 时注册的路由会记录到文档
+func (s *Server) Router() *Router {
+	return NewRouter(s.openapi, &s.hertz.RouterGroup)
+}
+
+// OpenAPI 获取 OpenAPI 文档, 未开启时返回 nil
+func (s *Server) OpenAPI() *OpenAPI {
+	return s.openapi
+}
+
+// Config 获取服务配置
+func (s *Server) Config() WebConfig {
+	return s.cfg
+}
+
+// Liveness 存活检查注册表
+func (s *Server) Liveness() *HealthRegistry {
+	return s.liveness
+}
+
+// Readiness 就绪检查注册表
+func (s *Server) Readiness() *HealthRegistry {
+	return s.readiness
+}
+
+// OnShutdown 注册关闭钩子, 钩子在监听停止、存量请求处理完成后按 order 依次执行
+func (s *Server) OnShutdown(name string, order int, hook ShutdownHook) {
+	s.hooks.Register(name, order, hook)
+}
+
+// Run 启动服务并阻塞, 收到退出信号后停止监听, 等待存量请求完成后执行关闭钩子
+func (s *Server) Run() error {
+	logs.Infof("Web服务启动，监听地址：%s:%d, TLS: %v", s.cfg.Host, s.cfg.Port, s.hertz.GetOptions().TLS != nil)
+	s.hertz.Spin()
+	return s.runHooks()
+}
+
+// Shutdown 主动关闭服务, 用于非信号触发的场景
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.readiness.MarkShutdown()
+	if err := s.hertz.Shutdown(ctx); err != nil {
+		logs.Errorf("停止Web服务失败：%v", err)
+	}
+	return s.runHooks()
+}
+
+func (s *Server) runHooks() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.cfg.ShutdownTimeout)*time.Millisecond)
+	defer cancel()
+	err := s.hooks.Run(ctx)
+	logs.Infof("Web服务已停止")
+	return err
+}
@@ -0,0 +1,104 @@
+package hertzx
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ShutdownHook 关闭钩子
+type ShutdownHook func(ctx context.Context) error
+
+type shutdownEntry struct {
+	name  string
+	order int
+	seq   int
+	hook  ShutdownHook
+}
+
+// ShutdownHooks 有序关闭钩子注册表
+// 钩子在监听停止、存量请求处理完成后按 order 从小到大依次执行, order 相同时按注册顺序执行
+type ShutdownHooks struct {
+	mu      sync.Mutex
+	entries []*shutdownEntry
+	done    bool
+}
+
+// NewShutdownHooks 创建关闭钩子注册表
+func NewShutdownHooks() *ShutdownHooks {
+	return &ShutdownHooks{}
+}
+
+// Register 注册关闭钩子
+func (sh *ShutdownHooks) Register(name string, order int, hook ShutdownHook) {
+	if hook == nil {
+		return
+	}
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.entries = append(sh.entries, &shutdownEntry{
+		name:  name,
+		order: order,
+		seq:   len(sh.entries),
+		hook:  hook,
+	})
+}
+
+// RegisterCloser 注册仅返回error的关闭函数, 如 sql.DB.Close
+func (sh *ShutdownHooks) RegisterCloser(name string, order int, closer func() error) {
+	if closer == nil {
+		return
+	}
+	sh.Register(name, order, func(ctx context.Context) error {
+		return closer()
+	})
+}
+
+// Run 依次执行所有钩子, 仅执行一次, 返回执行过程中的第一个错误
+func (sh *ShutdownHooks) Run(ctx context.Context) error {
+	sh.mu.Lock()
+	if sh.done {
+		sh.mu.Unlock()
+		return nil
+	}
+	sh.done = true
+	entries := make([]*shutdownEntry, len(sh.entries))
+	copy(entries, sh.entries)
+	sh.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].order != entries[j].order {
+			return entries[i].order < entries[j].order
+		}
+		return entries[i].seq < entries[j].seq
+	})
+	var firstErr error
+	for _, e := range entries {
+		start := time.Now()
+		err := runShutdownHook(ctx, e)
+		if err != nil {
+			logs.Errorf("执行关闭钩子失败：%s, 错误：%v", e.name, err)
+			if firstErr == nil {
+				firstErr = errors.WithMessagef(err, "执行关闭钩子失败：%s", e.name)
+			}
+			continue
+		}
+		logs.Infof("执行关闭钩子成功：%s, 耗时：%v", e.name, time.Since(start))
+	}
+	return firstErr
+}
+
+func runShutdownHook(ctx context.Context, e *shutdownEntry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Errorf("panic: %v", r)
+		}
+	}()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return e.hook(ctx)
+}
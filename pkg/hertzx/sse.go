@@ -1,8 +1,19 @@
 package hertzx
 
 import (
+	"context"
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/network"
+	"github.com/cloudwego/hertz/pkg/protocol/http1/resp"
 	"github.com/hertz-contrib/sse"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"github.com/xiehqing/common/pkg/safego"
 	"github.com/xiehqing/common/pkg/util"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type SseSender struct {
@@ -36,3 +47,323 @@ func BuildDataEvent(data any) *sse.Event {
 		Data: []byte(m),
 	}
 }
+
+// EventName SSE事件名称
+type EventName string
+
+// 常用事件名称
+const (
+	EventMessage EventName = "message"
+	EventDelta   EventName = "delta"
+	EventError   EventName = "error"
+	EventDone    EventName = "done"
+)
+
+// OverflowPolicy 发送队列已满时的处理策略
+type OverflowPolicy int
+
+const (
+	// OverflowClose 关闭连接, 客户端可通过 Last-Event-ID 重连后补发
+	OverflowClose OverflowPolicy = iota
+	// OverflowDrop 丢弃当前事件
+	OverflowDrop
+)
+
+// sseConnCheckInterval 检查客户端连接是否断开的间隔
+const sseConnCheckInterval = time.Second
+
+var (
+	ErrSseClosed    = errors.New("sse stream closed")
+	ErrSseQueueFull = errors.New("sse send queue is full")
+)
+
+// SseOption SSE流选项
+type SseOption func(s *SseStream)
+
+// WithHeartbeat 心跳间隔, 定期发送注释行保持连接, 小于等于0时关闭心跳, 默认15s
+func WithHeartbeat(interval time.Duration) SseOption {
+	return func(s *SseStream) {
+		s.heartbeat = interval
+	}
+}
+
+// WithQueueSize 发送队列长度, 默认256
+func WithQueueSize(size int) SseOption {
+	return func(s *SseStream) {
+		if size > 0 {
+			s.queueSize = size
+		}
+	}
+}
+
+// WithOverflowPolicy 发送队列已满时的处理策略, 默认 OverflowClose
+func WithOverflowPolicy(policy OverflowPolicy) SseOption {
+	return func(s *SseStream) {
+		s.policy = policy
+	}
+}
+
+// WithReplay 开启事件补发, 事件保存在 store 中以 streamID 区分的环形缓冲区内
+// 客户端重连时携带 Last-Event-ID, 将补发该ID之后的事件
+func WithReplay(store *SseReplayStore, streamID string) SseOption {
+	return func(s *SseStream) {
+		s.replay, s.streamID = store, streamID
+	}
+}
+
+// SseStream 带心跳、事件补发与发送队列的SSE流
+// 生产者通过 Send 写入队列, 由处理器所在协程负责写出, 客户端断开时 Context 会被取消.
+// 连接支持 IsActive(netpoll)时每秒检查一次连接状态, 否则客户端断开要到下一次写出事件或心跳失败时才能发现
+type SseStream struct {
+	c      *app.RequestContext
+	writer network.ExtWriter
+	stream *sse.Stream
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	heartbeat time.Duration
+	queueSize int
+	policy    OverflowPolicy
+	replay    *SseReplayStore
+	streamID  string
+
+	mu       sync.RWMutex
+	sendMu   sync.Mutex // 保证分配ID、入队与写入补发缓冲区的顺序一致
+	queue    chan queuedEvent
+	closed   bool
+	seq      uint64
+	dropped  int64
+	err      error
+	replayed uint64 // 已补发的最大事件ID, 队列中不大于该ID的事件不再发送
+}
+
+// queuedEvent 队列中的事件, seq 为补发缓冲区分配的ID, 未开启补发或自定义ID时为0
+type queuedEvent struct {
+	event *sse.Event
+	seq   uint64
+}
+
+// NewSseStream 创建SSE流, 返回的流需调用 Run 或 Wait 阻塞处理器直到推送结束
+func NewSseStream(ctx context.Context, c *app.RequestContext, opts ...SseOption) *SseStream {
+	return newSseStream(ctx, c, resp.NewChunkedBodyWriter(&c.Response, c.GetWriter()), opts...)
+}
+
+func newSseStream(ctx context.Context, c *app.RequestContext, writer network.ExtWriter, opts ...SseOption) *SseStream {
+	s := &SseStream{
+		c:         c,
+		writer:    writer,
+		heartbeat: 15 * time.Second,
+		queueSize: 256,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	s.queue = make(chan queuedEvent, s.queueSize)
+	c.Response.Header.Set("X-Accel-Buffering", "no")
+	s.stream = sse.NewStreamWithWriter(c, writer)
+	return s
+}
+
+// Context 推送上下文, 客户端断开、写出失败或流关闭时取消, 断开的发现时机见 SseStream
+func (s *SseStream) Context() context.Context {
+	return s.ctx
+}
+
+// Dropped 因队列已满未能发送的事件数
+func (s *SseStream) Dropped() int64 {
+	return atomic.LoadInt64(&s.dropped)
+}
+
+// Send 发送事件, data 为字符串时原样发送, 其他类型序列化为JSON
+func (s *SseStream) Send(name EventName, data any) error {
+	event := BuildDataEvent(data)
+	if event == nil {
+		event = &sse.Event{}
+	}
+	e := *event
+	if name != "" {
+		e.Event = string(name)
+	}
+	return s.SendEvent(&e)
+}
+
+// SendEvent 发送原始事件, 未设置ID时自动分配递增ID
+func (s *SseStream) SendEvent(e *sse.Event) error {
+	err := s.enqueue(e)
+	if err == ErrSseQueueFull && s.policy == OverflowClose {
+		s.fail(err)
+	}
+	return err
+}
+
+func (s *SseStream) enqueue(e *sse.Event) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrSseClosed
+	}
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	var seq uint64
+	if e.ID == "" {
+		if s.replay != nil {
+			seq = s.replay.nextID(s.streamID)
+		} else {
+			seq = atomic.AddUint64(&s.seq, 1)
+		}
+		e.ID = strconv.FormatUint(seq, 10)
+	}
+	select {
+	case <-s.ctx.Done():
+		return ErrSseClosed
+	default:
+	}
+	select {
+	case s.queue <- queuedEvent{event: e, seq: seq}:
+		if s.replay != nil && seq > 0 {
+			s.replay.add(s.streamID, e)
+		}
+		return nil
+	default:
+	}
+	atomic.AddInt64(&s.dropped, 1)
+	return ErrSseQueueFull
+}
+
+// Close 停止接收新事件, 队列中的事件写出后结束推送
+func (s *SseStream) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+}
+
+// Run 在独立协程中执行生产者并阻塞直到推送结束, 生产者返回后自动关闭流
+func (s *SseStream) Run(producer func(ctx context.Context, s *SseStream) error) error {
+	done := make(chan error, 1)
+	safego.Go(s.ctx, func() {
+		var err error
+		// 先传出错误再关闭队列, Wait 因队列关闭返回时一定能取到生产者的结果
+		defer func() {
+			done <- err
+			s.Close()
+		}()
+		err = producer(s.ctx, s)
+		if err != nil {
+			logs.CtxWarnf(s.ctx, "sse producer exit with error: %v", err)
+		}
+	})
+	if err := s.Wait(); err != nil {
+		return err
+	}
+	select {
+	case err := <-done:
+		return err
+	default:
+		// 上下文取消导致提前结束时生产者可能仍在运行, 不等待其返回
+		return nil
+	}
+}
+
+// Wait 写出队列中的事件并发送心跳, 直到流关闭或客户端断开
+func (s *SseStream) Wait() error {
+	defer s.cancel()
+	if err := s.writeReplay(); err != nil {
+		s.fail(err)
+		return err
+	}
+	var tick <-chan time.Time
+	if s.heartbeat > 0 {
+		ticker := time.NewTicker(s.heartbeat)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var check <-chan time.Time
+	if _, ok := s.c.GetConn().(interface{ IsActive() bool }); ok {
+		ticker := time.NewTicker(sseConnCheckInterval)
+		defer ticker.Stop()
+		check = ticker.C
+	}
+	for {
+		select {
+		case qe, ok := <-s.queue:
+			if !ok {
+				return nil
+			}
+			// 补发快照之前已入队的事件已经补发过
+			if s.replay != nil && qe.seq > 0 && qe.seq <= s.replayed {
+				continue
+			}
+			if err := s.stream.Publish(qe.event); err != nil {
+				s.fail(err)
+				return err
+			}
+		case <-tick:
+			if err := s.ping(); err != nil {
+				s.fail(err)
+				return err
+			}
+		case <-check:
+			if !s.active() {
+				s.fail(ErrSseClosed)
+				return ErrSseClosed
+			}
+		case <-s.ctx.Done():
+			s.mu.RLock()
+			err := s.err
+			s.mu.RUnlock()
+			return err
+		}
+	}
+}
+
+// writeReplay 根据 Last-Event-ID 补发缓冲区中的事件, 并记录补发的最大ID
+func (s *SseStream) writeReplay() error {
+	if s.replay == nil {
+		return nil
+	}
+	lastID := sse.GetLastEventID(s.c)
+	if lastID == "" {
+		return nil
+	}
+	for _, e := range s.replay.since(s.streamID, lastID) {
+		if err := s.stream.Publish(e); err != nil {
+			return err
+		}
+		if id, err := strconv.ParseUint(e.ID, 10, 64); err == nil && id > s.replayed {
+			s.replayed = id
+		}
+	}
+	return nil
+}
+
+// active 连接是否仍然可用, 无法判断时返回 true
+func (s *SseStream) active() bool {
+	conn, ok := s.c.GetConn().(interface{ IsActive() bool })
+	return !ok || conn.IsActive()
+}
+
+// ping 发送注释行作为心跳, 并检查连接是否仍然可用
+func (s *SseStream) ping() error {
+	if !s.active() {
+		return ErrSseClosed
+	}
+	if _, err := s.writer.Write([]byte(": ping\n\n")); err != nil {
+		return err
+	}
+	return s.writer.Flush()
+}
+
+// fail 记录错误并取消上下文, 通知生产者停止
+func (s *SseStream) fail(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+	s.cancel()
+}
@@ -0,0 +1,113 @@
+package hertzx
+
+import (
+	"github.com/hertz-contrib/sse"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SseReplayStore 保存各个流最近的事件, 用于断线重连后的补发
+// 每个流保存最近 size 条事件, 超过 ttl 未写入的流会被清理
+type SseReplayStore struct {
+	size      int
+	ttl       time.Duration
+	mu        sync.Mutex
+	streams   map[string]*replayBuffer
+	lastEvict time.Time
+}
+
+type replayBuffer struct {
+	events  []*sse.Event
+	start   int
+	seq     uint64
+	updated time.Time
+}
+
+// NewSseReplayStore 创建补发缓冲区, size 默认100, ttl 默认10分钟
+func NewSseReplayStore(size int, ttl time.Duration) *SseReplayStore {
+	if size <= 0 {
+		size = 100
+	}
+	if ttl <= 0 {
+		ttl = 10 * time.Minute
+	}
+	return &SseReplayStore{size: size, ttl: ttl, streams: map[string]*replayBuffer{}}
+}
+
+// Delete 删除流的缓冲区, 流正常结束后调用
+func (r *SseReplayStore) Delete(streamID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.streams, streamID)
+}
+
+// nextID 分配流的下一个事件ID
+func (r *SseReplayStore) nextID(streamID string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.buffer(streamID)
+	b.seq++
+	return b.seq
+}
+
+// add 保存已进入发送队列的事件, 被丢弃的事件不保存, 避免重连后补发
+func (r *SseReplayStore) add(streamID string, e *sse.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := r.buffer(streamID)
+	cp := *e
+	if len(b.events) < r.size {
+		b.events = append(b.events, &cp)
+	} else {
+		b.events[b.start] = &cp
+		b.start = (b.start + 1) % r.size
+	}
+}
+
+// buffer 获取流的缓冲区, 不存在时创建
+func (r *SseReplayStore) buffer(streamID string) *replayBuffer {
+	now := time.Now()
+	r.evict(now)
+	b, ok := r.streams[streamID]
+	if !ok {
+		b = &replayBuffer{}
+		r.streams[streamID] = b
+	}
+	b.updated = now
+	return b
+}
+
+// since 获取 lastID 之后的事件, lastID 无法识别时返回缓冲区中的全部事件
+func (r *SseReplayStore) since(streamID, lastID string) []*sse.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.streams[streamID]
+	if !ok {
+		return nil
+	}
+	last, err := strconv.ParseUint(lastID, 10, 64)
+	if err != nil {
+		last = 0
+	}
+	var events []*sse.Event
+	for i := 0; i < len(b.events); i++ {
+		e := b.events[(b.start+i)%len(b.events)]
+		if id, _ := strconv.ParseUint(e.ID, 10, 64); id > last {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+func (r *SseReplayStore) evict(now time.Time) {
+	if now.Sub(r.lastEvict) < time.Minute {
+		return
+	}
+	r.lastEvict = now
+	for id, b := range r.streams {
+		if now.Sub(b.updated) > r.ttl {
+			delete(r.streams, id)
+		}
+	}
+}
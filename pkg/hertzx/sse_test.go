@@ -0,0 +1,155 @@
+package hertzx
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/hertz-contrib/sse"
+)
+
+type bufferWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *bufferWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *bufferWriter) Flush() error    { return nil }
+func (w *bufferWriter) Finalize() error { return nil }
+
+func (w *bufferWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestSseStreamReplay(t *testing.T) {
+	store := NewSseReplayStore(2, time.Minute)
+	w := &bufferWriter{}
+	s := newSseStream(context.Background(), app.NewContext(0), w, WithReplay(store, "chat-1"), WithHeartbeat(5*time.Millisecond))
+	err := s.Run(func(ctx context.Context, s *SseStream) error {
+		for _, text := range []string{"a", "b", "c"} {
+			if err := s.Send(EventDelta, text); err != nil {
+				return err
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return s.Send(EventDone, map[string]string{"status": "ok"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := w.String()
+	if !strings.Contains(out, "id:3\nevent:delta\ndata:c\n\n") || !strings.Contains(out, ": ping\n\n") {
+		t.Fatalf("unexpected output: %q", out)
+	}
+
+	c := app.NewContext(0)
+	c.Request.Header.Set("Last-Event-ID", "2")
+	w2 := &bufferWriter{}
+	s2 := newSseStream(context.Background(), c, w2, WithReplay(store, "chat-1"), WithHeartbeat(0))
+	s2.Close()
+	if err := s2.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if out := w2.String(); !strings.HasPrefix(out, "id:3\n") || !strings.Contains(out, "id:4\nevent:done") {
+		t.Fatalf("unexpected replay: %q", out)
+	}
+}
+
+func TestSseStreamReconnect(t *testing.T) {
+	store := NewSseReplayStore(1000, time.Minute)
+	for i := 0; i < 3; i++ {
+		store.add("chat-2", &sse.Event{ID: strconv.FormatUint(store.nextID("chat-2"), 10)})
+	}
+	c := app.NewContext(0)
+	c.Request.Header.Set("Last-Event-ID", "1")
+	w := &bufferWriter{}
+	s := newSseStream(context.Background(), c, w, WithReplay(store, "chat-2"), WithHeartbeat(0))
+	// 补发前已入队的事件同时出现在补发快照与发送队列中
+	if err := s.Send(EventDelta, "early"); err != nil {
+		t.Fatal(err)
+	}
+	err := s.Run(func(ctx context.Context, s *SseStream) error {
+		for i := 0; i < 200; i++ {
+			if err := s.Send(EventDelta, strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := regexp.MustCompile(`(?m)^id:(\d+)$`).FindAllStringSubmatch(w.String(), -1)
+	if len(ids) != 203 {
+		t.Fatalf("expected 203 events, got %d", len(ids))
+	}
+	for i, m := range ids {
+		if m[1] != strconv.Itoa(i+2) {
+			t.Fatalf("event %d has id %s, events must not repeat", i, m[1])
+		}
+	}
+
+	// 队列已满被丢弃的事件不进入补发缓冲区
+	s = newSseStream(context.Background(), app.NewContext(0), &bufferWriter{}, WithReplay(store, "chat-3"),
+		WithQueueSize(1), WithOverflowPolicy(OverflowDrop))
+	_ = s.Send(EventMessage, "a")
+	if err := s.Send(EventMessage, "b"); err != ErrSseQueueFull {
+		t.Fatalf("expected drop, got %v", err)
+	}
+	if events := store.since("chat-3", ""); len(events) != 1 || string(events[0].Data) != "a" {
+		t.Fatalf("dropped event should not be replayed: %v", events)
+	}
+}
+
+func TestSseStreamOverflow(t *testing.T) {
+	s := newSseStream(context.Background(), app.NewContext(0), &bufferWriter{}, WithQueueSize(1), WithOverflowPolicy(OverflowDrop))
+	_ = s.Send(EventMessage, "a")
+	if err := s.Send(EventMessage, "b"); err != ErrSseQueueFull || s.Dropped() != 1 {
+		t.Fatalf("expected drop, got %v", err)
+	}
+
+	s = newSseStream(context.Background(), app.NewContext(0), &bufferWriter{}, WithQueueSize(1))
+	_ = s.Send(EventMessage, "a")
+	_ = s.Send(EventMessage, "b")
+	if s.Context().Err() == nil {
+		t.Fatal("expected context cancelled after overflow")
+	}
+	if err := s.Wait(); err != ErrSseQueueFull {
+		t.Fatalf("expected queue full error, got %v", err)
+	}
+}
+
+func TestSseStreamRunResult(t *testing.T) {
+	s := newSseStream(context.Background(), app.NewContext(0), &bufferWriter{}, WithHeartbeat(0))
+	if err := s.Run(func(ctx context.Context, s *SseStream) error {
+		return context.DeadlineExceeded
+	}); err != context.DeadlineExceeded {
+		t.Fatalf("expected producer error, got %v", err)
+	}
+
+	// 上下文取消时不等待仍在运行的生产者, 也不能读写其结果产生数据竞争
+	ctx, cancel := context.WithCancel(context.Background())
+	s = newSseStream(ctx, app.NewContext(0), &bufferWriter{}, WithHeartbeat(0))
+	release := make(chan struct{})
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := s.Run(func(ctx context.Context, s *SseStream) error {
+		<-release
+		return context.Canceled
+	}); err != nil {
+		t.Fatalf("expected nil after cancel, got %v", err)
+	}
+	close(release)
+}
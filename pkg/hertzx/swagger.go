@@ -0,0 +1,33 @@
+package hertzx
+
+import (
+	"fmt"
+	"html"
+)
+
+// swaggerUIPage Swagger UI 页面, 静态资源从 SwaggerUICDN 加载
+func swaggerUIPage(cfg OpenAPIConfig) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8" />
+  <meta name="viewport" content="width=device-width, initial-scale=1" />
+  <title>%s</title>
+  <link rel="stylesheet" href="%s/swagger-ui.css" />
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="%s/swagger-ui-bundle.js" crossorigin></script>
+<script>
+  window.onload = function () {
+    window.ui = SwaggerUIBundle({
+      url: %q,
+      dom_id: "#swagger-ui",
+      deepLinking: true,
+      persistAuthorization: true
+    });
+  };
+</script>
+</body>
+</html>`, html.EscapeString(cfg.Title), cfg.SwaggerUICDN, cfg.SwaggerUICDN, cfg.DocPath)
+}
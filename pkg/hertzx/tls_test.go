@@ -0,0 +1,80 @@
+package hertzx
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/xiehqing/common/pkg/tlsx"
+)
+
+func TestWebEngineTLS(t *testing.T) {
+	ca, err := tlsx.NewCA("web-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ca.IssueServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := ca.IssueClient("web-client", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert, err := client.TLSCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 证书文件开启热加载, 服务关闭时停止文件监听
+	certFile, keyFile, err := server.WriteFiles(t.TempDir(), "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := freePort(t)
+	h := WebEngine(WebConfig{Host: "127.0.0.1", Port: port, TLS: tlsx.ServerConfig{
+		TLSCert:           certFile,
+		TLSKey:            keyFile,
+		TLSAllowedCACerts: []string{string(ca.CertPEM)},
+		TLSAutoReload:     true,
+	}})
+	h.GET("/ping", func(ctx context.Context, c *app.RequestContext) {
+		c.String(http.StatusOK, "pong")
+	})
+	go h.Run()
+	defer h.Shutdown(context.Background())
+
+	base := fmt.Sprintf("127.0.0.1:%d", port)
+	get := func(scheme string, cfg *tls.Config) (*http.Response, error) {
+		hc := &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true}, Timeout: time.Second}
+		return hc.Get(scheme + "://" + base + "/ping")
+	}
+	var resp *http.Response
+	for i := 0; ; i++ {
+		resp, err = get("https", &tls.Config{RootCAs: ca.Pool(), Certificates: []tls.Certificate{clientCert}})
+		if err == nil {
+			break
+		} else if i > 50 {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Fatalf("unexpected response %d", resp.StatusCode)
+	}
+	// 没有客户端证书时握手失败, 明文请求不会得到正常响应
+	if _, err := get("https", &tls.Config{RootCAs: ca.Pool()}); err == nil {
+		t.Fatal("expected handshake failure without client certificate")
+	}
+	if resp, err := get("http", nil); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Fatal("server accepted plaintext request")
+		}
+	}
+}
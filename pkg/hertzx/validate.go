@@ -0,0 +1,15 @@
+package hertzx
+
+import (
+	"github.com/xiehqing/common/pkg/validate"
+	"reflect"
+)
+
+// validateField 按规则校验字段, 返回该字段的所有错误
+func validateField(f *fieldInfo, fv reflect.Value) FieldErrors {
+	var errs FieldErrors
+	for _, msg := range validate.Check(f.rules, fv) {
+		errs = append(errs, FieldError{Field: f.name, Message: msg})
+	}
+	return errs
+}
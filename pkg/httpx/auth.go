@@ -0,0 +1,216 @@
+package httpx
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthProvider 认证方式, 在请求发出前设置认证信息
+type AuthProvider interface {
+	Apply(req *http.Request) error
+}
+
+// AuthProviderFunc 函数形式的认证方式
+type AuthProviderFunc func(req *http.Request) error
+
+func (f AuthProviderFunc) Apply(req *http.Request) error {
+	return f(req)
+}
+
+// BasicAuth 基础认证
+func BasicAuth(username, password string) AuthProvider {
+	token := "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	return AuthProviderFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", token)
+		return nil
+	})
+}
+
+// BearerToken Bearer 令牌认证
+func BearerToken(token string) AuthProvider {
+	return AuthProviderFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// APIKeyHeader 通过请求头传递 API Key, header 为空时使用 X-API-Key
+func APIKeyHeader(header, key string) AuthProvider {
+	if header == "" {
+		header = "X-API-Key"
+	}
+	return AuthProviderFunc(func(req *http.Request) error {
+		req.Header.Set(header, key)
+		return nil
+	})
+}
+
+// OAuth2Config OAuth2 客户端凭证模式配置
+type OAuth2Config struct {
+	TokenURL     string            `json:"tokenUrl" yaml:"token-url" mapstructure:"token-url"`
+	ClientID     string            `json:"clientId" yaml:"client-id" mapstructure:"client-id"`
+	ClientSecret string            `json:"clientSecret" yaml:"client-secret" mapstructure:"client-secret"`
+	Scopes       []string          `json:"scopes" yaml:"scopes" mapstructure:"scopes"`
+	Params       map[string]string `json:"params" yaml:"params" mapstructure:"params"`                       // 额外的请求参数, 如 audience
+	AuthInHeader bool              `json:"authInHeader" yaml:"auth-in-header" mapstructure:"auth-in-header"` // 是否通过 Basic 认证头传递客户端凭证
+	ExpiryDelta  time.Duration     `json:"expiryDelta" yaml:"expiry-delta" mapstructure:"expiry-delta"`      // 提前刷新时间, 默认30s, 最多为令牌有效期的 1/4
+}
+
+// OAuth2ClientCredentials 客户端凭证模式认证, 令牌缓存到过期前 ExpiryDelta
+type OAuth2ClientCredentials struct {
+	cfg    OAuth2Config
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewOAuth2ClientCredentials 创建客户端凭证模式认证, client 为空时使用默认客户端
+func NewOAuth2ClientCredentials(cfg OAuth2Config, client *http.Client) *OAuth2ClientCredentials {
+	if cfg.ExpiryDelta <= 0 {
+		cfg.ExpiryDelta = 30 * time.Second
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OAuth2ClientCredentials{cfg: cfg, client: client}
+}
+
+func (o *OAuth2ClientCredentials) Apply(req *http.Request) error {
+	token, err := o.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Invalidate 清除缓存的令牌, 下次请求时重新获取
+func (o *OAuth2ClientCredentials) Invalidate() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.token = ""
+}
+
+// Token 获取访问令牌, 缓存有效时直接返回
+func (o *OAuth2ClientCredentials) Token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.token != "" && time.Now().Before(o.expires) {
+		return o.token, nil
+	}
+	token, expiresIn, err := o.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	o.token = token
+	if expiresIn <= 0 {
+		expiresIn = 3600
+	}
+	o.expires = tokenExpiry(time.Now(), time.Duration(expiresIn)*time.Second, o.cfg.ExpiryDelta)
+	return token, nil
+}
+
+// tokenExpiry 令牌的缓存截止时间. 提前刷新时间最多为有效期的 1/4,
+// 避免有效期小于 ExpiryDelta 的令牌每次请求都重新获取
+func tokenExpiry(now time.Time, lifetime, delta time.Duration) time.Time {
+	if limit := lifetime / 4; delta > limit {
+		delta = limit
+	}
+	return now.Add(lifetime - delta)
+}
+
+func (o *OAuth2ClientCredentials) fetch(ctx context.Context) (string, int64, error) {
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(o.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.cfg.Scopes, " "))
+	}
+	for k, v := range o.cfg.Params {
+		form.Set(k, v)
+	}
+	if !o.cfg.AuthInHeader {
+		form.Set("client_id", o.cfg.ClientID)
+		form.Set("client_secret", o.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if o.cfg.AuthInHeader {
+		req.SetBasicAuth(url.QueryEscape(o.cfg.ClientID), url.QueryEscape(o.cfg.ClientSecret))
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", 0, errors.WithMessage(err, "获取OAuth2令牌失败")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, errors.WithMessage(err, "读取OAuth2令牌失败")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, errors.Errorf("获取OAuth2令牌失败, 状态码: %d, 响应: %s", resp.StatusCode, string(body))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", 0, errors.WithMessage(err, "解析OAuth2令牌失败")
+	}
+	if token.AccessToken == "" {
+		return "", 0, errors.Errorf("OAuth2响应中缺少 access_token: %s", string(body))
+	}
+	return token.AccessToken, token.ExpiresIn, nil
+}
+
+// AuthMiddleware 认证中间件, 响应 401 时清除可失效的缓存令牌
+func AuthMiddleware(provider AuthProvider) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			r := req.Clone(req.Context())
+			if err := provider.Apply(r); err != nil {
+				return nil, err
+			}
+			resp, err := next.RoundTrip(r)
+			if err == nil && resp.StatusCode == http.StatusUnauthorized {
+				if inv, ok := provider.(interface{ Invalidate() }); ok {
+					inv.Invalidate()
+				}
+			}
+			return resp, err
+		})
+	}
+}
+
+// HeadersMiddleware 默认请求头中间件, 请求中已设置的请求头不会被覆盖
+func HeadersMiddleware(headers map[string]string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			r := req
+			for k, v := range headers {
+				if r.Header.Get(k) != "" {
+					continue
+				}
+				if r == req {
+					r = req.Clone(req.Context())
+				}
+				r.Header.Set(k, v)
+			}
+			return next.RoundTrip(r)
+		})
+	}
+}
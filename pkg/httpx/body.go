@@ -0,0 +1,210 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FormBody application/x-www-form-urlencoded 请求体
+type FormBody url.Values
+
+// RawBody 原样发送的字符串请求体. 普通 string 请求体与其他类型一样按 JSON 编码
+type RawBody string
+
+// ReaderBody 流式请求体, 内容原样发送
+type ReaderBody struct {
+	Reader      io.Reader
+	ContentType string
+	Length      int64 // 内容长度, 未知时为0
+}
+
+// FormFile multipart 文件, Reader 与 Path 二选一
+type FormFile struct {
+	Field       string
+	FileName    string
+	ContentType string
+	Reader      io.Reader
+	Path        string
+}
+
+// MultipartBody multipart/form-data 请求体, 以流的方式边读边发送, 不会整体加载到内存
+type MultipartBody struct {
+	Fields map[string]string
+	Files  []FormFile
+}
+
+// WithFormBody 设置表单请求体
+func WithFormBody(values map[string]string) Option {
+	return func(option *RequestOption) {
+		form := url.Values{}
+		for k, v := range values {
+			form.Set(k, v)
+		}
+		option.Body = FormBody(form)
+	}
+}
+
+// WithRawBody 设置原样发送的字符串请求体, contentType 为空时不设置 Content-Type
+func WithRawBody(body string, contentType string) Option {
+	return func(option *RequestOption) {
+		if contentType == "" {
+			option.Body = RawBody(body)
+			return
+		}
+		option.Body = &ReaderBody{Reader: strings.NewReader(body), ContentType: contentType, Length: int64(len(body))}
+	}
+}
+
+// WithReaderBody 设置流式请求体
+func WithReaderBody(reader io.Reader, contentType string) Option {
+	return func(option *RequestOption) {
+		option.Body = &ReaderBody{Reader: reader, ContentType: contentType}
+	}
+}
+
+// WithMultipartBody 设置 multipart/form-data 请求体
+func WithMultipartBody(fields map[string]string, files ...FormFile) Option {
+	return func(option *RequestOption) {
+		option.Body = &MultipartBody{Fields: fields, Files: files}
+	}
+}
+
+// encodeBody 根据请求体类型编码, 返回请求体与默认的 Content-Type
+func encodeBody(body interface{}) (io.Reader, string, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, "", nil
+	case []byte:
+		return bytes.NewReader(b), "", nil
+	case RawBody:
+		return strings.NewReader(string(b)), "", nil
+	case FormBody:
+		return strings.NewReader(url.Values(b).Encode()), "application/x-www-form-urlencoded", nil
+	case url.Values:
+		return strings.NewReader(b.Encode()), "application/x-www-form-urlencoded", nil
+	case *ReaderBody:
+		return b.Reader, b.ContentType, nil
+	case *MultipartBody:
+		return b.stream()
+	case io.Reader:
+		return b, "", nil
+	default:
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, "", fmt.Errorf("解析http请求结果失败: %v", err)
+		}
+		return bytes.NewReader(jsonData), "application/json", nil
+	}
+}
+
+// stream 通过管道写出 multipart 内容, 写出失败时请求会随之失败.
+// 返回的 *io.PipeReader 未被读取完时需要关闭, 否则写出的 goroutine 会一直阻塞
+func (m *MultipartBody) stream() (io.Reader, string, error) {
+	for _, f := range m.Files {
+		if f.Reader == nil && f.Path == "" {
+			return nil, "", fmt.Errorf("文件字段 %s 未指定内容", f.Field)
+		}
+	}
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(m.write(mw))
+	}()
+	return pr, mw.FormDataContentType(), nil
+}
+
+func (m *MultipartBody) write(mw *multipart.Writer) error {
+	for k, v := range m.Fields {
+		if err := mw.WriteField(k, v); err != nil {
+			return err
+		}
+	}
+	for _, f := range m.Files {
+		if err := writeFormFile(mw, f); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+func writeFormFile(mw *multipart.Writer, f FormFile) error {
+	reader := f.Reader
+	if reader == nil {
+		file, err := os.Open(f.Path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		reader = file
+	}
+	name := f.FileName
+	if name == "" && f.Path != "" {
+		name = filepath.Base(f.Path)
+	}
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(f.Field), escapeQuotes(name)))
+	h.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, reader)
+	return err
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// logBody 请求日志中的请求体, 流式请求体不读取内容
+func logBody(body interface{}) interface{} {
+	switch b := body.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return string(b)
+	case RawBody:
+		return string(b)
+	case FormBody:
+		return url.Values(b).Encode()
+	case url.Values:
+		return b.Encode()
+	case *MultipartBody:
+		var files []string
+		for _, f := range b.Files {
+			files = append(files, f.Field+"="+firstNonEmpty(f.FileName, filepath.Base(f.Path)))
+		}
+		return map[string]interface{}{"fields": b.Fields, "files": files}
+	case *ReaderBody, io.Reader:
+		return "<stream>"
+	default:
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Sprintf("<%v>", err)
+		}
+		return string(jsonData)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
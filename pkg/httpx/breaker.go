@@ -0,0 +1,225 @@
+package httpx
+
+import (
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器处于打开状态, 请求被直接拒绝
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	StateClosed BreakerState = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig 熔断配置
+type BreakerConfig struct {
+	FailureThreshold int                 // 连续失败多少次后打开熔断, 默认5
+	OpenTimeout      time.Duration       // 打开状态持续时间, 之后进入半开状态, 默认30s
+	HalfOpenRequests int                 // 半开状态允许的探测请求数, 全部成功后关闭熔断, 默认1
+	IsFailure        func(code int) bool // 判断响应是否视为失败, 默认 5xx
+}
+
+func (c *BreakerConfig) Prepare() {
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = 5
+	}
+	if c.OpenTimeout <= 0 {
+		c.OpenTimeout = 30 * time.Second
+	}
+	if c.HalfOpenRequests <= 0 {
+		c.HalfOpenRequests = 1
+	}
+	if c.IsFailure == nil {
+		c.IsFailure = func(code int) bool {
+			return code >= http.StatusInternalServerError
+		}
+	}
+}
+
+// CircuitBreaker 熔断器
+type CircuitBreaker struct {
+	name       string
+	cfg        BreakerConfig
+	mu         sync.Mutex
+	state      BreakerState
+	generation uint64 // 每次状态切换加一, 用于丢弃切换前放行的请求结果
+	failures   int
+	openedAt   time.Time
+	probing    int
+	successes  int
+}
+
+// breakerTicket 放行请求时的状态代次, 以及是否占用了半开探测名额
+type breakerTicket struct {
+	generation uint64
+	probe      bool
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(name string, cfg BreakerConfig) *CircuitBreaker {
+	cfg.Prepare()
+	return &CircuitBreaker{name: name, cfg: cfg}
+}
+
+// State 当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currentState(time.Now())
+}
+
+// Allow 判断是否允许请求, 允许时返回 done, 需在请求结束后调用一次上报结果, 重复调用会被忽略.
+// 状态切换前放行的请求, 其结果不会计入切换后的状态
+func (b *CircuitBreaker) Allow() (done func(success bool), ok bool) {
+	t, ok := b.acquire()
+	if !ok {
+		return nil, false
+	}
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() { b.finish(t, success, true) })
+	}, true
+}
+
+func (b *CircuitBreaker) acquire() (breakerTicket, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.currentState(time.Now()) {
+	case StateClosed:
+		return breakerTicket{generation: b.generation}, true
+	case StateHalfOpen:
+		if b.probing >= b.cfg.HalfOpenRequests {
+			return breakerTicket{}, false
+		}
+		b.probing++
+		return breakerTicket{generation: b.generation, probe: true}, true
+	default:
+		return breakerTicket{}, false
+	}
+}
+
+// finish 上报请求结果, counted 为 false 时只释放探测名额, 不计入成功或失败
+func (b *CircuitBreaker) finish(t breakerTicket, success, counted bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if t.generation != b.generation {
+		return
+	}
+	if t.probe {
+		b.probing--
+	}
+	if !counted {
+		return
+	}
+	switch b.state {
+	case StateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.trip()
+		}
+	case StateHalfOpen:
+		if !success {
+			b.trip()
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenRequests {
+			logs.Infof("熔断器[%s]探测成功, 恢复请求", b.name)
+			b.setState(StateClosed)
+		}
+	}
+}
+
+// currentState 打开状态超时后转为半开
+func (b *CircuitBreaker) currentState(now time.Time) BreakerState {
+	if b.state == StateOpen && now.Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) trip() {
+	logs.Warnf("熔断器[%s]打开, 连续失败 %d 次, %v 后尝试恢复", b.name, b.failures, b.cfg.OpenTimeout)
+	b.setState(StateOpen)
+	b.openedAt = time.Now()
+}
+
+// setState 切换状态并重置计数, 之前放行的请求结果将被忽略
+func (b *CircuitBreaker) setState(state BreakerState) {
+	b.state, b.failures, b.probing, b.successes = state, 0, 0, 0
+	b.generation++
+}
+
+// BreakerGroup 按主机划分的熔断器集合
+type BreakerGroup struct {
+	cfg      BreakerConfig
+	breakers sync.Map // map[string]*CircuitBreaker
+}
+
+// NewBreakerGroup 创建熔断器集合
+func NewBreakerGroup(cfg BreakerConfig) *BreakerGroup {
+	cfg.Prepare()
+	return &BreakerGroup{cfg: cfg}
+}
+
+// Get 获取主机对应的熔断器
+func (g *BreakerGroup) Get(host string) *CircuitBreaker {
+	if b, ok := g.breakers.Load(host); ok {
+		return b.(*CircuitBreaker)
+	}
+	b, _ := g.breakers.LoadOrStore(host, NewCircuitBreaker(host, g.cfg))
+	return b.(*CircuitBreaker)
+}
+
+// CircuitBreakerMiddleware 按主机熔断的中间件, 熔断打开时返回 ErrCircuitOpen
+func CircuitBreakerMiddleware(cfg BreakerConfig) Middleware {
+	group := NewBreakerGroup(cfg)
+	return group.Middleware()
+}
+
+// Middleware 使用当前集合的熔断中间件
+func (g *BreakerGroup) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			b := g.Get(req.URL.Host)
+			t, ok := b.acquire()
+			if !ok {
+				return nil, errors.WithMessagef(ErrCircuitOpen, "host %s", req.URL.Host)
+			}
+			resp, err := next.RoundTrip(req)
+			if err != nil {
+				// 调用方主动取消不计入成功或失败, 只释放探测名额
+				b.finish(t, false, req.Context().Err() == nil)
+				return nil, err
+			}
+			b.finish(t, !g.cfg.IsFailure(resp.StatusCode), true)
+			return resp, nil
+		})
+	}
+}
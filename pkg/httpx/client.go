@@ -2,9 +2,9 @@ package httpx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/xiehqing/common/pkg/logs"
 	"io"
 	"net/http"
 	"net/url"
@@ -49,19 +49,10 @@ func NewDefaultClient(baseUrl string) *Client {
 }
 
 // buildRequest 构建HTTP请求
-func (c *Client) buildRequest(options *RequestOption) (*http.Request, error) {
-	var body io.Reader
-	if options.Body != nil {
-		// 判断options.Body是否是[]byte
-		if _, ok := options.Body.([]byte); ok {
-			body = bytes.NewBuffer(options.Body.([]byte))
-		} else {
-			jsonData, err := json.Marshal(options.Body)
-			if err != nil {
-				return nil, fmt.Errorf("解析http请求结果失败: %v", err)
-			}
-			body = bytes.NewBuffer(jsonData)
-		}
+func (c *Client) buildRequest(ctx context.Context, options *RequestOption) (*http.Request, error) {
+	body, contentType, err := encodeBody(options.Body)
+	if err != nil {
+		return nil, err
 	}
 	// 处理查询参数
 	reqURL := c.BaseUrl + options.Path
@@ -72,10 +63,20 @@ func (c *Client) buildRequest(options *RequestOption) (*http.Request, error) {
 		}
 		reqURL = fmt.Sprintf("%s?%s", reqURL, params.Encode())
 	}
-	req, err := http.NewRequest(options.Method.String(), reqURL, body)
+	req, err := http.NewRequestWithContext(ctx, options.Method.String(), reqURL, body)
 	if err != nil {
+		// multipart 请求体通过管道写出, 关闭后写出的 goroutine 才能退出
+		if pr, ok := body.(*io.PipeReader); ok {
+			pr.Close()
+		}
 		return nil, fmt.Errorf("创建HTTP请求失败: %v", err)
 	}
+	if rb, ok := options.Body.(*ReaderBody); ok && rb.Length > 0 {
+		req.ContentLength = rb.Length
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 	// 设置请求头
 	for key, value := range options.Headers {
 		req.Header.Set(key, value)
@@ -85,40 +86,17 @@ func (c *Client) buildRequest(options *RequestOption) (*http.Request, error) {
 
 // Do 发送HTTP请求并返回响应
 func (c *Client) Do(options *RequestOption) (*http.Response, error) {
+	return c.DoCtx(context.Background(), options)
+}
+
+// DoCtx 发送HTTP请求并返回响应, 请求随 ctx 取消, options.Timeout 大于0时覆盖客户端超时时间
+func (c *Client) DoCtx(ctx context.Context, options *RequestOption) (*http.Response, error) {
 	requestTime := time.Now()
-	logs.Infof("请求方法: %v", options)
-	request, err := c.buildRequest(options)
-	logs.Infof("发送HTTP请求: %v", request)
-	logs.Infof("打印日志: %v", options.PrintLog)
-	if options.PrintLog {
-		r := &RequestLog{
-			Timestamp: requestTime.Format("2006-01-02 15:04:05.000"),
-			Method:    options.Method.String(),
-			URL:       request.URL.String(),
-			Headers:   options.Headers,
-			Body:      options.Body,
-			RequestID: options.RequestID,
-		}
-		// 判断options.Body是否是[]byte
-		if _, ok := options.Body.([]byte); ok {
-			r.Body = string(options.Body.([]byte))
-		} else {
-			jsonData, err := json.Marshal(options.Body)
-			if err != nil {
-				logs.Errorf("解析http请求结果失败: %v", err)
-			} else {
-				r.Body = string(jsonData)
-			}
-		}
-		LogRequestJSON(r, options.Sensitive)
-	}
-	if err != nil {
-		return nil, err
-	}
-	response, err := c.Client.Do(request)
+	response, cancel, err := c.send(ctx, options, requestTime)
 	if err != nil {
 		return nil, err
 	}
+	defer cancel()
 	defer response.Body.Close()
 
 	// 读取响应体内容到缓冲区
@@ -165,9 +143,66 @@ func (c *Client) Do(options *RequestOption) (*http.Response, error) {
 	return bufferedResp.Response, nil
 }
 
+// DoStream 发送HTTP请求并直接返回响应, 响应体不做缓冲, 调用方读取完毕后需关闭 Body
+// 适用于大文件下载、SSE、NDJSON 等流式接口, 客户端超时包含读取响应体的时间, 长时间的流可通过 WithTimeout 覆盖
+func (c *Client) DoStream(ctx context.Context, options *RequestOption) (*http.Response, error) {
+	response, cancel, err := c.send(ctx, options, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	response.Body = &cancelBody{ReadCloser: response.Body, cancel: cancel}
+	if options.PrintLog {
+		LogResponseJSON(&ResponseLog{
+			Timestamp:  time.Now().Format("2006-01-02 15:04:05.000"),
+			StatusCode: response.StatusCode,
+			RequestID:  options.RequestID,
+			Body:       "<stream>",
+		})
+	}
+	return response, nil
+}
+
+// send 构建并发送请求, 返回的 cancel 需在响应体读取完毕后调用
+func (c *Client) send(ctx context.Context, options *RequestOption, requestTime time.Time) (*http.Response, context.CancelFunc, error) {
+	client := c.Client
+	cancel := context.CancelFunc(func() {})
+	if options.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		cli := *c.Client
+		cli.Timeout = 0
+		client = &cli
+	}
+	request, err := c.buildRequest(ctx, options)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if options.PrintLog {
+		LogRequestJSON(&RequestLog{
+			Timestamp: requestTime.Format("2006-01-02 15:04:05.000"),
+			Method:    options.Method.String(),
+			URL:       request.URL.String(),
+			Headers:   options.Headers,
+			Body:      logBody(options.Body),
+			RequestID: options.RequestID,
+		}, options.Sensitive)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return response, cancel, nil
+}
+
 // DoWithPtr 发送HTTP请求并返回响应
 func (c *Client) DoWithPtr(options *RequestOption, resp interface{}) error {
-	response, err := c.Do(options)
+	return c.DoWithPtrCtx(context.Background(), options, resp)
+}
+
+// DoWithPtrCtx 发送HTTP请求并将响应体解析到 resp
+func (c *Client) DoWithPtrCtx(ctx context.Context, options *RequestOption, resp interface{}) error {
+	response, err := c.DoCtx(ctx, options)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,139 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// partSuffix 下载中的临时文件后缀
+	partSuffix = ".part"
+	// validatorSuffix 记录临时文件对应的 ETag 或 Last-Modified, 续传时作为 If-Range 发送
+	validatorSuffix = ".part.validator"
+)
+
+// Download 下载文件到 dest, 返回文件大小
+// 下载过程写入 dest.part, 再次下载时通过 Range 与 If-Range 请求从已下载的位置继续, 完成后重命名为 dest.
+// 服务端不支持 Range、文件已变化或没有 ETag/Last-Modified 无法确认文件未变化时重新下载
+func (c *Client) Download(ctx context.Context, options *RequestOption, dest string) (int64, error) {
+	return c.download(ctx, options, dest, true)
+}
+
+func (c *Client) download(ctx context.Context, options *RequestOption, dest string, resume bool) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return 0, errors.WithMessagef(err, "创建目录失败")
+	}
+	part := dest + partSuffix
+	validatorPath := dest + validatorSuffix
+	var offset int64
+	var validator string
+	if fi, err := os.Stat(part); err == nil && resume {
+		if data, err := os.ReadFile(validatorPath); err == nil {
+			offset, validator = fi.Size(), strings.TrimSpace(string(data))
+		}
+	}
+	opt := *options
+	opt.Headers = make(map[string]string, len(options.Headers)+2)
+	for k, v := range options.Headers {
+		opt.Headers[k] = v
+	}
+	if offset > 0 && validator != "" {
+		opt.Headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+		opt.Headers["If-Range"] = validator
+	} else {
+		offset = 0
+	}
+	resp, err := c.DoStream(ctx, &opt)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, ok := contentRangeStart(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return 0, errors.Errorf("断点续传位置不一致, 期望 %d, Content-Range: %s", offset, resp.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// Content-Range: bytes */total 与已下载大小一致时说明已下载完整, 否则临时文件无效, 重新下载
+		if total, ok := contentRangeTotal(resp.Header.Get("Content-Range")); ok && total == offset {
+			if err := os.Rename(part, dest); err != nil {
+				return 0, err
+			}
+			os.Remove(validatorPath)
+			return offset, nil
+		}
+		resp.Body.Close()
+		return c.download(ctx, options, dest, false)
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+		// 记录本次下载内容的校验值, 中断后续传时用于确认文件未变化
+		if v := rangeValidator(resp.Header); v != "" {
+			if err := os.WriteFile(validatorPath, []byte(v), 0644); err != nil {
+				return 0, errors.WithMessagef(err, "保存下载校验值失败")
+			}
+		} else {
+			os.Remove(validatorPath)
+		}
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return 0, errors.Errorf("下载失败, 状态码: %d, 响应: %s", resp.StatusCode, string(body))
+	}
+
+	file, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "创建文件失败")
+	}
+	n, err := io.Copy(file, resp.Body)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return offset + n, errors.WithMessagef(err, "下载中断, 已下载 %d 字节", offset+n)
+	}
+	if err := os.Rename(part, dest); err != nil {
+		return 0, err
+	}
+	os.Remove(validatorPath)
+	return offset + n, nil
+}
+
+// rangeValidator If-Range 使用的校验值, 优先使用强 ETag, 弱 ETag 不能用于 If-Range
+func rangeValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
+
+// contentRangeTotal 解析 Content-Range: bytes */total 中的总大小
+func contentRangeTotal(value string) (int64, bool) {
+	_, total, ok := strings.Cut(value, "/")
+	if !ok || total == "*" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(total), 10, 64)
+	return n, err == nil
+}
+
+// contentRangeStart 解析 Content-Range: bytes start-end/total 中的起始位置
+func contentRangeStart(value string) (int64, bool) {
+	value = strings.TrimPrefix(value, "bytes ")
+	start, _, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(start), 10, 64)
+	return n, err == nil
+}
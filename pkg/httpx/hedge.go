@@ -0,0 +1,141 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HedgePolicy 对冲请求策略
+// 首个请求在 Delay 内未返回时再发出一个相同请求, 最多同时 MaxRequests 个, 取最先成功的响应
+type HedgePolicy struct {
+	Delay       time.Duration // 发出下一个对冲请求前的等待时间, 默认100ms
+	MaxRequests int           // 最多同时发出的请求数(含首次), 默认2
+}
+
+func (p *HedgePolicy) Prepare() {
+	if p.Delay <= 0 {
+		p.Delay = 100 * time.Millisecond
+	}
+	if p.MaxRequests <= 0 {
+		p.MaxRequests = 2
+	}
+}
+
+type hedgeResult struct {
+	index  int
+	resp   *http.Response
+	err    error
+	cancel context.CancelFunc
+}
+
+// HedgeMiddleware 对冲请求中间件, 仅作用于幂等请求, 用于降低长尾延迟
+// 响应状态码小于500视为成功, 其余请求会被取消
+func HedgeMiddleware(policy HedgePolicy) Middleware {
+	policy.Prepare()
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if policy.MaxRequests <= 1 || !isIdempotent(req.Method) ||
+				(req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+				return next.RoundTrip(req)
+			}
+			results := make(chan hedgeResult, policy.MaxRequests)
+			var cancels []context.CancelFunc
+			send := func(first bool) error {
+				ctx, cancel := context.WithCancel(req.Context())
+				index := len(cancels)
+				cancels = append(cancels, cancel)
+				r := req.Clone(ctx)
+				if !first && req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						cancel()
+						return err
+					}
+					r.Body = body
+				}
+				go func() {
+					resp, err := next.RoundTrip(r)
+					results <- hedgeResult{index: index, resp: resp, err: err, cancel: cancel}
+				}()
+				return nil
+			}
+			if err := send(true); err != nil {
+				return nil, err
+			}
+			inflight, sent := 1, 1
+			timer := time.NewTimer(policy.Delay)
+			defer timer.Stop()
+
+			var last hedgeResult
+			for inflight > 0 {
+				select {
+				case res := <-results:
+					inflight--
+					if res.err == nil && res.resp.StatusCode < http.StatusInternalServerError {
+						for i, cancel := range cancels {
+							if i != res.index {
+								cancel()
+							}
+						}
+						go discardHedges(results, inflight)
+						res.resp.Body = &cancelBody{ReadCloser: res.resp.Body, cancel: res.cancel}
+						return res.resp, nil
+					}
+					if last.resp != nil {
+						drainBody(last.resp.Body)
+					}
+					if last.cancel != nil {
+						last.cancel()
+					}
+					last = res
+					// 失败时立即补发, 不再等待
+					if sent < policy.MaxRequests && req.Context().Err() == nil {
+						if err := send(false); err == nil {
+							inflight++
+							sent++
+						}
+					}
+				case <-timer.C:
+					if sent < policy.MaxRequests {
+						if err := send(false); err == nil {
+							inflight++
+							sent++
+						}
+						timer.Reset(policy.Delay)
+					}
+				}
+			}
+			if last.err != nil {
+				last.cancel()
+				return nil, last.err
+			}
+			last.resp.Body = &cancelBody{ReadCloser: last.resp.Body, cancel: last.cancel}
+			return last.resp, nil
+		})
+	}
+}
+
+// discardHedges 取消并回收其余未完成的请求
+func discardHedges(results chan hedgeResult, n int) {
+	for i := 0; i < n; i++ {
+		res := <-results
+		if res.resp != nil {
+			drainBody(res.resp.Body)
+		}
+		res.cancel()
+	}
+}
+
+// cancelBody 关闭响应体时释放对应请求的上下文
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
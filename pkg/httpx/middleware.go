@@ -0,0 +1,43 @@
+package httpx
+
+import (
+	"net/http"
+)
+
+// Middleware RoundTripper 中间件, 用于在请求发出前后增加重试、熔断等能力
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc 函数形式的 RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain 将中间件包装到 base 上, 第一个中间件位于最外层
+func Chain(base http.RoundTripper, mws ...Middleware) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		base = mws[i](base)
+	}
+	return base
+}
+
+// Use 为客户端添加中间件, 先添加的中间件位于外层
+// 如 Use(RetryMiddleware(...), CircuitBreakerMiddleware(...)) 时每次重试都会经过熔断器
+func (c *Client) Use(mws ...Middleware) *Client {
+	c.Client.Transport = Chain(c.Client.Transport, mws...)
+	return c
+}
+
+// isIdempotent 是否为幂等请求方法
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
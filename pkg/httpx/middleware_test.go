@@ -0,0 +1,133 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryMiddleware(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	client := NewDefaultClient(srv.URL).Use(RetryMiddleware(RetryPolicy{InitialBackoff: time.Millisecond}))
+	resp, err := client.DoCtx(context.Background(), NewRequestOption(WithMethodGet(), WithPath("/")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" || atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("unexpected result: %d %s after %d calls", resp.StatusCode, body, calls)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	resp, err = client.Do(NewRequestOption(WithMethodPost(), WithPath("/"), WithBody(map[string]string{"a": "b"})))
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("post should not be retried: %v %d calls", err, calls)
+	}
+}
+
+func TestCircuitBreakerMiddleware(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewDefaultClient(srv.URL).Use(CircuitBreakerMiddleware(BreakerConfig{FailureThreshold: 2, OpenTimeout: 20 * time.Millisecond}))
+	opt := NewRequestOption(WithMethodGet(), WithPath("/"))
+	for i := 0; i < 2; i++ {
+		if _, err := client.Do(opt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := client.Do(opt); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected circuit open, got %v", err)
+	}
+	fail.Store(false)
+	time.Sleep(30 * time.Millisecond)
+	if resp, err := client.Do(opt); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("half-open probe should pass: %v", err)
+	}
+	if _, err := client.Do(opt); err != nil {
+		t.Fatalf("breaker should be closed: %v", err)
+	}
+}
+
+func TestHedgeMiddleware(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = io.WriteString(w, "fast")
+	}))
+	defer srv.Close()
+
+	client := NewDefaultClient(srv.URL).Use(HedgeMiddleware(HedgePolicy{Delay: 10 * time.Millisecond}))
+	start := time.Now()
+	resp, err := client.Do(NewRequestOption(WithMethodGet(), WithPath("/"), WithTimeout(500*time.Millisecond)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "fast" || time.Since(start) > 300*time.Millisecond {
+		t.Fatalf("unexpected hedge result: %s in %v", body, time.Since(start))
+	}
+}
+
+func TestCircuitBreakerProbes(t *testing.T) {
+	b := NewCircuitBreaker("test", BreakerConfig{FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond, HalfOpenRequests: 2})
+	stale, ok := b.Allow()
+	if !ok {
+		t.Fatal("closed breaker should allow")
+	}
+	fail, _ := b.Allow()
+	fail(false)
+	if b.State() != StateOpen {
+		t.Fatalf("breaker should be open, got %s", b.State())
+	}
+	time.Sleep(20 * time.Millisecond)
+	// 打开前放行的请求结果不计入半开状态
+	stale(true)
+	probe1, ok1 := b.Allow()
+	probe2, ok2 := b.Allow()
+	if _, ok := b.Allow(); !ok1 || !ok2 || ok {
+		t.Fatalf("half-open should allow exactly 2 probes: %v %v %v", ok1, ok2, ok)
+	}
+	probe1(true)
+	probe1(true)
+	// 取消的探测只释放名额, 不计入成功
+	tk, ok := b.acquire()
+	if !ok || !tk.probe {
+		t.Fatal("finished probe should release its slot")
+	}
+	b.finish(tk, false, false)
+	if _, ok := b.Allow(); !ok || b.State() != StateHalfOpen {
+		t.Fatalf("canceled probe should release its slot, state %s", b.State())
+	}
+	probe2(true)
+	if b.State() != StateClosed {
+		t.Fatalf("breaker should be closed, got %s", b.State())
+	}
+}
@@ -0,0 +1,206 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+)
+
+// HTTPError 非 2xx 响应
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte      // 原始响应体
+	Payload    interface{} // 按 WithErrorPayload 解析后的错误内容, 未配置或解析失败时为空
+}
+
+func (e *HTTPError) Error() string {
+	body := string(e.Body)
+	if len(body) > 512 {
+		body = body[:512] + "..."
+	}
+	return fmt.Sprintf("%s %s: %s, 响应: %s", e.Method, e.URL, e.Status, body)
+}
+
+// DecodePayload 将响应体按JSON解析到 v
+func (e *HTTPError) DecodePayload(v interface{}) error {
+	return json.Unmarshal(e.Body, v)
+}
+
+// AsHTTPError 判断错误是否为 HTTPError
+func AsHTTPError(err error) (*HTTPError, bool) {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he, true
+	}
+	return nil, false
+}
+
+// RestClient 类型化的 REST 客户端, 配合 Get、Post 等泛型函数使用
+type RestClient struct {
+	client       *Client
+	auth         AuthProvider
+	headers      map[string]string
+	errorDecoder func(body []byte) (interface{}, error)
+	mws          []Middleware
+}
+
+// RestOption REST 客户端选项
+type RestOption func(rc *RestClient)
+
+// WithAuthProvider 设置认证方式
+func WithAuthProvider(provider AuthProvider) RestOption {
+	return func(rc *RestClient) {
+		rc.auth = provider
+	}
+}
+
+// WithDefaultHeaders 设置默认请求头
+func WithDefaultHeaders(headers map[string]string) RestOption {
+	return func(rc *RestClient) {
+		if rc.headers == nil {
+			rc.headers = map[string]string{}
+		}
+		for k, v := range headers {
+			rc.headers[k] = v
+		}
+	}
+}
+
+// WithRestMiddlewares 追加中间件, 如重试、熔断
+func WithRestMiddlewares(mws ...Middleware) RestOption {
+	return func(rc *RestClient) {
+		rc.mws = append(rc.mws, mws...)
+	}
+}
+
+// WithErrorPayload 非 2xx 响应的响应体按JSON解析为 E, 放入 HTTPError.Payload
+func WithErrorPayload[E any]() RestOption {
+	return func(rc *RestClient) {
+		rc.errorDecoder = func(body []byte) (interface{}, error) {
+			var e E
+			if err := json.Unmarshal(body, &e); err != nil {
+				return nil, err
+			}
+			return &e, nil
+		}
+	}
+}
+
+// NewRestClient 基于 Client 创建 REST 客户端, 不会修改传入的 Client
+func NewRestClient(c *Client, opts ...RestOption) *RestClient {
+	rc := &RestClient{}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	httpClient := *c.Client
+	rc.client = &Client{Client: &httpClient, BaseUrl: c.BaseUrl}
+	mws := append([]Middleware{}, rc.mws...)
+	if len(rc.headers) > 0 {
+		mws = append(mws, HeadersMiddleware(rc.headers))
+	}
+	if rc.auth != nil {
+		mws = append(mws, AuthMiddleware(rc.auth))
+	}
+	rc.client.Use(mws...)
+	return rc
+}
+
+// Client 获取底层客户端
+func (rc *RestClient) Client() *Client {
+	return rc.client
+}
+
+// Call 发送请求并解析响应, 2xx 响应按JSON解析为 T, 其他响应返回 *HTTPError
+// T 为 []byte 或 string 时返回原始响应体
+func Call[T any](ctx context.Context, rc *RestClient, options *RequestOption) (T, error) {
+	var result T
+	resp, err := rc.client.DoCtx(ctx, options)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		he := &HTTPError{
+			Method:     options.Method.String(),
+			URL:        resp.Request.URL.Redacted(),
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header,
+			Body:       body,
+		}
+		if rc.errorDecoder != nil && len(body) > 0 {
+			if payload, derr := rc.errorDecoder(body); derr == nil {
+				he.Payload = payload
+			}
+		}
+		return result, he
+	}
+	switch v := any(&result).(type) {
+	case *[]byte:
+		*v = body
+		return result, nil
+	case *string:
+		*v = string(body)
+		return result, nil
+	}
+	if len(body) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, errors.WithMessagef(err, "解析响应失败, 响应: %s", string(body))
+	}
+	return result, nil
+}
+
+// Get 发送 GET 请求
+func Get[T any](ctx context.Context, rc *RestClient, path string, opts ...Option) (T, error) {
+	return Call[T](ctx, rc, restOption(GET, path, nil, opts))
+}
+
+// Delete 发送 DELETE 请求
+func Delete[T any](ctx context.Context, rc *RestClient, path string, opts ...Option) (T, error) {
+	return Call[T](ctx, rc, restOption(DELETE, path, nil, opts))
+}
+
+// Post 发送 POST 请求, 请求体默认按JSON序列化
+func Post[Req, Resp any](ctx context.Context, rc *RestClient, path string, body Req, opts ...Option) (Resp, error) {
+	return Call[Resp](ctx, rc, restOption(POST, path, body, opts))
+}
+
+// Put 发送 PUT 请求
+func Put[Req, Resp any](ctx context.Context, rc *RestClient, path string, body Req, opts ...Option) (Resp, error) {
+	return Call[Resp](ctx, rc, restOption(PUT, path, body, opts))
+}
+
+// Patch 发送 PATCH 请求
+func Patch[Req, Resp any](ctx context.Context, rc *RestClient, path string, body Req, opts ...Option) (Resp, error) {
+	return Call[Resp](ctx, rc, restOption(PATCH, path, body, opts))
+}
+
+func restOption(method HttpMethod, path string, body interface{}, opts []Option) *RequestOption {
+	all := make([]Option, 0, len(opts)+3)
+	all = append(all, WithMethod(method), WithPath(path))
+	if body != nil {
+		all = append(all, WithBody(body))
+	}
+	all = append(all, opts...)
+	option := NewRequestOption(all...)
+	if option.Headers == nil {
+		option.Headers = map[string]string{}
+	}
+	if _, ok := option.Headers["Accept"]; !ok {
+		option.Headers["Accept"] = "application/json"
+	}
+	return option
+}
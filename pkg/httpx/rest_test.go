@@ -0,0 +1,90 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestRestClient(t *testing.T) {
+	var tokenCalls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenCalls, 1)
+		if r.FormValue("client_id") != "id" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"access_token":"tk","token_type":"bearer","expires_in":3600}`)
+	})
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tk" || r.Header.Get("X-Tenant") != "t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			var u map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&u)
+			w.WriteHeader(http.StatusConflict)
+			_, _ = fmt.Fprintf(w, `{"code":"EXISTS","message":"%s exists"}`, u["name"])
+			return
+		}
+		_, _ = fmt.Fprint(w, `[{"name":"tom"}]`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	auth := NewOAuth2ClientCredentials(OAuth2Config{TokenURL: srv.URL + "/oauth/token", ClientID: "id", ClientSecret: "secret"}, nil)
+	rc := NewRestClient(NewDefaultClient(srv.URL),
+		WithAuthProvider(auth),
+		WithDefaultHeaders(map[string]string{"X-Tenant": "t1"}),
+		WithErrorPayload[apiError](),
+	)
+	type user struct {
+		Name string `json:"name"`
+	}
+	users, err := Get[[]user](context.Background(), rc, "/users")
+	if err != nil || len(users) != 1 || users[0].Name != "tom" {
+		t.Fatalf("unexpected users: %v %v", users, err)
+	}
+	_, err = Post[user, user](context.Background(), rc, "/users", user{Name: "tom"})
+	he, ok := AsHTTPError(err)
+	if !ok || he.StatusCode != http.StatusConflict {
+		t.Fatalf("expected conflict error, got %v", err)
+	}
+	if p, ok := he.Payload.(*apiError); !ok || p.Code != "EXISTS" {
+		t.Fatalf("unexpected payload: %#v", he.Payload)
+	}
+	if atomic.LoadInt32(&tokenCalls) != 1 {
+		t.Fatalf("token should be cached, fetched %d times", tokenCalls)
+	}
+}
+
+func TestOAuth2ShortLivedToken(t *testing.T) {
+	var tokenCalls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&tokenCalls, 1)
+		_, _ = fmt.Fprint(w, `{"access_token":"tk","token_type":"bearer","expires_in":20}`)
+	}))
+	defer srv.Close()
+
+	// 有效期小于默认的 30s 提前刷新时间, 仍然需要缓存
+	auth := NewOAuth2ClientCredentials(OAuth2Config{TokenURL: srv.URL, ClientID: "id", ClientSecret: "secret"}, nil)
+	for i := 0; i < 3; i++ {
+		if token, err := auth.Token(context.Background()); err != nil || token != "tk" {
+			t.Fatalf("unexpected token %q: %v", token, err)
+		}
+	}
+	if atomic.LoadInt32(&tokenCalls) != 1 {
+		t.Fatalf("short-lived token should be cached, fetched %d times", tokenCalls)
+	}
+}
@@ -0,0 +1,186 @@
+package httpx
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	MaxAttempts        int           // 最大尝试次数(含首次), 默认3
+	InitialBackoff     time.Duration // 首次重试等待时间, 默认100ms
+	MaxBackoff         time.Duration // 最大等待时间, 默认5s
+	Multiplier         float64       // 退避倍数, 默认2
+	Jitter             float64       // 随机抖动比例 0~1, 默认0.2
+	RetryStatusCodes   []int         // 需要重试的响应状态码, 默认 429、502、503、504
+	RetryNonIdempotent bool          // 是否重试非幂等请求(POST、PATCH), 默认否
+	MaxRetryAfter      time.Duration // Retry-After 可接受的最大等待时间, 超过时不再重试, 默认30s
+}
+
+func (p *RetryPolicy) Prepare() {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = 100 * time.Millisecond
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 5 * time.Second
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = 2
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		p.Jitter = 0.2
+	}
+	if p.RetryStatusCodes == nil {
+		p.RetryStatusCodes = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+	if p.MaxRetryAfter <= 0 {
+		p.MaxRetryAfter = 30 * time.Second
+	}
+}
+
+// Backoff 第 attempt 次重试(从1开始)前的等待时间, 指数退避并加入随机抖动
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d = d * (1 - p.Jitter*rand.Float64())
+	}
+	return time.Duration(d)
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.RetryStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryMiddleware 重试中间件
+// 网络错误与指定状态码会触发重试, 非幂等请求默认不重试, 请求体需支持 GetBody 才能重试
+func RetryMiddleware(policy RetryPolicy) Middleware {
+	policy.Prepare()
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if !isIdempotent(req.Method) && !policy.RetryNonIdempotent {
+				return next.RoundTrip(req)
+			}
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				return next.RoundTrip(req)
+			}
+			for attempt := 1; ; attempt++ {
+				r := req
+				if attempt > 1 && req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, errors.WithMessage(err, "重置请求体失败")
+					}
+					r = req.Clone(req.Context())
+					r.Body = body
+				}
+				resp, err := next.RoundTrip(r)
+				if attempt >= policy.MaxAttempts {
+					return resp, err
+				}
+				wait, retry := policy.shouldRetry(req.Context(), attempt, resp, err)
+				if !retry {
+					return resp, err
+				}
+				if resp != nil {
+					drainBody(resp.Body)
+				}
+				logs.CtxDebugf(req.Context(), "HTTP请求 %s %s 第%d次重试, 等待 %v, 上次错误: %v", req.Method, req.URL.Redacted(), attempt, wait, describe(resp, err))
+				if err := sleepCtx(req.Context(), wait); err != nil {
+					return nil, err
+				}
+			}
+		})
+	}
+}
+
+// shouldRetry 判断是否需要重试并计算等待时间
+func (p RetryPolicy) shouldRetry(ctx context.Context, attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+			return 0, false
+		}
+		return p.Backoff(attempt), true
+	}
+	if !p.retryableStatus(resp.StatusCode) {
+		return 0, false
+	}
+	wait := p.Backoff(attempt)
+	if after, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		if after > p.MaxRetryAfter {
+			return 0, false
+		}
+		if after > wait {
+			wait = after
+		}
+	}
+	return wait, true
+}
+
+// ParseRetryAfter 解析 Retry-After 响应头, 支持秒数与 HTTP 日期两种格式
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// drainBody 读取并关闭响应体, 以便连接复用
+func drainBody(body io.ReadCloser) {
+	if body == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(body, 64*1024))
+	_ = body.Close()
+}
+
+func describe(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return resp.Status
+}
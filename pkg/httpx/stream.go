@@ -0,0 +1,164 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SSEEvent 服务端推送事件
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  string
+	Retry int // 重连间隔, 单位毫秒
+}
+
+// SSEDecoder 按 text/event-stream 格式逐个读取事件
+type SSEDecoder struct {
+	scanner *bufio.Scanner
+	lastID  string
+}
+
+// NewSSEDecoder 创建SSE解码器, 单行最大1MB
+func NewSSEDecoder(r io.Reader) *SSEDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	return &SSEDecoder{scanner: scanner}
+}
+
+// LastEventID 最近收到的事件ID, 重连时作为 Last-Event-ID 请求头
+func (d *SSEDecoder) LastEventID() string {
+	return d.lastID
+}
+
+// Next 读取下一个事件, 流结束时返回 io.EOF, 注释行与空事件会被忽略
+func (d *SSEDecoder) Next() (*SSEEvent, error) {
+	var event SSEEvent
+	var data []string
+	hasData := false
+	for d.scanner.Scan() {
+		line := d.scanner.Text()
+		if line == "" {
+			if !hasData {
+				event = SSEEvent{}
+				continue
+			}
+			event.Data = strings.Join(data, "\n")
+			if event.ID == "" {
+				event.ID = d.lastID
+			}
+			return &event, nil
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data = append(data, value)
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				event.ID, d.lastID = value, value
+			}
+		case "retry":
+			if n, err := strconv.Atoi(value); err == nil {
+				event.Retry = n
+			}
+		}
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if hasData {
+		event.Data = strings.Join(data, "\n")
+		return &event, nil
+	}
+	return nil, io.EOF
+}
+
+// TypedEvent 数据已解析的SSE事件
+type TypedEvent[T any] struct {
+	ID    string
+	Event string
+	Data  T
+}
+
+// ReadSSE 读取SSE流并将每个事件的 data 按JSON解析为 T, fn 返回错误时停止读取
+// done 为数据结束标记, 如 OpenAI 风格接口的 [DONE], 为空时读取到流结束
+func ReadSSE[T any](r io.Reader, done string, fn func(e TypedEvent[T]) error) error {
+	d := NewSSEDecoder(r)
+	for {
+		e, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if done != "" && e.Data == done {
+			return nil
+		}
+		typed := TypedEvent[T]{ID: e.ID, Event: e.Event}
+		if err := json.Unmarshal([]byte(e.Data), &typed.Data); err != nil {
+			return errors.WithMessagef(err, "解析SSE事件失败, event: %s", e.Event)
+		}
+		if err := fn(typed); err != nil {
+			return err
+		}
+	}
+}
+
+// NDJSONDecoder 按行读取 JSON 对象
+type NDJSONDecoder[T any] struct {
+	reader *bufio.Reader
+	line   int
+}
+
+// NewNDJSONDecoder 创建NDJSON解码器
+func NewNDJSONDecoder[T any](r io.Reader) *NDJSONDecoder[T] {
+	return &NDJSONDecoder[T]{reader: bufio.NewReader(r)}
+}
+
+// Next 读取下一行, 流结束时返回 io.EOF, 空行会被忽略
+func (d *NDJSONDecoder[T]) Next() (T, error) {
+	var v T
+	for {
+		line, err := d.reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			d.line++
+			if jerr := json.Unmarshal(line, &v); jerr != nil {
+				return v, errors.WithMessagef(jerr, "解析第%d行失败", d.line)
+			}
+			return v, nil
+		}
+		if err != nil {
+			return v, err
+		}
+	}
+}
+
+// ReadNDJSON 逐行读取 NDJSON 并回调, fn 返回错误时停止读取
+func ReadNDJSON[T any](r io.Reader, fn func(v T) error) error {
+	d := NewNDJSONDecoder[T](r)
+	for {
+		v, err := d.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+}
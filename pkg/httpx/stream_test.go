@@ -0,0 +1,146 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultipartBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(f)
+		fmt.Fprintf(w, "%s|%s|%s", r.FormValue("name"), h.Filename, content)
+	}))
+	defer srv.Close()
+
+	client := NewDefaultClient(srv.URL)
+	resp, err := client.Do(NewRequestOption(WithMethodPost(), WithMultipartBody(
+		map[string]string{"name": "report"},
+		FormFile{Field: "file", FileName: "a.txt", Reader: strings.NewReader("hello")},
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "report|a.txt|hello" {
+		t.Fatalf("unexpected response: %s", body)
+	}
+}
+
+func TestReadSSEAndNDJSON(t *testing.T) {
+	type delta struct {
+		Text string `json:"text"`
+	}
+	sse := ": ping\n\nid: 1\nevent: delta\ndata: {\"text\":\"a\"}\n\nevent: delta\ndata: {\"text\":\"b\"}\n\ndata: [DONE]\n\n"
+	var got []string
+	err := ReadSSE[delta](strings.NewReader(sse), "[DONE]", func(e TypedEvent[delta]) error {
+		got = append(got, e.ID+e.Event+e.Data.Text)
+		return nil
+	})
+	if err != nil || strings.Join(got, ",") != "1deltaa,1deltab" {
+		t.Fatalf("unexpected sse events: %v %v", got, err)
+	}
+
+	got = nil
+	err = ReadNDJSON[delta](strings.NewReader("{\"text\":\"x\"}\n\n{\"text\":\"y\"}"), func(v delta) error {
+		got = append(got, v.Text)
+		return nil
+	})
+	if err != nil || strings.Join(got, "") != "xy" {
+		t.Fatalf("unexpected ndjson values: %v %v", got, err)
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	var ranged int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			ranged++
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+	client := NewDefaultClient(srv.URL)
+	dir := t.TempDir()
+
+	for _, c := range []struct {
+		name      string
+		part      []byte
+		validator string
+		ranged    int
+	}{
+		{name: "resume", part: content[:300], validator: `"v1"`, ranged: 1},
+		// 文件已变化, If-Range 不匹配时服务端返回完整内容
+		{name: "changed", part: []byte("stale"), validator: `"v0"`, ranged: 1},
+		// 没有校验值时无法确认文件未变化, 不续传
+		{name: "no-validator", part: []byte("stale")},
+		// 临时文件比服务端文件大, 416 不代表已下载完整
+		{name: "oversize", part: bytes.Repeat([]byte("x"), 1200), validator: `"v1"`, ranged: 1},
+	} {
+		ranged = 0
+		dest := filepath.Join(dir, c.name+".bin")
+		if err := os.WriteFile(dest+partSuffix, c.part, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if c.validator != "" {
+			if err := os.WriteFile(dest+validatorSuffix, []byte(c.validator), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		n, err := client.Download(context.Background(), NewRequestOption(WithMethodGet(), WithPath("/data.bin")), dest)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		data, _ := os.ReadFile(dest)
+		if n != int64(len(content)) || !bytes.Equal(data, content) || ranged != c.ranged {
+			t.Fatalf("%s: unexpected download result: %d bytes, %d range requests", c.name, n, ranged)
+		}
+		if _, err := os.Stat(dest + validatorSuffix); !os.IsNotExist(err) {
+			t.Fatalf("%s: validator not removed: %v", c.name, err)
+		}
+	}
+}
+
+func TestEncodeBody(t *testing.T) {
+	for body, want := range map[interface{}]string{
+		"text":          `"text"`,
+		RawBody("text"): "text",
+	} {
+		r, _, err := encodeBody(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		if string(data) != want {
+			t.Fatalf("encodeBody(%#v) = %s, want %s", body, data, want)
+		}
+	}
+
+	// 创建请求失败时关闭 multipart 管道, 写出的 goroutine 随之退出
+	client := NewDefaultClient("http://127.0.0.1")
+	_, err := client.buildRequest(context.Background(), NewRequestOption(WithMethod("BAD METHOD"), WithMultipartBody(
+		map[string]string{"name": strings.Repeat("x", 1<<20)},
+	)))
+	if err == nil {
+		t.Fatal("expected invalid method error")
+	}
+}
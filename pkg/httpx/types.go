@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 type HttpMethod string
@@ -34,6 +35,7 @@ type RequestOption struct {
 	PrintLog  bool
 	Sensitive bool
 	RequestID string
+	Timeout   time.Duration // 单次请求超时时间, 大于0时覆盖客户端超时时间
 }
 
 type Option func(option *RequestOption)
@@ -110,6 +112,13 @@ func WithSensitive(sensitive bool) Option {
 	}
 }
 
+// WithTimeout 设置单次请求超时时间
+func WithTimeout(timeout time.Duration) Option {
+	return func(option *RequestOption) {
+		option.Timeout = timeout
+	}
+}
+
 func NewRequestOption(options ...Option) *RequestOption {
 	option := &RequestOption{
 		Headers:   make(map[string]string),
@@ -0,0 +1,103 @@
+package mcpx
+
+import (
+	"context"
+	"fmt"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/jwtx"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrUnauthorized 缺少或无效的访问令牌
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrPermissionDenied 没有调用工具的权限
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+// Authenticator 校验访问令牌, 返回令牌对应的用户信息
+type Authenticator func(ctx context.Context, token string) (*jwtx.AccessDetails, error)
+
+// JwtAuthenticator 使用 jwtx 校验 Bearer 令牌.
+// tokenPrefix 与 jwtx.Jwt.CreateAuth 使用的前缀一致, 不为空时还会检查令牌是否仍保存在 Store 中, 已注销的令牌无法继续使用
+func JwtAuthenticator(j *jwtx.Jwt, tokenPrefix string) Authenticator {
+	return func(ctx context.Context, token string) (*jwtx.AccessDetails, error) {
+		details, err := j.ExtractToken(token)
+		if err != nil {
+			return nil, errors.Wrap(ErrUnauthorized, err.Error())
+		}
+		if tokenPrefix != "" && j.Store != nil {
+			identity, err := j.Store.GetAccessToken(ctx, fmt.Sprintf("%s:%s", tokenPrefix, details.AccessUuid))
+			if err != nil || identity == "" {
+				return nil, errors.Wrap(ErrUnauthorized, "token revoked or expired")
+			}
+		}
+		return details, nil
+	}
+}
+
+type accessKey struct{}
+
+// WithAccess 把用户信息放入 context
+func WithAccess(ctx context.Context, details *jwtx.AccessDetails) context.Context {
+	return context.WithValue(ctx, accessKey{}, details)
+}
+
+// AccessFromContext 获取认证后的用户信息, 工具处理函数中可以通过 ctx 获取当前调用者
+func AccessFromContext(ctx context.Context) (*jwtx.AccessDetails, bool) {
+	details, ok := ctx.Value(accessKey{}).(*jwtx.AccessDetails)
+	return details, ok && details != nil
+}
+
+// BearerToken 从 Authorization 请求头中提取 Bearer 令牌
+func BearerToken(header string) string {
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// AuthMiddleware HTTP 认证中间件, 校验失败时返回 401, 成功时把用户信息放入请求的 context
+func AuthMiddleware(auth Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		details, err := auth(r.Context(), BearerToken(r.Header.Get("Authorization")))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithAccess(r.Context(), details)))
+	})
+}
+
+// PermissionFunc 工具权限检查, 返回错误时拒绝调用.
+// 调用工具时 args 为调用参数, 列出工具时 args 为 nil, 返回错误的工具不会出现在工具列表中
+type PermissionFunc func(ctx context.Context, tool string, args map[string]interface{}) error
+
+// WithPermission 为所有工具添加权限检查
+func WithPermission(check PermissionFunc) server.ServerOption {
+	middleware := func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if err := check(ctx, request.Params.Name, request.GetArguments()); err != nil {
+				return nil, errors.Wrapf(ErrPermissionDenied, "%s: %v", request.Params.Name, err)
+			}
+			return next(ctx, request)
+		}
+	}
+	filter := func(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+		allowed := make([]mcp.Tool, 0, len(tools))
+		for _, tool := range tools {
+			if check(ctx, tool.Name, nil) == nil {
+				allowed = append(allowed, tool)
+			}
+		}
+		return allowed
+	}
+	return func(s *server.MCPServer) {
+		server.WithToolHandlerMiddleware(middleware)(s)
+		server.WithToolFilter(filter)(s)
+	}
+}
@@ -2,12 +2,17 @@ package mcpx
 
 import (
 	"context"
-	"fmt"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/xiehqing/common/pkg/logs"
 	"strconv"
-	"time"
+)
+
+// 传输方式
+const (
+	TransportSSE            = "sse"
+	TransportStreamableHTTP = "streamable-http"
+	TransportStdio          = "stdio"
 )
 
 type Config struct {
@@ -20,9 +25,18 @@ type Config struct {
 	WithToolCapabilities     bool   `yaml:"with-tool-capabilities"`
 	WithLogging              bool   `yaml:"with-logging"`
 	WithRecovery             bool   `yaml:"with-recovery"`
+	// Transport 传输方式: sse(默认)、streamable-http、stdio
+	Transport string `yaml:"transport"`
+	// EndpointPath streamable-http 的请求路径, 默认 /mcp; sse 的基础路径, 默认为空
+	EndpointPath string `yaml:"endpoint-path"`
+	// Stateless streamable-http 是否使用无状态模式, 不保存会话
+	Stateless bool `yaml:"stateless"`
+	// ShutdownTimeout 关闭时等待处理中请求的超时时间, 单位毫秒, 默认 10000
+	ShutdownTimeout int `yaml:"shutdown-timeout"`
 }
 
-func NewMcpServer(cfg *Config, hooks *server.Hooks) *server.MCPServer {
+// NewMcpServer 创建 MCP 服务, extra 为额外的服务选项, 如 WithPermission
+func NewMcpServer(cfg *Config, hooks *server.Hooks, extra ...server.ServerOption) *server.MCPServer {
 	var opts []server.ServerOption
 	if cfg.WithLogging {
 		// 启用默认日志中间件
@@ -48,8 +62,8 @@ func NewMcpServer(cfg *Config, hooks *server.Hooks) *server.MCPServer {
 		// toolChanged: 设置为 true 时，当工具列表发生变化（如新增/删除工具）时， 服务端会自动向所有已初始化的客户端发送 tools/list_changed 通知
 		opts = append(opts, server.WithToolCapabilities(true))
 	}
-	if hooks == nil {
-		// 启用默认日志几率中间件
+	if hooks != nil {
+		// 启用钩子
 		// 功能说明:
 		//   - 允许在请求处理的关键阶段插入自定义逻辑:
 		//     * 请求前/后处理 (Before/AfterRequest)
@@ -62,6 +76,7 @@ func NewMcpServer(cfg *Config, hooks *server.Hooks) *server.MCPServer {
 		//     4. 错误统一格式化
 		opts = append(opts, server.WithHooks(hooks))
 	}
+	opts = append(opts, extra...)
 	mcpServer := server.NewMCPServer(
 		cfg.Name,
 		cfg.Version,
@@ -70,31 +85,28 @@ func NewMcpServer(cfg *Config, hooks *server.Hooks) *server.MCPServer {
 	return mcpServer
 }
 
+// InitSseMcpServer 使用 SSE 传输启动已创建的 MCP 服务, 返回的函数关闭服务并等待处理中的请求完成
+//
+// Deprecated: 使用 NewServer, 支持 streamable-http、stdio 传输和认证; 与 Web 服务共用端口时使用 hertzx.MountMCP
 func InitSseMcpServer(cfg *Config, mcpServer *server.MCPServer) func() {
-	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-	sseServer := server.NewSSEServer(mcpServer)
-	go func() {
-		logs.Infof("Mcp服务启动成功，监听地址：%s", addr)
-		err := sseServer.Start(addr)
-		if err != nil {
-			panic(err)
-		}
-	}()
+	sseCfg := *cfg
+	sseCfg.Transport = TransportSSE
+	s := &Server{cfg: &sseCfg, mcp: mcpServer}
+	if err := s.Start(); err != nil {
+		panic(err)
+	}
 	return func() {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(1000))
+		ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout())
 		defer cancel()
-		if err := sseServer.Shutdown(ctx); err != nil {
+		if err := s.Shutdown(ctx); err != nil {
 			logs.Errorf("停止Mcp服务失败：%v", err)
+			return
 		}
-		select {
-		case <-ctx.Done():
-			logs.Infof("Mcp服务停止成功")
-		default:
-			logs.Infof("Mcp服务停止成功")
-		}
+		logs.Infof("Mcp服务停止成功")
 	}
 }
 
+// GetParamInt64 获取 int64 参数, 不存在或无法转换时返回默认值
 func GetParamInt64(c mcp.CallToolRequest, key string, defaultValue int64) int64 {
 	args := c.GetArguments()
 	if val, ok := args[key]; ok {
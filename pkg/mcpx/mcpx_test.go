@@ -0,0 +1,187 @@
+package mcpx
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/jwtx"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type greetArgs struct {
+	Name  string   `json:"name" validate:"required,max=5" jsonschema:"description=用户名"`
+	Count int      `json:"count,omitempty" validate:"min=1,max=3"`
+	Tags  []string `json:"tags,omitempty" validate:"enum=a|b"`
+}
+
+type greetResult struct {
+	Greeting string `json:"greeting"`
+	Caller   string `json:"caller"`
+}
+
+func newClient(t *testing.T, url, token string) *client.Client {
+	t.Helper()
+	var opts []transport.StreamableHTTPCOption
+	if token != "" {
+		opts = append(opts, transport.WithHTTPHeaders(map[string]string{"Authorization": "Bearer " + token}))
+	}
+	c, err := client.NewStreamableHttpClient(url+"/mcp", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func initialize(ctx context.Context, c *client.Client) error {
+	req := mcp.InitializeRequest{}
+	req.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	_, err := c.Initialize(ctx, req)
+	return err
+}
+
+func callTool(ctx context.Context, c *client.Client, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	return c.CallTool(ctx, req)
+}
+
+func TestServer(t *testing.T) {
+	j := &jwtx.Jwt{SigningKey: "secret", AccessExpired: 10, RefreshExpired: 10}
+	td, err := j.CreateTokens("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer(&Config{Name: "test", Version: "1.0", Transport: TransportStreamableHTTP, WithToolCapabilities: true},
+		WithAuthenticator(JwtAuthenticator(j, "")),
+		WithServerOptions(WithPermission(func(ctx context.Context, tool string, args map[string]interface{}) error {
+			if access, ok := AccessFromContext(ctx); tool == "admin" && (!ok || access.UserIdentity != "root") {
+				return errors.New("admin only")
+			}
+			return nil
+		})),
+	)
+	if err := AddTool(s.MCP(), "greet", "打招呼", func(ctx context.Context, in *greetArgs) (greetResult, error) {
+		access, _ := AccessFromContext(ctx)
+		return greetResult{Greeting: strings.Repeat("hi "+in.Name+" ", max(in.Count, 1)), Caller: access.UserIdentity}, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s.MCP().AddTools(MustNewTool("admin", "管理", func(ctx context.Context, in *struct{}) (string, error) {
+		return "ok", nil
+	}))
+	var slowDone atomic.Bool
+	if err := AddTool(s.MCP(), "slow", "慢调用", func(ctx context.Context, in *struct{}) (string, error) {
+		time.Sleep(300 * time.Millisecond)
+		slowDone.Store(true)
+		return "done", nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	if err := initialize(ctx, newClient(t, ts.URL, "")); err == nil {
+		t.Fatal("expected unauthorized error without token")
+	}
+	c := newClient(t, ts.URL, td.AccessToken)
+	if err := initialize(ctx, c); err != nil {
+		t.Fatal(err)
+	}
+
+	tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var schema map[string]interface{}
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+		if tool.Name == "greet" {
+			data, _ := json.Marshal(tool)
+			json.Unmarshal(data, &schema)
+		}
+	}
+	if strings.Join(names, ",") != "greet,slow" {
+		t.Fatalf("unexpected tools: %v", names)
+	}
+	input, _ := json.Marshal(schema["inputSchema"])
+	for _, want := range []string{`"required":["name"]`, `"maxLength":5`, `"enum":["a","b"]`, `"maximum":3`, `"description":"用户名"`} {
+		if !strings.Contains(string(input), want) {
+			t.Fatalf("input schema missing %s: %s", want, input)
+		}
+	}
+	if schema["outputSchema"] == nil {
+		t.Fatal("missing output schema")
+	}
+
+	result, err := callTool(ctx, c, "greet", map[string]interface{}{"name": "bob", "count": 2})
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected result %+v: %v", result, err)
+	}
+	var greeting greetResult
+	data, _ := json.Marshal(result.StructuredContent)
+	if json.Unmarshal(data, &greeting); greeting != (greetResult{Greeting: "hi bob hi bob ", Caller: "alice"}) {
+		t.Fatalf("unexpected structured content: %s", data)
+	}
+
+	result, err = callTool(ctx, c, "greet", map[string]interface{}{"name": "toolong", "tags": []string{"c"}})
+	if err != nil || !result.IsError {
+		t.Fatalf("expected validation error, got %+v: %v", result, err)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "name 长度不能大于 5") || !strings.Contains(text, "tags 取值必须为 a, b 之一") {
+		t.Fatalf("unexpected validation message: %s", text)
+	}
+
+	if _, err := callTool(ctx, c, "admin", nil); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("expected permission error, got %v", err)
+	}
+
+	// 关闭时等待处理中的调用完成, 之后的调用被拒绝
+	go callTool(ctx, c, "slow", nil)
+	time.Sleep(100 * time.Millisecond)
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(shutdownCtx); err != nil {
+		t.Fatal(err)
+	}
+	if !slowDone.Load() {
+		t.Fatal("shutdown returned before the tool call finished")
+	}
+	if _, err := callTool(ctx, c, "greet", map[string]interface{}{"name": "bob"}); err == nil {
+		t.Fatal("expected error after shutdown")
+	}
+}
+
+type badTagArgs struct {
+	Items []struct {
+		Name string `json:"name" validate:"max=x"`
+	} `json:"items"`
+}
+
+func TestNewToolErrors(t *testing.T) {
+	if _, err := NewTool("scalar", "", func(ctx context.Context, in *string) (string, error) { return *in, nil }); err == nil {
+		t.Fatal("expected error for non-struct input")
+	}
+	if _, err := NewTool("bad", "", func(ctx context.Context, in *badTagArgs) (string, error) { return "", nil }); err == nil || !strings.Contains(err.Error(), "validate") {
+		t.Fatalf("expected validate tag error, got %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustNewTool should panic")
+		}
+	}()
+	MustNewTool("bad", "", func(ctx context.Context, in *badTagArgs) (string, error) { return "", nil })
+}
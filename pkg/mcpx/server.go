@@ -0,0 +1,285 @@
+package mcpx
+
+import (
+	"context"
+	"fmt"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrServerClosing 服务正在关闭, 不再接受新的工具调用
+var ErrServerClosing = errors.New("mcp server is shutting down")
+
+// Option 服务选项
+type Option func(s *Server)
+
+// WithHooks 设置 MCP 钩子
+func WithHooks(hooks *server.Hooks) Option {
+	return func(s *Server) {
+		s.hooks = hooks
+	}
+}
+
+// WithServerOptions 追加 mcp-go 的服务选项, 如 WithPermission
+func WithServerOptions(opts ...server.ServerOption) Option {
+	return func(s *Server) {
+		s.serverOpts = append(s.serverOpts, opts...)
+	}
+}
+
+// WithAuthenticator 设置 HTTP 传输的认证方式, 如 JwtAuthenticator, stdio 传输不认证
+func WithAuthenticator(auth Authenticator) Option {
+	return func(s *Server) {
+		s.auth = auth
+	}
+}
+
+// WithStdio 设置 stdio 传输的输入输出, 默认为 os.Stdin、os.Stdout
+func WithStdio(in io.Reader, out io.Writer) Option {
+	return func(s *Server) {
+		s.stdin, s.stdout = in, out
+	}
+}
+
+// Server 按配置的传输方式运行的 MCP 服务.
+// 关闭时先拒绝新的工具调用, 再等待连接关闭与处理中的工具调用完成
+type Server struct {
+	cfg        *Config
+	mcp        *server.MCPServer
+	hooks      *server.Hooks
+	serverOpts []server.ServerOption
+	auth       Authenticator
+	stdin      io.Reader
+	stdout     io.Writer
+
+	mu         sync.Mutex
+	started    bool
+	closing    bool
+	inflight   sync.WaitGroup
+	handler    http.Handler
+	httpServer *http.Server
+	sse        *server.SSEServer
+	streamable *server.StreamableHTTPServer
+	cancel     context.CancelFunc
+	done       chan error
+}
+
+// NewServer 创建 MCP 服务, 通过 MCP 注册工具、资源与提示
+func NewServer(cfg *Config, opts ...Option) *Server {
+	s := &Server{cfg: cfg, stdin: os.Stdin, stdout: os.Stdout}
+	for _, opt := range opts {
+		opt(s)
+	}
+	serverOpts := append([]server.ServerOption{server.WithToolHandlerMiddleware(s.track)}, s.serverOpts...)
+	s.mcp = NewMcpServer(cfg, s.hooks, serverOpts...)
+	return s
+}
+
+// MCP 底层的 mcp-go 服务
+func (s *Server) MCP() *server.MCPServer {
+	return s.mcp
+}
+
+// Config 服务配置
+func (s *Server) Config() *Config {
+	return s.cfg
+}
+
+// Authenticator HTTP 传输的认证方式, 未设置时返回 nil
+func (s *Server) Authenticator() Authenticator {
+	return s.auth
+}
+
+// Handler HTTP 传输的处理器, 已包含认证, 用于挂载到已有的 HTTP 服务上; stdio 传输返回 nil
+func (s *Server) Handler() http.Handler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.initHandler()
+}
+
+func (s *Server) initHandler() http.Handler {
+	if s.handler != nil || s.transport() == TransportStdio {
+		return s.handler
+	}
+	s.httpServer = &http.Server{Addr: fmt.Sprintf("%s:%d", s.cfg.Host, s.cfg.Port)}
+	var handler http.Handler
+	switch s.transport() {
+	case TransportStreamableHTTP:
+		path := s.cfg.EndpointPath
+		if path == "" {
+			path = "/mcp"
+		}
+		s.streamable = server.NewStreamableHTTPServer(s.mcp,
+			server.WithEndpointPath(path),
+			server.WithStateLess(s.cfg.Stateless),
+			server.WithStreamableHTTPServer(s.httpServer),
+		)
+		mux := http.NewServeMux()
+		mux.Handle(path, s.streamable)
+		handler = mux
+	default:
+		sseOpts := []server.SSEOption{server.WithHTTPServer(s.httpServer)}
+		if s.cfg.EndpointPath != "" {
+			sseOpts = append(sseOpts, server.WithStaticBasePath(s.cfg.EndpointPath))
+		}
+		s.sse = server.NewSSEServer(s.mcp, sseOpts...)
+		handler = s.sse
+	}
+	if s.auth != nil {
+		handler = AuthMiddleware(s.auth, handler)
+	}
+	s.handler = handler
+	s.httpServer.Handler = handler
+	return handler
+}
+
+func (s *Server) transport() string {
+	if s.cfg.Transport == "" {
+		return TransportSSE
+	}
+	return s.cfg.Transport
+}
+
+func (s *Server) shutdownTimeout() time.Duration {
+	if s.cfg.ShutdownTimeout <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(s.cfg.ShutdownTimeout) * time.Millisecond
+}
+
+// Start 启动服务, 监听失败时返回错误, 不阻塞
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return errors.New("mcp server already started")
+	}
+	transport := s.transport()
+	switch transport {
+	case TransportSSE, TransportStreamableHTTP:
+	case TransportStdio:
+	default:
+		return errors.Errorf("unsupported mcp transport: %s", transport)
+	}
+
+	s.done = make(chan error, 1)
+	if transport == TransportStdio {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		stdio := server.NewStdioServer(s.mcp)
+		go func() {
+			err := stdio.Listen(ctx, s.stdin, s.stdout)
+			if errors.Is(err, context.Canceled) || errors.Is(err, io.EOF) {
+				err = nil
+			}
+			s.done <- err
+		}()
+		s.started = true
+		logs.Infof("Mcp服务启动成功，传输方式：%s", transport)
+		return nil
+	}
+
+	s.initHandler()
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen mcp server on %s", s.httpServer.Addr)
+	}
+	go func() {
+		err := s.httpServer.Serve(ln)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		s.done <- err
+	}()
+	s.started = true
+	logs.Infof("Mcp服务启动成功，传输方式：%s，监听地址：%s", transport, ln.Addr())
+	return nil
+}
+
+// Run 启动服务并阻塞, ctx 取消后关闭服务并等待处理中的请求完成
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.Start(); err != nil {
+		return err
+	}
+	select {
+	case err := <-s.done:
+		// 服务异常退出, 放回结果供 Shutdown 读取
+		s.done <- err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout())
+	defer cancel()
+	return s.Shutdown(shutdownCtx)
+}
+
+// Shutdown 关闭服务: 拒绝新的工具调用, 关闭连接, 等待处理中的工具调用和服务协程退出, 超过 ctx 期限时返回错误
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if s.closing {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closing = true
+	started := s.started
+	s.mu.Unlock()
+
+	var err error
+	switch {
+	case s.sse != nil:
+		// 同时关闭所有 SSE 会话, 否则长连接会一直阻塞关闭
+		err = s.sse.Shutdown(ctx)
+	case s.streamable != nil:
+		err = s.streamable.Shutdown(ctx)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to shutdown mcp http server")
+	}
+
+	// SSE 的工具调用在请求返回后异步执行, 需要单独等待
+	waited := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "timeout waiting for mcp tool calls")
+	}
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if !started {
+		return nil
+	}
+	select {
+	case err = <-s.done:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "timeout waiting for mcp server to stop")
+	}
+}
+
+// track 记录处理中的工具调用, 关闭后拒绝新的调用
+func (s *Server) track(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		s.mu.Lock()
+		if s.closing {
+			s.mu.Unlock()
+			return nil, ErrServerClosing
+		}
+		s.inflight.Add(1)
+		s.mu.Unlock()
+		defer s.inflight.Done()
+		return next(ctx, request)
+	}
+}
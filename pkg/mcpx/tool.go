@@ -0,0 +1,224 @@
+package mcpx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/invopop/jsonschema"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/validate"
+	"reflect"
+	"strconv"
+)
+
+// ToolHandler 类型化的工具处理函数, in 为解码并校验后的参数.
+// Out 为 string 时返回文本结果, 为 *mcp.CallToolResult 时原样返回, 其他类型返回结构化结果
+type ToolHandler[In, Out any] func(ctx context.Context, in *In) (Out, error)
+
+// NewTool 根据 In、Out 类型生成工具定义与处理函数.
+// 参数 schema 由 In 的 json、jsonschema 标签生成, validate 标签同时生成 schema 约束并在调用前校验参数;
+// Out 为结构体时生成输出 schema. In 不是结构体、标签配置错误或 schema 生成失败时返回错误
+func NewTool[In, Out any](name, description string, handler ToolHandler[In, Out], opts ...mcp.ToolOption) (server.ServerTool, error) {
+	inType := reflect.TypeOf((*In)(nil)).Elem()
+	if inType.Kind() != reflect.Struct {
+		return server.ServerTool{}, errors.Errorf("工具 %s 的参数类型 %s 必须为结构体", name, inType)
+	}
+
+	tool := mcp.NewTool(name, append([]mcp.ToolOption{mcp.WithDescription(description)}, opts...)...)
+	tool.InputSchema.Type = ""
+	inSchema, err := schemaOf(inType)
+	if err != nil {
+		return server.ServerTool{}, errors.WithMessagef(err, "工具 %s 的参数定义错误", name)
+	}
+	tool.RawInputSchema = inSchema
+	outType := reflect.TypeOf((*Out)(nil)).Elem()
+	if derefType(outType).Kind() == reflect.Struct && outType != reflect.TypeOf(&mcp.CallToolResult{}) {
+		outSchema, err := schemaOf(outType)
+		if err != nil {
+			return server.ServerTool{}, errors.WithMessagef(err, "工具 %s 的结果定义错误", name)
+		}
+		tool.RawOutputSchema = outSchema
+	}
+
+	return server.ServerTool{
+		Tool: tool,
+		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			in := new(In)
+			if request.Params.Arguments != nil {
+				if err := request.BindArguments(in); err != nil {
+					return mcp.NewToolResultError("参数格式错误: " + err.Error()), nil
+				}
+			}
+			if errs := validateValue(reflect.ValueOf(in), ""); len(errs) > 0 {
+				return mcp.NewToolResultError(errs.Error()), nil
+			}
+			out, err := handler(ctx, in)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			return toolResult(out)
+		},
+	}, nil
+}
+
+// MustNewTool 与 NewTool 相同, 出错时 panic, 用于在包初始化时定义工具
+func MustNewTool[In, Out any](name, description string, handler ToolHandler[In, Out], opts ...mcp.ToolOption) server.ServerTool {
+	tool, err := NewTool(name, description, handler, opts...)
+	if err != nil {
+		panic(fmt.Sprintf("mcpx: %v", err))
+	}
+	return tool
+}
+
+// AddTool 注册类型化的工具, 见 NewTool
+func AddTool[In, Out any](s *server.MCPServer, name, description string, handler ToolHandler[In, Out], opts ...mcp.ToolOption) error {
+	tool, err := NewTool(name, description, handler, opts...)
+	if err != nil {
+		return err
+	}
+	s.AddTools(tool)
+	return nil
+}
+
+func toolResult(out interface{}) (*mcp.CallToolResult, error) {
+	switch v := out.(type) {
+	case *mcp.CallToolResult:
+		return v, nil
+	case string:
+		return mcp.NewToolResultText(v), nil
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化工具结果失败")
+	}
+	if derefType(reflect.TypeOf(out)).Kind() != reflect.Struct {
+		return mcp.NewToolResultText(string(data)), nil
+	}
+	return mcp.NewToolResultStructured(out, string(data)), nil
+}
+
+// schemaOf 生成内联的 JSON schema, 并把 validate 标签转换为 schema 约束
+func schemaOf(t reflect.Type) (json.RawMessage, error) {
+	reflector := jsonschema.Reflector{
+		DoNotReference:             true,
+		Anonymous:                  true,
+		AllowAdditionalProperties:  true,
+		RequiredFromJSONSchemaTags: true,
+	}
+	s := reflector.ReflectFromType(t)
+	s.Version = ""
+	if err := applySchemaRules(s, t); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "生成 %s 的 schema 失败", t)
+	}
+	return data, nil
+}
+
+func applySchemaRules(s *jsonschema.Schema, t reflect.Type) error {
+	if s == nil {
+		return nil
+	}
+	t = derefType(t)
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return applySchemaRules(s.Items, t.Elem())
+	case reflect.Struct:
+		if s.Properties == nil {
+			return nil
+		}
+		specs, err := structSpec(t)
+		if err != nil {
+			return err
+		}
+		for _, f := range specs {
+			prop, ok := s.Properties.Get(f.name)
+			if !ok {
+				continue
+			}
+			if f.required() && !contains(s.Required, f.name) {
+				s.Required = append(s.Required, f.name)
+			}
+			applyRules(prop, f.rules)
+			if err := applySchemaRules(prop, f.typ); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyRules 将 validate 规则转换为 schema 约束
+func applyRules(s *jsonschema.Schema, rules []validate.Rule) {
+	target := s
+	if s.Type == "array" && s.Items != nil {
+		target = s.Items
+	}
+	for _, r := range rules {
+		switch r.Name {
+		case "min", "max":
+			applyLimit(s, r.Name == "min", r.Limit)
+		case "regex":
+			target.Pattern = r.Param
+		case "enum":
+			target.Enum = nil
+			for _, e := range r.Enums {
+				target.Enum = append(target.Enum, e)
+			}
+		}
+	}
+}
+
+func applyLimit(s *jsonschema.Schema, isMin bool, n float64) {
+	size := uint64(0)
+	if n > 0 {
+		size = uint64(n)
+	}
+	switch s.Type {
+	case "integer", "number":
+		num := json.Number(strconv.FormatFloat(n, 'f', -1, 64))
+		if isMin {
+			s.Minimum = num
+		} else {
+			s.Maximum = num
+		}
+	case "string":
+		if isMin {
+			s.MinLength = &size
+		} else {
+			s.MaxLength = &size
+		}
+	case "array":
+		if isMin {
+			s.MinItems = &size
+		} else {
+			s.MaxItems = &size
+		}
+	case "object":
+		if isMin {
+			s.MinProperties = &size
+		} else {
+			s.MaxProperties = &size
+		}
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package mcpx
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/validate"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// 参数校验使用与 hertzx 相同的 validate 标签(由 pkg/validate 解析), 如 `validate:"required,min=1,max=20,enum=a|b,regex=^\w+$"`
+
+// fieldSpec 结构体字段的参数名与校验规则
+type fieldSpec struct {
+	index []int
+	name  string
+	typ   reflect.Type
+	rules []validate.Rule
+}
+
+func (f *fieldSpec) required() bool {
+	return validate.Has(f.rules, "required")
+}
+
+// structSpecs 结构体的字段信息, 标签解析失败时记录错误
+type structSpecs struct {
+	fields []*fieldSpec
+	err    error
+}
+
+var specCache sync.Map // map[reflect.Type]*structSpecs
+
+// structSpec 解析结构体字段, 匿名嵌入的结构体字段会被展开, 标签配置错误时返回错误
+func structSpec(t reflect.Type) ([]*fieldSpec, error) {
+	if v, ok := specCache.Load(t); ok {
+		ss := v.(*structSpecs)
+		return ss.fields, ss.err
+	}
+	fields, err := collectSpecs(t, nil)
+	specCache.Store(t, &structSpecs{fields: fields, err: err})
+	return fields, err
+}
+
+func collectSpecs(t reflect.Type, parent []int) ([]*fieldSpec, error) {
+	var fields []*fieldSpec
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+		name := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && name == "" {
+			embedded, err := collectSpecs(sf.Type, index)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, embedded...)
+			continue
+		}
+		if !sf.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		rules, err := validate.Parse(sf.Tag.Get("validate"))
+		if err != nil {
+			return nil, errors.Errorf("%s.%s 的 validate 标签错误: %v", t, sf.Name, err)
+		}
+		fields = append(fields, &fieldSpec{index: index, name: name, typ: sf.Type, rules: rules})
+	}
+	return fields, nil
+}
+
+// FieldError 参数校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldErrors 参数校验错误列表
+type FieldErrors []FieldError
+
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, len(fe))
+	for i, e := range fe {
+		msgs[i] = e.Field + " " + e.Message
+	}
+	return "参数校验失败: " + strings.Join(msgs, "; ")
+}
+
+// validateValue 校验参数, 嵌套的结构体与结构体切片会递归校验
+func validateValue(v reflect.Value, path string) FieldErrors {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	var errs FieldErrors
+	switch v.Kind() {
+	case reflect.Struct:
+		specs, err := structSpec(v.Type())
+		if err != nil {
+			return FieldErrors{{Field: path, Message: err.Error()}}
+		}
+		for _, f := range specs {
+			name := f.name
+			if path != "" {
+				name = path + "." + f.name
+			}
+			fv := v.FieldByIndex(f.index)
+			fieldErrs := validateField(name, f.rules, fv)
+			errs = append(errs, fieldErrs...)
+			if len(fieldErrs) == 0 {
+				errs = append(errs, validateValue(fv, name)...)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			errs = append(errs, validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return errs
+}
+
+func validateField(name string, rules []validate.Rule, fv reflect.Value) FieldErrors {
+	var errs FieldErrors
+	for _, msg := range validate.Check(rules, fv) {
+		errs = append(errs, FieldError{Field: name, Message: msg})
+	}
+	return errs
+}
@@ -0,0 +1,216 @@
+package ormx
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+)
+
+// DerivedColumn 由源字段计算的派生列, 如盲索引列、拼音搜索列. 源字段通过 Tag 标签声明派生列名, 例如
+//
+//	ApiKey string `gorm:"column:api_key;serializer:encrypted;blindIndex:api_key_bidx"`
+type DerivedColumn struct {
+	Name   string                                       // 派生列的名称, 用于错误信息, 如 盲索引
+	Tag    string                                       // 源字段上声明派生列名的标签, 需大写, 如 BLINDINDEX
+	Match  func(field *schema.Field) bool               // 过滤源字段, 为空时包含所有设置了 Tag 的字段
+	Derive func(value interface{}) (interface{}, error) // 根据源字段的值计算派生列的值, 源字段为零值时 value 为 nil
+}
+
+// Fields 模型中需要维护派生列的源字段
+func (d DerivedColumn) Fields(s *schema.Schema) []*schema.Field {
+	var fields []*schema.Field
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.TagSettings[d.Tag] == "" {
+			continue
+		}
+		if d.Match == nil || d.Match(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Register 在 db 上注册创建、更新前维护派生列的回调, name 为回调名称.
+// 注意 UpdateColumn 不会触发回调, 需要自行维护派生列
+func (d DerivedColumn) Register(db *gorm.DB, name string) error {
+	if err := db.Callback().Create().Before("gorm:create").Register(name, d.fill); err != nil {
+		return errors.WithMessagef(err, "注册%s列回调失败", d.Name)
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register(name, d.fill); err != nil {
+		return errors.WithMessagef(err, "注册%s列回调失败", d.Name)
+	}
+	return nil
+}
+
+// fill 保存前根据源字段的值填充派生列
+func (d DerivedColumn) fill(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	s := db.Statement.Schema
+	ctx := db.Statement.Context
+	for _, field := range d.Fields(s) {
+		column := field.TagSettings[d.Tag]
+		target := s.LookUpField(column)
+		if target == nil {
+			_ = db.AddError(errors.Errorf("模型 %s 缺少%s列 %s", s.Name, d.Name, column))
+			return
+		}
+		// Update("nickName", v) 或 Updates(map) 时更新内容为 map
+		if updates, ok := db.Statement.Dest.(map[string]interface{}); ok {
+			for _, key := range []string{field.DBName, field.Name} {
+				if value, ok := updates[key]; ok {
+					derived, err := d.Derive(value)
+					if err != nil {
+						_ = db.AddError(errors.WithMessagef(err, "计算%s列 %s 失败", d.Name, column))
+						return
+					}
+					updates[target.DBName] = derived
+				}
+			}
+			continue
+		}
+		setColumn := func(rv reflect.Value) {
+			if rv.Kind() != reflect.Struct || rv.Type() != s.ModelType {
+				return
+			}
+			// 带序列化器的字段 ValueOf 返回的是序列化器, 直接读取字段值
+			var value interface{}
+			if fv := reflect.Indirect(field.ReflectValueOf(ctx, rv)); fv.IsValid() && !fv.IsZero() {
+				value = fv.Interface()
+			}
+			derived, err := d.Derive(value)
+			if err != nil {
+				_ = db.AddError(errors.WithMessagef(err, "计算%s列 %s 失败", d.Name, column))
+				return
+			}
+			if err := target.Set(ctx, rv, derived); err != nil {
+				_ = db.AddError(err)
+			}
+		}
+		targets := []reflect.Value{db.Statement.ReflectValue}
+		// Model(&T{}).Updates(T{...}) 时更新内容来自 Dest
+		if dest := reflect.Indirect(reflect.ValueOf(db.Statement.Dest)); dest.IsValid() && dest.Kind() == reflect.Struct && dest.CanAddr() {
+			targets = append(targets, dest)
+		}
+		for _, rv := range targets {
+			switch rv.Kind() {
+			case reflect.Slice, reflect.Array:
+				for i := 0; i < rv.Len(); i++ {
+					setColumn(reflect.Indirect(rv.Index(i)))
+				}
+			case reflect.Struct:
+				setColumn(rv)
+			}
+		}
+	}
+}
+
+// Backfill 为已有数据批量生成派生列, 见 BatchUpdate
+func (d DerivedColumn) Backfill(ctx context.Context, db *gorm.DB, model interface{}, batchSize int) (*BatchResult, error) {
+	var fields []*schema.Field
+	return BatchUpdate(ctx, db, model, batchSize, func(s *schema.Schema) []*schema.Field {
+		fields = d.Fields(s)
+		return fields
+	}, func(row map[string]interface{}) (map[string]interface{}, error) {
+		updates := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			derived, err := d.Derive(row[field.DBName])
+			if err != nil {
+				return nil, errors.WithMessagef(err, "计算%s列 %s 失败", d.Name, field.TagSettings[d.Tag])
+			}
+			updates[field.TagSettings[d.Tag]] = derived
+		}
+		return updates, nil
+	})
+}
+
+// BatchResult 按主键分批更新的结果
+type BatchResult struct {
+	Table   string
+	Total   int
+	Updated int
+	Failed  int
+}
+
+// BatchUpdate 按主键分批读取 fields 返回的字段对应的原始列值, 由 update 计算需要更新的列后直接按列更新, 不触发模型钩子.
+// update 返回空 map 时跳过该行, 返回错误时记录日志并计入失败. model 需要有单一主键, 没有需要读取的字段时直接返回
+func BatchUpdate(ctx context.Context, db *gorm.DB, model interface{}, batchSize int,
+	fields func(s *schema.Schema) []*schema.Field, update func(row map[string]interface{}) (map[string]interface{}, error)) (*BatchResult, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, errors.WithMessage(err, "解析模型失败")
+	}
+	s := stmt.Schema
+	if s.PrioritizedPrimaryField == nil {
+		return nil, errors.Errorf("模型 %s 没有主键", s.Name)
+	}
+	result := &BatchResult{Table: stmt.Table}
+	selected := fields(s)
+	if len(selected) == 0 {
+		return result, nil
+	}
+	pk := s.PrioritizedPrimaryField.DBName
+	columns := []string{pk}
+	for _, field := range selected {
+		columns = append(columns, field.DBName)
+	}
+	var last interface{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		var rows []map[string]interface{}
+		query := db.WithContext(ctx).Table(stmt.Table).Select(columns).Order(pk).Limit(batchSize)
+		if last != nil {
+			query = query.Where(fmt.Sprintf("%s > ?", pk), last)
+		}
+		if err := query.Find(&rows).Error; err != nil {
+			return result, errors.WithMessagef(err, "读取表 %s 失败", stmt.Table)
+		}
+		if len(rows) == 0 {
+			return result, nil
+		}
+		for _, row := range rows {
+			last = row[pk]
+			result.Total++
+			updates, err := update(row)
+			if err != nil {
+				logs.CtxErrorf(ctx, "处理 %s 失败, %s=%v: %v", stmt.Table, pk, last, err)
+				result.Failed++
+				continue
+			}
+			if len(updates) == 0 {
+				continue
+			}
+			err = db.WithContext(ctx).Table(stmt.Table).Where(fmt.Sprintf("%s = ?", pk), last).UpdateColumns(updates).Error
+			if err != nil {
+				logs.CtxErrorf(ctx, "保存 %s 失败, %s=%v: %v", stmt.Table, pk, last, err)
+				result.Failed++
+				continue
+			}
+			result.Updated++
+		}
+	}
+}
+
+// ColumnString 原始列值转换为字符串
+func ColumnString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
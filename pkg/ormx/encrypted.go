@@ -0,0 +1,265 @@
+package ormx
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/crypto"
+	"github.com/xiehqing/common/pkg/logs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"sync"
+)
+
+// EncryptedSerializerName 加密字段序列化器名称, 使用方式 `gorm:"serializer:encrypted"`.
+// 配合 `blindIndex:列名` 可以在保存时把明文的 HMAC 写入指定列, 用于等值查询, 例如
+//
+//	ApiKey      string `gorm:"column:api_key;serializer:encrypted;blindIndex:api_key_bidx"`
+//	ApiKeyIndex string `gorm:"column:api_key_bidx;type:varchar(64);index"`
+//
+// 注意 Update("api_key", v) 这类按列名更新不会经过序列化器, 需要使用 EncryptValue 自行加密
+const EncryptedSerializerName = "encrypted"
+
+const blindIndexTag = "BLINDINDEX"
+
+var encryption struct {
+	sync.RWMutex
+	crypto   crypto.RotatableCrypto
+	indexKey []byte
+}
+
+// plaintextWarning 未配置加密器时按明文写入, 只提示一次
+var plaintextWarning sync.Once
+
+func init() {
+	schema.RegisterSerializer(EncryptedSerializerName, EncryptedSerializer{})
+}
+
+// EncryptionOption 字段加密选项
+type EncryptionOption func(db *gorm.DB) error
+
+// WithBlindIndexKey 设置盲索引密钥, 应与加密密钥不同, 并且不能轮换, 否则已有索引失效
+func WithBlindIndexKey(key []byte) EncryptionOption {
+	return func(db *gorm.DB) error {
+		encryption.Lock()
+		encryption.indexKey = append([]byte(nil), key...)
+		encryption.Unlock()
+		return nil
+	}
+}
+
+// ConfigureEncryption 只设置字段加密使用的加密器, 不依赖数据库连接, 可以在打开数据库前调用以解密配置中的密码
+func ConfigureEncryption(c crypto.RotatableCrypto, opts ...EncryptionOption) error {
+	encryption.Lock()
+	encryption.crypto = c
+	encryption.Unlock()
+	for _, opt := range opts {
+		if err := opt(nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetupEncryption 设置字段加密使用的加密器, 并在 db 上注册维护盲索引列的回调, db 为空时等同于 ConfigureEncryption
+func SetupEncryption(db *gorm.DB, c crypto.RotatableCrypto, opts ...EncryptionOption) error {
+	if err := ConfigureEncryption(c, opts...); err != nil {
+		return err
+	}
+	if db == nil {
+		return nil
+	}
+	return blindIndexColumn.Register(db, "ormx:blind_index")
+}
+
+func currentCrypto() (crypto.RotatableCrypto, error) {
+	encryption.RLock()
+	defer encryption.RUnlock()
+	if encryption.crypto == nil {
+		return nil, errors.New("未配置字段加密, 请先调用 ormx.SetupEncryption")
+	}
+	return encryption.crypto, nil
+}
+
+// EncryptValue 使用字段加密器加密, 空字符串不加密. 未配置加密器时按明文写入,
+// 配置后读取仍兼容明文, 可以通过 ReEncrypt 批量加密
+func EncryptValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	c, err := currentCrypto()
+	if err != nil {
+		plaintextWarning.Do(func() {
+			logs.Warnf("未配置字段加密, 加密字段将按明文保存, 请调用 ormx.SetupEncryption 或配置 DBConfig.Encryption")
+		})
+		return value, nil
+	}
+	return c.Encrypt(value)
+}
+
+// DecryptValue 解密字段值, 未加密的历史明文原样返回
+func DecryptValue(value string) (string, error) {
+	if value == "" || !crypto.IsVersioned(value) {
+		return value, nil
+	}
+	c, err := currentCrypto()
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := c.Decrypt(value)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// BlindIndex 计算盲索引, 查询时使用 db.Where("api_key_bidx = ?", ormx.BlindIndex(apiKey))
+func BlindIndex(value string) string {
+	encryption.RLock()
+	key := encryption.indexKey
+	encryption.RUnlock()
+	if value == "" || len(key) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// EncryptedSerializer 加密字段序列化器, 支持 string 和 []byte 类型字段
+type EncryptedSerializer struct{}
+
+// Scan 读取时解密, 未加密的历史明文原样返回以便逐步迁移
+func (EncryptedSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return errors.Errorf("加密字段 %s 不支持的数据库类型 %T", field.Name, dbValue)
+	}
+	plaintext, err := DecryptValue(value)
+	if err != nil {
+		return errors.WithMessagef(err, "解密字段 %s 失败", field.Name)
+	}
+	switch field.FieldType.Kind() {
+	case reflect.String:
+		field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	case reflect.Slice:
+		field.ReflectValueOf(ctx, dst).SetBytes([]byte(plaintext))
+	default:
+		return errors.Errorf("加密字段 %s 只支持 string 和 []byte 类型", field.Name)
+	}
+	return nil
+}
+
+// Value 写入时加密
+func (EncryptedSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	var value string
+	switch v := fieldValue.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return nil, errors.Errorf("加密字段 %s 只支持 string 和 []byte 类型", field.Name)
+	}
+	ciphertext, err := EncryptValue(value)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "加密字段 %s 失败", field.Name)
+	}
+	return ciphertext, nil
+}
+
+// blindIndexColumn 加密字段的盲索引列. 按列名更新时值为 EncryptValue 的密文, 先解密再计算
+var blindIndexColumn = DerivedColumn{
+	Name: "盲索引",
+	Tag:  blindIndexTag,
+	Match: func(field *schema.Field) bool {
+		_, ok := field.Serializer.(EncryptedSerializer)
+		return ok
+	},
+	Derive: func(value interface{}) (interface{}, error) {
+		plaintext, err := DecryptValue(ColumnString(value))
+		if err != nil {
+			return nil, err
+		}
+		return BlindIndex(plaintext), nil
+	},
+}
+
+// encryptedFields 获取模型中的加密字段
+func encryptedFields(s *schema.Schema) []*schema.Field {
+	var fields []*schema.Field
+	for _, field := range s.Fields {
+		if _, ok := field.Serializer.(EncryptedSerializer); ok && field.DBName != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// plaintext 未加密的历史数据, 作为迁移时的旧版加密器
+type plaintext struct{}
+
+func (plaintext) Encrypt(data string) (string, error) {
+	return data, nil
+}
+
+func (plaintext) Decrypt(data string) ([]byte, error) {
+	return []byte(data), nil
+}
+
+// ReEncryptResult 批量重新加密结果
+type ReEncryptResult struct {
+	Table    string
+	Total    int
+	Migrated int
+	Failed   int
+}
+
+// ReEncrypt 批量重新加密模型中所有加密字段, 包括未加密的历史明文和非当前密钥加密的密文,
+// 按主键分批读取原始列值, 直接按列更新密文和盲索引, 不触发模型钩子. model 需要有单一主键
+func ReEncrypt(ctx context.Context, db *gorm.DB, model interface{}, batchSize int) (*ReEncryptResult, error) {
+	c, err := currentCrypto()
+	if err != nil {
+		return nil, err
+	}
+	migrator := crypto.NewMigrator(plaintext{}, c)
+	var fields []*schema.Field
+	result, err := BatchUpdate(ctx, db, model, batchSize, func(s *schema.Schema) []*schema.Field {
+		fields = encryptedFields(s)
+		return fields
+	}, func(row map[string]interface{}) (map[string]interface{}, error) {
+		updates := make(map[string]interface{})
+		for _, field := range fields {
+			value := ColumnString(row[field.DBName])
+			if !migrator.NeedsMigration(value) {
+				continue
+			}
+			migrated, _, err := migrator.Migrate(value)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "重新加密 %s 失败", field.DBName)
+			}
+			updates[field.DBName] = migrated
+			if column := field.TagSettings[blindIndexTag]; column != "" {
+				index, err := blindIndexColumn.Derive(migrated)
+				if err != nil {
+					return nil, err
+				}
+				updates[column] = index
+			}
+		}
+		return updates, nil
+	})
+	if result == nil {
+		return nil, err
+	}
+	return &ReEncryptResult{Table: result.Table, Total: result.Total, Migrated: result.Updated, Failed: result.Failed}, err
+}
@@ -0,0 +1,128 @@
+package ormx
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/xiehqing/common/pkg/crypto"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type secretModel struct {
+	ID          int64  `gorm:"primaryKey"`
+	ApiKey      string `gorm:"column:api_key;serializer:encrypted;blindIndex:api_key_bidx"`
+	ApiKeyIndex string `gorm:"column:api_key_bidx"`
+}
+
+func TestEncryptedSerializer(t *testing.T) {
+	c, err := crypto.NewAESGCM(crypto.NewKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetupEncryption(db, c, WithBlindIndexKey([]byte("index-key"))); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &secretModel{ApiKey: "sk-123"}
+	tx := db.Create(m)
+	if tx.Error != nil {
+		t.Fatal(tx.Error)
+	}
+	stmt := tx.Statement
+	value, err := stmt.Vars[0].(driver.Valuer).Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, _ := value.(string)
+	if !strings.HasPrefix(ciphertext, crypto.CipherPrefix) {
+		t.Fatalf("api_key not encrypted: %v", stmt.Vars)
+	}
+	if m.ApiKeyIndex == "" || m.ApiKeyIndex != BlindIndex("sk-123") || stmt.Vars[1] != m.ApiKeyIndex {
+		t.Fatalf("unexpected blind index %q, vars %v", m.ApiKeyIndex, stmt.Vars)
+	}
+
+	// 按列名更新时值为 EncryptValue 的密文, 盲索引按明文计算
+	encrypted, err := EncryptValue("sk-456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx = db.Model(&secretModel{ID: 1}).Update("api_key", encrypted)
+	if tx.Error != nil || !contains(tx.Statement.Vars, BlindIndex("sk-456")) {
+		t.Fatalf("unexpected update vars %v: %v", tx.Statement.Vars, tx.Error)
+	}
+
+	s, err := schema.Parse(&secretModel{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := s.LookUpField("api_key")
+	for _, stored := range []string{ciphertext, "sk-123"} {
+		var got secretModel
+		if err := field.Serializer.Scan(context.Background(), field, reflect.ValueOf(&got).Elem(), stored); err != nil {
+			t.Fatal(err)
+		}
+		if got.ApiKey != "sk-123" {
+			t.Fatalf("scan(%q) = %q", stored, got.ApiKey)
+		}
+	}
+}
+
+func TestEncryptionWithoutCrypto(t *testing.T) {
+	if err := ConfigureEncryption(nil); err != nil {
+		t.Fatal(err)
+	}
+	value, err := EncryptValue("sk-123")
+	if err != nil || value != "sk-123" {
+		t.Fatalf("EncryptValue without crypto = %q, %v", value, err)
+	}
+	if _, err := ReEncrypt(context.Background(), nil, &secretModel{}, 0); err == nil {
+		t.Fatal("ReEncrypt without crypto should fail")
+	}
+}
+
+func TestResolvePassword(t *testing.T) {
+	c, err := crypto.NewAESGCM(crypto.NewKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureEncryption(c); err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, err := EncryptValue("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ORMX_TEST_DB_PASS", "from-env")
+	t.Setenv("ORMX_TEST_DB_CIPHER", ciphertext)
+	for raw, want := range map[string]string{
+		"plain":                   "plain",
+		ciphertext:                "secret",
+		"env:ORMX_TEST_DB_PASS":   "from-env",
+		"env:ORMX_TEST_DB_CIPHER": "secret",
+	} {
+		got, err := resolvePassword(raw)
+		if err != nil || got != want {
+			t.Fatalf("resolvePassword(%q) = %q, %v, want %q", raw, got, err, want)
+		}
+	}
+}
+
+func contains(items []interface{}, want interface{}) bool {
+	for _, item := range items {
+		if item == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package ormx
+
+import (
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/util"
+	"gorm.io/gorm"
+	"reflect"
+)
+
+// SetupIDGenerator 在 db 上注册创建前生成主键的回调, 主键为字符串且为空时(如 UuidModel)使用 gen 生成,
+// 已经设置的主键保持不变, 例如
+//
+//	gen, _ := util.NewIDGenerator(util.IDStrategyULID, 0)
+//	_ = ormx.SetupIDGenerator(db, gen)
+func SetupIDGenerator(db *gorm.DB, gen util.IDGenerator) error {
+	if gen == nil {
+		return errors.New("ID 生成器不能为空")
+	}
+	err := db.Callback().Create().Before("gorm:create").Register("ormx:generate_id", func(db *gorm.DB) {
+		generateID(db, gen)
+	})
+	if err != nil {
+		return errors.WithMessage(err, "注册主键生成回调失败")
+	}
+	return nil
+}
+
+// newIDGenerator 根据配置创建 ID 生成器, 策略为空时返回 nil.
+// snowflake 策略要求显式配置机器号, 避免多个实例默认使用同一个机器号生成重复 ID
+func newIDGenerator(name string, workerID *int64) (util.IDGenerator, error) {
+	if name == "" {
+		return nil, nil
+	}
+	strategy, err := util.ParseIDStrategy(name)
+	if err != nil {
+		return nil, err
+	}
+	var id int64
+	if workerID != nil {
+		id = *workerID
+	} else if strategy == util.IDStrategySnowflake {
+		return nil, errors.New("snowflake 策略需要配置 worker-id, 多实例部署时可使用 redisx.AcquireWorkerID 分配机器号后调用 SetupIDGenerator")
+	}
+	return util.NewIDGenerator(strategy, id)
+}
+
+// generateID 为空的字符串主键生成 ID
+func generateID(db *gorm.DB, gen util.IDGenerator) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	s := db.Statement.Schema
+	field := s.PrioritizedPrimaryField
+	if field == nil || field.FieldType.Kind() != reflect.String {
+		return
+	}
+	ctx := db.Statement.Context
+	setID := func(rv reflect.Value) {
+		if rv.Kind() != reflect.Struct || rv.Type() != s.ModelType {
+			return
+		}
+		if _, isZero := field.ValueOf(ctx, rv); !isZero {
+			return
+		}
+		id, err := gen.NextID()
+		if err != nil {
+			_ = db.AddError(errors.WithMessagef(err, "生成 %s 主键失败", s.Name))
+			return
+		}
+		if err := field.Set(ctx, rv, id); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+	rv := db.Statement.ReflectValue
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			setID(reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		setID(rv)
+	}
+}
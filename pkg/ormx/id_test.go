@@ -0,0 +1,57 @@
+package ormx
+
+import (
+	"testing"
+
+	"github.com/xiehqing/common/pkg/util"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+type idModel struct {
+	UuidModel
+	Name string
+}
+
+func TestSetupIDGenerator(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gen, err := util.NewIDGenerator(util.IDStrategySnowflake, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetupIDGenerator(db, gen); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &idModel{Name: "a"}
+	if err := db.Create(m).Error; err != nil || len(m.ID) != 19 {
+		t.Fatalf("unexpected id %q: %v", m.ID, err)
+	}
+	models := []*idModel{{UuidModel: UuidModel{ID: "fixed"}}, {Name: "b"}}
+	if err := db.Create(&models).Error; err != nil {
+		t.Fatal(err)
+	}
+	if models[0].ID != "fixed" || len(models[1].ID) != 19 || models[1].ID <= m.ID {
+		t.Fatalf("unexpected ids %q %q", models[0].ID, models[1].ID)
+	}
+}
+
+func TestNewIDGenerator(t *testing.T) {
+	if gen, err := newIDGenerator("", nil); gen != nil || err != nil {
+		t.Fatalf("unexpected generator %v: %v", gen, err)
+	}
+	if _, err := newIDGenerator("snowflake", nil); err == nil {
+		t.Fatal("snowflake without worker id should fail")
+	}
+	workerID := int64(3)
+	if gen, err := newIDGenerator("snowflake", &workerID); gen == nil || err != nil {
+		t.Fatalf("unexpected generator %v: %v", gen, err)
+	}
+	if gen, err := newIDGenerator("ulid", nil); gen == nil || err != nil {
+		t.Fatalf("unexpected generator %v: %v", gen, err)
+	}
+}
@@ -2,6 +2,7 @@ package ormx
 
 import (
 	"fmt"
+	"github.com/xiehqing/common/pkg/crypto"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
@@ -17,7 +18,7 @@ type DBConfig struct {
 	Host               string `yaml:"host" json:"host" mapstructure:"host"`
 	Port               int    `yaml:"port" json:"port" mapstructure:"port"`
 	Username           string `yaml:"username" json:"username" mapstructure:"username"`
-	Password           string `yaml:"password" json:"password" mapstructure:"password"`
+	Password           string `yaml:"password" json:"password" mapstructure:"password"` // 支持 env:NAME 从环境变量读取, enc: 或 envl: 前缀的密文由 Encryption 或 ConfigureEncryption 配置的加密器解密
 	Database           string `yaml:"database" json:"database" mapstructure:"database"`
 	Charset            string `yaml:"charset" json:"charset" mapstructure:"charset"`
 	AppendParams       string `yaml:"append-params" json:"appendParams" mapstructure:"append-params"`
@@ -25,6 +26,10 @@ type DBConfig struct {
 	MaxOpenConnections int    `yaml:"max-open-connections" json:"maxOpenConnections" mapstructure:"max-open-connections"`
 	MaxIdleConnections int    `yaml:"max-idle-connections" json:"maxIdleConnections" mapstructure:"max-idle-connections"`
 	TablePrefix        string `yaml:"table-prefix" json:"tablePrefix" mapstructure:"table-prefix"`
+	IDStrategy         string `yaml:"id-strategy" json:"idStrategy" mapstructure:"id-strategy"` // 字符串主键生成策略 snowflake/ulid/uuidv7, 为空时不自动生成
+	WorkerID           *int64 `yaml:"worker-id" json:"workerId" mapstructure:"worker-id"`       // snowflake 策略的机器号, 必须显式配置且各实例不同, 自动分配时使用 redisx.AcquireWorkerID 和 SetupIDGenerator
+	// Encryption 字段加密密钥, 配置后在打开数据库前设置字段加密器并用于解密 Password, 打开后注册盲索引回调
+	Encryption *crypto.KeyringConfig `yaml:"encryption" json:"encryption" mapstructure:"encryption"`
 }
 
 // GetDSNByDBName 获取指定名称数据库连接字符串
@@ -78,11 +83,34 @@ func (c *DBConfig) CopyWithDbName(dbName string) *DBConfig {
 		TablePrefix:        c.TablePrefix,
 		DbType:             c.DbType,
 		Debug:              c.Debug,
+		IDStrategy:         c.IDStrategy,
+		WorkerID:           c.WorkerID,
+		Encryption:         c.Encryption,
 	}
 }
 
 // NewDBClient 创建db客户端
 func NewDBClient(c DBConfig) (*gorm.DB, error) {
+	var cipher crypto.RotatableCrypto
+	if c.Encryption != nil {
+		built, err := c.Encryption.Build()
+		if err != nil {
+			return nil, fmt.Errorf("build db encryption failed: %v", err)
+		}
+		if err := ConfigureEncryption(built); err != nil {
+			return nil, err
+		}
+		cipher = built
+	}
+	gen, err := newIDGenerator(c.IDStrategy, c.WorkerID)
+	if err != nil {
+		return nil, err
+	}
+	password, err := resolvePassword(c.Password)
+	if err != nil {
+		return nil, err
+	}
+	c.Password = password
 	var dialect gorm.Dialector
 	switch strings.ToLower(c.DbType) {
 	case "mysql":
@@ -103,6 +131,16 @@ func NewDBClient(c DBConfig) (*gorm.DB, error) {
 	if c.Debug {
 		db = db.Debug()
 	}
+	if cipher != nil {
+		if err := SetupEncryption(db, cipher); err != nil {
+			return nil, err
+		}
+	}
+	if gen != nil {
+		if err := SetupIDGenerator(db, gen); err != nil {
+			return nil, err
+		}
+	}
 	sqlDb, err := db.DB()
 	if err != nil {
 		return nil, err
@@ -150,3 +188,16 @@ type Pagination struct {
 	SortField string `json:"sortField" form:"sortField"`
 	SortOrder string `json:"sortOrder" form:"sortOrder"`
 }
+
+// resolvePassword 解析配置中的密码, 先按 env:NAME 读取环境变量, 结果带版本前缀时使用字段加密器解密, 其余原样返回
+func resolvePassword(password string) (string, error) {
+	password = crypto.ReadValue(password)
+	if !crypto.IsVersioned(password) {
+		return password, nil
+	}
+	plaintext, err := DecryptValue(password)
+	if err != nil {
+		return "", fmt.Errorf("decrypt db password failed: %v", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,199 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// ErrUnknownHash 无法识别的哈希格式
+var ErrUnknownHash = errors.New("无法识别的密码哈希格式")
+
+// Hasher 密码哈希算法
+type Hasher interface {
+	// Hash 计算密码哈希, 结果中包含算法参数
+	Hash(password string) (string, error)
+	// Verify 校验密码, needsRehash 表示哈希参数与当前配置不一致, 需要重新计算
+	Verify(encoded, password string) (ok bool, needsRehash bool, err error)
+	// Match 是否为该算法生成的哈希
+	Match(encoded string) bool
+}
+
+// HashConfig 密码哈希配置
+type HashConfig struct {
+	Algorithm string `json:"algorithm" yaml:"algorithm" mapstructure:"algorithm"` // argon2id 或 bcrypt, 默认 argon2id
+	// argon2id 参数
+	Time    uint32 `json:"time" yaml:"time" mapstructure:"time"`
+	Memory  uint32 `json:"memory" yaml:"memory" mapstructure:"memory"` // 单位 KiB
+	Threads uint8  `json:"threads" yaml:"threads" mapstructure:"threads"`
+	SaltLen uint32 `json:"saltLen" yaml:"salt-len" mapstructure:"salt-len"`
+	KeyLen  uint32 `json:"keyLen" yaml:"key-len" mapstructure:"key-len"`
+	// bcrypt 参数
+	Cost int `json:"cost" yaml:"cost" mapstructure:"cost"`
+}
+
+// Prepare 设置默认值
+func (c *HashConfig) Prepare() {
+	if c.Algorithm == "" {
+		c.Algorithm = AlgorithmArgon2id
+	}
+	if c.Time == 0 {
+		c.Time = 3
+	}
+	if c.Memory == 0 {
+		c.Memory = 64 * 1024
+	}
+	if c.Threads == 0 {
+		c.Threads = 4
+	}
+	if c.SaltLen == 0 {
+		c.SaltLen = 16
+	}
+	if c.KeyLen == 0 {
+		c.KeyLen = 32
+	}
+	if c.Cost == 0 {
+		c.Cost = 12
+	}
+}
+
+// Argon2idHasher argon2id 哈希, 编码格式为 $argon2id$v=19$m=65536,t=3,p=4$salt$hash
+type Argon2idHasher struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+// NewArgon2idHasher 创建 argon2id 哈希
+func NewArgon2idHasher(cfg HashConfig) *Argon2idHasher {
+	cfg.Prepare()
+	return &Argon2idHasher{Time: cfg.Time, Memory: cfg.Memory, Threads: cfg.Threads, SaltLen: cfg.SaltLen, KeyLen: cfg.KeyLen}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(encoded, password string) (bool, bool, error) {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, false, err
+	}
+	actual := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(actual, key) != 1 {
+		return false, false, nil
+	}
+	needsRehash := p.Time != h.Time || p.Memory != h.Memory || p.Threads != h.Threads ||
+		uint32(len(salt)) != h.SaltLen || uint32(len(key)) != h.KeyLen
+	return true, needsRehash, nil
+}
+
+func (h *Argon2idHasher) Match(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+// minArgon2idKeyLen 校验时允许的最短哈希长度
+const minArgon2idKeyLen = 16
+
+// decodeArgon2id 解析 argon2id 哈希, 参数为0、盐为空或哈希过短时返回错误, 避免损坏的数据导致 panic 或校验绕过
+func decodeArgon2id(encoded string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, nil, nil, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errors.Errorf("不支持的 argon2 版本: %s", parts[2])
+	}
+	p := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return nil, nil, nil, errors.WithMessage(err, "解析 argon2id 参数失败")
+	}
+	if p.Memory == 0 || p.Time == 0 || p.Threads == 0 {
+		return nil, nil, nil, errors.Errorf("argon2id 参数错误: %s", parts[3])
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errors.WithMessage(err, "解析 argon2id 盐失败")
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, errors.WithMessage(err, "解析 argon2id 哈希失败")
+	}
+	// 空的盐或哈希会让任意密码都校验通过, 必须拒绝
+	if len(salt) == 0 {
+		return nil, nil, nil, errors.New("argon2id 盐不能为空")
+	}
+	if len(key) < minArgon2idKeyLen {
+		return nil, nil, nil, errors.Errorf("argon2id 哈希长度不能小于%d个字节", minArgon2idKeyLen)
+	}
+	return p, salt, key, nil
+}
+
+// BcryptMaxBytes bcrypt 支持的最大密码长度, 超出部分会被截断, 因此直接拒绝
+const BcryptMaxBytes = 72
+
+// ErrPasswordTooLong 密码超过 bcrypt 支持的长度
+var ErrPasswordTooLong = errors.Errorf("使用 bcrypt 时密码长度不能超过%d个字节", BcryptMaxBytes)
+
+// BcryptHasher bcrypt 哈希, 超过72字节的密码不能哈希, 校验时也不会通过
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher 创建 bcrypt 哈希
+func NewBcryptHasher(cfg HashConfig) *BcryptHasher {
+	cfg.Prepare()
+	return &BcryptHasher{Cost: cfg.Cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > BcryptMaxBytes {
+		return "", ErrPasswordTooLong
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", errors.WithMessage(err, "计算 bcrypt 哈希失败")
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) Verify(encoded, password string) (bool, bool, error) {
+	if len(password) > BcryptMaxBytes {
+		return false, false, nil
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true, true, nil
+	}
+	return true, cost != h.Cost, nil
+}
+
+func (h *BcryptHasher) Match(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
@@ -0,0 +1,139 @@
+package password
+
+import (
+	"github.com/pkg/errors"
+	"strconv"
+)
+
+// Config 密码配置
+type Config struct {
+	Hash   HashConfig   `json:"hash" yaml:"hash" mapstructure:"hash"`
+	Policy PolicyConfig `json:"policy" yaml:"policy" mapstructure:"policy"`
+}
+
+// Prepare 设置默认值
+func (c *Config) Prepare() {
+	c.Hash.Prepare()
+	c.Policy.Prepare()
+}
+
+// LegacyVerifier 旧版密码校验, 用于校验迁移前以明文或可逆加密保存的密码, 校验通过后会被重新哈希
+type LegacyVerifier func(stored, password string) bool
+
+// Option 密码管理选项
+type Option func(*Manager)
+
+// WithLegacyVerifier 设置旧版密码校验
+func WithLegacyVerifier(v LegacyVerifier) Option {
+	return func(m *Manager) {
+		m.legacy = v
+	}
+}
+
+// Manager 密码管理, 负责哈希、校验和策略检查.
+// 新密码使用配置的算法哈希; 校验时识别所有支持的算法, 算法或参数与当前配置不一致时提示重新哈希
+type Manager struct {
+	hasher  Hasher
+	hashers []Hasher
+	policy  *Policy
+	legacy  LegacyVerifier
+}
+
+// NewManager 创建密码管理
+func NewManager(cfg Config, opts ...Option) (*Manager, error) {
+	cfg.Prepare()
+	argon := NewArgon2idHasher(cfg.Hash)
+	bc := NewBcryptHasher(cfg.Hash)
+	m := &Manager{hashers: []Hasher{argon, bc}}
+	switch cfg.Hash.Algorithm {
+	case AlgorithmArgon2id:
+		m.hasher = argon
+	case AlgorithmBcrypt:
+		m.hasher = bc
+	default:
+		return nil, errors.Errorf("不支持的密码哈希算法: %s", cfg.Hash.Algorithm)
+	}
+	policy, err := NewPolicy(cfg.Policy)
+	if err != nil {
+		return nil, err
+	}
+	m.policy = policy
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m, nil
+}
+
+// Policy 密码策略
+func (m *Manager) Policy() *Policy {
+	return m.policy
+}
+
+// Hash 计算密码哈希
+func (m *Manager) Hash(password string) (string, error) {
+	return m.hasher.Hash(password)
+}
+
+// IsHashed 是否为支持的哈希格式
+func (m *Manager) IsHashed(encoded string) bool {
+	return m.find(encoded) != nil
+}
+
+// Verify 校验密码, needsRehash 为 true 时调用方应使用 Hash 重新计算并保存
+func (m *Manager) Verify(encoded, password string) (ok bool, needsRehash bool, err error) {
+	if encoded == "" {
+		return false, false, nil
+	}
+	h := m.find(encoded)
+	if h == nil {
+		if m.legacy != nil && m.legacy(encoded, password) {
+			return true, true, nil
+		}
+		return false, false, nil
+	}
+	ok, needsRehash, err = h.Verify(encoded, password)
+	if err != nil || !ok {
+		return false, false, err
+	}
+	return true, needsRehash || h != m.hasher, nil
+}
+
+// Validate 校验新密码是否满足策略, history 为最近使用过的密码哈希, 按时间倒序
+func (m *Manager) Validate(password, username string, history []string) error {
+	err := m.policy.Validate(password, username)
+	if _, ok := m.hasher.(*BcryptHasher); ok && len(password) > BcryptMaxBytes {
+		err = addViolation(err, "使用 bcrypt 时长度不能超过"+strconv.Itoa(BcryptMaxBytes)+"个字节")
+	}
+	size := m.policy.cfg.HistorySize
+	if size <= 0 {
+		return err
+	}
+	if len(history) > size {
+		history = history[:size]
+	}
+	for _, encoded := range history {
+		if ok, _, _ := m.Verify(encoded, password); ok {
+			return addViolation(err, "不能与最近"+strconv.Itoa(size)+"次使用过的密码相同")
+		}
+	}
+	return err
+}
+
+// addViolation 在策略错误中追加一条不满足的要求
+func addViolation(err error, violation string) error {
+	var pe *PolicyError
+	if !errors.As(err, &pe) {
+		pe = &PolicyError{}
+	}
+	pe.Violations = append(pe.Violations, violation)
+	return pe
+}
+
+func (m *Manager) find(encoded string) Hasher {
+	for _, h := range m.hashers {
+		if h.Match(encoded) {
+			return h
+		}
+	}
+	return nil
+}
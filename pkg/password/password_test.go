@@ -0,0 +1,116 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fastConfig(alg string) Config {
+	return Config{Hash: HashConfig{Algorithm: alg, Time: 1, Memory: 1024, Threads: 1, Cost: 4}}
+}
+
+func TestHashAndRehash(t *testing.T) {
+	argon, err := NewManager(fastConfig(AlgorithmArgon2id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, _ := argon.Hash("Secret#123")
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected encoding %s", hash)
+	}
+	if ok, rehash, err := argon.Verify(hash, "Secret#123"); !ok || rehash || err != nil {
+		t.Fatalf("verify = %v %v %v", ok, rehash, err)
+	}
+	if ok, _, _ := argon.Verify(hash, "secret#123"); ok {
+		t.Fatal("wrong password accepted")
+	}
+
+	// 参数变化或算法变化都需要重新哈希
+	stronger, _ := NewManager(Config{Hash: HashConfig{Time: 2, Memory: 1024, Threads: 1}})
+	if ok, rehash, _ := stronger.Verify(hash, "Secret#123"); !ok || !rehash {
+		t.Fatalf("expected rehash after parameter change, ok=%v rehash=%v", ok, rehash)
+	}
+	bc, _ := NewManager(fastConfig(AlgorithmBcrypt))
+	if ok, rehash, _ := bc.Verify(hash, "Secret#123"); !ok || !rehash {
+		t.Fatalf("expected rehash after algorithm change, ok=%v rehash=%v", ok, rehash)
+	}
+
+	long := strings.Repeat("Ab1#", 18)
+	if _, err := bc.Hash(long + "x"); err == nil {
+		t.Fatal("expected bcrypt to reject passwords over 72 bytes")
+	}
+	longHash, _ := bc.Hash(long)
+	if ok, _, _ := bc.Verify(longHash, long+"x"); ok {
+		t.Fatal("bcrypt accepted a password sharing the first 72 bytes")
+	}
+	if err := bc.Validate(long+"x", "u", nil); !IsPolicyError(err) || !strings.Contains(err.Error(), "72") {
+		t.Fatalf("expected policy error for long bcrypt password, got %v", err)
+	}
+
+	legacy, _ := NewManager(fastConfig(AlgorithmBcrypt), WithLegacyVerifier(func(stored, password string) bool {
+		return stored == password
+	}))
+	if ok, rehash, _ := legacy.Verify("plain", "plain"); !ok || !rehash {
+		t.Fatal("expected legacy password to be accepted and rehashed")
+	}
+}
+
+func TestVerifyMalformedArgon2id(t *testing.T) {
+	h := NewArgon2idHasher(fastConfig(AlgorithmArgon2id).Hash)
+	salt := "c2FsdHNhbHRzYWx0c2FsdA"
+	key := "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5"
+	cases := map[string]string{
+		"memory zero":  "$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key,
+		"time zero":    "$argon2id$v=19$m=1024,t=0,p=1$" + salt + "$" + key,
+		"threads zero": "$argon2id$v=19$m=1024,t=1,p=0$" + salt + "$" + key,
+		"empty salt":   "$argon2id$v=19$m=1024,t=1,p=1$$" + key,
+		"empty key":    "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$",
+		"short key":    "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$a2V5",
+	}
+	for name, encoded := range cases {
+		t.Run(name, func(t *testing.T) {
+			if ok, _, err := h.Verify(encoded, "any"); ok || err == nil {
+				t.Fatalf("malformed hash should fail with an error, ok=%v err=%v", ok, err)
+			}
+		})
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# top passwords\nP@ssw0rd123\n" + sha1Hex("Qwerty!2345") + ":42\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := fastConfig(AlgorithmArgon2id)
+	cfg.Policy = PolicyConfig{MinLength: 10, MinCharClasses: 3, HistorySize: 2, MaxAge: time.Hour, BreachedListFile: path}
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]bool{
+		"short":              false,
+		"alllowercaseletter": false,
+		"zhangsan-Secret1":   false, // 包含用户名
+		"P@ssw0rd123":        false,
+		"Qwerty!2345":        false,
+		"Correct-Horse-42":   true,
+	}
+	for pwd, valid := range cases {
+		err := m.Validate(pwd, "zhangsan", nil)
+		if (err == nil) != valid || (err != nil && !IsPolicyError(err)) {
+			t.Errorf("Validate(%q) = %v, want valid=%v", pwd, err, valid)
+		}
+	}
+	old, _ := m.Hash("Correct-Horse-42")
+	if err := m.Validate("Correct-Horse-42", "zhangsan", []string{old}); !IsPolicyError(err) {
+		t.Fatalf("expected history violation, got %v", err)
+	}
+	changed := time.Now().Add(-2 * time.Hour)
+	if !m.Policy().Expired(&changed) || !m.Policy().Expired(nil) {
+		t.Fatal("expected password to be expired")
+	}
+}
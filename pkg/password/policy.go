@@ -0,0 +1,216 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PolicyConfig 密码策略配置
+type PolicyConfig struct {
+	MinLength        int           `json:"minLength" yaml:"min-length" mapstructure:"min-length"`                        // 最小长度, 默认8
+	MaxLength        int           `json:"maxLength" yaml:"max-length" mapstructure:"max-length"`                        // 最大长度, 默认128
+	RequireUpper     bool          `json:"requireUpper" yaml:"require-upper" mapstructure:"require-upper"`               // 必须包含大写字母
+	RequireLower     bool          `json:"requireLower" yaml:"require-lower" mapstructure:"require-lower"`               // 必须包含小写字母
+	RequireDigit     bool          `json:"requireDigit" yaml:"require-digit" mapstructure:"require-digit"`               // 必须包含数字
+	RequireSymbol    bool          `json:"requireSymbol" yaml:"require-symbol" mapstructure:"require-symbol"`            // 必须包含特殊字符
+	MinCharClasses   int           `json:"minCharClasses" yaml:"min-char-classes" mapstructure:"min-char-classes"`       // 至少包含的字符种类数(大写/小写/数字/特殊字符)
+	AllowUsername    bool          `json:"allowUsername" yaml:"allow-username" mapstructure:"allow-username"`            // 是否允许密码包含用户名
+	HistorySize      int           `json:"historySize" yaml:"history-size" mapstructure:"history-size"`                  // 不能与最近N次使用过的密码相同, 0不限制
+	MaxAge           time.Duration `json:"maxAge" yaml:"max-age" mapstructure:"max-age"`                                 // 密码有效期, 0永不过期
+	BreachedListFile string        `json:"breachedListFile" yaml:"breached-list-file" mapstructure:"breached-list-file"` // 泄露密码列表文件
+}
+
+// Prepare 设置默认值
+func (c *PolicyConfig) Prepare() {
+	if c.MinLength <= 0 {
+		c.MinLength = 8
+	}
+	if c.MaxLength <= 0 {
+		c.MaxLength = 128
+	}
+}
+
+// PolicyError 密码不满足策略, Violations 为所有不满足的规则
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "密码不符合要求: " + strings.Join(e.Violations, "; ")
+}
+
+// IsPolicyError 是否为密码策略错误
+func IsPolicyError(err error) bool {
+	var pe *PolicyError
+	return errors.As(err, &pe)
+}
+
+// Policy 密码策略
+type Policy struct {
+	cfg      PolicyConfig
+	breached *BreachedList
+}
+
+// NewPolicy 创建密码策略, 配置了泄露密码列表文件时加载该文件
+func NewPolicy(cfg PolicyConfig) (*Policy, error) {
+	cfg.Prepare()
+	p := &Policy{cfg: cfg}
+	if cfg.BreachedListFile != "" {
+		list, err := LoadBreachedList(cfg.BreachedListFile)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = list
+	}
+	return p, nil
+}
+
+// Config 策略配置
+func (p *Policy) Config() PolicyConfig {
+	return p.cfg
+}
+
+// SetBreachedList 设置泄露密码列表
+func (p *Policy) SetBreachedList(list *BreachedList) {
+	p.breached = list
+}
+
+// Validate 校验密码复杂度、长度以及是否在泄露列表中, 不校验历史密码
+func (p *Policy) Validate(password, username string) error {
+	var violations []string
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		violations = append(violations, "长度不能少于"+strconv.Itoa(p.cfg.MinLength)+"个字符")
+	}
+	if length > p.cfg.MaxLength {
+		violations = append(violations, "长度不能超过"+strconv.Itoa(p.cfg.MaxLength)+"个字符")
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.cfg.RequireUpper && !upper {
+		violations = append(violations, "必须包含大写字母")
+	}
+	if p.cfg.RequireLower && !lower {
+		violations = append(violations, "必须包含小写字母")
+	}
+	if p.cfg.RequireDigit && !digit {
+		violations = append(violations, "必须包含数字")
+	}
+	if p.cfg.RequireSymbol && !symbol {
+		violations = append(violations, "必须包含特殊字符")
+	}
+	if p.cfg.MinCharClasses > 0 && countTrue(upper, lower, digit, symbol) < p.cfg.MinCharClasses {
+		violations = append(violations, "至少包含大写字母、小写字母、数字、特殊字符中的"+strconv.Itoa(p.cfg.MinCharClasses)+"种")
+	}
+	if !p.cfg.AllowUsername && username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, "不能包含用户名")
+	}
+	if p.breached != nil && p.breached.Contains(password) {
+		violations = append(violations, "该密码已出现在泄露密码列表中")
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Expired 密码是否已过期, changedAt 为空表示从未修改过, 视为已过期
+func (p *Policy) Expired(changedAt *time.Time) bool {
+	if p.cfg.MaxAge <= 0 {
+		return false
+	}
+	return changedAt == nil || time.Since(*changedAt) > p.cfg.MaxAge
+}
+
+// BreachedList 泄露密码列表, 使用 SHA-1 保存, 兼容 Have I Been Pwned 的 HASH:COUNT 格式
+type BreachedList struct {
+	hashes map[string]struct{}
+}
+
+// NewBreachedList 使用明文密码创建泄露密码列表
+func NewBreachedList(passwords ...string) *BreachedList {
+	l := &BreachedList{hashes: make(map[string]struct{}, len(passwords))}
+	for _, password := range passwords {
+		l.hashes[sha1Hex(password)] = struct{}{}
+	}
+	return l
+}
+
+// LoadBreachedList 从文件加载泄露密码列表, 每行一个, 可以是明文密码或40位 SHA-1, 以 # 开头的行为注释
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "打开泄露密码列表文件失败: %s", path)
+	}
+	defer f.Close()
+	l := &BreachedList{hashes: make(map[string]struct{})}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1Hex(hash) {
+			l.hashes[strings.ToLower(hash)] = struct{}{}
+			continue
+		}
+		l.hashes[sha1Hex(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithMessagef(err, "读取泄露密码列表文件失败: %s", path)
+	}
+	return l, nil
+}
+
+// Contains 密码是否在列表中
+func (l *BreachedList) Contains(password string) bool {
+	_, ok := l.hashes[sha1Hex(password)]
+	return ok
+}
+
+// Len 列表大小
+func (l *BreachedList) Len() int {
+	return len(l.hashes)
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func isSHA1Hex(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,97 @@
+package pinyin
+
+import (
+	"context"
+	"github.com/xiehqing/common/pkg/ormx"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// 模型字段使用 `pinyin:列名` 标签时, 保存前把字段值的拼音搜索关键字(NewNameIndex(值).SearchText())写入指定列, 例如
+//
+//	NickName       string `gorm:"column:nickName;pinyin:nick_name_pinyin"`
+//	NickNamePinyin string `gorm:"column:nick_name_pinyin;type:varchar(1000)"`
+//
+// 查询时使用 db.Scopes(pinyin.SearchScope("nickName", "nick_name_pinyin", keyword)),
+// 数据库只做粗筛, 需要 Limit 时配合 RankScope 先按匹配度粗排, 再用 Matcher 计算得分. 注意 UpdateColumn 不会触发回调, 需要自行维护拼音列
+
+const pinyinTag = "PINYIN"
+
+// searchColumn 拼音搜索列, 值为源字段的 NewNameIndex(值).SearchText()
+var searchColumn = ormx.DerivedColumn{
+	Name: "拼音",
+	Tag:  pinyinTag,
+	Derive: func(value interface{}) (interface{}, error) {
+		if value == nil {
+			return "", nil
+		}
+		return NewNameIndex(ormx.ColumnString(value)).SearchText(), nil
+	},
+}
+
+// RegisterCallbacks 在 db 上注册维护拼音列的回调
+func RegisterCallbacks(db *gorm.DB) error {
+	return searchColumn.Register(db, "pinyin:search_column")
+}
+
+// SearchScope 按原文或拼音模糊查询, 查询词中的汉字会转为拼音后匹配拼音列.
+// Matcher 支持 zhs、zhangsf 这类全拼与首字母混合的查询词, 因此拼音列只按字符顺序粗筛(如 %z%h%s%),
+// 结果是 Matcher 匹配结果的超集, 需要再用 Matcher 计算得分
+func SearchScope(column, searchColumn, query string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		query = strings.TrimSpace(query)
+		if query == "" {
+			return db
+		}
+		return db.Where(clause.Or(
+			clause.Like{Column: clause.Column{Name: column}, Value: "%" + escapeLike(query) + "%"},
+			clause.Like{Column: clause.Column{Name: searchColumn}, Value: subsequencePattern(SearchKey(query))},
+		))
+	}
+}
+
+// RankScope 按匹配度排序: 原文完全匹配、原文前缀匹配、拼音前缀匹配、拼音包含、其他, 用于在 Limit 粗筛前让更相关的记录排在前面
+func RankScope(column, searchColumn, query string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		query = strings.TrimSpace(query)
+		if query == "" {
+			return db
+		}
+		key := escapeLike(SearchKey(query))
+		return db.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL: "CASE WHEN ? = ? THEN 0 WHEN ? LIKE ? THEN 1 WHEN ? LIKE ? OR ? LIKE ? THEN 2 WHEN ? LIKE ? THEN 3 ELSE 4 END",
+			Vars: []interface{}{
+				clause.Column{Name: column}, query,
+				clause.Column{Name: column}, escapeLike(query) + "%",
+				clause.Column{Name: searchColumn}, key + "%",
+				clause.Column{Name: searchColumn}, "% " + key + "%",
+				clause.Column{Name: searchColumn}, "%" + key + "%",
+			},
+			WithoutParentheses: true,
+		}})
+	}
+}
+
+// subsequencePattern 按字符顺序匹配的 LIKE 模式, 如 zhs 转为 %z%h%s%
+func subsequencePattern(key string) string {
+	var b strings.Builder
+	b.WriteString("%")
+	for _, r := range key {
+		b.WriteString(escapeLike(string(r)))
+		b.WriteString("%")
+	}
+	return b.String()
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// BackfillResult 批量生成拼音列的结果
+type BackfillResult = ormx.BatchResult
+
+// Backfill 为已有数据批量生成拼音列, 按主键分批读取, 直接按列更新, 不触发模型钩子. model 需要有单一主键
+func Backfill(ctx context.Context, db *gorm.DB, model interface{}, batchSize int) (*BackfillResult, error) {
+	return searchColumn.Backfill(ctx, db, model, batchSize)
+}
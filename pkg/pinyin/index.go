@@ -0,0 +1,155 @@
+package pinyin
+
+import (
+	"github.com/mozillazg/go-pinyin"
+	"strings"
+	"unicode"
+)
+
+// DefaultMaxCombinations 多音字组合的默认上限, 超过后只保留前面的组合
+const DefaultMaxCombinations = 32
+
+var heteronymArgs = func() pinyin.Args {
+	args := pinyin.NewArgs()
+	args.Heteronym = true
+	args.Fallback = func(r rune, a pinyin.Args) []string { return nil }
+	return args
+}()
+
+// syllable 文本中的一个字, 汉字包含全部读音, 其他字符的读音为小写的字符本身
+type syllable struct {
+	char     rune
+	han      bool
+	readings []string
+}
+
+// Index 文本的拼音索引, 用于拼音搜索
+type Index struct {
+	// Text 原文
+	Text string `json:"text"`
+	// Full 全拼的全部多音字组合, 如 张三 为 zhangsan
+	Full []string `json:"full"`
+	// Initials 首字母的全部多音字组合, 如 张三 为 zs
+	Initials []string `json:"initials"`
+
+	syllables []syllable
+}
+
+// IndexOption 拼音索引选项
+type IndexOption func(o *indexOptions)
+
+type indexOptions struct {
+	name            bool
+	maxCombinations int
+}
+
+// WithName 按姓名处理, 首字为多音姓氏时优先使用姓氏读音, 如 单 读作 shan
+func WithName() IndexOption {
+	return func(o *indexOptions) {
+		o.name = true
+	}
+}
+
+// WithMaxCombinations 多音字组合的上限, 默认 DefaultMaxCombinations
+func WithMaxCombinations(n int) IndexOption {
+	return func(o *indexOptions) {
+		o.maxCombinations = n
+	}
+}
+
+// NewIndex 生成文本的拼音索引, 包含全拼、首字母及多音字的全部组合. 非汉字的字母统一转为小写, 空白字符被忽略
+func NewIndex(text string, opts ...IndexOption) *Index {
+	o := &indexOptions{maxCombinations: DefaultMaxCombinations}
+	for _, opt := range opts {
+		opt(o)
+	}
+	idx := &Index{Text: text}
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		readings := pinyin.SinglePinyin(r, heteronymArgs)
+		if len(readings) == 0 {
+			idx.syllables = append(idx.syllables, syllable{char: r, readings: []string{strings.ToLower(string(r))}})
+			continue
+		}
+		if surname, ok := surnameMap[string(r)]; ok && o.name && len(idx.syllables) == 0 {
+			readings = prepend(surname, readings)
+		}
+		idx.syllables = append(idx.syllables, syllable{char: r, han: true, readings: readings})
+	}
+	idx.Full = idx.combinations(o.maxCombinations, func(reading string) string { return reading })
+	idx.Initials = idx.combinations(o.maxCombinations, func(reading string) string { return string([]rune(reading)[:1]) })
+	return idx
+}
+
+// NewNameIndex 生成姓名的拼音索引, 等同于 NewIndex(name, WithName())
+func NewNameIndex(name string, opts ...IndexOption) *Index {
+	return NewIndex(name, append([]IndexOption{WithName()}, opts...)...)
+}
+
+// combinations 按读音顺序生成多音字组合, 第一个组合为每个字的首选读音
+func (idx *Index) combinations(limit int, part func(reading string) string) []string {
+	if len(idx.syllables) == 0 {
+		return nil
+	}
+	results := []string{""}
+	for _, s := range idx.syllables {
+		parts := make([]string, 0, len(s.readings))
+		seen := make(map[string]bool, len(s.readings))
+		for _, r := range s.readings {
+			if p := part(r); !seen[p] {
+				seen[p] = true
+				parts = append(parts, p)
+			}
+		}
+		next := make([]string, 0, len(results)*len(parts))
+		for _, prefix := range results {
+			for _, p := range parts {
+				if limit > 0 && len(next) >= limit {
+					break
+				}
+				next = append(next, prefix+p)
+			}
+		}
+		results = next
+	}
+	return results
+}
+
+// Keys 全部搜索关键字, 全拼在前, 首字母在后, 已去重
+func (idx *Index) Keys() []string {
+	keys := make([]string, 0, len(idx.Full)+len(idx.Initials))
+	seen := make(map[string]bool, cap(keys))
+	for _, k := range append(append([]string{}, idx.Full...), idx.Initials...) {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// SearchText 以空格拼接的搜索关键字, 用于保存到数据库的拼音列, 如 "zhangsan zs"
+func (idx *Index) SearchText() string {
+	return strings.Join(idx.Keys(), " ")
+}
+
+// SearchKey 把查询词中的汉字转为拼音, 用于在拼音列中模糊查询, 如 张s 转为 zhangs
+func SearchKey(query string) string {
+	var b strings.Builder
+	for _, s := range NewIndex(query, WithMaxCombinations(1)).syllables {
+		b.WriteString(s.readings[0])
+	}
+	return b.String()
+}
+
+func prepend(first string, items []string) []string {
+	result := []string{first}
+	for _, item := range items {
+		if item != first {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package pinyin
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestIndex(t *testing.T) {
+	idx := NewNameIndex("单田芳")
+	if idx.Full[0] != "shantianfang" || !contains(idx.Full, "dantianfang") || !contains(idx.Initials, "stf") {
+		t.Fatalf("unexpected index %+v", idx)
+	}
+	if got := NewIndex("张三 Tom").SearchText(); got != "zhangsantom zstom" {
+		t.Fatalf("unexpected search text %q", got)
+	}
+	if got := SearchKey("张s"); got != "zhangs" {
+		t.Fatalf("unexpected search key %q", got)
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	idx := NewNameIndex("张三")
+	for query, want := range map[string]int{
+		"张三": ScoreExact, "张": ScorePrefix, "三": ScoreContains,
+		"zs": ScoreWhole, "zhangs": ScoreWhole, "张s": ScoreWhole, "zhs": ScoreWhole, "Zhang San": ScoreWhole,
+		"zh": ScoreSyllablePrefix, "san": ScoreSyllableInfix, "zx": 0, "zhangsanf": 0,
+	} {
+		if got := idx.Match(query); got != want {
+			t.Errorf("Match(%q) = %d, want %d", query, got, want)
+		}
+	}
+
+	m := NewNameMatcher("李四", "张三丰", "张三", "赵四")
+	var names []string
+	for _, c := range m.Search("zs", 0) {
+		names = append(names, c.Text)
+	}
+	if !reflect.DeepEqual(names, []string{"张三", "赵四", "张三丰"}) {
+		t.Fatalf("unexpected search result %v", names)
+	}
+}
+
+type userModel struct {
+	ID             int64  `gorm:"primaryKey"`
+	NickName       string `gorm:"column:nickName;pinyin:nick_name_pinyin"`
+	NickNamePinyin string `gorm:"column:nick_name_pinyin"`
+}
+
+func TestRegisterCallbacks(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "user:pass@tcp(127.0.0.1:3306)/test", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterCallbacks(db); err != nil {
+		t.Fatal(err)
+	}
+
+	u := &userModel{NickName: "张三"}
+	if err := db.Create(u).Error; err != nil || u.NickNamePinyin != "zhangsan zs" {
+		t.Fatalf("unexpected pinyin column %q: %v", u.NickNamePinyin, err)
+	}
+	tx := db.Model(&userModel{ID: 1}).Update("nickName", "李四")
+	if tx.Error != nil || !strings.Contains(tx.Statement.SQL.String(), "`nick_name_pinyin`=") || !contains(tx.Statement.Vars, "lisi ls") {
+		t.Fatalf("unexpected update %s %v: %v", tx.Statement.SQL.String(), tx.Statement.Vars, tx.Error)
+	}
+
+	tx = db.Scopes(SearchScope("nickName", "nick_name_pinyin", "张s")).Find(&[]userModel{})
+	if !strings.Contains(tx.Statement.SQL.String(), "`nickName` LIKE ? OR `nick_name_pinyin` LIKE ?") || !contains(tx.Statement.Vars, "%z%h%a%n%g%s%") {
+		t.Fatalf("unexpected search %s %v", tx.Statement.SQL.String(), tx.Statement.Vars)
+	}
+
+	tx = db.Scopes(SearchScope("nickName", "nick_name_pinyin", "张s"), RankScope("nickName", "nick_name_pinyin", "张s")).Limit(10).Find(&[]userModel{})
+	if !strings.Contains(tx.Statement.SQL.String(), "ORDER BY CASE WHEN `nickName` = ? THEN 0") || !contains(tx.Statement.Vars, "zhangs%") || !contains(tx.Statement.Vars, "% zhangs%") {
+		t.Fatalf("unexpected rank %s %v", tx.Statement.SQL.String(), tx.Statement.Vars)
+	}
+}
+
+func contains[T comparable](items []T, want interface{}) bool {
+	for _, item := range items {
+		if interface{}(item) == want {
+			return true
+		}
+	}
+	return false
+}
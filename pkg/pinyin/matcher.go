@@ -0,0 +1,170 @@
+package pinyin
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 匹配得分, 分数越高越相关
+const (
+	// ScoreExact 原文与查询词相同
+	ScoreExact = 100
+	// ScorePrefix 原文以查询词开头
+	ScorePrefix = 90
+	// ScoreContains 原文包含查询词
+	ScoreContains = 80
+	// ScoreWhole 查询词从首字匹配到末字, 如 zhangsan、zs、张s 匹配 张三
+	ScoreWhole = 70
+	// ScoreSyllablePrefix 查询词从首字开始匹配部分字, 如 zh、zhangs 匹配 张三丰
+	ScoreSyllablePrefix = 60
+	// ScoreSyllableInfix 查询词从中间的字开始匹配, 如 sf 匹配 张三丰
+	ScoreSyllableInfix = 40
+)
+
+// Match 计算查询词与索引的匹配得分, 不匹配时返回 0.
+// 查询词可以混合汉字与拼音, 每个字可以匹配汉字本身、任一读音的全拼或前缀, 如 zs、zhangs、张s、zhs 都能匹配 张三
+func (idx *Index) Match(query string) int {
+	query = normalize(query)
+	if query == "" {
+		return 0
+	}
+	text := normalize(idx.Text)
+	switch {
+	case text == query:
+		return ScoreExact
+	case strings.HasPrefix(text, query):
+		return ScorePrefix
+	case strings.Contains(text, query):
+		return ScoreContains
+	}
+	m := &syllableMatcher{syllables: idx.syllables, query: []rune(query), memo: make(map[[2]int]int)}
+	switch m.match(0, 0) {
+	case matchWhole:
+		return ScoreWhole
+	case matchPartial:
+		return ScoreSyllablePrefix
+	}
+	for start := 1; start < len(idx.syllables); start++ {
+		if m.match(start, 0) != matchNone {
+			return ScoreSyllableInfix
+		}
+	}
+	return 0
+}
+
+const (
+	matchNone = iota + 1
+	matchPartial
+	matchWhole
+)
+
+// syllableMatcher 按字匹配查询词, memo 记录 (字序号, 查询词位置) 的匹配结果
+type syllableMatcher struct {
+	syllables []syllable
+	query     []rune
+	memo      map[[2]int]int
+}
+
+// match 从第 i 个字、查询词第 j 个字符开始匹配, 查询词用完时匹配成功, 恰好用完全部字时为完整匹配
+func (m *syllableMatcher) match(i, j int) int {
+	if j == len(m.query) {
+		if i == len(m.syllables) {
+			return matchWhole
+		}
+		return matchPartial
+	}
+	if i == len(m.syllables) {
+		return matchNone
+	}
+	key := [2]int{i, j}
+	if result, ok := m.memo[key]; ok {
+		return result
+	}
+	best := matchNone
+	try := func(next int) {
+		if result := m.match(i+1, next); result > best {
+			best = result
+		}
+	}
+	s := m.syllables[i]
+	if s.han && m.query[j] == s.char {
+		try(j + 1)
+	}
+	for _, reading := range s.readings {
+		r := []rune(reading)
+		for n := 0; n < len(r) && j+n < len(m.query) && m.query[j+n] == r[n]; n++ {
+			// 查询词在读音中间结束时也视为匹配, 如 zhangsa 匹配 张三
+			try(j + n + 1)
+		}
+		if best == matchWhole {
+			break
+		}
+	}
+	m.memo[key] = best
+	return best
+}
+
+// normalize 转为小写并去掉空白与拼音分隔符
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '\'' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// Candidate 搜索结果
+type Candidate struct {
+	// Index 候选项的序号
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+	Score int    `json:"score"`
+}
+
+// Matcher 在一组候选项中按拼音搜索, 候选项的拼音索引只计算一次. 不是并发安全的
+type Matcher struct {
+	indexes []*Index
+}
+
+// NewMatcher 创建匹配器, 候选项使用 NewIndex 或 NewNameIndex 生成
+func NewMatcher(indexes ...*Index) *Matcher {
+	return &Matcher{indexes: indexes}
+}
+
+// NewNameMatcher 按姓名创建匹配器
+func NewNameMatcher(names ...string) *Matcher {
+	m := &Matcher{indexes: make([]*Index, 0, len(names))}
+	for _, name := range names {
+		m.Add(NewNameIndex(name))
+	}
+	return m
+}
+
+// Add 添加候选项, 返回候选项的序号
+func (m *Matcher) Add(idx *Index) int {
+	m.indexes = append(m.indexes, idx)
+	return len(m.indexes) - 1
+}
+
+// Search 返回匹配的候选项, 按得分从高到低排序, 得分相同时较短的在前. limit 小于等于 0 时不限制数量
+func (m *Matcher) Search(query string, limit int) []Candidate {
+	var candidates []Candidate
+	for i, idx := range m.indexes {
+		if score := idx.Match(query); score > 0 {
+			candidates = append(candidates, Candidate{Index: i, Text: idx.Text, Score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return len([]rune(a.Text)) < len([]rune(b.Text))
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
@@ -0,0 +1,51 @@
+package redisx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/xiehqing/common/pkg/tlsx"
+)
+
+func TestNewRedisMutualTLS(t *testing.T) {
+	ca, err := tlsx.NewCA("redis-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, _ := ca.IssueServer()
+	serverCert, err := server.TLSCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTLS, err := (&tlsx.ServerConfig{TLSAllowedCACerts: []string{string(ca.CertPEM)}}).TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTLS.Certificates = append(serverTLS.Certificates, serverCert)
+	s, err := miniredis.RunTLS(serverTLS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	client, _ := ca.IssueClient("redis-client", "")
+	rdb, err := NewRedis(RedisConfig{
+		Address: s.Addr(),
+		ClientConfig: tlsx.ClientConfig{
+			UseTLS:  true,
+			TLSCA:   string(ca.CertPEM),
+			TLSCert: string(client.CertPEM),
+			TLSKey:  string(client.KeyPEM),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rdb.Set(context.Background(), "k", "v", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get("k"); got != "v" {
+		t.Fatalf("unexpected value %q", got)
+	}
+}
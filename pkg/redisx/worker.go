@@ -0,0 +1,174 @@
+package redisx
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/xiehqing/common/pkg/logs"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WorkerIDLease 通过 Redis 租约分配的 Snowflake 机器号, 实现 util.WorkerLease.
+// 后台定期续期, 续期失败超过 TTL 或 key 被其他实例占用时租约失效
+type WorkerIDLease struct {
+	client        Redis
+	key           string
+	value         string
+	id            int64
+	ttl           time.Duration
+	renewInterval time.Duration
+	expireAt      atomic.Int64
+	lost          atomic.Bool
+	stop          chan struct{}
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+// WorkerLeaseOptions 机器号租约配置
+type WorkerLeaseOptions struct {
+	Prefix        string        // key 前缀, 默认 snowflake:worker:, 不同业务使用不同前缀可以复用机器号
+	MaxWorkerID   int64         // 最大机器号, 默认 1023
+	Value         string        // 租约持有者标识, 为空时使用主机名加 UUID
+	TTL           time.Duration // 租约有效期, 默认 30 秒, 应大于可能的时钟偏差
+	RenewInterval time.Duration // 续期间隔, 默认为 TTL 的 1/3
+}
+
+// AcquireWorkerID 从随机位置开始查找空闲的机器号并持有租约, 直到 Close
+func AcquireWorkerID(ctx context.Context, client Redis, opts WorkerLeaseOptions) (*WorkerIDLease, error) {
+	if opts.Prefix == "" {
+		opts.Prefix = "snowflake:worker:"
+	}
+	if opts.MaxWorkerID <= 0 {
+		opts.MaxWorkerID = 1023
+	}
+	if opts.Value == "" {
+		host, _ := os.Hostname()
+		opts.Value = host + "/" + uuid.New().String()
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 30 * time.Second
+	}
+	if opts.RenewInterval <= 0 {
+		opts.RenewInterval = opts.TTL / 3
+	}
+	n := opts.MaxWorkerID + 1
+	start := rand.Int64N(n)
+	for i := int64(0); i < n; i++ {
+		id := (start + i) % n
+		key := fmt.Sprintf("%s%d", opts.Prefix, id)
+		begin := time.Now()
+		ok, err := client.SetNX(ctx, key, opts.Value, opts.TTL).Result()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "分配机器号失败")
+		}
+		if !ok {
+			continue
+		}
+		l := &WorkerIDLease{
+			client:        client,
+			key:           key,
+			value:         opts.Value,
+			id:            id,
+			ttl:           opts.TTL,
+			renewInterval: opts.RenewInterval,
+			stop:          make(chan struct{}),
+			done:          make(chan struct{}),
+		}
+		l.expireAt.Store(begin.Add(opts.TTL).UnixNano())
+		go l.keepAlive()
+		logs.Infof("分配机器号 %d, 租约 %s", id, key)
+		return l, nil
+	}
+	return nil, errors.Errorf("分配机器号失败: 0-%d 均已被占用", opts.MaxWorkerID)
+}
+
+// WorkerID 机器号
+func (l *WorkerIDLease) WorkerID() int64 {
+	return l.id
+}
+
+// Valid 租约是否仍然有效
+func (l *WorkerIDLease) Valid() bool {
+	return !l.lost.Load() && time.Now().UnixNano() < l.expireAt.Load()
+}
+
+// Renew 续期租约, key 已被其他实例占用时租约失效且不再恢复
+func (l *WorkerIDLease) Renew(ctx context.Context) error {
+	if l.lost.Load() {
+		return errors.Errorf("机器号 %d 租约已失效", l.id)
+	}
+	// key 过期后如果没有被其他实例占用, 重新占用
+	script := `
+		local v = redis.call("get", KEYS[1])
+		if v == ARGV[1] then
+			return redis.call("pexpire", KEYS[1], ARGV[2])
+		elseif not v then
+			redis.call("set", KEYS[1], ARGV[1], "px", ARGV[2])
+			return 1
+		else
+			return 0
+		end
+	`
+	begin := time.Now()
+	result, err := l.client.Eval(ctx, script, []string{l.key}, l.value, l.ttl.Milliseconds()).Result()
+	if err != nil {
+		return errors.WithMessagef(err, "续期机器号 %d 失败", l.id)
+	}
+	if result == int64(0) {
+		l.lost.Store(true)
+		return errors.Errorf("机器号 %d 已被其他实例占用", l.id)
+	}
+	l.expireAt.Store(begin.Add(l.ttl).UnixNano())
+	return nil
+}
+
+func (l *WorkerIDLease) keepAlive() {
+	defer close(l.done)
+	ticker := time.NewTicker(l.renewInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.renewInterval)
+			err := l.Renew(ctx)
+			cancel()
+			if err != nil {
+				logs.Errorf("%v", err)
+				if l.lost.Load() {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close 停止续期并释放机器号
+func (l *WorkerIDLease) Close(ctx context.Context) error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.stop)
+		<-l.done
+		wasLost := l.lost.Swap(true)
+		if wasLost {
+			return
+		}
+		script := `
+			if redis.call("get", KEYS[1]) == ARGV[1] then
+				return redis.call("del", KEYS[1])
+			else
+				return 0
+			end
+		`
+		if e := l.client.Eval(ctx, script, []string{l.key}, l.value).Err(); e != nil {
+			err = errors.WithMessagef(e, "释放机器号 %d 失败", l.id)
+		}
+	})
+	return err
+}
@@ -0,0 +1,58 @@
+package redisx
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestAcquireWorkerID(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	ctx := context.Background()
+	opts := WorkerLeaseOptions{MaxWorkerID: 1, TTL: time.Minute}
+
+	a, err := AcquireWorkerID(ctx, client, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := AcquireWorkerID(ctx, client, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.WorkerID() == b.WorkerID() || !a.Valid() || !b.Valid() {
+		t.Fatalf("unexpected leases %d %d", a.WorkerID(), b.WorkerID())
+	}
+	if _, err := AcquireWorkerID(ctx, client, opts); err == nil {
+		t.Fatal("expected all worker ids taken")
+	}
+
+	// key 过期后未被占用时续期重新占用
+	s.Del(a.key)
+	if err := a.Renew(ctx); err != nil || !s.Exists(a.key) {
+		t.Fatalf("expected lease restored: %v", err)
+	}
+	// 被其他实例占用后租约失效
+	_ = s.Set(a.key, "other")
+	if err := a.Renew(ctx); err == nil || a.Valid() {
+		t.Fatal("expected lease lost")
+	}
+	if err := a.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Get(a.key); got != "other" {
+		t.Fatalf("lease of other instance released: %q", got)
+	}
+
+	if err := b.Close(ctx); err != nil || s.Exists(b.key) || b.Valid() {
+		t.Fatalf("expected lease released: %v", err)
+	}
+	c, err := AcquireWorkerID(ctx, client, opts)
+	if err != nil || c.WorkerID() != b.WorkerID() {
+		t.Fatalf("expected released worker id reused: %v", err)
+	}
+	_ = c.Close(ctx)
+}
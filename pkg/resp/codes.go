@@ -0,0 +1,191 @@
+package resp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	LangZh = "zh"
+	LangEn = "en"
+)
+
+// DefaultLang 默认语言
+var DefaultLang = LangZh
+
+// ErrorCode 错误码定义
+type ErrorCode struct {
+	Code            int64             // 数字错误码, 写入 Response.Code
+	Key             string            // 稳定的字符串错误码, 如 USER_NOT_FOUND
+	HTTPStatus      int               // HTTP状态码
+	Messages        map[string]string // 各语言的消息模板, 模板参数使用 fmt 格式
+	AffectStability bool              // 是否影响系统稳定性
+}
+
+// New 创建错误, args 为消息模板参数
+func (c *ErrorCode) New(args ...interface{}) *StatusError {
+	return &StatusError{code: c, args: args}
+}
+
+// Wrap 包装底层错误, 底层错误仅用于日志, 不会返回给调用方
+func (c *ErrorCode) Wrap(err error, args ...interface{}) *StatusError {
+	return &StatusError{code: c, args: args, cause: err}
+}
+
+// Format 按语言格式化消息模板
+func (c *ErrorCode) Format(lang string, args ...interface{}) string {
+	// RegisterMessages 会整体替换模板, 读取时与注册表共用一把锁
+	codeMu.RLock()
+	messages := c.Messages
+	codeMu.RUnlock()
+	tpl, ok := messages[normalizeLang(lang)]
+	if !ok {
+		tpl, ok = messages[DefaultLang]
+	}
+	if !ok {
+		for _, v := range messages {
+			tpl = v
+			break
+		}
+	}
+	if tpl == "" {
+		tpl = c.Key
+	}
+	if len(args) == 0 {
+		return tpl
+	}
+	return fmt.Sprintf(tpl, args...)
+}
+
+// Is 判断err是否为当前错误码
+func (c *ErrorCode) Is(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.code == c
+	}
+	return false
+}
+
+var (
+	codeMu     sync.RWMutex
+	codesByNum = map[int64]*ErrorCode{}
+	codesByKey = map[string]*ErrorCode{}
+)
+
+// Register 注册错误码, 数字错误码或字符串错误码(不区分大小写)重复时 panic, 应在 init 或包级变量中调用
+func Register(code ErrorCode) *ErrorCode {
+	if code.Key == "" {
+		panic("resp: error code key is empty")
+	}
+	if code.HTTPStatus == 0 {
+		code.HTTPStatus = http.StatusInternalServerError
+	}
+	c := &code
+	codeMu.Lock()
+	defer codeMu.Unlock()
+	if exist, ok := codesByNum[c.Code]; ok {
+		panic(fmt.Sprintf("resp: error code %d already registered by %s", c.Code, exist.Key))
+	}
+	if _, ok := codesByKey[normalizeKey(c.Key)]; ok {
+		panic(fmt.Sprintf("resp: error code key %s already registered", c.Key))
+	}
+	codesByNum[c.Code] = c
+	codesByKey[normalizeKey(c.Key)] = c
+	return c
+}
+
+// RegisterMessages 为已注册的错误码追加语言模板, 用于覆盖内置文案或扩展语言, 模板以写时复制方式替换, 可在运行时调用
+func RegisterMessages(key, lang, tpl string) error {
+	codeMu.Lock()
+	defer codeMu.Unlock()
+	c, ok := codesByKey[normalizeKey(key)]
+	if !ok {
+		return fmt.Errorf("error code %s not registered", key)
+	}
+	messages := make(map[string]string, len(c.Messages)+1)
+	for k, v := range c.Messages {
+		messages[k] = v
+	}
+	messages[normalizeLang(lang)] = tpl
+	c.Messages = messages
+	return nil
+}
+
+// LookupCode 根据数字错误码查找
+func LookupCode(code int64) (*ErrorCode, bool) {
+	codeMu.RLock()
+	defer codeMu.RUnlock()
+	c, ok := codesByNum[code]
+	return c, ok
+}
+
+// LookupKey 根据字符串错误码查找, 不区分大小写
+func LookupKey(key string) (*ErrorCode, bool) {
+	codeMu.RLock()
+	defer codeMu.RUnlock()
+	c, ok := codesByKey[normalizeKey(key)]
+	return c, ok
+}
+
+// normalizeKey 字符串错误码索引统一使用大写, 注册与查找不区分大小写
+func normalizeKey(key string) string {
+	return strings.ToUpper(key)
+}
+
+// Codes 返回所有已注册的错误码, 按数字错误码排序
+func Codes() []*ErrorCode {
+	codeMu.RLock()
+	defer codeMu.RUnlock()
+	lst := make([]*ErrorCode, 0, len(codesByNum))
+	for _, c := range codesByNum {
+		lst = append(lst, c)
+	}
+	sort.Slice(lst, func(i, j int) bool {
+		return lst[i].Code < lst[j].Code
+	})
+	return lst
+}
+
+// 内置错误码
+var (
+	ErrFailed = Register(ErrorCode{
+		Code: int64(Failed), Key: "FAILED", HTTPStatus: http.StatusInternalServerError, AffectStability: true,
+		Messages: map[string]string{LangZh: "操作失败", LangEn: "operation failed"},
+	})
+	ErrBadRequest = Register(ErrorCode{
+		Code: int64(BadRequest), Key: "BAD_REQUEST", HTTPStatus: http.StatusBadRequest,
+		Messages: map[string]string{LangZh: "请求参数错误", LangEn: "bad request"},
+	})
+	ErrUnauthorized = Register(ErrorCode{
+		Code: int64(Unauthorized), Key: "UNAUTHORIZED", HTTPStatus: http.StatusUnauthorized,
+		Messages: map[string]string{LangZh: "未登录或登录已过期", LangEn: "unauthorized"},
+	})
+	ErrForbidden = Register(ErrorCode{
+		Code: int64(Forbidden), Key: "FORBIDDEN", HTTPStatus: http.StatusForbidden,
+		Messages: map[string]string{LangZh: "无权限访问", LangEn: "forbidden"},
+	})
+	ErrNotFound = Register(ErrorCode{
+		Code: int64(NotFound), Key: "NOT_FOUND", HTTPStatus: http.StatusNotFound,
+		Messages: map[string]string{LangZh: "资源不存在", LangEn: "resource not found"},
+	})
+	ErrConflict = Register(ErrorCode{
+		Code: int64(Conflict), Key: "CONFLICT", HTTPStatus: http.StatusConflict,
+		Messages: map[string]string{LangZh: "资源冲突", LangEn: "resource conflict"},
+	})
+	ErrTooManyRequests = Register(ErrorCode{
+		Code: int64(TooManyRequests), Key: "TOO_MANY_REQUESTS", HTTPStatus: http.StatusTooManyRequests,
+		Messages: map[string]string{LangZh: "请求过于频繁", LangEn: "too many requests"},
+	})
+	ErrInternal = Register(ErrorCode{
+		Code: int64(InternalError), Key: "INTERNAL_ERROR", HTTPStatus: http.StatusInternalServerError, AffectStability: true,
+		Messages: map[string]string{LangZh: "服务内部错误", LangEn: "internal server error"},
+	})
+	ErrServiceUnavailable = Register(ErrorCode{
+		Code: int64(ServiceUnavailable), Key: "SERVICE_UNAVAILABLE", HTTPStatus: http.StatusServiceUnavailable, AffectStability: true,
+		Messages: map[string]string{LangZh: "服务暂不可用", LangEn: "service unavailable"},
+	})
+)
@@ -0,0 +1,74 @@
+package resp
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+var errUserNotFound = Register(ErrorCode{
+	Code: 10001, Key: "USER_NOT_FOUND", HTTPStatus: http.StatusNotFound,
+	Messages: map[string]string{LangZh: "用户 %s 不存在", LangEn: "user %s not found"},
+})
+
+var errOrderClosed = Register(ErrorCode{Code: 10002, Key: "order_closed", HTTPStatus: http.StatusConflict})
+
+func TestFromError(t *testing.T) {
+	err := errors.WithMessage(errUserNotFound.New("tom"), "查询用户失败")
+	se := FromError(err)
+	if se.Key() != "USER_NOT_FOUND" || se.HTTPStatus() != http.StatusNotFound {
+		t.Fatalf("unexpected status error: %v", se)
+	}
+	if msg := se.Localize("en-US"); msg != "user tom not found" {
+		t.Fatalf("unexpected en message: %s", msg)
+	}
+	if msg := se.Localize("fr"); msg != "用户 tom 不存在" {
+		t.Fatalf("unexpected fallback message: %s", msg)
+	}
+	if !errUserNotFound.Is(err) {
+		t.Fatalf("expected wrapped error to match code")
+	}
+
+	unknown := FromError(errors.New("boom"))
+	if unknown.Key() != ErrInternal.Key || !unknown.IsAffectStability() {
+		t.Fatalf("unexpected unknown error mapping: %v", unknown)
+	}
+	r := ErrorResponse(unknown, LangEn)
+	if r.Code != InternalError || r.Message != "internal server error" {
+		t.Fatalf("unexpected response: %+v", r)
+	}
+}
+
+func TestLookupKey(t *testing.T) {
+	for _, key := range []string{"order_closed", "ORDER_CLOSED", "Order_Closed"} {
+		if got, ok := LookupKey(key); !ok || got != errOrderClosed {
+			t.Fatalf("LookupKey(%q) should find the registered code", key)
+		}
+	}
+	// 运行时注册模板与格式化消息并发执行
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_ = errOrderClosed.Format(LangEn)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if err := RegisterMessages("ORDER_CLOSED", LangEn, "order closed"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if msg := errOrderClosed.Format(LangEn); msg != "order closed" {
+		t.Fatalf("unexpected message: %s", msg)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a key that differs only in case should panic")
+		}
+	}()
+	Register(ErrorCode{Code: 10003, Key: "ORDER_CLOSED"})
+}
@@ -1,5 +1,10 @@
 package resp
 
+import (
+	"fmt"
+	"strings"
+)
+
 type StatusCode interface {
 	error
 	Code() int64
@@ -7,3 +12,128 @@ type StatusCode interface {
 	IsAffectStability() bool
 	Extra() map[string]string
 }
+
+var (
+	_ StatusCode = (*StatusError)(nil)
+	_ ResultCode = (*StatusError)(nil)
+)
+
+// StatusError 带错误码的错误, 由 ErrorCode.New / ErrorCode.Wrap 创建
+type StatusError struct {
+	code  *ErrorCode
+	args  []interface{}
+	msg   string
+	cause error
+	extra map[string]string
+}
+
+// Error 返回默认语言的错误信息, 若包含底层错误则一并输出
+func (e *StatusError) Error() string {
+	msg := e.Message()
+	if e.cause != nil {
+		return fmt.Sprintf("[%s] %s: %v", e.code.Key, msg, e.cause)
+	}
+	return fmt.Sprintf("[%s] %s", e.code.Key, msg)
+}
+
+// Code 数字错误码
+func (e *StatusError) Code() int64 {
+	return e.code.Code
+}
+
+// Key 稳定的字符串错误码
+func (e *StatusError) Key() string {
+	return e.code.Key
+}
+
+// HTTPStatus HTTP状态码
+func (e *StatusError) HTTPStatus() int {
+	return e.code.HTTPStatus
+}
+
+// ErrorCode 错误码定义
+func (e *StatusError) ErrorCode() *ErrorCode {
+	return e.code
+}
+
+// Message 默认语言的错误信息
+func (e *StatusError) Message() string {
+	return e.Localize(DefaultLang)
+}
+
+// Localize 指定语言的错误信息, 未配置该语言时回退到默认语言
+func (e *StatusError) Localize(lang string) string {
+	if e.msg != "" {
+		return e.msg
+	}
+	return e.code.Format(lang, e.args...)
+}
+
+// IsAffectStability 是否影响系统稳定性, 用于告警与SLA统计
+func (e *StatusError) IsAffectStability() bool {
+	return e.code.AffectStability
+}
+
+// Extra 附加信息
+func (e *StatusError) Extra() map[string]string {
+	return e.extra
+}
+
+// WithExtra 追加附加信息
+func (e *StatusError) WithExtra(key, value string) *StatusError {
+	if e.extra == nil {
+		e.extra = make(map[string]string)
+	}
+	e.extra[key] = value
+	return e
+}
+
+// WithMessage 覆盖错误信息, 覆盖后不再进行国际化
+func (e *StatusError) WithMessage(msg string) *StatusError {
+	e.msg = msg
+	return e
+}
+
+// Unwrap 返回底层错误
+func (e *StatusError) Unwrap() error {
+	return e.cause
+}
+
+// Cause 兼容 pkg/errors 的 Cause
+func (e *StatusError) Cause() error {
+	return e.cause
+}
+
+// Is 相同错误码的错误视为相同
+func (e *StatusError) Is(target error) bool {
+	t, ok := target.(*StatusError)
+	if !ok {
+		return false
+	}
+	return t.code == e.code
+}
+
+// Format 支持 %+v 输出底层错误的堆栈
+func (e *StatusError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') && e.cause != nil {
+			_, _ = fmt.Fprintf(s, "[%s] %s: %+v", e.code.Key, e.Message(), e.cause)
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = fmt.Fprint(s, e.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// normalizeLang 规范化语言标识, 如 zh-CN -> zh, en-US -> en
+func normalizeLang(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
@@ -1,17 +1,29 @@
 package resp
 
+import "errors"
+
 type ResponseCode int64
 
 const (
-	Succeeded  ResponseCode = 0
-	Failed     ResponseCode = 1
-	BadRequest ResponseCode = 400
+	Succeeded          ResponseCode = 0
+	Failed             ResponseCode = 1
+	BadRequest         ResponseCode = 400
+	Unauthorized       ResponseCode = 401
+	Forbidden          ResponseCode = 403
+	NotFound           ResponseCode = 404
+	Conflict           ResponseCode = 409
+	TooManyRequests    ResponseCode = 429
+	InternalError      ResponseCode = 500
+	ServiceUnavailable ResponseCode = 503
 )
 
 type Response struct {
-	Code    ResponseCode `json:"code"`
-	Message string       `json:"message"`
-	Data    interface{}  `json:"data"`
+	Code      ResponseCode      `json:"code"`
+	Message   string            `json:"message"`
+	Data      interface{}       `json:"data"`
+	ErrorCode string            `json:"errorCode,omitempty"`
+	RequestID string            `json:"requestId,omitempty"`
+	Extra     map[string]string `json:"extra,omitempty"`
 }
 
 func NewResponse(code ResponseCode, message string, data interface{}) *Response {
@@ -34,6 +46,38 @@ func Error(code ResponseCode, message string) *Response {
 	return NewResponse(code, message, nil)
 }
 
+// FromError 将任意错误转换为 StatusError, 支持 pkg/errors 包装的错误, 无法识别的错误视为内部错误
+func FromError(err error) *StatusError {
+	if err == nil {
+		return nil
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se
+	}
+	var sc StatusCode
+	if errors.As(err, &sc) {
+		if c, ok := LookupCode(sc.Code()); ok {
+			return c.Wrap(err).WithMessage(sc.Message())
+		}
+	}
+	return ErrInternal.Wrap(err)
+}
+
+// ErrorResponse 根据错误构建响应, lang 为响应语言
+func ErrorResponse(err error, lang string) *Response {
+	se := FromError(err)
+	if se == nil {
+		return Success(nil)
+	}
+	return &Response{
+		Code:      ResponseCode(se.Code()),
+		Message:   se.Localize(lang),
+		ErrorCode: se.Key(),
+		Extra:     se.Extra(),
+	}
+}
+
 // PageEntity 分页对象
 type PageEntity struct {
 	Total    int64 `json:"total"`
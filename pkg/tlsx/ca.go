@@ -0,0 +1,174 @@
+package tlsx
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CA is a small in-memory certificate authority intended for tests. It mints
+// ECDSA P-256 server and client certificates signed by a self-signed root.
+type CA struct {
+	Cert    *x509.Certificate
+	CertPEM []byte
+	key     *ecdsa.PrivateKey
+}
+
+// KeyPair is a certificate and its private key in PEM form.
+type KeyPair struct {
+	CertPEM []byte
+	KeyPEM  []byte
+}
+
+// CertOptions customizes a minted certificate.
+type CertOptions struct {
+	CommonName string
+	DNSNames   []string
+	IPs        []net.IP
+	SPIFFEID   string // spiffe://trust-domain/path added as a URI SAN
+	NotBefore  time.Time
+	NotAfter   time.Time
+}
+
+// NewCA creates a self-signed root certificate valid for 24 hours.
+func NewCA(commonName string) (*CA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{Cert: cert, CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), key: key}, nil
+}
+
+// Pool returns a cert pool containing the CA certificate.
+func (ca *CA) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+	return pool
+}
+
+// IssueServer mints a server certificate for the given names, IP addresses
+// in names are added as IP SANs. localhost and 127.0.0.1 are used when no
+// name is given.
+func (ca *CA) IssueServer(names ...string) (*KeyPair, error) {
+	if len(names) == 0 {
+		names = []string{"localhost", "127.0.0.1"}
+	}
+	opts := CertOptions{CommonName: names[0]}
+	for _, name := range names {
+		if ip := net.ParseIP(name); ip != nil {
+			opts.IPs = append(opts.IPs, ip)
+		} else {
+			opts.DNSNames = append(opts.DNSNames, name)
+		}
+	}
+	return ca.Issue(opts, x509.ExtKeyUsageServerAuth)
+}
+
+// IssueClient mints a client certificate. spiffeID is optional.
+func (ca *CA) IssueClient(commonName, spiffeID string) (*KeyPair, error) {
+	return ca.Issue(CertOptions{CommonName: commonName, DNSNames: []string{commonName}, SPIFFEID: spiffeID}, x509.ExtKeyUsageClientAuth)
+}
+
+// Issue mints a certificate with the given options and extended key usages.
+func (ca *CA) Issue(opts CertOptions, usages ...x509.ExtKeyUsage) (*KeyPair, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if opts.NotBefore.IsZero() {
+		opts.NotBefore = time.Now().Add(-time.Minute)
+	}
+	if opts.NotAfter.IsZero() {
+		opts.NotAfter = time.Now().Add(12 * time.Hour)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: opts.CommonName},
+		NotBefore:    opts.NotBefore,
+		NotAfter:     opts.NotAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  usages,
+		DNSNames:     opts.DNSNames,
+		IPAddresses:  opts.IPs,
+	}
+	if opts.SPIFFEID != "" {
+		u, err := url.Parse(opts.SPIFFEID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SPIFFE ID %q: %v", opts.SPIFFEID, err)
+		}
+		tmpl.URIs = []*url.URL{u}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.Cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("could not create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{
+		CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// TLSCertificate parses the key pair into a tls.Certificate.
+func (kp *KeyPair) TLSCertificate() (tls.Certificate, error) {
+	return tls.X509KeyPair(kp.CertPEM, kp.KeyPEM)
+}
+
+// WriteFiles writes name.crt and name.key into dir and returns their paths.
+func (kp *KeyPair) WriteFiles(dir, name string) (certFile, keyFile string, err error) {
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	if err = os.WriteFile(certFile, kp.CertPEM, 0600); err != nil {
+		return "", "", err
+	}
+	if err = os.WriteFile(keyFile, kp.KeyPEM, 0600); err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
+// WriteFile writes the CA certificate into dir/name.crt and returns its path.
+func (ca *CA) WriteFile(dir, name string) (string, error) {
+	path := filepath.Join(dir, name+".crt")
+	return path, os.WriteFile(path, ca.CertPEM, 0600)
+}
+
+func serialNumber() *big.Int {
+	n, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return n
+}
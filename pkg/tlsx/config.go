@@ -4,12 +4,12 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
-	"gorm.io/gorm/utils"
-	"os"
+	"io"
 	"strings"
 )
 
 // ClientConfig represents the standard client TLS config.
+// TLSCA, TLSCert and TLSKey accept a file path, inline PEM or "env:NAME", see ReadSource.
 type ClientConfig struct {
 	UseTLS             bool
 	TLSCA              string
@@ -20,9 +20,15 @@ type ClientConfig struct {
 	ServerName         string
 	TLSMinVersion      string
 	TLSMaxVersion      string
+	// TLSAutoReload watches the certificate files and reloads them on change.
+	TLSAutoReload bool
+	// TLSAllowedSPIFFEIDs restricts the server certificate to the given SPIFFE IDs.
+	// It requires a verified chain and can't be combined with InsecureSkipVerify.
+	TLSAllowedSPIFFEIDs []string
 }
 
 // ServerConfig represents the standard server TLS config.
+// TLSCert, TLSKey and TLSAllowedCACerts accept a file path, inline PEM or "env:NAME", see ReadSource.
 type ServerConfig struct {
 	TLSCert            string
 	TLSKey             string
@@ -32,14 +38,33 @@ type ServerConfig struct {
 	TLSMinVersion      string
 	TLSMaxVersion      string
 	TLSAllowedDNSNames []string
+	// TLSAutoReload watches the certificate files and reloads them on change.
+	TLSAutoReload bool
+	// TLSAllowedSPIFFEIDs restricts client certificates to the given SPIFFE IDs.
+	// Client certificates are only requested when TLSAllowedCACerts is set, so
+	// it is a config error without them.
+	TLSAllowedSPIFFEIDs []string
 }
 
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
-// configured.
+// configured. With TLSAutoReload the certificate watcher runs for the rest of
+// the process, use TLSConfigWithCloser when the config has a shorter lifetime.
 func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
+	tlsConfig, _, err := c.TLSConfigWithCloser()
+	return tlsConfig, err
+}
+
+// TLSConfigWithCloser is like TLSConfig and also returns a closer that stops
+// the certificate watcher started by TLSAutoReload. The closer is never nil.
+func (c *ClientConfig) TLSConfigWithCloser() (*tls.Config, io.Closer, error) {
 	if !c.UseTLS {
-		return nil, nil
+		return nil, nopCloser{}, nil
+	}
+	if c.InsecureSkipVerify && len(c.TLSAllowedSPIFFEIDs) > 0 {
+		// Without chain verification any self-signed certificate could carry the SPIFFE ID.
+		return nil, nil, fmt.Errorf("tls allowed SPIFFE IDs can't be used with insecure skip verify")
 	}
+	var closer io.Closer = nopCloser{}
 
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: c.InsecureSkipVerify,
@@ -49,18 +74,28 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 	if c.TLSCA != "" {
 		pool, err := makeCertPool([]string{c.TLSCA})
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		tlsConfig.RootCAs = pool
 	}
 
 	if c.TLSCert != "" && c.TLSKey != "" {
-		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey)
-		if err != nil {
-			return nil, err
+		if c.TLSAutoReload {
+			reloader, err := NewCertReloader(c.TLSCert, c.TLSKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			tlsConfig.GetClientCertificate = reloader.GetClientCertificate
+			closer = reloader
+		} else if err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey); err != nil {
+			return nil, nil, err
 		}
 	}
 
+	if len(c.TLSAllowedSPIFFEIDs) > 0 {
+		tlsConfig.VerifyPeerCertificate = peerVerifier(nil, c.TLSAllowedSPIFFEIDs)
+	}
+
 	if c.ServerName != "" {
 		tlsConfig.ServerName = c.ServerName
 	}
@@ -85,38 +120,61 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 		tlsConfig.MaxVersion = tls.VersionTLS13
 	}
 
-	return tlsConfig, nil
+	return tlsConfig, closer, nil
 }
 
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
-// configured.
+// configured. With TLSAutoReload the certificate watcher runs for the rest of
+// the process, use TLSConfigWithCloser when the config has a shorter lifetime.
 func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
+	tlsConfig, _, err := c.TLSConfigWithCloser()
+	return tlsConfig, err
+}
+
+// TLSConfigWithCloser is like TLSConfig and also returns a closer that stops
+// the certificate watcher started by TLSAutoReload. The closer is never nil.
+func (c *ServerConfig) TLSConfigWithCloser() (_ *tls.Config, _ io.Closer, err error) {
 	if c.TLSCert == "" && c.TLSKey == "" && len(c.TLSAllowedCACerts) == 0 {
-		return nil, nil
+		return nil, nopCloser{}, nil
+	}
+	if len(c.TLSAllowedSPIFFEIDs) > 0 && len(c.TLSAllowedCACerts) == 0 {
+		return nil, nil, fmt.Errorf("tls allowed SPIFFE IDs require tls allowed CA certs to verify client certificates")
 	}
+	var closer io.Closer = nopCloser{}
+	defer func() {
+		if err != nil {
+			_ = closer.Close()
+		}
+	}()
 
 	tlsConfig := &tls.Config{}
 
 	if len(c.TLSAllowedCACerts) != 0 {
 		pool, err := makeCertPool(c.TLSAllowedCACerts)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		tlsConfig.ClientCAs = pool
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	}
 
 	if c.TLSCert != "" && c.TLSKey != "" {
-		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey)
-		if err != nil {
-			return nil, err
+		if c.TLSAutoReload {
+			reloader, err := NewCertReloader(c.TLSCert, c.TLSKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			tlsConfig.GetCertificate = reloader.GetCertificate
+			closer = reloader
+		} else if err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey); err != nil {
+			return nil, nil, err
 		}
 	}
 
 	if len(c.TLSCipherSuites) != 0 {
 		cipherSuites, err := ParseCiphers(c.TLSCipherSuites)
 		if err != nil {
-			return nil, fmt.Errorf(
+			return nil, nil, fmt.Errorf(
 				"could not parse server cipher suites %s: %v", strings.Join(c.TLSCipherSuites, ","), err)
 		}
 		tlsConfig.CipherSuites = cipherSuites
@@ -125,7 +183,7 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 	if c.TLSMaxVersion != "" {
 		version, err := ParseTLSVersion(c.TLSMaxVersion)
 		if err != nil {
-			return nil, fmt.Errorf(
+			return nil, nil, fmt.Errorf(
 				"could not parse tls max version %q: %v", c.TLSMaxVersion, err)
 		}
 		tlsConfig.MaxVersion = version
@@ -134,67 +192,51 @@ func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
 	if c.TLSMinVersion != "" {
 		version, err := ParseTLSVersion(c.TLSMinVersion)
 		if err != nil {
-			return nil, fmt.Errorf(
+			return nil, nil, fmt.Errorf(
 				"could not parse tls min version %q: %v", c.TLSMinVersion, err)
 		}
 		tlsConfig.MinVersion = version
 	}
 
 	if tlsConfig.MinVersion != 0 && tlsConfig.MaxVersion != 0 && tlsConfig.MinVersion > tlsConfig.MaxVersion {
-		return nil, fmt.Errorf(
+		return nil, nil, fmt.Errorf(
 			"tls min version %q can't be greater than tls max version %q", tlsConfig.MinVersion, tlsConfig.MaxVersion)
 	}
 
 	// Since clientAuth is tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	// there must be certs to validate.
-	if len(c.TLSAllowedCACerts) > 0 && len(c.TLSAllowedDNSNames) > 0 {
-		tlsConfig.VerifyPeerCertificate = c.verifyPeerCertificate
+	if len(c.TLSAllowedCACerts) > 0 {
+		tlsConfig.VerifyPeerCertificate = peerVerifier(c.TLSAllowedDNSNames, c.TLSAllowedSPIFFEIDs)
 	}
 
-	return tlsConfig, nil
+	return tlsConfig, closer, nil
 }
 
-func makeCertPool(certFiles []string) (*x509.CertPool, error) {
+func makeCertPool(certSources []string) (*x509.CertPool, error) {
 	pool := x509.NewCertPool()
-	for _, certFile := range certFiles {
-		pem, err := os.ReadFile(certFile)
+	for _, source := range certSources {
+		pem, err := ReadSource(source)
 		if err != nil {
 			return nil, fmt.Errorf(
-				"could not read certificate %q: %v", certFile, err)
+				"could not read certificate %q: %v", describeSource(source), err)
 		}
 		if !pool.AppendCertsFromPEM(pem) {
 			return nil, fmt.Errorf(
-				"could not parse any PEM certificates %q: %v", certFile, err)
+				"could not parse any PEM certificates %q", describeSource(source))
 		}
 	}
 	return pool, nil
 }
 
-func loadCertificate(config *tls.Config, certFile, keyFile string) error {
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+func loadCertificate(config *tls.Config, certSource, keySource string) error {
+	cert, err := loadKeyPair(certSource, keySource)
 	if err != nil {
-		return fmt.Errorf(
-			"could not load keypair %s:%s: %v", certFile, keyFile, err)
+		return err
 	}
-
-	config.Certificates = []tls.Certificate{cert}
-	config.BuildNameToCertificate()
+	config.Certificates = []tls.Certificate{*cert}
 	return nil
 }
 
-func (c *ServerConfig) verifyPeerCertificate(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
-	// The certificate chain is client + intermediate + root.
-	// Let's review the client certificate.
-	cert, err := x509.ParseCertificate(rawCerts[0])
-	if err != nil {
-		return fmt.Errorf("could not validate peer certificate: %v", err)
-	}
+type nopCloser struct{}
 
-	for _, name := range cert.DNSNames {
-		if utils.Contains(c.TLSAllowedDNSNames, name) {
-			return nil
-		}
-	}
-
-	return fmt.Errorf("peer certificate not in allowed DNS Name list: %v", cert.DNSNames)
-}
+func (nopCloser) Close() error { return nil }
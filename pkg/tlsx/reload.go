@@ -0,0 +1,153 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/xiehqing/common/pkg/logs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CertReloader keeps a key pair in memory and reloads it when the backing
+// files change. It is plugged into tls.Config through GetCertificate on the
+// server side and GetClientCertificate on the client side, so rotated
+// certificates are picked up by new handshakes without a restart.
+type CertReloader struct {
+	certSource string
+	keySource  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewCertReloader loads the key pair and, when both sources are files, starts
+// watching their directories. Directories are watched instead of the files so
+// that atomic renames and Kubernetes secret symlink swaps are detected.
+func NewCertReloader(certSource, keySource string) (*CertReloader, error) {
+	r := &CertReloader{certSource: certSource, keySource: keySource, done: make(chan struct{})}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	if !IsFileSource(certSource) || !IsFileSource(keySource) {
+		return r, nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("could not create certificate watcher: %v", err)
+	}
+	dirs := map[string]bool{filepath.Dir(certSource): true, filepath.Dir(keySource): true}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("could not watch %q: %v", dir, err)
+		}
+	}
+	r.watcher = watcher
+	go r.watch()
+	return r, nil
+}
+
+// Reload reads the key pair from its sources. The previous certificate is
+// kept if loading fails.
+func (r *CertReloader) Reload() error {
+	cert, err := loadKeyPair(r.certSource, r.keySource)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.cert = cert
+	r.mu.Unlock()
+	return nil
+}
+
+// Certificate returns the current key pair.
+func (r *CertReloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate.
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// Close stops watching the files.
+func (r *CertReloader) Close() error {
+	var err error
+	r.once.Do(func() {
+		close(r.done)
+		if r.watcher != nil {
+			err = r.watcher.Close()
+		}
+	})
+	return err
+}
+
+func (r *CertReloader) watch() {
+	// Writers usually touch the cert and key separately, debounce the events
+	// so both files are read after they have been written.
+	var timer *time.Timer
+	reload := make(chan struct{}, 1)
+	for {
+		select {
+		case <-r.done:
+			return
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(200*time.Millisecond, func() {
+					select {
+					case reload <- struct{}{}:
+					default:
+					}
+				})
+			} else {
+				timer.Reset(200 * time.Millisecond)
+			}
+		case <-reload:
+			if err := r.Reload(); err != nil {
+				logs.Errorf("failed to reload certificate %s: %v", r.certSource, err)
+				continue
+			}
+			logs.Infof("certificate %s reloaded", r.certSource)
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			logs.Errorf("certificate watcher error: %v", err)
+		}
+	}
+}
+
+func loadKeyPair(certSource, keySource string) (*tls.Certificate, error) {
+	certPEM, err := ReadSource(certSource)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := ReadSource(keySource)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not load keypair %s:%s: %v", describeSource(certSource), describeSource(keySource), err)
+	}
+	return &cert, nil
+}
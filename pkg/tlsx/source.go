@@ -0,0 +1,56 @@
+package tlsx
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	pemPrefix = "-----BEGIN"
+	envPrefix = "env:"
+)
+
+// ReadSource reads PEM data from a certificate source. A source can be
+//   - inline PEM content, starting with "-----BEGIN"
+//   - an environment variable holding PEM content, written as "env:NAME"
+//   - a file path
+func ReadSource(source string) ([]byte, error) {
+	source = strings.TrimSpace(source)
+	switch {
+	case source == "":
+		return nil, fmt.Errorf("empty certificate source")
+	case strings.HasPrefix(source, pemPrefix):
+		return []byte(source), nil
+	case strings.HasPrefix(source, envPrefix):
+		name := strings.TrimPrefix(source, envPrefix)
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("environment variable %q is not set", name)
+		}
+		return []byte(value), nil
+	default:
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("could not read %q: %v", source, err)
+		}
+		return data, nil
+	}
+}
+
+// IsFileSource reports whether the source refers to a file path and
+// therefore can be watched for changes.
+func IsFileSource(source string) bool {
+	source = strings.TrimSpace(source)
+	return source != "" && !strings.HasPrefix(source, pemPrefix) && !strings.HasPrefix(source, envPrefix)
+}
+
+// describeSource returns a printable name of a source without leaking inline key material.
+func describeSource(source string) string {
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(source), pemPrefix):
+		return "<inline pem>"
+	default:
+		return source
+	}
+}
@@ -0,0 +1,83 @@
+package tlsx
+
+import (
+	"crypto/x509"
+	"fmt"
+	"gorm.io/gorm/utils"
+	"net/url"
+	"strings"
+)
+
+// SPIFFEIDs returns the spiffe:// URI SANs of a certificate.
+func SPIFFEIDs(cert *x509.Certificate) []string {
+	var ids []string
+	for _, u := range cert.URIs {
+		if strings.EqualFold(u.Scheme, "spiffe") {
+			ids = append(ids, u.String())
+		}
+	}
+	return ids
+}
+
+// MatchSPIFFEID reports whether id matches one of the allowed patterns.
+// A pattern is either an exact ID (spiffe://example.org/ns/prod/sa/api),
+// a prefix ending with "/*" (spiffe://example.org/ns/prod/*), or a bare
+// trust domain (spiffe://example.org) which matches every ID in it.
+func MatchSPIFFEID(id string, allowed []string) bool {
+	u, err := url.Parse(id)
+	if err != nil || !strings.EqualFold(u.Scheme, "spiffe") || u.Host == "" {
+		return false
+	}
+	for _, pattern := range allowed {
+		switch {
+		case pattern == id:
+			return true
+		case strings.HasSuffix(pattern, "/*") && strings.HasPrefix(id, strings.TrimSuffix(pattern, "*")):
+			return true
+		case !strings.Contains(strings.TrimPrefix(pattern, "spiffe://"), "/") && strings.EqualFold(pattern, "spiffe://"+u.Host):
+			return true
+		}
+	}
+	return false
+}
+
+// peerVerifier builds a VerifyPeerCertificate callback that checks the leaf
+// certificate against allowed DNS names and SPIFFE IDs. Each non-empty list
+// must be satisfied. The callback runs after the standard chain verification.
+func peerVerifier(allowedDNSNames, allowedSPIFFEIDs []string) func([][]byte, [][]*x509.Certificate) error {
+	if len(allowedDNSNames) == 0 && len(allowedSPIFFEIDs) == 0 {
+		return nil
+	}
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return fmt.Errorf("no peer certificate presented")
+		}
+		// The certificate chain is leaf + intermediate + root.
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return fmt.Errorf("could not validate peer certificate: %v", err)
+		}
+		if len(allowedDNSNames) > 0 && !containsAny(allowedDNSNames, cert.DNSNames) {
+			return fmt.Errorf("peer certificate not in allowed DNS Name list: %v", cert.DNSNames)
+		}
+		if len(allowedSPIFFEIDs) > 0 {
+			ids := SPIFFEIDs(cert)
+			for _, id := range ids {
+				if MatchSPIFFEID(id, allowedSPIFFEIDs) {
+					return nil
+				}
+			}
+			return fmt.Errorf("peer certificate SPIFFE ID not allowed: %v", ids)
+		}
+		return nil
+	}
+}
+
+func containsAny(allowed, names []string) bool {
+	for _, name := range names {
+		if utils.Contains(allowed, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package tlsx
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func newMTLSServer(t *testing.T, cfg ServerConfig) (string, *tls.Config) {
+	t.Helper()
+	tlsConfig, closer, err := cfg.TLSConfigWithCloser()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := closer.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}),
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return "https://" + ln.Addr().String(), tlsConfig
+}
+
+func TestMutualTLSWithReloadAndSPIFFE(t *testing.T) {
+	ca, err := NewCA("test-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	server, err := ca.IssueServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile, err := server.WriteFiles(dir, "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, serverTLS := newMTLSServer(t, ServerConfig{
+		TLSCert:             certFile,
+		TLSKey:              keyFile,
+		TLSAllowedCACerts:   []string{string(ca.CertPEM)},
+		TLSAllowedSPIFFEIDs: []string{"spiffe://example.org/ns/prod/*"},
+		TLSAutoReload:       true,
+	})
+
+	get := func(commonName, spiffeID string) (*http.Response, error) {
+		client, err := ca.IssueClient(commonName, spiffeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("TEST_CLIENT_KEY", string(client.KeyPEM))
+		cfg := ClientConfig{UseTLS: true, TLSCA: string(ca.CertPEM), TLSCert: string(client.CertPEM), TLSKey: "env:TEST_CLIENT_KEY"}
+		tlsConfig, err := cfg.TLSConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		return httpClient.Get(url)
+	}
+	resp, err := get("api", "spiffe://example.org/ns/prod/sa/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if _, err := get("other", "spiffe://example.org/ns/dev/sa/api"); err == nil {
+		t.Fatal("expected SPIFFE ID rejection")
+	}
+
+	// 轮换服务端证书, 新的握手应使用新证书
+	before := serverTLS.GetCertificate
+	old, err := before(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := ca.IssueServer("localhost", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, rotated.KeyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(certFile, rotated.CertPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		current, err := before(&tls.ClientHelloInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(current.Certificate[0]) != string(old.Certificate[0]) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("certificate was not reloaded")
+}
+
+func TestSPIFFEConfigErrors(t *testing.T) {
+	client := ClientConfig{UseTLS: true, InsecureSkipVerify: true, TLSAllowedSPIFFEIDs: []string{"spiffe://example.org"}}
+	if _, err := client.TLSConfig(); err == nil {
+		t.Fatal("expected error for SPIFFE IDs with insecure skip verify")
+	}
+	ca, err := NewCA("test-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := ca.IssueServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile, err := server.WriteFiles(t.TempDir(), "server")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := ServerConfig{TLSCert: certFile, TLSKey: keyFile, TLSAllowedSPIFFEIDs: []string{"spiffe://example.org"}}
+	if _, err := cfg.TLSConfig(); err == nil {
+		t.Fatal("expected error for SPIFFE IDs without client CA")
+	}
+}
+
+func TestMatchSPIFFEID(t *testing.T) {
+	cases := []struct {
+		id      string
+		allowed []string
+		want    bool
+	}{
+		{"spiffe://example.org/ns/prod/sa/api", []string{"spiffe://example.org/ns/prod/sa/api"}, true},
+		{"spiffe://example.org/ns/prod/sa/api", []string{"spiffe://example.org"}, true},
+		{"spiffe://example.org/ns/prod/sa/api", []string{"spiffe://example.org/ns/dev/*"}, false},
+		{"spiffe://evil.org/ns/prod/sa/api", []string{"spiffe://example.org"}, false},
+		{"https://example.org/ns/prod", []string{"spiffe://example.org"}, false},
+	}
+	for _, c := range cases {
+		if got := MatchSPIFFEID(c.id, c.allowed); got != c.want {
+			t.Errorf("MatchSPIFFEID(%s, %v) = %v, want %v", c.id, c.allowed, got, c.want)
+		}
+	}
+}
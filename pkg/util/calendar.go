@@ -0,0 +1,365 @@
+package util
+
+import (
+	_ "embed"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"iter"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout 日历使用的日期格式
+const DateLayout = "2006-01-02"
+
+// maxScanDays 查找工作日时最多向前或向后扫描的天数, 防止错误的日历配置导致死循环
+const maxScanDays = 3660
+
+//go:embed holidays_cn.json
+var chinaHolidays []byte
+
+// HolidayDay 节假日表中的一天, 格式与 holiday-cn 项目的 JSON 一致
+type HolidayDay struct {
+	// Name 节日名称
+	Name string `json:"name" yaml:"name"`
+	// Date 日期, 格式 2006-01-02
+	Date string `json:"date" yaml:"date"`
+	// IsOffDay true 为放假, false 为调休上班
+	IsOffDay bool `json:"isOffDay" yaml:"is-off-day"`
+}
+
+// HolidayTable 节假日与调休上班表
+type HolidayTable struct {
+	Days []HolidayDay `json:"days" yaml:"days"`
+}
+
+// LoadHolidayTable 读取 JSON 格式的节假日表
+func LoadHolidayTable(r io.Reader) (*HolidayTable, error) {
+	var table HolidayTable
+	if err := json.NewDecoder(r).Decode(&table); err != nil {
+		return nil, errors.WithMessage(err, "解析节假日表失败")
+	}
+	for _, day := range table.Days {
+		if _, err := time.Parse(DateLayout, day.Date); err != nil {
+			return nil, errors.Errorf("节假日表中的日期格式错误: %s", day.Date)
+		}
+	}
+	return &table, nil
+}
+
+// LoadHolidayFile 读取 JSON 格式的节假日表文件
+func LoadHolidayFile(path string) (*HolidayTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "打开节假日表 %s 失败", path)
+	}
+	defer f.Close()
+	return LoadHolidayTable(f)
+}
+
+// ChinaHolidays 内置的中国法定节假日与调休上班表(依据国务院办公厅每年发布的放假安排), 新年度的安排可使用 LoadHolidayFile 加载
+func ChinaHolidays() *HolidayTable {
+	table, err := LoadHolidayTable(strings.NewReader(string(chinaHolidays)))
+	if err != nil {
+		panic(err)
+	}
+	return table
+}
+
+// ClockRange 一天中的时间段, 如 09:00-12:00, 单位为距零点的时长
+type ClockRange struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseClockRange 解析 HH:MM-HH:MM 格式的时间段
+func ParseClockRange(s string) (ClockRange, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return ClockRange{}, errors.Errorf("时间段格式错误: %s", s)
+	}
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return ClockRange{}, err
+	}
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return ClockRange{}, err
+	}
+	if end <= start {
+		return ClockRange{}, errors.Errorf("时间段结束时间需要晚于开始时间: %s", s)
+	}
+	return ClockRange{Start: start, End: end}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	hm := strings.Split(strings.TrimSpace(s), ":")
+	if len(hm) != 2 {
+		return 0, errors.Errorf("时间格式错误: %s", s)
+	}
+	h, err1 := strconv.Atoi(hm[0])
+	m, err2 := strconv.Atoi(hm[1])
+	if err1 != nil || err2 != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, errors.Errorf("时间格式错误: %s", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
+}
+
+// Calendar 工作日历, 包含周末、节假日、调休上班与工作时间, 日期按日历的时区计算
+type Calendar struct {
+	loc       *time.Location
+	weekStart time.Weekday
+	weekends  map[time.Weekday]bool
+	holidays  map[string]string
+	workdays  map[string]string
+	hours     []ClockRange
+}
+
+// CalendarOption 日历选项
+type CalendarOption func(c *Calendar)
+
+// WithLocation 日历的时区, 默认 Asia/Shanghai
+func WithLocation(loc *time.Location) CalendarOption {
+	return func(c *Calendar) {
+		c.loc = loc
+	}
+}
+
+// WithWeekends 周末, 默认周六、周日
+func WithWeekends(days ...time.Weekday) CalendarOption {
+	return func(c *Calendar) {
+		c.weekends = make(map[time.Weekday]bool, len(days))
+		for _, d := range days {
+			c.weekends[d] = true
+		}
+	}
+}
+
+// WithWeekStart 每周的第一天, 默认周一
+func WithWeekStart(day time.Weekday) CalendarOption {
+	return func(c *Calendar) {
+		c.weekStart = day
+	}
+}
+
+// WithHolidayTable 节假日与调休上班表, 可以多次使用, 后面的表覆盖前面相同日期的设置
+func WithHolidayTable(table *HolidayTable) CalendarOption {
+	return func(c *Calendar) {
+		c.AddHolidayTable(table)
+	}
+}
+
+// WithBusinessHours 工作时间段, 默认 09:00-18:00, 时间段需按时间顺序且不重叠
+func WithBusinessHours(ranges ...ClockRange) CalendarOption {
+	return func(c *Calendar) {
+		c.hours = append([]ClockRange(nil), ranges...)
+		sort.Slice(c.hours, func(i, j int) bool { return c.hours[i].Start < c.hours[j].Start })
+	}
+}
+
+// NewCalendar 创建工作日历, 默认使用 Asia/Shanghai 时区、周六周日休息、09:00-18:00 工作, 不包含节假日
+func NewCalendar(opts ...CalendarOption) *Calendar {
+	c := &Calendar{
+		loc:       time.Local,
+		weekStart: time.Monday,
+		weekends:  map[time.Weekday]bool{time.Saturday: true, time.Sunday: true},
+		holidays:  make(map[string]string),
+		workdays:  make(map[string]string),
+		hours:     []ClockRange{{Start: 9 * time.Hour, End: 18 * time.Hour}},
+	}
+	if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		c.loc = loc
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewChinaCalendar 使用内置中国法定节假日表的工作日历
+func NewChinaCalendar(opts ...CalendarOption) *Calendar {
+	return NewCalendar(append([]CalendarOption{WithHolidayTable(ChinaHolidays())}, opts...)...)
+}
+
+// AddHolidayTable 追加节假日与调休上班表, 相同日期以后追加的为准. 不是并发安全的, 应在使用前完成加载
+func (c *Calendar) AddHolidayTable(table *HolidayTable) {
+	if table == nil {
+		return
+	}
+	for _, day := range table.Days {
+		if day.IsOffDay {
+			c.holidays[day.Date] = day.Name
+			delete(c.workdays, day.Date)
+		} else {
+			c.workdays[day.Date] = day.Name
+			delete(c.holidays, day.Date)
+		}
+	}
+}
+
+// Location 日历的时区
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// StartOfDay t 所在日期的零点, 按日历时区计算
+func (c *Calendar) StartOfDay(t time.Time) time.Time {
+	t = t.In(c.loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+}
+
+// IsWorkday 是否为工作日: 调休上班日为工作日, 节假日与周末不是工作日
+func (c *Calendar) IsWorkday(t time.Time) bool {
+	date := t.In(c.loc).Format(DateLayout)
+	if _, ok := c.workdays[date]; ok {
+		return true
+	}
+	if _, ok := c.holidays[date]; ok {
+		return false
+	}
+	return !c.weekends[t.In(c.loc).Weekday()]
+}
+
+// IsHoliday 是否为法定节假日, 返回节日名称. 普通周末不属于节假日
+func (c *Calendar) IsHoliday(t time.Time) (string, bool) {
+	name, ok := c.holidays[t.In(c.loc).Format(DateLayout)]
+	return name, ok
+}
+
+// NextWorkday t 之后(不含 t 当天)的第一个工作日的零点, 找不到时返回零值
+func (c *Calendar) NextWorkday(t time.Time) time.Time {
+	day := c.StartOfDay(t)
+	for i := 0; i < maxScanDays; i++ {
+		day = day.AddDate(0, 0, 1)
+		if c.IsWorkday(day) {
+			return day
+		}
+	}
+	return time.Time{}
+}
+
+// AddWorkdays 增加 n 个工作日, n 为负数时减少, 保持 t 的时刻不变.
+// 从 t 的后一天(n<0 时为前一天)开始计数, 如周五或周六加 1 个工作日都为下周一
+func (c *Calendar) AddWorkdays(t time.Time, n int) time.Time {
+	t = t.In(c.loc)
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for i := 0; n > 0 && i < maxScanDays; i++ {
+		t = t.AddDate(0, 0, step)
+		if c.IsWorkday(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// WorkdaysBetween [start, end) 之间的工作日数量, 按日期计算
+func (c *Calendar) WorkdaysBetween(start, end time.Time) int {
+	n := 0
+	for range c.Workdays(TimeRange{StartTime: start, EndTime: end}) {
+		n++
+	}
+	return n
+}
+
+// Workdays 遍历 r 中的工作日, 返回每个工作日的零点
+func (c *Calendar) Workdays(r TimeRange) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		for day := c.StartOfDay(r.StartTime); day.Before(r.EndTime); day = day.AddDate(0, 0, 1) {
+			if c.IsWorkday(day) && !yield(day) {
+				return
+			}
+		}
+	}
+}
+
+// BusinessHours t 所在日期的工作时间段, 非工作日返回空
+func (c *Calendar) BusinessHours(t time.Time) []TimeRange {
+	if !c.IsWorkday(t) {
+		return nil
+	}
+	day := c.StartOfDay(t)
+	ranges := make([]TimeRange, 0, len(c.hours))
+	for _, h := range c.hours {
+		ranges = append(ranges, TimeRange{StartTime: clockOf(day, h.Start), EndTime: clockOf(day, h.End)})
+	}
+	return ranges
+}
+
+// clockOf 按墙上时间计算当天的时刻, 夏令时切换当天 09:00 仍为 09:00
+func clockOf(day time.Time, d time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(d/time.Hour), int(d%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// IsBusinessTime t 是否在工作日的工作时间内
+func (c *Calendar) IsBusinessTime(t time.Time) bool {
+	for _, r := range c.BusinessHours(t) {
+		if r.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// BusinessDuration [start, end) 之间的工作时长, 只统计工作日的工作时间段
+func (c *Calendar) BusinessDuration(start, end time.Time) time.Duration {
+	var total time.Duration
+	for day := c.StartOfDay(start); day.Before(end); day = day.AddDate(0, 0, 1) {
+		for _, r := range c.BusinessHours(day) {
+			if overlap, ok := r.Intersect(TimeRange{StartTime: start, EndTime: end}); ok {
+				total += overlap.Duration()
+			}
+		}
+	}
+	return total
+}
+
+// AddBusinessDuration 从 t 开始经过 d 的工作时长后的时刻, 用于计算响应时限等 SLA, 如周五 17:00 加 2 小时为下周一 10:00.
+// d 小于等于 0 或日历中找不到工作时间时返回 t
+func (c *Calendar) AddBusinessDuration(t time.Time, d time.Duration) time.Time {
+	if d <= 0 {
+		return t
+	}
+	day := c.StartOfDay(t)
+	for i := 0; i < maxScanDays; i++ {
+		for _, r := range c.BusinessHours(day) {
+			if r.EndTime.Before(t) || r.EndTime.Equal(t) {
+				continue
+			}
+			start := r.StartTime
+			if start.Before(t) {
+				start = t
+			}
+			remain := r.EndTime.Sub(start)
+			if d <= remain {
+				return start.Add(d)
+			}
+			d -= remain
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// NextBusinessTime t 之后(含 t)最近的工作时间, 找不到时返回零值
+func (c *Calendar) NextBusinessTime(t time.Time) time.Time {
+	day := c.StartOfDay(t)
+	for i := 0; i < maxScanDays; i++ {
+		for _, r := range c.BusinessHours(day) {
+			if r.Contains(t) {
+				return t
+			}
+			if r.StartTime.After(t) {
+				return r.StartTime
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,109 @@
+package util
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestCalendar(t *testing.T) {
+	c := NewChinaCalendar()
+	date := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02 15:04", s, c.Location())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	// 2026 年国庆节 10月1日至7日放假, 9月20日(周日)与10月10日(周六)上班
+	if c.IsWorkday(date("2026-10-01 10:00")) || !c.IsWorkday(date("2026-10-10 10:00")) || !c.IsWorkday(date("2026-09-20 10:00")) {
+		t.Fatal("unexpected national day workdays")
+	}
+	if name, ok := c.IsHoliday(date("2026-10-03 10:00")); !ok || name != "国庆节" {
+		t.Fatalf("unexpected holiday %q", name)
+	}
+	if got := c.AddWorkdays(date("2026-09-30 15:00"), 1); !got.Equal(date("2026-10-08 15:00")) {
+		t.Fatalf("unexpected AddWorkdays %s", got)
+	}
+	if got := c.AddWorkdays(date("2026-10-08 15:00"), -2); !got.Equal(date("2026-09-29 15:00")) {
+		t.Fatalf("unexpected AddWorkdays %s", got)
+	}
+	if n := c.WorkdaysBetween(date("2026-10-01 00:00"), date("2026-10-12 00:00")); n != 3 {
+		t.Fatalf("unexpected workdays %d", n)
+	}
+
+	table, err := LoadHolidayTable(strings.NewReader(`{"days":[{"name":"团建","date":"2026-10-12","isOffDay":true}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AddHolidayTable(table)
+	if c.IsWorkday(date("2026-10-12 10:00")) {
+		t.Fatal("expected loaded holiday")
+	}
+
+	lunch, _ := ParseClockRange("09:00-12:00")
+	afternoon, _ := ParseClockRange("13:00-18:00")
+	c = NewChinaCalendar(WithBusinessHours(afternoon, lunch))
+	if c.IsBusinessTime(date("2026-10-09 12:30")) || !c.IsBusinessTime(date("2026-10-09 13:00")) {
+		t.Fatal("unexpected business time")
+	}
+	if got := c.AddBusinessDuration(date("2026-09-30 17:00"), 2*time.Hour); !got.Equal(date("2026-10-08 10:00")) {
+		t.Fatalf("unexpected AddBusinessDuration %s", got)
+	}
+	// 周五 6 小时 + 周六调休上班 8 小时 + 周一 1 小时
+	if d := c.BusinessDuration(date("2026-10-09 11:00"), date("2026-10-12 10:00")); d != 15*time.Hour {
+		t.Fatalf("unexpected BusinessDuration %s", d)
+	}
+
+	schedule, err := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow).Parse("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := c.WorkdaySchedule(schedule).Next(date("2026-09-30 10:00")); !next.Equal(date("2026-10-08 09:00")) {
+		t.Fatalf("unexpected next schedule %s", next)
+	}
+	every, _ := cron.ParseStandard("*/30 * * * *")
+	if next := c.BusinessHoursSchedule(every).Next(date("2026-10-09 11:50")); !next.Equal(date("2026-10-09 13:00")) {
+		t.Fatalf("unexpected next business schedule %s", next)
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	c := NewCalendar(WithLocation(loc))
+	// UTC 时间 2026-03-01 20:00 为东八区 3月2日(周一) 04:00
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		got  TimeRange
+		want [2]string
+	}{
+		{c.LastPeriods(now, PeriodDay, 7), [2]string{"2026-02-23", "2026-03-02"}},
+		{c.LastPeriods(now, PeriodWeek, 1), [2]string{"2026-02-23", "2026-03-02"}},
+		{c.LastPeriods(now, PeriodMonth, 3), [2]string{"2025-12-01", "2026-03-01"}},
+		{c.Period(now, PeriodQuarter), [2]string{"2026-01-01", "2026-04-01"}},
+	}
+	for i, tc := range cases {
+		if tc.got.StartTime.Format(DateLayout) != tc.want[0] || tc.got.EndTime.Format(DateLayout) != tc.want[1] || tc.got.StartTime.Location() != loc {
+			t.Fatalf("case %d: unexpected range %s - %s", i, tc.got.StartTime, tc.got.EndTime)
+		}
+	}
+	if r := c.RecentPeriods(now, PeriodMonth, 1); r.StartTime.Format(DateLayout) != "2026-03-01" || !r.EndTime.Equal(now) {
+		t.Fatalf("unexpected recent range %v", r)
+	}
+
+	var months []string
+	r := TimeRange{StartTime: time.Date(2026, 1, 15, 0, 0, 0, 0, loc), EndTime: time.Date(2026, 3, 10, 0, 0, 0, 0, loc)}
+	for p := range c.Periods(r, PeriodMonth) {
+		months = append(months, p.StartTime.Format("01-02")+"~"+p.EndTime.Format("01-02"))
+	}
+	if strings.Join(months, ",") != "01-15~02-01,02-01~03-01,03-01~03-10" {
+		t.Fatalf("unexpected periods %v", months)
+	}
+	if unit, err := ParsePeriodUnit("周"); err != nil || unit != PeriodWeek {
+		t.Fatalf("unexpected unit %s: %v", unit, err)
+	}
+}
@@ -144,7 +144,6 @@ func ToInt64(input interface{}) (int64, error) {
 	default:
 		return 0, fmt.Errorf("unsupported number type: %T", input)
 	}
-	return 0, nil
 }
 
 // ToJson 对象转换为json
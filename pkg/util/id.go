@@ -0,0 +1,68 @@
+package util
+
+import (
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// IDGenerator 唯一 ID 生成器, 生成的 ID 按时间递增, 可直接用作字符串主键
+type IDGenerator interface {
+	NextID() (string, error)
+}
+
+// IDStrategy ID 生成策略
+type IDStrategy string
+
+const (
+	// IDStrategySnowflake 19 位数字, 需要为每个实例分配不同的机器号
+	IDStrategySnowflake IDStrategy = "snowflake"
+	// IDStrategyULID 26 位 Crockford Base32 字符串
+	IDStrategyULID IDStrategy = "ulid"
+	// IDStrategyUUIDv7 36 位带时间前缀的 UUID
+	IDStrategyUUIDv7 IDStrategy = "uuidv7"
+)
+
+// ParseIDStrategy 解析 ID 生成策略, 为空时使用 ulid
+func ParseIDStrategy(s string) (IDStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "ulid":
+		return IDStrategyULID, nil
+	case "snowflake":
+		return IDStrategySnowflake, nil
+	case "uuidv7", "uuid7", "uuid":
+		return IDStrategyUUIDv7, nil
+	}
+	return "", errors.Errorf("不支持的 ID 生成策略: %s", s)
+}
+
+// NewIDGenerator 按策略创建 ID 生成器, workerID 只用于 snowflake 策略,
+// 需要自动分配机器号时使用 NewSnowflakeWithLease
+func NewIDGenerator(strategy IDStrategy, workerID int64, opts ...SnowflakeOption) (IDGenerator, error) {
+	switch strategy {
+	case IDStrategySnowflake:
+		return NewSnowflake(workerID, opts...)
+	case IDStrategyULID, "":
+		return NewULIDGenerator(), nil
+	case IDStrategyUUIDv7:
+		return UUIDv7Generator{}, nil
+	}
+	return nil, errors.Errorf("不支持的 ID 生成策略: %s", strategy)
+}
+
+// UUIDv7Generator UUIDv7 生成器, 同一毫秒内按序递增
+type UUIDv7Generator struct{}
+
+// NextID 生成 UUIDv7
+func (UUIDv7Generator) NextID() (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", errors.WithMessage(err, "生成 UUIDv7 失败")
+	}
+	return id.String(), nil
+}
+
+// NewUUIDv7 生成 UUIDv7, 失败时 panic
+func NewUUIDv7() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+func TestSnowflake(t *testing.T) {
+	s, err := NewSnowflake(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 20000
+	ids := make(chan string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < n/4; j++ {
+				id, err := s.NextID()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	seen := make(map[string]bool, n)
+	for id := range ids {
+		if len(id) != 19 || seen[id] {
+			t.Fatalf("unexpected id %q", id)
+		}
+		seen[id] = true
+	}
+
+	id, _ := s.Next()
+	if at, worker, _ := s.Decompose(id); worker != 7 || time.Since(at) > time.Second {
+		t.Fatalf("unexpected decompose %s %d", at, worker)
+	}
+
+	// 时钟回拨超过允许范围时拒绝生成
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	if _, err := s.Next(); err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return now.Add(-time.Second) }
+	if _, err := s.Next(); !errors.Is(err, ErrClockBackward) {
+		t.Fatalf("expected clock backward, got %v", err)
+	}
+	if _, err := NewSnowflake(SnowflakeMaxWorkerID + 1); err == nil {
+		t.Fatal("expected invalid worker id")
+	}
+	lease := &testLease{id: 3, valid: true}
+	s, _ = NewSnowflakeWithLease(lease)
+	lease.valid = false
+	if _, err := s.Next(); !errors.Is(err, ErrWorkerLeaseLost) {
+		t.Fatalf("expected lease lost, got %v", err)
+	}
+}
+
+type testLease struct {
+	id    int64
+	valid bool
+}
+
+func (l *testLease) WorkerID() int64 { return l.id }
+func (l *testLease) Valid() bool     { return l.valid }
+
+func TestULID(t *testing.T) {
+	g := NewULIDGenerator()
+	at := time.UnixMilli(1760000000123)
+	var ids []string
+	for i := 0; i < 1000; i++ {
+		u, err := g.New(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, u.String())
+	}
+	if !sort.StringsAreSorted(ids) || ids[0] == ids[1] {
+		t.Fatalf("ulids not monotonic: %v", ids[:2])
+	}
+	u, err := ParseULID(ids[10])
+	if err != nil || u.String() != ids[10] || !u.Time().Equal(at) {
+		t.Fatalf("unexpected parse %s %s: %v", u, u.Time(), err)
+	}
+	if _, err := ParseULID("8ZZZZZZZZZZZZZZZZZZZZZZZZZ"); err == nil {
+		t.Fatal("expected overflow error")
+	}
+	if u, _ := ParseULID("01arz3ndektsv4rrffq69g5fav"); u.String() != "01ARZ3NDEKTSV4RRFFQ69G5FAV" || u.Time().UnixMilli() != 1469922850259 {
+		t.Fatalf("unexpected ulid %s %d", u, u.Time().UnixMilli())
+	}
+
+	for _, strategy := range []IDStrategy{IDStrategyULID, IDStrategyUUIDv7, IDStrategySnowflake} {
+		gen, err := NewIDGenerator(strategy, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, _ := gen.NextID()
+		time.Sleep(2 * time.Millisecond)
+		b, _ := gen.NextID()
+		if a >= b {
+			t.Fatalf("%s ids not sorted: %s %s", strategy, a, b)
+		}
+	}
+	if id := uuid.MustParse(NewUUIDv7()); id.Version() != 7 {
+		t.Fatalf("unexpected uuid version %d", id.Version())
+	}
+}
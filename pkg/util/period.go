@@ -0,0 +1,177 @@
+package util
+
+import (
+	"github.com/pkg/errors"
+	"iter"
+	"strings"
+	"time"
+)
+
+// Contains t 是否在 [StartTime, EndTime) 内
+func (r TimeRange) Contains(t time.Time) bool {
+	return !t.Before(r.StartTime) && t.Before(r.EndTime)
+}
+
+// Duration 时间范围的时长
+func (r TimeRange) Duration() time.Duration {
+	return r.EndTime.Sub(r.StartTime)
+}
+
+// Intersect 两个时间范围的交集, 没有交集时返回 false
+func (r TimeRange) Intersect(o TimeRange) (TimeRange, bool) {
+	start, end := r.StartTime, r.EndTime
+	if o.StartTime.After(start) {
+		start = o.StartTime
+	}
+	if o.EndTime.Before(end) {
+		end = o.EndTime
+	}
+	if !start.Before(end) {
+		return TimeRange{}, false
+	}
+	return TimeRange{StartTime: start, EndTime: end}, true
+}
+
+// PeriodUnit 自然周期单位
+type PeriodUnit string
+
+const (
+	PeriodHour    PeriodUnit = "hour"
+	PeriodDay     PeriodUnit = "day"
+	PeriodWeek    PeriodUnit = "week"
+	PeriodMonth   PeriodUnit = "month"
+	PeriodQuarter PeriodUnit = "quarter"
+	PeriodYear    PeriodUnit = "year"
+)
+
+// ParsePeriodUnit 解析周期单位, 支持 hour/day/week/month/quarter/year 及 小时/天/周/月/季度/年
+func ParsePeriodUnit(s string) (PeriodUnit, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "hour", "h", "小时":
+		return PeriodHour, nil
+	case "day", "d", "天", "日":
+		return PeriodDay, nil
+	case "week", "w", "周":
+		return PeriodWeek, nil
+	case "month", "月":
+		return PeriodMonth, nil
+	case "quarter", "季度":
+		return PeriodQuarter, nil
+	case "year", "y", "年":
+		return PeriodYear, nil
+	}
+	return "", errors.Errorf("不支持的周期单位: %s", s)
+}
+
+// Truncate t 所在自然周期的开始时间, 按日历时区计算, 周从 WithWeekStart 设置的日期开始
+func (c *Calendar) Truncate(t time.Time, unit PeriodUnit) time.Time {
+	t = t.In(c.loc)
+	switch unit {
+	case PeriodHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, c.loc)
+	case PeriodWeek:
+		offset := (int(t.Weekday()) - int(c.weekStart) + 7) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, c.loc)
+	case PeriodMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, c.loc)
+	case PeriodQuarter:
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, c.loc)
+	case PeriodYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, c.loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+	}
+}
+
+// AddPeriods 增加 n 个周期, start 需为周期的开始时间. 按日期计算, 夏令时切换时一天不一定是 24 小时
+func (c *Calendar) AddPeriods(start time.Time, unit PeriodUnit, n int) time.Time {
+	start = start.In(c.loc)
+	switch unit {
+	case PeriodHour:
+		return start.Add(time.Duration(n) * time.Hour)
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7*n)
+	case PeriodMonth:
+		return start.AddDate(0, n, 0)
+	case PeriodQuarter:
+		return start.AddDate(0, 3*n, 0)
+	case PeriodYear:
+		return start.AddDate(n, 0, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+// Period t 所在的自然周期
+func (c *Calendar) Period(t time.Time, unit PeriodUnit) TimeRange {
+	start := c.Truncate(t, unit)
+	return TimeRange{StartTime: start, EndTime: c.AddPeriods(start, unit, 1)}
+}
+
+// LastPeriods 最近 n 个完整的自然周期, 不含当前周期, 如 LastPeriods(now, PeriodDay, 7) 为前 7 天的零点到今天零点
+func (c *Calendar) LastPeriods(now time.Time, unit PeriodUnit, n int) TimeRange {
+	end := c.Truncate(now, unit)
+	return TimeRange{StartTime: c.AddPeriods(end, unit, -n), EndTime: end}
+}
+
+// RecentPeriods 包含当前周期在内的最近 n 个自然周期, 结束时间为 now, 如本月至今为 RecentPeriods(now, PeriodMonth, 1)
+func (c *Calendar) RecentPeriods(now time.Time, unit PeriodUnit, n int) TimeRange {
+	start := c.AddPeriods(c.Truncate(now, unit), unit, 1-n)
+	return TimeRange{StartTime: start, EndTime: now.In(c.loc)}
+}
+
+// Periods 按自然周期切分 r, 首尾不完整的周期会被截断到 r 的范围内, 可用于按天、按月分段查询数据源
+func (c *Calendar) Periods(r TimeRange, unit PeriodUnit) iter.Seq[TimeRange] {
+	return func(yield func(TimeRange) bool) {
+		for start := c.Truncate(r.StartTime, unit); start.Before(r.EndTime); start = c.AddPeriods(start, unit, 1) {
+			period, ok := TimeRange{StartTime: start, EndTime: c.AddPeriods(start, unit, 1)}.Intersect(r)
+			if ok && !yield(period) {
+				return
+			}
+		}
+	}
+}
+
+// Schedule 调度接口, 与 cron.Schedule 相同
+type Schedule interface {
+	Next(time.Time) time.Time
+}
+
+type workdaySchedule struct {
+	c             *Calendar
+	schedule      Schedule
+	businessHours bool
+}
+
+// WorkdaySchedule 只在工作日触发的调度, 可通过 cron.Cron.Schedule 注册
+func (c *Calendar) WorkdaySchedule(schedule Schedule) Schedule {
+	return &workdaySchedule{c: c, schedule: schedule}
+}
+
+// BusinessHoursSchedule 只在工作时间内触发的调度, 可通过 cron.Cron.Schedule 注册
+func (c *Calendar) BusinessHoursSchedule(schedule Schedule) Schedule {
+	return &workdaySchedule{c: c, schedule: schedule, businessHours: true}
+}
+
+// Next 下一次触发时间, 跳过非工作日(或非工作时间), 找不到时返回零值
+func (s *workdaySchedule) Next(t time.Time) time.Time {
+	for i := 0; i < maxScanDays; i++ {
+		t = s.schedule.Next(t)
+		if t.IsZero() {
+			return t
+		}
+		if !s.businessHours && s.c.IsWorkday(t) || s.businessHours && s.c.IsBusinessTime(t) {
+			return t
+		}
+		// 跳到下一个可触发的时间之前, 避免高频调度逐次尝试
+		next := s.c.NextWorkday(t)
+		if s.businessHours {
+			next = s.c.NextBusinessTime(t)
+		}
+		if next.IsZero() {
+			return next
+		}
+		t = next.Add(-time.Nanosecond)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,154 @@
+package util
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// Snowflake ID 由 41 位毫秒时间戳、10 位机器号和 12 位序列号组成, 每个机器号每毫秒最多生成 4096 个
+const (
+	SnowflakeWorkerBits   = 10
+	SnowflakeSequenceBits = 12
+	SnowflakeMaxWorkerID  = 1<<SnowflakeWorkerBits - 1
+
+	snowflakeTimeBits    = 41
+	snowflakeMaxSequence = 1<<SnowflakeSequenceBits - 1
+)
+
+// DefaultSnowflakeEpoch Snowflake 默认起始时间
+var DefaultSnowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	// ErrClockBackward 时钟回拨超过允许的范围
+	ErrClockBackward = errors.New("时钟回拨, 拒绝生成 ID")
+	// ErrWorkerLeaseLost 机器号租约已失效
+	ErrWorkerLeaseLost = errors.New("机器号租约已失效, 拒绝生成 ID")
+)
+
+// WorkerLease 机器号租约, 如 redisx.WorkerIDLease. 租约失效后其他实例可能分配到同一机器号, 生成器会停止生成
+type WorkerLease interface {
+	WorkerID() int64
+	Valid() bool
+}
+
+// Snowflake 雪花算法 ID 生成器, 并发安全
+type Snowflake struct {
+	mu          sync.Mutex
+	epoch       int64
+	workerID    int64
+	lease       WorkerLease
+	maxBackward time.Duration
+	now         func() time.Time
+	lastMs      int64
+	sequence    int64
+}
+
+// SnowflakeOption Snowflake 选项
+type SnowflakeOption func(*Snowflake)
+
+// WithSnowflakeEpoch 设置起始时间, 同一业务的所有实例必须一致, 41 位时间戳可以使用约 69 年
+func WithSnowflakeEpoch(epoch time.Time) SnowflakeOption {
+	return func(s *Snowflake) {
+		s.epoch = epoch.UnixMilli()
+	}
+}
+
+// WithMaxClockBackward 设置允许等待的时钟回拨时长, 默认 10ms, 超过时返回 ErrClockBackward
+func WithMaxClockBackward(d time.Duration) SnowflakeOption {
+	return func(s *Snowflake) {
+		s.maxBackward = d
+	}
+}
+
+// NewSnowflake 使用固定机器号创建生成器, 机器号范围 0-1023, 需要保证各实例不同
+func NewSnowflake(workerID int64, opts ...SnowflakeOption) (*Snowflake, error) {
+	if workerID < 0 || workerID > SnowflakeMaxWorkerID {
+		return nil, errors.Errorf("机器号 %d 超出范围 0-%d", workerID, SnowflakeMaxWorkerID)
+	}
+	s := &Snowflake{
+		epoch:       DefaultSnowflakeEpoch.UnixMilli(),
+		workerID:    workerID,
+		maxBackward: 10 * time.Millisecond,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// NewSnowflakeWithLease 使用租约分配的机器号创建生成器
+func NewSnowflakeWithLease(lease WorkerLease, opts ...SnowflakeOption) (*Snowflake, error) {
+	s, err := NewSnowflake(lease.WorkerID(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.lease = lease
+	return s, nil
+}
+
+// WorkerID 机器号
+func (s *Snowflake) WorkerID() int64 {
+	return s.workerID
+}
+
+// Next 生成 int64 类型的 ID
+func (s *Snowflake) Next() (int64, error) {
+	if s.lease != nil && !s.lease.Valid() {
+		return 0, ErrWorkerLeaseLost
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ms := s.now().UnixMilli() - s.epoch
+	if ms < s.lastMs {
+		backward := time.Duration(s.lastMs-ms) * time.Millisecond
+		if backward > s.maxBackward {
+			return 0, errors.WithMessagef(ErrClockBackward, "回拨 %s", backward)
+		}
+		time.Sleep(backward)
+		ms = s.waitAfter(s.lastMs - 1)
+	}
+	if ms == s.lastMs {
+		s.sequence = (s.sequence + 1) & snowflakeMaxSequence
+		if s.sequence == 0 {
+			// 当前毫秒序列号用完, 等到下一毫秒
+			ms = s.waitAfter(s.lastMs)
+		}
+	} else {
+		s.sequence = 0
+	}
+	if ms < 0 || ms >= 1<<snowflakeTimeBits {
+		return 0, errors.Errorf("当前时间超出 Snowflake 时间戳范围, 起始时间 %s", time.UnixMilli(s.epoch))
+	}
+	s.lastMs = ms
+	return ms<<(SnowflakeWorkerBits+SnowflakeSequenceBits) | s.workerID<<SnowflakeSequenceBits | s.sequence, nil
+}
+
+// waitAfter 等到时间戳大于 ms
+func (s *Snowflake) waitAfter(ms int64) int64 {
+	for {
+		now := s.now().UnixMilli() - s.epoch
+		if now > ms {
+			return now
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+}
+
+// NextID 生成 ID, 固定为 19 位数字, 字符串顺序与数值顺序一致
+func (s *Snowflake) NextID() (string, error) {
+	id, err := s.Next()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%019d", id), nil
+}
+
+// Decompose 解析 ID 的生成时间、机器号和序列号
+func (s *Snowflake) Decompose(id int64) (t time.Time, workerID int64, sequence int64) {
+	t = time.UnixMilli(id>>(SnowflakeWorkerBits+SnowflakeSequenceBits) + s.epoch)
+	workerID = id >> SnowflakeSequenceBits & SnowflakeMaxWorkerID
+	return t, workerID, id & snowflakeMaxSequence
+}
@@ -0,0 +1,144 @@
+package util
+
+import (
+	"crypto/rand"
+	"github.com/pkg/errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ULID 由 48 位毫秒时间戳和 80 位随机数组成, 编码为 26 位 Crockford Base32 字符串, 字符串顺序即时间顺序
+type ULID [16]byte
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+var crockfordIndex = func() [256]byte {
+	var index [256]byte
+	for i := range index {
+		index[i] = 0xFF
+	}
+	for i := 0; i < len(crockfordAlphabet); i++ {
+		index[crockfordAlphabet[i]] = byte(i)
+		index[strings.ToLower(crockfordAlphabet[i : i+1])[0]] = byte(i)
+	}
+	return index
+}()
+
+// ErrULIDOverflow 同一毫秒内的随机部分递增溢出
+var ErrULIDOverflow = errors.New("同一毫秒内生成的 ULID 过多")
+
+// Time ULID 中的时间
+func (u ULID) Time() time.Time {
+	ms := int64(u[0])<<40 | int64(u[1])<<32 | int64(u[2])<<24 | int64(u[3])<<16 | int64(u[4])<<8 | int64(u[5])
+	return time.UnixMilli(ms)
+}
+
+// String 26 位 Crockford Base32 编码
+func (u ULID) String() string {
+	// 128 位从高位开始每 5 位一组, 首字符只有 3 位有效
+	dst := make([]byte, 26)
+	var acc uint32
+	bits := 2
+	pos := 0
+	for _, b := range u {
+		acc = acc<<8 | uint32(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			dst[pos] = crockfordAlphabet[acc>>bits&0x1F]
+			pos++
+		}
+	}
+	return string(dst)
+}
+
+// ParseULID 解析 ULID 字符串, 不区分大小写
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 {
+		return u, errors.Errorf("无效的 ULID %q: 长度应为 26", s)
+	}
+	if crockfordIndex[s[0]] > 7 {
+		return u, errors.Errorf("无效的 ULID %q: 超出范围", s)
+	}
+	var acc uint32
+	bits := -2
+	pos := 0
+	for i := 0; i < len(s); i++ {
+		v := crockfordIndex[s[i]]
+		if v == 0xFF {
+			return u, errors.Errorf("无效的 ULID %q: 非法字符 %q", s, s[i])
+		}
+		acc = acc<<5 | uint32(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			u[pos] = byte(acc >> bits)
+			pos++
+		}
+	}
+	return u, nil
+}
+
+// ULIDGenerator 单调递增的 ULID 生成器, 同一毫秒内在上一个随机数上加一, 并发安全
+type ULIDGenerator struct {
+	mu     sync.Mutex
+	lastMs int64
+	last   ULID
+}
+
+// NewULIDGenerator 创建 ULID 生成器
+func NewULIDGenerator() *ULIDGenerator {
+	return &ULIDGenerator{}
+}
+
+// New 生成时间为 t 的 ULID, t 早于上次生成的时间时按上次的时间递增, 保证单调
+func (g *ULIDGenerator) New(t time.Time) (ULID, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ms := t.UnixMilli()
+	if ms < 0 || ms >= 1<<48 {
+		return ULID{}, errors.Errorf("时间 %s 超出 ULID 范围", t)
+	}
+	if ms <= g.lastMs {
+		u := g.last
+		for i := len(u) - 1; i >= 6; i-- {
+			u[i]++
+			if u[i] != 0 {
+				g.last = u
+				return u, nil
+			}
+		}
+		return ULID{}, ErrULIDOverflow
+	}
+	var u ULID
+	for i := 0; i < 6; i++ {
+		u[i] = byte(ms >> (40 - 8*i))
+	}
+	if _, err := rand.Read(u[6:]); err != nil {
+		return ULID{}, errors.WithMessage(err, "生成随机数失败")
+	}
+	g.lastMs, g.last = ms, u
+	return u, nil
+}
+
+// NextID 生成 ULID 字符串
+func (g *ULIDGenerator) NextID() (string, error) {
+	u, err := g.New(time.Now())
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+var defaultULID = NewULIDGenerator()
+
+// NewULID 生成 ULID 字符串, 同一进程内单调递增
+func NewULID() string {
+	u, err := defaultULID.New(time.Now())
+	if err != nil {
+		panic(err)
+	}
+	return u.String()
+}
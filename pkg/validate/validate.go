@@ -0,0 +1,193 @@
+// Package validate 解析 validate 标签并按规则校验字段, hertzx 的参数绑定与 mcpx 的工具参数共用同一套规则
+//
+// 规则之间使用逗号分隔, 如 `validate:"required,min=1,max=20,enum=a|b,regex=^\w+$"`:
+//   - required: 不能为空
+//   - min/max: 数值的上下限, 字符串与集合为长度的上下限
+//   - enum: 取值必须为 | 分隔的值之一
+//   - date: 日期格式, 参数为 time.Parse 的 layout
+//   - regex: 正则表达式, 需放在最后, 以便正则表达式中可以包含逗号
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Rule 校验规则
+type Rule struct {
+	Name   string
+	Param  string
+	Limit  float64        // min/max 的数值
+	Regexp *regexp.Regexp // regex 编译后的正则表达式
+	Enums  []string       // enum 的可选值
+}
+
+// Parse 解析 validate 标签, 规则不支持或参数不合法时返回错误
+func Parse(tag string) ([]Rule, error) {
+	var rules []Rule
+	for tag != "" {
+		var item string
+		if strings.HasPrefix(tag, "regex=") {
+			item, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			item, tag = tag[:i], tag[i+1:]
+		} else {
+			item, tag = tag, ""
+		}
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		r := Rule{Name: item}
+		if i := strings.Index(item, "="); i > 0 {
+			r.Name, r.Param = item[:i], item[i+1:]
+		}
+		switch r.Name {
+		case "required", "date":
+		case "min", "max":
+			limit, err := strconv.ParseFloat(r.Param, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s=%s 不是数字", r.Name, r.Param)
+			}
+			r.Limit = limit
+		case "regex":
+			re, err := regexp.Compile(r.Param)
+			if err != nil {
+				return nil, fmt.Errorf("正则表达式 %q 不合法: %v", r.Param, err)
+			}
+			r.Regexp = re
+		case "enum":
+			r.Enums = strings.Split(r.Param, "|")
+		default:
+			return nil, fmt.Errorf("不支持的校验规则 %s", r.Name)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Has 规则中是否包含指定名称的规则
+func Has(rules []Rule, name string) bool {
+	for _, r := range rules {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 按规则校验字段值, 返回所有错误信息, 未设置 required 时空值跳过其他规则
+func Check(rules []Rule, fv reflect.Value) []string {
+	if len(rules) == 0 {
+		return nil
+	}
+	var msgs []string
+	addErr := func(format string, args ...interface{}) {
+		msgs = append(msgs, fmt.Sprintf(format, args...))
+	}
+	required := Has(rules, "required")
+	if required && isEmptyValue(fv) {
+		return []string{"不能为空"}
+	}
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	for _, r := range rules {
+		switch r.Name {
+		case "min", "max":
+			n, isLen, ok := measure(fv)
+			if !ok || (isLen && n == 0 && !required) {
+				continue
+			}
+			unit := ""
+			if isLen {
+				unit = "长度"
+			}
+			if r.Name == "min" && n < r.Limit {
+				addErr("%s不能小于 %s", unit, r.Param)
+			}
+			if r.Name == "max" && n > r.Limit {
+				addErr("%s不能大于 %s", unit, r.Param)
+			}
+		case "regex":
+			forEachString(fv, func(s string) {
+				if s != "" && !r.Regexp.MatchString(s) {
+					addErr("格式不正确")
+				}
+			})
+		case "enum":
+			forEachString(fv, func(s string) {
+				if s == "" {
+					return
+				}
+				for _, e := range r.Enums {
+					if s == e {
+						return
+					}
+				}
+				addErr("取值必须为 %s 之一", strings.Join(r.Enums, ", "))
+			})
+		case "date":
+			forEachString(fv, func(s string) {
+				if s == "" {
+					return
+				}
+				if _, err := time.Parse(r.Param, s); err != nil {
+					addErr("日期格式错误, 格式应为 %s", r.Param)
+				}
+			})
+		}
+	}
+	return msgs
+}
+
+func isEmptyValue(fv reflect.Value) bool {
+	switch fv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return fv.IsNil()
+	case reflect.Slice, reflect.Map, reflect.String, reflect.Array:
+		return fv.Len() == 0
+	default:
+		return fv.IsZero()
+	}
+}
+
+// measure 获取用于 min/max 比较的数值, 字符串与集合返回长度
+func measure(fv reflect.Value) (float64, bool, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(fv.String())), true, true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(fv.Len()), true, true
+	default:
+		return 0, false, false
+	}
+}
+
+// forEachString 对字符串或字符串切片的每个元素执行校验
+func forEachString(fv reflect.Value, fn func(s string)) {
+	switch fv.Kind() {
+	case reflect.String:
+		fn(fv.String())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			if item := fv.Index(i); item.Kind() == reflect.String {
+				fn(item.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	rules, err := Parse("required, max=8,enum=a|b,regex=^[a-z,]+$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 4 || rules[1].Limit != 8 || len(rules[2].Enums) != 2 || rules[3].Regexp == nil {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+	if !rules[3].Regexp.MatchString("a,b") {
+		t.Fatal("regex should keep commas")
+	}
+	for _, tag := range []string{"min=a", "regex=[a-", "unknown"} {
+		if _, err := Parse(tag); err == nil {
+			t.Fatalf("Parse(%q) should fail", tag)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		tag   string
+		value interface{}
+		want  []string
+	}{
+		{"required", "", []string{"不能为空"}},
+		{"max=2", "abc", []string{"长度不能大于 2"}},
+		{"min=1", "", nil},
+		{"min=1", 0, []string{"不能小于 1"}},
+		{"enum=a|b", []string{"a", "c"}, []string{"取值必须为 a, b 之一"}},
+		{"date=2006-01-02", "2024/01/02", []string{"日期格式错误, 格式应为 2006-01-02"}},
+		{"regex=^\\d+$", "12", nil},
+	}
+	for _, c := range cases {
+		rules, err := Parse(c.tag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Check(rules, reflect.ValueOf(c.value)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Check(%q, %v) = %v, want %v", c.tag, c.value, got, c.want)
+		}
+	}
+}